@@ -4,6 +4,7 @@ import (
 	"auto-forge/internal/cron"
 	"auto-forge/internal/middleware"
 	"auto-forge/internal/routes"
+	"auto-forge/internal/services/openapi_tool"
 	taskService "auto-forge/internal/services/task"
 	toolConfigService "auto-forge/internal/services/tool_config"
 	uploadService "auto-forge/internal/services/upload"
@@ -14,6 +15,7 @@ import (
 	"auto-forge/pkg/email"
 	"auto-forge/pkg/errors"
 	"auto-forge/pkg/logger"
+	"auto-forge/pkg/utools/plugin"
 	"fmt"
 	"time"
 
@@ -23,6 +25,7 @@ import (
 	_ "auto-forge/pkg/utools/aliyunoss"
 	_ "auto-forge/pkg/utools/baidu"
 	_ "auto-forge/pkg/utools/context"
+	_ "auto-forge/pkg/utools/datastore"
 	_ "auto-forge/pkg/utools/downloader"
 	_ "auto-forge/pkg/utools/email"
 	_ "auto-forge/pkg/utools/feishu"
@@ -37,6 +40,7 @@ import (
 	_ "auto-forge/pkg/utools/pixelpunk"
 	_ "auto-forge/pkg/utools/qrcode"
 	_ "auto-forge/pkg/utools/rssfeed"
+	_ "auto-forge/pkg/utools/script"
 	_ "auto-forge/pkg/utools/tencentcos"
 	_ "auto-forge/pkg/utools/web"
 	_ "auto-forge/pkg/utools/weibo"
@@ -92,6 +96,22 @@ func main() {
 	// 注册路由
 	routes.RegisterRoutes(r)
 
+	// 注册从 OpenAPI 文档导入的工具
+	openapi_tool.LoadOpenAPITools()
+
+	// 加载外部插件工具（需在同步工具定义之前注册）
+	if pluginsConfig := config.GetConfig().Plugins; pluginsConfig.Dir != "" {
+		pluginManager, err := plugin.Load(pluginsConfig.Dir, plugin.Options{
+			Timeout:        time.Duration(pluginsConfig.Timeout) * time.Second,
+			HealthInterval: time.Duration(pluginsConfig.HealthInterval) * time.Second,
+		})
+		if err != nil {
+			logger.Error("加载插件失败: %v", err)
+		} else {
+			defer pluginManager.Close()
+		}
+	}
+
 	// 同步工具定义到数据库
 	syncService := toolConfigService.NewToolConfigService()
 	if err := syncService.SyncToolsFromRegistry(); err != nil {
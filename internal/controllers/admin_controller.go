@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"strconv"
+	"time"
 	"auto-forge/internal/services"
 	"auto-forge/pkg/common"
 	"auto-forge/pkg/errors"
@@ -178,6 +179,52 @@ func (c *AdminController) GetStats(ctx *gin.Context) {
 	common.Success(ctx, stats, "获取成功")
 }
 
+type AgentUsageQueryParams struct {
+	Start   int64  `form:"start"`
+	End     int64  `form:"end"`
+	GroupBy string `form:"group_by" binding:"omitempty,oneof=user model conversation day"`
+	UserID  string `form:"user_id"`
+}
+
+// GetAgentUsage 获取 Agent 用量报表，默认统计最近 30 天并按用户分组
+func (c *AdminController) GetAgentUsage(ctx *gin.Context) {
+	var params AgentUsageQueryParams
+	if err := ctx.ShouldBindQuery(&params); err != nil {
+		common.BadRequest(ctx, "请求参数错误")
+		return
+	}
+
+	if params.Start == 0 {
+		params.Start = time.Now().AddDate(0, 0, -30).Unix()
+	}
+	if params.GroupBy == "" {
+		params.GroupBy = "user"
+	}
+
+	groups, err := c.adminService.GetAgentUsage(params.Start, params.End, params.GroupBy, params.UserID)
+	if err != nil {
+		common.ServerError(ctx, err.Error())
+		return
+	}
+
+	common.Success(ctx, gin.H{
+		"start":    params.Start,
+		"end":      params.End,
+		"group_by": params.GroupBy,
+		"groups":   groups,
+	}, "获取成功")
+}
+
+// GetToolPolicyReport 获取引用了已禁用、受限或已废弃工具的工作流报表
+func (c *AdminController) GetToolPolicyReport(ctx *gin.Context) {
+	reports, err := c.adminService.GetToolPolicyReport()
+	if err != nil {
+		common.HandleError(ctx, err)
+		return
+	}
+
+	common.Success(ctx, reports, "获取成功")
+}
 
 type ExecutionQueryParams struct {
 	Page     int    `form:"page" binding:"omitempty,min=1"`
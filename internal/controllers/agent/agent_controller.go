@@ -4,17 +4,29 @@ import (
 	"auto-forge/internal/dto/request"
 	"auto-forge/internal/models"
 	"auto-forge/internal/services/agent"
+	"auto-forge/internal/services/workflow"
+	"auto-forge/pkg/agent/executor"
 	"auto-forge/pkg/errors"
 	log "auto-forge/pkg/logger"
 	"context"
 	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	agentFilesDir    = "/tmp/agent-files" // 对话上传文件的保存目录
+	maxAgentFiles    = 10                 // 单条消息最多上传的文件数
+	maxAgentFileSize = 20 << 20           // 单个文件的最大字节数
+)
+
 // CreateConversation 创建对话
 func CreateConversation(c *gin.Context) {
 	userID, _ := c.Get("user_id")
@@ -135,11 +147,92 @@ func GetMessages(c *gin.Context) {
 	errors.ResponseSuccess(c, messages, "获取消息列表成功")
 }
 
+// GetUsage 获取当前用户的 Agent 用量、费用与预算
+func GetUsage(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	agentService := agent.NewAgentService()
+	report, err := agentService.GetUserUsageReport(userID)
+	if err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInternal, err.Error()))
+		return
+	}
+
+	errors.ResponseSuccess(c, report, "获取用量成功")
+}
+
+// GetWorkflowTools 获取可被 Agent 调用的工作流及其工具定义
+// 传入 conversation_id 时先校验对话归属，返回该对话中 Agent 可使用的工作流工具
+func GetWorkflowTools(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "用户未登录"))
+		return
+	}
+
+	if conversationID := c.Query("conversation_id"); conversationID != "" {
+		conversation, err := agent.NewAgentService().GetConversationByID(conversationID, userID)
+		if err != nil || conversation.UserID != userID {
+			errors.HandleError(c, errors.New(errors.CodeNotFound, "对话不存在"))
+			return
+		}
+	}
+
+	svc := workflow.NewWorkflowService()
+	workflows, err := svc.GetAgentCallableWorkflows(userID)
+	if err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInternal, err.Error()))
+		return
+	}
+
+	tools := make([]gin.H, 0, len(workflows))
+	for i := range workflows {
+		wf := &workflows[i]
+		tools = append(tools, gin.H{
+			"workflow_id": wf.GetID(),
+			"name":        wf.Name,
+			"description": wf.Description,
+			"tool_name":   workflow.AgentToolName(wf.GetID()),
+			"parameters":  workflow.BuildAPIParamsSchema(wf.APIParams),
+		})
+	}
+
+	errors.ResponseSuccess(c, tools, "获取工作流工具成功")
+}
+
+// GetMessageExecutions 获取 Agent 消息调用工作流产生的执行记录
+func GetMessageExecutions(c *gin.Context) {
+	userID := c.GetString("user_id")
+	messageID := c.Param("id")
+
+	if _, err := agent.NewAgentService().GetOwnedMessage(messageID, userID); err != nil {
+		errors.HandleError(c, errors.New(errors.CodeNotFound, "消息不存在"))
+		return
+	}
+
+	executions, err := workflow.NewExecutionService().GetExecutionsByAgentMessage(messageID, userID)
+	if err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInternal, err.Error()))
+		return
+	}
+
+	errors.ResponseSuccess(c, executions, "获取执行记录成功")
+}
+
 // SendMessage 发送消息（支持流式响应）
 func SendMessage(c *gin.Context) {
-	_, _ = c.Get("user_id")
+	userID := c.GetString("user_id")
 	conversationID := c.Param("id")
 
+	agentService := agent.NewAgentService()
+
+	// 执行使用对话所属用户的凭证连接与工作流，必须先校验对话归属，再保存上传文件或创建消息
+	conversation, err := agentService.GetConversationByID(conversationID, userID)
+	if err != nil || conversation.UserID != userID {
+		errors.HandleError(c, errors.New(errors.CodeNotFound, "对话不存在"))
+		return
+	}
+
 	// 解析请求（支持 JSON 和 multipart/form-data）
 	var userMessage string
 	var files []models.AgentFile
@@ -156,10 +249,22 @@ func SendMessage(c *gin.Context) {
 			json.Unmarshal([]byte(configStr), config)
 		}
 
-		// TODO: 处理文件上传
-		// form, _ := c.MultipartForm()
-		// fileHeaders := form.File["files"]
-		// ...
+		// 处理文件上传
+		if form, err := c.MultipartForm(); err == nil && form.File != nil {
+			fileHeaders := form.File["files"]
+			if len(fileHeaders) > maxAgentFiles {
+				errors.HandleError(c, errors.New(errors.CodeInvalidParameter, fmt.Sprintf("最多上传 %d 个文件", maxAgentFiles)))
+				return
+			}
+			for _, fileHeader := range fileHeaders {
+				file, err := saveAgentFile(c, conversationID, fileHeader)
+				if err != nil {
+					errors.HandleError(c, errors.New(errors.CodeInvalidParameter, err.Error()))
+					return
+				}
+				files = append(files, file)
+			}
+		}
 
 	} else {
 		// JSON 请求
@@ -171,22 +276,24 @@ func SendMessage(c *gin.Context) {
 		userMessage = req.Message
 		if req.Config != nil {
 			config = &models.AgentConfig{
-				Mode:         req.Config.Mode,
-				Model:        req.Config.Model,
-				MaxSteps:     req.Config.MaxSteps,
-				Temperature:  req.Config.Temperature,
-				AllowedTools: req.Config.AllowedTools,
+				Mode:          req.Config.Mode,
+				Model:         req.Config.Model,
+				MaxSteps:      req.Config.MaxSteps,
+				Temperature:   req.Config.Temperature,
+				AllowedTools:  req.Config.AllowedTools,
+				ToolApprovals: req.Config.ToolApprovals,
 			}
 		}
 	}
 
+	if userMessage == "" && len(files) > 0 {
+		userMessage = "请查看我上传的文件。"
+	}
 	if userMessage == "" {
 		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "消息内容不能为空"))
 		return
 	}
 
-	agentService := agent.NewAgentService()
-
 	// 1. 创建用户消息
 	userMsg, err := agentService.CreateMessage(conversationID, "user", userMessage, files)
 	if err != nil {
@@ -201,31 +308,77 @@ func SendMessage(c *gin.Context) {
 		return
 	}
 
-	// 3. 获取对话历史
-	messages, _ := agentService.GetMessages(conversationID)
-	conversationHistory := agentService.BuildContextFromMessages(messages, 10)
-
-	// 4. 检查是否请求流式响应
+	// 3. 检查是否请求流式响应（对话历史由 AgentService 按模型上下文窗口加载）
 	acceptHeader := c.GetHeader("Accept")
 	if strings.Contains(acceptHeader, "text/event-stream") {
 		// 流式响应
-		handleStreamResponse(c, agentService, agentMsg.ID, userMessage, files, config, conversationHistory)
+		handleStreamResponse(c, agentService, userID, agentMsg.ID, userMessage, files, config)
 	} else {
 		// 普通响应
-		handleNormalResponse(c, agentService, agentMsg.ID, userMessage, files, config, conversationHistory, userMsg, agentMsg)
+		handleNormalResponse(c, agentService, userID, agentMsg.ID, userMessage, files, config, userMsg, agentMsg)
+	}
+}
+
+// saveAgentFile 保存对话中上传的文件，返回的本地路径可作为工具的 file 参数
+func saveAgentFile(c *gin.Context, conversationID string, fileHeader *multipart.FileHeader) (models.AgentFile, error) {
+	if fileHeader.Size > maxAgentFileSize {
+		return models.AgentFile{}, fmt.Errorf("文件 %s 超过 %d MB", fileHeader.Filename, maxAgentFileSize>>20)
+	}
+
+	// 1. 按对话创建目录
+	dir := filepath.Join(agentFilesDir, filepath.Base(conversationID))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return models.AgentFile{}, fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	// 2. 安全处理文件名（加时间戳前缀避免同名覆盖）
+	filename := filepath.Base(fileHeader.Filename)
+	filename = strings.ReplaceAll(filename, "..", "")
+	filePath := filepath.Join(dir, fmt.Sprintf("%d_%s", time.Now().UnixNano(), filename))
+
+	// 3. 保存文件
+	if err := c.SaveUploadedFile(fileHeader, filePath); err != nil {
+		return models.AgentFile{}, fmt.Errorf("保存文件失败: %w", err)
+	}
+
+	// 4. 检测 MIME 类型（文本类文件以扩展名为准）
+	mimeType := fileHeader.Header.Get("Content-Type")
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		if file, err := os.Open(filePath); err == nil {
+			buffer := make([]byte, 512)
+			n, _ := file.Read(buffer)
+			file.Close()
+			mimeType = http.DetectContentType(buffer[:n])
+		}
 	}
+
+	log.Info("Agent 文件已保存: %s, 大小: %d bytes, MIME: %s", filePath, fileHeader.Size, mimeType)
+
+	return models.AgentFile{
+		Path:     filePath,
+		Filename: filename,
+		Size:     fileHeader.Size,
+		MimeType: mimeType,
+	}, nil
 }
 
 // handleStreamResponse 处理流式响应（SSE）
 func handleStreamResponse(
 	c *gin.Context,
 	agentService *agent.AgentService,
+	userID string,
 	messageID string,
 	userMessage string,
 	files []models.AgentFile,
 	config *models.AgentConfig,
-	conversationHistory string,
 ) {
+	streamAgentEvents(c, func(streamCallback func(event agent.AgentStreamEvent) error) error {
+		return agentService.ExecuteAgent(context.Background(), messageID, userID, userMessage, files, config, streamCallback)
+	})
+}
+
+// streamAgentEvents 以 SSE 推送一次 Agent 执行（新消息或恢复执行）的事件
+func streamAgentEvents(c *gin.Context, run func(streamCallback func(event agent.AgentStreamEvent) error) error) {
 	// 设置 SSE 响应头
 	c.Header("Content-Type", "text/event-stream")
 	c.Header("Cache-Control", "no-cache")
@@ -251,11 +404,7 @@ func handleStreamResponse(
 		return nil
 	}
 
-	// 异步执行 Agent
-	ctx := context.Background()
-	err := agentService.ExecuteAgent(ctx, messageID, userMessage, files, config, conversationHistory, streamCallback)
-
-	if err != nil {
+	if err := run(streamCallback); err != nil {
 		// 发送错误事件
 		errorEvent := agent.AgentStreamEvent{
 			Type: "error",
@@ -278,17 +427,17 @@ func handleStreamResponse(
 func handleNormalResponse(
 	c *gin.Context,
 	agentService *agent.AgentService,
+	userID string,
 	messageID string,
 	userMessage string,
 	files []models.AgentFile,
 	config *models.AgentConfig,
-	conversationHistory string,
 	userMsg *models.AgentMessage,
 	agentMsg *models.AgentMessage,
 ) {
 	// 同步执行 Agent
 	ctx := context.Background()
-	err := agentService.ExecuteAgent(ctx, messageID, userMessage, files, config, conversationHistory, nil)
+	err := agentService.ExecuteAgent(ctx, messageID, userID, userMessage, files, config, nil)
 
 	if err != nil {
 		errors.HandleError(c, errors.New(errors.CodeInternal, "Agent 执行失败: "+err.Error()))
@@ -306,3 +455,72 @@ func handleNormalResponse(
 		"agent_message": finalAgentMsg,
 	}, "发送消息成功")
 }
+
+// ResolveToolApproval 确认、修改参数或拒绝等待确认的工具调用，并恢复 Agent 执行（支持流式响应）
+func ResolveToolApproval(c *gin.Context) {
+	userID := c.GetString("user_id")
+	messageID := c.Param("id")
+
+	var req request.AgentToolApprovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "参数错误: "+err.Error()))
+		return
+	}
+	if req.Decision == executor.ApprovalEdit && req.Arguments == nil {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "修改参数时必须提供 arguments"))
+		return
+	}
+
+	decision := executor.ApprovalDecision{
+		Action: req.Decision,
+		Args:   req.Arguments,
+		Reason: req.Reason,
+	}
+
+	agentService := agent.NewAgentService()
+
+	if strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		streamAgentEvents(c, func(streamCallback func(event agent.AgentStreamEvent) error) error {
+			return agentService.ResumeAgent(context.Background(), messageID, userID, decision, streamCallback)
+		})
+		return
+	}
+
+	if err := agentService.ResumeAgent(context.Background(), messageID, userID, decision, nil); err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "恢复执行失败: "+err.Error()))
+		return
+	}
+
+	agentMsg, err := agentService.GetMessageByID(messageID)
+	if err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInternal, "获取 Agent 消息失败: "+err.Error()))
+		return
+	}
+
+	errors.ResponseSuccess(c, agentMsg, "处理工具调用确认成功")
+}
+
+// CreateWorkflowFromMessage 将已完成的 Agent 消息转换为工作流（未启用，可在编辑器中调整后启用调度）
+func CreateWorkflowFromMessage(c *gin.Context) {
+	userID := c.GetString("user_id")
+	messageID := c.Param("id")
+
+	var req request.CreateWorkflowFromAgentMessageRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "参数错误: "+err.Error()))
+			return
+		}
+	}
+
+	wf, skipped, err := agent.NewAgentService().CreateWorkflowFromMessage(messageID, userID, req.Name, req.Description)
+	if err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "生成工作流失败: "+err.Error()))
+		return
+	}
+
+	errors.ResponseSuccess(c, gin.H{
+		"workflow":      workflow.NewWorkflowService().ToWorkflowResponse(wf),
+		"skipped_steps": skipped,
+	}, "生成工作流成功")
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"auto-forge/internal/dto/request"
+	"auto-forge/internal/services/tool_config"
+	"auto-forge/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListToolConnections 获取当前用户的工具凭证连接
+func ListToolConnections(c *gin.Context) {
+	service := tool_config.NewConnectionService()
+
+	connections, err := service.ListConnections(c.GetString("user_id"), c.Query("tool_code"))
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	errors.ResponseSuccess(c, connections, "获取成功")
+}
+
+// CreateToolConnection 创建工具凭证连接
+func CreateToolConnection(c *gin.Context) {
+	var req request.CreateToolConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "参数错误: "+err.Error()))
+		return
+	}
+
+	service := tool_config.NewConnectionService()
+	connection, err := service.CreateConnection(c.GetString("user_id"), req.ToolCode, req.Name, req.Config, req.IsDefault)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	errors.ResponseSuccess(c, connection, "创建成功")
+}
+
+// UpdateToolConnection 更新工具凭证连接
+func UpdateToolConnection(c *gin.Context) {
+	var req request.UpdateToolConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "参数错误: "+err.Error()))
+		return
+	}
+
+	service := tool_config.NewConnectionService()
+	connection, err := service.UpdateConnection(c.Param("id"), c.GetString("user_id"), req.Name, req.Config, req.IsDefault)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	errors.ResponseSuccess(c, connection, "更新成功")
+}
+
+// DeleteToolConnection 删除工具凭证连接
+func DeleteToolConnection(c *gin.Context) {
+	service := tool_config.NewConnectionService()
+	if err := service.DeleteConnection(c.Param("id"), c.GetString("user_id")); err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	errors.ResponseSuccess(c, nil, "删除成功")
+}
@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"auto-forge/internal/dto/request"
+	"auto-forge/internal/services/datastore"
+	"auto-forge/pkg/errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListDataCollections 获取当前用户的数据集合
+func ListDataCollections(c *gin.Context) {
+	service := datastore.NewDataStoreService()
+
+	collections, err := service.ListCollections(c.GetString("user_id"))
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	errors.ResponseSuccess(c, collections, "获取成功")
+}
+
+// CreateDataCollection 创建数据集合
+func CreateDataCollection(c *gin.Context) {
+	var req request.CreateDataCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "参数错误: "+err.Error()))
+		return
+	}
+
+	service := datastore.NewDataStoreService()
+	collection, err := service.CreateCollection(c.GetString("user_id"), req.Name, req.Description)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	errors.ResponseSuccess(c, collection, "创建成功")
+}
+
+// UpdateDataCollection 更新数据集合
+func UpdateDataCollection(c *gin.Context) {
+	var req request.UpdateDataCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "参数错误: "+err.Error()))
+		return
+	}
+
+	service := datastore.NewDataStoreService()
+	collection, err := service.UpdateCollection(c.Param("id"), c.GetString("user_id"), req.Name, req.Description)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	errors.ResponseSuccess(c, collection, "更新成功")
+}
+
+// DeleteDataCollection 删除数据集合及其全部文档
+func DeleteDataCollection(c *gin.Context) {
+	service := datastore.NewDataStoreService()
+	if err := service.DeleteCollection(c.Param("id"), c.GetString("user_id")); err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	errors.ResponseSuccess(c, nil, "删除成功")
+}
+
+// ListDataDocuments 分页浏览集合中的文档，支持按键前缀筛选
+func ListDataDocuments(c *gin.Context) {
+	query := &datastore.Query{SortBy: c.Query("sort_by"), Desc: c.Query("desc") == "true"}
+	query.Limit, _ = strconv.Atoi(c.Query("limit"))
+	query.Offset, _ = strconv.Atoi(c.Query("offset"))
+	if prefix := c.Query("prefix"); prefix != "" {
+		query.Filters = append(query.Filters, datastore.Filter{Field: datastore.FieldKey, Op: datastore.OpPrefix, Value: prefix})
+	}
+
+	service := datastore.NewDataStoreService()
+	result, err := service.QueryDocuments(c.GetString("user_id"), c.Param("id"), query)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	errors.ResponseSuccess(c, result, "获取成功")
+}
+
+// QueryDataDocuments 按过滤条件查询集合中的文档
+func QueryDataDocuments(c *gin.Context) {
+	var query datastore.Query
+	if err := c.ShouldBindJSON(&query); err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "参数错误: "+err.Error()))
+		return
+	}
+
+	service := datastore.NewDataStoreService()
+	result, err := service.QueryDocuments(c.GetString("user_id"), c.Param("id"), &query)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	errors.ResponseSuccess(c, result, "查询成功")
+}
+
+// GetDataDocument 获取文档
+func GetDataDocument(c *gin.Context) {
+	service := datastore.NewDataStoreService()
+
+	document, err := service.GetDocument(c.GetString("user_id"), c.Param("id"), c.Param("key"))
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	errors.ResponseSuccess(c, document, "获取成功")
+}
+
+// PutDataDocument 创建或覆盖文档
+func PutDataDocument(c *gin.Context) {
+	var req request.PutDataDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "参数错误: "+err.Error()))
+		return
+	}
+
+	service := datastore.NewDataStoreService()
+	document, _, err := service.UpsertDocument(c.GetString("user_id"), c.Param("id"), c.Param("key"), req.Data)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	errors.ResponseSuccess(c, document, "保存成功")
+}
+
+// DeleteDataDocument 删除文档
+func DeleteDataDocument(c *gin.Context) {
+	service := datastore.NewDataStoreService()
+
+	deleted, err := service.DeleteDocument(c.GetString("user_id"), c.Param("id"), c.Param("key"))
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+	if !deleted {
+		errors.HandleError(c, errors.New(errors.CodeNotFound, "文档不存在"))
+		return
+	}
+
+	errors.ResponseSuccess(c, nil, "删除成功")
+}
+
+// ExportDataCollection 导出集合为 JSON 或 CSV 文件
+func ExportDataCollection(c *gin.Context) {
+	service := datastore.NewDataStoreService()
+
+	collection, err := service.GetCollection(c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	format := c.DefaultQuery("format", datastore.ExportJSON)
+	data, contentType, err := service.ExportCollection(c.GetString("user_id"), collection.GetID(), format)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", collection.Name, time.Now().Format("20060102150405"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(filename)))
+	c.Data(http.StatusOK, contentType, data)
+}
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"auto-forge/internal/dto/request"
+	"auto-forge/internal/services/openapi_tool"
+	"auto-forge/pkg/common"
+	"io"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSpecUploadSize 上传文档的大小上限
+const maxSpecUploadSize = 10 * 1024 * 1024
+
+// ParseOpenAPISpec 解析 OpenAPI 文档，返回可导入的操作（管理端）
+func ParseOpenAPISpec(c *gin.Context) {
+	req, spec, ok := bindOpenAPIImport(c)
+	if !ok {
+		return
+	}
+
+	service := openapi_tool.NewOpenAPIToolService()
+	doc, err := service.ParseSpec(spec, req.SpecURL)
+	if err != nil {
+		common.HandleError(c, err)
+		return
+	}
+
+	common.Success(c, doc, "解析文档成功")
+}
+
+// ImportOpenAPITools 将文档中选中的操作导入为工具（管理端）
+func ImportOpenAPITools(c *gin.Context) {
+	req, spec, ok := bindOpenAPIImport(c)
+	if !ok {
+		return
+	}
+
+	service := openapi_tool.NewOpenAPIToolService()
+	tools, err := service.ImportTools(spec, req.SpecURL, req.OperationIDs, req.BaseURL)
+	if err != nil {
+		common.HandleError(c, err)
+		return
+	}
+
+	common.Success(c, tools, "导入工具成功")
+}
+
+// GetOpenAPITools 获取 OpenAPI 工具列表（管理端）
+func GetOpenAPITools(c *gin.Context) {
+	service := openapi_tool.NewOpenAPIToolService()
+
+	tools, err := service.ListTools()
+	if err != nil {
+		common.HandleError(c, err)
+		return
+	}
+
+	common.Success(c, tools, "获取工具列表成功")
+}
+
+// GetOpenAPITool 获取 OpenAPI 工具详情（管理端）
+func GetOpenAPITool(c *gin.Context) {
+	service := openapi_tool.NewOpenAPIToolService()
+
+	tool, err := service.GetTool(c.Param("id"))
+	if err != nil {
+		common.HandleError(c, err)
+		return
+	}
+
+	common.Success(c, tool, "获取工具详情成功")
+}
+
+// UpdateOpenAPITool 更新 OpenAPI 工具定义（管理端）
+func UpdateOpenAPITool(c *gin.Context) {
+	var req request.UpdateOpenAPIToolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(c, "参数错误")
+		return
+	}
+
+	service := openapi_tool.NewOpenAPIToolService()
+	tool, err := service.UpdateTool(c.Param("id"), req.Definition)
+	if err != nil {
+		common.HandleError(c, err)
+		return
+	}
+
+	common.Success(c, tool, "更新工具成功")
+}
+
+// DeleteOpenAPITool 删除 OpenAPI 工具（管理端）
+func DeleteOpenAPITool(c *gin.Context) {
+	service := openapi_tool.NewOpenAPIToolService()
+
+	if err := service.DeleteTool(c.Param("id")); err != nil {
+		common.HandleError(c, err)
+		return
+	}
+
+	common.SuccessWithMessage(c, "删除工具成功")
+}
+
+// bindOpenAPIImport 读取导入参数：JSON 请求体，或上传文档文件的 multipart 表单（file / spec_url / operation_ids / base_url）
+func bindOpenAPIImport(c *gin.Context) (*request.ImportOpenAPIToolsRequest, []byte, bool) {
+	var req request.ImportOpenAPIToolsRequest
+
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		req.SpecURL = c.PostForm("spec_url")
+		req.BaseURL = c.PostForm("base_url")
+		if ids := strings.TrimSpace(c.PostForm("operation_ids")); ids != "" {
+			for _, id := range strings.Split(ids, ",") {
+				req.OperationIDs = append(req.OperationIDs, strings.TrimSpace(id))
+			}
+		}
+
+		file, err := c.FormFile("file")
+		if err != nil {
+			return &req, nil, true
+		}
+		if file.Size > maxSpecUploadSize {
+			common.BadRequest(c, "文档文件过大")
+			return nil, nil, false
+		}
+		f, err := file.Open()
+		if err != nil {
+			common.BadRequest(c, "读取文档文件失败")
+			return nil, nil, false
+		}
+		defer f.Close()
+		spec, err := io.ReadAll(f)
+		if err != nil {
+			common.BadRequest(c, "读取文档文件失败")
+			return nil, nil, false
+		}
+		return &req, spec, true
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.BadRequest(c, "参数错误")
+		return nil, nil, false
+	}
+	return &req, []byte(req.Spec), true
+}
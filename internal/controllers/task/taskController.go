@@ -6,6 +6,7 @@ import (
 	"auto-forge/internal/dto/request"
 	"auto-forge/internal/dto/response"
 	taskService "auto-forge/internal/services/task"
+	"auto-forge/internal/services/workflow"
 	"auto-forge/pkg/common"
 	"auto-forge/pkg/errors"
 
@@ -258,6 +259,12 @@ func DisableTask(c *gin.Context) {
 
 
 func GetTaskExecutions(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "用户未登录"))
+		return
+	}
+
 	taskID := c.Param("id")
 	page := c.DefaultQuery("page", "1")
 	pageSize := c.DefaultQuery("page_size", "20")
@@ -272,7 +279,7 @@ func GetTaskExecutions(c *gin.Context) {
 	}
 
 	service := taskService.GetTaskService()
-	executions, total, err := service.GetTaskExecutions(taskID, pageInt, pageSizeInt)
+	executions, total, err := service.GetTaskExecutions(taskID, userID, pageInt, pageSizeInt)
 	if err != nil {
 		errors.HandleError(c, err)
 		return
@@ -290,10 +297,16 @@ func GetTaskExecutions(c *gin.Context) {
 
 
 func GetExecution(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "用户未登录"))
+		return
+	}
+
 	id := c.Param("id")
 
 	service := taskService.GetTaskService()
-	execution, err := service.GetExecutionByID(id)
+	execution, err := service.GetExecutionByID(id, userID)
 	if err != nil {
 		errors.HandleError(c, err)
 		return
@@ -305,10 +318,10 @@ func GetExecution(c *gin.Context) {
 
 func TriggerTask(c *gin.Context) {
 	id := c.Param("id")
-	userID := c.Query("user_id")
+	userID := c.GetString("user_id")
 
 	if userID == "" {
-		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "user_id不能为空"))
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "用户未登录"))
 		return
 	}
 
@@ -321,3 +334,57 @@ func TriggerTask(c *gin.Context) {
 	errors.ResponseSuccess(c, nil, "触发任务成功")
 }
 
+
+
+// GetTaskAlertPolicy 获取任务告警策略（即任务承载工作流的告警策略）
+func GetTaskAlertPolicy(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "用户未登录"))
+		return
+	}
+
+	task, err := taskService.GetTaskService().GetTaskByID(c.Param("id"), userID)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	policy, err := workflow.NewAlertService().GetPolicy(task.WorkflowID, userID)
+	if err != nil {
+		errors.HandleError(c, errors.New(errors.CodeNotFound, "获取告警策略失败: "+err.Error()))
+		return
+	}
+
+	errors.ResponseSuccess(c, policy, "获取告警策略成功")
+}
+
+
+// UpdateTaskAlertPolicy 保存任务告警策略
+func UpdateTaskAlertPolicy(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "用户未登录"))
+		return
+	}
+
+	req, err := common.ValidateRequest[request.UpdateAlertPolicyRequest](c)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	task, err := taskService.GetTaskService().GetTaskByID(c.Param("id"), userID)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	policy, err := workflow.NewAlertService().SavePolicy(task.WorkflowID, userID, req)
+	if err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "更新告警策略失败: "+err.Error()))
+		return
+	}
+
+	errors.ResponseSuccess(c, policy, "告警策略已保存")
+}
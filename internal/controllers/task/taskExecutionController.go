@@ -10,10 +10,10 @@ import (
 
 func DeleteExecution(c *gin.Context) {
 	id := c.Param("id")
-	userID := c.Query("user_id")
+	userID := c.GetString("user_id")
 
 	if userID == "" {
-		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "user_id不能为空"))
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "用户未登录"))
 		return
 	}
 
@@ -29,10 +29,10 @@ func DeleteExecution(c *gin.Context) {
 
 func DeleteAllExecutions(c *gin.Context) {
 	taskID := c.Param("id")
-	userID := c.Query("user_id")
+	userID := c.GetString("user_id")
 
 	if userID == "" {
-		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "user_id不能为空"))
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "用户未登录"))
 		return
 	}
 
@@ -2,6 +2,7 @@ package task
 
 import (
 	"auto-forge/internal/models"
+	"auto-forge/internal/services/tool_config"
 	"auto-forge/pkg/common"
 	"auto-forge/pkg/errors"
 	"auto-forge/pkg/utools"
@@ -16,6 +17,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// maxTestResponseSize 测试请求响应体大小上限
+const maxTestResponseSize = 1 << 20
+
 
 type TestTaskRequest struct {
 	ToolCode string                 `json:"tool_code"`
@@ -78,6 +82,10 @@ func testWithToolSystem(c *gin.Context, req *TestTaskRequest) {
 		errors.HandleError(c, errors.NewValidationError("tool_code", "工具不存在: "+toolCode))
 		return
 	}
+	if err := tool_config.CheckToolPolicy(toolCode, c.GetString("user_id")); err != nil {
+		errors.HandleError(c, err)
+		return
+	}
 
 
 	var config map[string]interface{}
@@ -105,10 +113,17 @@ func testWithToolSystem(c *gin.Context, req *TestTaskRequest) {
 	}
 
 
+	connectionID, config := utools.SplitConnection(config)
+	config, err = utools.CoerceConfig(tool.GetSchema(), config)
+	if err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "工具配置校验失败: "+err.Error()))
+		return
+	}
 	ctx := &utools.ExecutionContext{
-		Context: context.Background(),
-		TaskID:  "test",
-		UserID:  c.GetString("user_id"),
+		Context:      context.Background(),
+		TaskID:       "test",
+		UserID:       c.GetString("user_id"),
+		ConnectionID: connectionID,
 	}
 
 	result, err := tool.Execute(ctx, config)
@@ -189,9 +204,11 @@ func testHTTPRequest(c *gin.Context, req *TestTaskRequest) {
 	}
 
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
+	// 测试地址由用户填写，使用遵循出站策略的客户端，响应只用于展示，限制为 1MB
+	client := utools.NewHTTPClient(utools.HTTPClientOptions{
+		Timeout:         30 * time.Second,
+		MaxResponseSize: maxTestResponseSize,
+	})
 
 	resp, err := client.Do(httpReq)
 	durationMs := time.Since(startTime).Milliseconds()
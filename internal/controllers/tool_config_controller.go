@@ -105,7 +105,7 @@ func UpdateToolSettings(c *gin.Context) {
 	service := tool_config.NewToolConfigService()
 
 	// 更新设置
-	if err := service.UpdateToolSettings(toolCode, req.Enabled, req.Visible, req.SortOrder); err != nil {
+	if err := service.UpdateToolSettings(toolCode, req.Enabled, req.Visible, req.SortOrder, req.AllowedRoles); err != nil {
 		log.Error("更新工具设置失败: %v", err)
 		common.ServerError(c, "更新设置失败")
 		return
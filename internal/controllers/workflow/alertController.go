@@ -0,0 +1,87 @@
+package workflow
+
+import (
+	"auto-forge/internal/dto/request"
+	"auto-forge/internal/services/workflow"
+	"auto-forge/pkg/common"
+	"auto-forge/pkg/errors"
+	log "auto-forge/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+var alertService = workflow.NewAlertService()
+
+// GetAlertPolicy 获取工作流告警策略
+func GetAlertPolicy(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "未授权"))
+		return
+	}
+
+	policy, err := alertService.GetPolicy(c.Param("id"), userID)
+	if err != nil {
+		errors.HandleError(c, errors.New(errors.CodeNotFound, "获取告警策略失败: "+err.Error()))
+		return
+	}
+
+	errors.ResponseSuccess(c, policy, "获取告警策略成功")
+}
+
+// UpdateAlertPolicy 创建或更新工作流告警策略
+func UpdateAlertPolicy(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "未授权"))
+		return
+	}
+
+	req, err := common.ValidateRequest[request.UpdateAlertPolicyRequest](c)
+	if err != nil {
+		errors.HandleError(c, err)
+		return
+	}
+
+	policy, err := alertService.SavePolicy(c.Param("id"), userID, req)
+	if err != nil {
+		log.Error("更新告警策略失败: %v", err)
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "更新告警策略失败: "+err.Error()))
+		return
+	}
+
+	errors.ResponseSuccess(c, policy, "告警策略已保存")
+}
+
+// DeleteAlertPolicy 删除工作流告警策略
+func DeleteAlertPolicy(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "未授权"))
+		return
+	}
+
+	if err := alertService.DeletePolicy(c.Param("id"), userID); err != nil {
+		log.Error("删除告警策略失败: %v", err)
+		errors.HandleError(c, errors.New(errors.CodeInternal, "删除告警策略失败: "+err.Error()))
+		return
+	}
+
+	errors.ResponseSuccess(c, nil, "告警策略已删除")
+}
+
+// TestAlertPolicy 向已配置的渠道发送测试告警
+func TestAlertPolicy(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "未授权"))
+		return
+	}
+
+	if err := alertService.SendTestAlert(c.Param("id"), userID); err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInternal, "发送测试告警失败: "+err.Error()))
+		return
+	}
+
+	errors.ResponseSuccess(c, nil, "测试告警已发送")
+}
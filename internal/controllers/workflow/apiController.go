@@ -191,3 +191,36 @@ func UpdateAPIWebhook(c *gin.Context) {
 
 	errors.ResponseSuccess(c, nil, "Webhook URL 已更新")
 }
+
+// UpdateAgentCallable 设置工作流是否可作为 Agent 工具调用
+func UpdateAgentCallable(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "未授权"))
+		return
+	}
+
+	workflowID := c.Param("id")
+	if workflowID == "" {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "工作流ID不能为空"))
+		return
+	}
+
+	var req request.UpdateAgentCallableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "参数错误: "+err.Error()))
+		return
+	}
+
+	svc := workflow.NewWorkflowService()
+	if err := svc.SetAgentCallable(workflowID, userID, *req.AgentCallable); err != nil {
+		log.Error("更新 Agent 调用状态失败: %v", err)
+		errors.HandleError(c, errors.New(errors.CodeInternal, "更新失败: "+err.Error()))
+		return
+	}
+
+	errors.ResponseSuccess(c, gin.H{
+		"agent_callable": *req.AgentCallable,
+		"tool_name":      workflow.AgentToolName(workflowID),
+	}, "Agent 调用设置已更新")
+}
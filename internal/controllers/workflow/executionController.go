@@ -324,3 +324,27 @@ func updateExecutionIDInParams(params map[string]interface{}, newExecutionID str
 		}
 	}
 }
+
+// GetExecutionChain 获取执行的链式触发关系
+func GetExecutionChain(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "未授权"))
+		return
+	}
+
+	executionID := c.Param("executionId")
+	if executionID == "" {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "执行ID不能为空"))
+		return
+	}
+
+	chain, err := workflow.NewChainService().GetExecutionChain(executionID, userID)
+	if err != nil {
+		log.Error("获取执行触发链失败: %v", err)
+		errors.HandleError(c, errors.New(errors.CodeNotFound, "获取执行触发链失败: "+err.Error()))
+		return
+	}
+
+	errors.ResponseSuccess(c, chain, "获取执行触发链成功")
+}
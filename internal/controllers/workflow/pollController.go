@@ -0,0 +1,81 @@
+package workflow
+
+import (
+	"auto-forge/internal/services/workflow"
+	"auto-forge/pkg/errors"
+	log "auto-forge/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+var pollService = workflow.NewPollService()
+
+// GetPollState 获取轮询触发器状态（游标与去重键）
+func GetPollState(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "未授权"))
+		return
+	}
+
+	workflowID := c.Param("id")
+	if workflowID == "" {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "工作流ID不能为空"))
+		return
+	}
+
+	state, err := pollService.GetPollState(workflowID, userID)
+	if err != nil {
+		errors.HandleError(c, errors.New(errors.CodeNotFound, "获取轮询状态失败: "+err.Error()))
+		return
+	}
+
+	errors.ResponseSuccess(c, state, "获取轮询状态成功")
+}
+
+// ResetPollState 重置轮询触发器状态
+func ResetPollState(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "未授权"))
+		return
+	}
+
+	workflowID := c.Param("id")
+	if workflowID == "" {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "工作流ID不能为空"))
+		return
+	}
+
+	if err := pollService.ResetPollState(workflowID, userID); err != nil {
+		log.Error("重置轮询状态失败: %v", err)
+		errors.HandleError(c, errors.New(errors.CodeInternal, err.Error()))
+		return
+	}
+
+	errors.ResponseSuccess(c, nil, "轮询状态已重置")
+}
+
+// PollNow 立即执行一次轮询
+func PollNow(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "未授权"))
+		return
+	}
+
+	workflowID := c.Param("id")
+	if workflowID == "" {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "工作流ID不能为空"))
+		return
+	}
+
+	result, err := pollService.PollNow(workflowID, userID)
+	if err != nil {
+		log.Error("手动轮询失败: %v", err)
+		errors.HandleError(c, errors.New(errors.CodeInternal, "轮询失败: "+err.Error()))
+		return
+	}
+
+	errors.ResponseSuccess(c, result, "轮询完成")
+}
@@ -6,6 +6,7 @@ import (
 	"auto-forge/internal/services/workflow"
 	"auto-forge/pkg/errors"
 	log "auto-forge/pkg/logger"
+	"auto-forge/pkg/utools"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -175,7 +176,8 @@ func sendWebhookNotification(webhookURL string, executionID string, userID strin
 		return
 	}
 
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(payloadBytes))
+	client := utools.NewHTTPClient(utools.HTTPClientOptions{Timeout: 30 * time.Second})
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewBuffer(payloadBytes))
 	if err != nil {
 		log.Error("发送 Webhook 通知失败: URL=%s, Error=%v", webhookURL, err)
 		return
@@ -0,0 +1,30 @@
+package workflow
+
+import (
+	"auto-forge/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpgradeToolVersions 将工作流节点固定的旧工具版本升级到最新版本
+func UpgradeToolVersions(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "未授权"))
+		return
+	}
+
+	workflowID := c.Param("id")
+	if workflowID == "" {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "工作流ID不能为空"))
+		return
+	}
+
+	upgrades, err := workflowService.UpgradeToolVersions(workflowID, userID)
+	if err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "升级工具版本失败: "+err.Error()))
+		return
+	}
+
+	errors.ResponseSuccess(c, upgrades, "升级工具版本成功")
+}
@@ -0,0 +1,199 @@
+package workflow
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/internal/services/workflow"
+	"auto-forge/pkg/errors"
+	log "auto-forge/pkg/logger"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxWebhookBodySize 入站 Webhook 请求体大小上限（10MB）
+const maxWebhookBodySize = 10 << 20
+
+// ReceiveWebhook 接收入站 Webhook 并触发工作流
+func ReceiveWebhook(c *gin.Context) {
+	token := c.Param("token")
+	if token == "" {
+		errors.HandleError(c, errors.New(errors.CodeNotFound, "Webhook 地址无效"))
+		return
+	}
+
+	svc := workflow.NewWorkflowService()
+
+	wf, err := svc.GetWorkflowByWebhookToken(token)
+	if err != nil {
+		errors.HandleError(c, errors.New(errors.CodeNotFound, "Webhook 地址无效"))
+		return
+	}
+	if !wf.Enabled {
+		errors.HandleError(c, errors.New(errors.CodeForbidden, "工作流已禁用"))
+		return
+	}
+
+	triggerNode := svc.FindWebhookTriggerNode(wf.Nodes, wf.Edges)
+	if triggerNode == nil {
+		errors.HandleError(c, errors.New(errors.CodeNotFound, "工作流未配置 Webhook 触发器"))
+		return
+	}
+
+	rawBody, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBodySize+1))
+	if err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "读取请求体失败"))
+		return
+	}
+	if len(rawBody) > maxWebhookBodySize {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "请求体过大"))
+		return
+	}
+
+	cfg := workflow.ParseWebhookTriggerConfig(triggerNode.Config)
+	secret := workflow.ResolveWebhookSecret(cfg.Secret, wf.EnvVars)
+	if err := workflow.VerifyWebhookSignature(cfg, secret, c.Request.Header, rawBody); err != nil {
+		log.Warn("Webhook 签名校验失败: WorkflowID=%s, Error=%v", wf.GetID(), err)
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "签名校验失败"))
+		return
+	}
+
+	triggerData := buildWebhookTriggerData(c, rawBody)
+
+	executionSvc := workflow.NewExecutionService()
+	execution, err := executionSvc.CreateExecution(wf.GetID(), wf.UserID, "webhook")
+	if err != nil {
+		log.Error("创建执行记录失败: %v", err)
+		errors.HandleError(c, errors.New(errors.CodeInternal, "执行失败: "+err.Error()))
+		return
+	}
+
+	if cfg.ResponseMode == workflow.WebhookResponseSync {
+		handleSyncWebhook(c, svc, wf, execution, triggerData)
+		return
+	}
+
+	engineSvc := workflow.NewEngineService()
+	execID := execution.GetID()
+	go func() {
+		if err := engineSvc.ExecuteWorkflowWithTrigger(execID, nil, nil, triggerData); err != nil {
+			log.Error("Webhook 触发工作流执行失败: ExecutionID=%s, Error=%v", execID, err)
+		}
+	}()
+
+	errors.ResponseSuccess(c, gin.H{
+		"execution_id": execID,
+		"status":       "running",
+	}, "已接受")
+}
+
+func handleSyncWebhook(c *gin.Context, svc *workflow.WorkflowService, wf *models.Workflow, execution *models.WorkflowExecution, triggerData map[string]interface{}) {
+	result, err := svc.ExecuteWorkflowSyncWithTrigger(execution.GetID(), wf.UserID, wf.APITimeout, nil, triggerData)
+	if err != nil {
+		log.Error("Webhook 同步执行工作流失败: %v", err)
+		errors.HandleError(c, errors.New(errors.CodeInternal, "执行失败: "+err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// buildWebhookTriggerData 将请求头、查询参数和请求体转换为 {{trigger.*}} 变量
+func buildWebhookTriggerData(c *gin.Context, rawBody []byte) map[string]interface{} {
+	headers := make(map[string]interface{}, len(c.Request.Header))
+	for key, values := range c.Request.Header {
+		headers[strings.ToLower(key)] = strings.Join(values, ", ")
+	}
+
+	query := make(map[string]interface{})
+	for key, values := range c.Request.URL.Query() {
+		if len(values) == 1 {
+			query[key] = values[0]
+		} else {
+			query[key] = values
+		}
+	}
+
+	contentType := c.ContentType()
+
+	return map[string]interface{}{
+		"type":         "webhook",
+		"method":       c.Request.Method,
+		"headers":      headers,
+		"query":        query,
+		"body":         parseWebhookBody(contentType, rawBody),
+		"raw_body":     string(rawBody),
+		"content_type": contentType,
+		"remote_ip":    c.ClientIP(),
+		"received_at":  time.Now().Unix(),
+	}
+}
+
+// parseWebhookBody 按 Content-Type 解析请求体：JSON、表单或原始文本
+func parseWebhookBody(contentType string, rawBody []byte) interface{} {
+	if len(rawBody) == 0 {
+		return nil
+	}
+
+	switch {
+	case strings.Contains(contentType, "json"):
+		var body interface{}
+		if err := json.Unmarshal(rawBody, &body); err == nil {
+			return body
+		}
+	case contentType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(rawBody))
+		if err != nil {
+			break
+		}
+		form := make(map[string]interface{}, len(values))
+		for key, vals := range values {
+			if len(vals) == 1 {
+				form[key] = vals[0]
+			} else {
+				form[key] = vals
+			}
+		}
+		// GitHub 表单格式的 Webhook 将 JSON 放在 payload 字段中
+		if payload, ok := form["payload"].(string); ok {
+			var parsed interface{}
+			if err := json.Unmarshal([]byte(payload), &parsed); err == nil {
+				form["payload"] = parsed
+			}
+		}
+		return form
+	}
+
+	return string(rawBody)
+}
+
+// RegenerateWebhookToken 重新生成 Webhook 地址
+func RegenerateWebhookToken(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		errors.HandleError(c, errors.New(errors.CodeUnauthorized, "未授权"))
+		return
+	}
+
+	workflowID := c.Param("id")
+	if workflowID == "" {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "工作流ID不能为空"))
+		return
+	}
+
+	svc := workflow.NewWorkflowService()
+	token, err := svc.RegenerateWebhookToken(workflowID, userID)
+	if err != nil {
+		log.Error("重新生成 Webhook 令牌失败: %v", err)
+		errors.HandleError(c, errors.New(errors.CodeInternal, "重新生成失败: "+err.Error()))
+		return
+	}
+
+	errors.ResponseSuccess(c, gin.H{
+		"webhook_token": token,
+	}, "Webhook 地址已重新生成")
+}
@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"auto-forge/internal/dto/request"
+	"auto-forge/internal/services/agent"
 	"auto-forge/internal/services/workflow"
 	"auto-forge/pkg/errors"
 	log "auto-forge/pkg/logger"
@@ -33,7 +34,9 @@ func CreateWorkflow(c *gin.Context) {
 		return
 	}
 
-	errors.ResponseSuccess(c, workflowService.ToWorkflowResponse(wf), "创建工作流成功")
+	resp := workflowService.ToWorkflowResponse(wf)
+	resp.ToolIssues = workflowService.CheckToolReferences(userID, wf.Nodes)
+	errors.ResponseSuccess(c, resp, "创建工作流成功")
 }
 
 
@@ -81,7 +84,9 @@ func GetWorkflowByID(c *gin.Context) {
 		return
 	}
 
-	errors.ResponseSuccess(c, workflowService.ToWorkflowResponse(wf), "获取工作流详情成功")
+	resp := workflowService.ToWorkflowResponse(wf)
+	resp.ToolIssues = workflowService.CheckToolReferences(userID, wf.Nodes)
+	errors.ResponseSuccess(c, resp, "获取工作流详情成功")
 }
 
 
@@ -111,7 +116,9 @@ func UpdateWorkflow(c *gin.Context) {
 		return
 	}
 
-	errors.ResponseSuccess(c, workflowService.ToWorkflowResponse(wf), "更新工作流成功")
+	resp := workflowService.ToWorkflowResponse(wf)
+	resp.ToolIssues = workflowService.CheckToolReferences(userID, wf.Nodes)
+	errors.ResponseSuccess(c, resp, "更新工作流成功")
 }
 
 
@@ -192,6 +199,24 @@ func GetWorkflowStats(c *gin.Context) {
 }
 
 
+// GenerateWorkflow 根据自然语言描述生成工作流草稿（不保存，由前端确认后创建）
+func GenerateWorkflow(c *gin.Context) {
+	var req request.GenerateWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errors.HandleError(c, errors.New(errors.CodeInvalidParameter, "参数错误: "+err.Error()))
+		return
+	}
+
+	draft, err := agent.NewAgentService().GenerateWorkflow(c.Request.Context(), req.Description, req.Model)
+	if err != nil {
+		log.Error("生成工作流失败: %v", err)
+		errors.HandleError(c, errors.New(errors.CodeInternal, "生成工作流失败: "+err.Error()))
+		return
+	}
+
+	errors.ResponseSuccess(c, draft, "生成工作流成功")
+}
+
 func ValidateWorkflow(c *gin.Context) {
 	var req request.ValidateWorkflowRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -210,11 +235,23 @@ func ValidateWorkflow(c *gin.Context) {
 		return
 	}
 
+	// 引用已禁用或无权使用的工具视为无效，已废弃的工具只给出警告
+	var validationErrors, warnings []string
+	for _, issue := range workflowService.CheckToolReferences(c.GetString("user_id"), req.Nodes) {
+		message := "节点 " + issue.NodeID + ": " + issue.Message
+		if issue.Blocking {
+			validationErrors = append(validationErrors, message)
+		} else {
+			warnings = append(warnings, message)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"code": 0,
 		"data": gin.H{
-			"valid":  true,
-			"errors": nil,
+			"valid":    len(validationErrors) == 0,
+			"errors":   validationErrors,
+			"warnings": warnings,
 		},
 	})
 }
@@ -0,0 +1,17 @@
+package cron
+
+import (
+	"auto-forge/internal/services/workflow"
+	"auto-forge/pkg/logger"
+)
+
+// slowRunCheckSpec 运行中执行超时检查的调度表达式（每分钟）
+const slowRunCheckSpec = "0 * * * * *"
+
+// registerSlowRunCheck 注册运行中执行的超时告警检查，卡住不结束的执行也能触发告警
+func registerSlowRunCheck() {
+	alertService := workflow.NewAlertService()
+	if _, err := cronManager.AddFunc(slowRunCheckSpec, alertService.CheckRunningExecutions); err != nil {
+		logger.Error("注册执行超时检查任务失败: %v", err)
+	}
+}
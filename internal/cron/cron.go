@@ -20,9 +20,6 @@ func InitCronManager() {
 	cronManager.Start()
 	logger.Info("Cron manager started")
 
-	// 初始化任务调度器
-	InitTaskScheduler()
-
 	// 初始化工作流调度器
 	InitWorkflowScheduler()
 }
@@ -30,7 +27,7 @@ func InitCronManager() {
 // registerTasks 注册所有定时任务
 func registerTasks() {
 	// 在这里注册其他定时任务
-	// registerOtherTask()
+	registerSlowRunCheck()
 }
 
 // Stop 停止所有定时任务
@@ -40,9 +37,6 @@ func Stop() {
 		logger.Info("Cron manager stopped")
 	}
 
-	// 停止任务调度器
-	StopTaskScheduler()
-
 	// 停止工作流调度器
 	StopWorkflowScheduler()
 }
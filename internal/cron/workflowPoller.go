@@ -0,0 +1,62 @@
+package cron
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/internal/services/workflow"
+	"auto-forge/pkg/database"
+	"auto-forge/pkg/logger"
+	"fmt"
+
+	"github.com/robfig/cron/v3"
+)
+
+// reloadPollingWorkflows 重新加载所有带轮询触发器的工作流
+func (ws *WorkflowScheduler) reloadPollingWorkflows() {
+	for _, entryID := range ws.pollIDs {
+		ws.cron.Remove(entryID)
+	}
+	ws.pollIDs = make(map[string]cron.EntryID)
+
+	var workflows []models.Workflow
+	if err := database.GetDB().Where("enabled = ?", true).Find(&workflows).Error; err != nil {
+		logger.Error("获取轮询工作流列表失败: %v", err)
+		return
+	}
+
+	for i := range workflows {
+		wf := workflows[i]
+		node := ws.workflowService.FindPollTriggerNode(wf.Nodes, wf.Edges)
+		if node == nil {
+			continue
+		}
+
+		cfg := workflow.ParsePollTriggerConfig(node.Config)
+		spec := fmt.Sprintf("@every %ds", cfg.Interval)
+
+		entryID, err := ws.cron.AddFunc(spec, func() {
+			ws.pollWorkflow(&wf)
+		})
+		if err != nil {
+			logger.Error("添加轮询工作流失败 [%s]: %v", wf.Name, err)
+			continue
+		}
+
+		ws.pollIDs[wf.GetID()] = entryID
+		logger.Info("轮询工作流已添加到调度器: %s (ID: %s, 数据源: %s, 调度: %s)", wf.Name, wf.GetID(), cfg.ToolCode, spec)
+	}
+
+	logger.Info("轮询触发器加载完成: %d 个工作流", len(ws.pollIDs))
+}
+
+// pollWorkflow 执行一次轮询
+func (ws *WorkflowScheduler) pollWorkflow(wf *models.Workflow) {
+	result, err := ws.pollService.Poll(wf)
+	if err != nil {
+		logger.Error("轮询工作流失败: WorkflowID=%s, Error=%v", wf.GetID(), err)
+		return
+	}
+
+	if result.Triggered {
+		logger.Info("轮询发现 %d 个新条目，已触发工作流: %s (ExecutionID: %s)", result.NewItems, wf.Name, result.ExecutionID)
+	}
+}
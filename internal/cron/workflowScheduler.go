@@ -6,6 +6,7 @@ import (
 	"auto-forge/pkg/database"
 	"auto-forge/pkg/logger"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/robfig/cron/v3"
@@ -21,7 +22,9 @@ type WorkflowScheduler struct {
 	workflowService  *workflow.WorkflowService
 	executionService *workflow.ExecutionService
 	engineService    *workflow.EngineService
+	pollService      *workflow.PollService
 	workflowIDs      map[string]cron.EntryID // workflowID -> entryID 的映射
+	pollIDs          map[string]cron.EntryID // 轮询触发的 workflowID -> entryID 的映射
 }
 
 // InitWorkflowScheduler 初始化工作流调度器
@@ -31,7 +34,9 @@ func InitWorkflowScheduler() {
 		workflowService:  workflow.NewWorkflowService(),
 		executionService: workflow.NewExecutionService(),
 		engineService:    workflow.NewEngineService(),
+		pollService:      workflow.NewPollService(),
 		workflowIDs:      make(map[string]cron.EntryID),
+		pollIDs:          make(map[string]cron.EntryID),
 	}
 
 	// 注册工作流变更回调
@@ -83,6 +88,9 @@ func (ws *WorkflowScheduler) ReloadWorkflows() {
 	}
 
 	logger.Info("===== 工作流调度器加载完成: 成功 %d/%d =====", successCount, len(workflows))
+
+	// 重新加载轮询触发的工作流
+	ws.reloadPollingWorkflows()
 }
 
 // getAllScheduledWorkflows 获取所有需要调度的工作流
@@ -180,9 +188,12 @@ func (ws *WorkflowScheduler) buildCronSpec(scheduleType, scheduleValue string) (
 		return fmt.Sprintf("%s %s * * * *", parts[1], parts[0]), nil
 
 	case "interval":
-		// scheduleValue: 秒数
-		// cron 不直接支持间隔，这里使用每N秒执行一次的方式
-		return fmt.Sprintf("*/%s * * * * *", scheduleValue), nil
+		// scheduleValue: 秒数。不能写成秒字段的 */N，N 超过 59 时会退化为每分钟执行
+		seconds, err := strconv.Atoi(strings.TrimSpace(scheduleValue))
+		if err != nil || seconds <= 0 {
+			return "", fmt.Errorf("invalid interval format: %s", scheduleValue)
+		}
+		return fmt.Sprintf("@every %ds", seconds), nil
 
 	case "cron":
 		// 直接使用 cron 表达式
@@ -0,0 +1,33 @@
+package cron
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+func TestBuildCronSpecInterval(t *testing.T) {
+	ws := &WorkflowScheduler{}
+	parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, seconds := range []int{30, 60, 300, 7200} {
+		spec, err := ws.buildCronSpec("interval", strconv.Itoa(seconds))
+		if err != nil {
+			t.Fatalf("buildCronSpec(%d): %v", seconds, err)
+		}
+		schedule, err := parser.Parse(spec)
+		if err != nil {
+			t.Fatalf("parse %q: %v", spec, err)
+		}
+		if got := schedule.Next(start).Sub(start); got != time.Duration(seconds)*time.Second {
+			t.Errorf("interval %ds fires after %v", seconds, got)
+		}
+	}
+
+	if _, err := ws.buildCronSpec("interval", "abc"); err == nil {
+		t.Error("expected error for invalid interval")
+	}
+}
@@ -12,11 +12,12 @@ type UpdateAgentConversationRequest struct {
 
 // AgentConfig Agent 配置
 type AgentConfig struct {
-	Mode         string   `json:"mode,omitempty"`          // plan/direct
-	Model        string   `json:"model,omitempty"`         // gpt-4o-mini, etc.
-	MaxSteps     int      `json:"max_steps,omitempty"`     // 最大步骤数
-	Temperature  float64  `json:"temperature,omitempty"`   // 温度
-	AllowedTools []string `json:"allowed_tools,omitempty"` // 允许的工具列表
+	Mode          string            `json:"mode,omitempty"`                                                            // plan/direct
+	Model         string            `json:"model,omitempty"`                                                           // gpt-4o-mini, etc.
+	MaxSteps      int               `json:"max_steps,omitempty"`                                                       // 最大步骤数
+	Temperature   float64           `json:"temperature,omitempty"`                                                     // 温度
+	AllowedTools  []string          `json:"allowed_tools,omitempty"`                                                   // 允许的工具列表
+	ToolApprovals map[string]string `json:"tool_approvals,omitempty" binding:"omitempty,dive,oneof=auto confirm deny"` // 工具审批策略，键为工具名或 "*"
 }
 
 // SendAgentMessageRequest 发送消息请求
@@ -24,3 +25,16 @@ type SendAgentMessageRequest struct {
 	Message string       `json:"message" binding:"required"`
 	Config  *AgentConfig `json:"config"`
 }
+
+// AgentToolApprovalRequest 处理待确认工具调用的请求
+type AgentToolApprovalRequest struct {
+	Decision  string                 `json:"decision" binding:"required,oneof=approve edit reject"` // approve/edit/reject
+	Arguments map[string]interface{} `json:"arguments"`                                             // edit 时的新参数
+	Reason    string                 `json:"reason"`                                                // reject 时告知模型的原因
+}
+
+// CreateWorkflowFromAgentMessageRequest 将 Agent 消息转换为工作流的请求
+type CreateWorkflowFromAgentMessageRequest struct {
+	Name        string `json:"name"`        // 工作流名称，默认取用户消息
+	Description string `json:"description"` // 工作流描述
+}
@@ -0,0 +1,18 @@
+package request
+
+// CreateDataCollectionRequest 创建数据集合请求
+type CreateDataCollectionRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+// UpdateDataCollectionRequest 更新数据集合请求，未传的字段保持不变
+type UpdateDataCollectionRequest struct {
+	Name        *string `json:"name" binding:"omitempty,max=100"`
+	Description *string `json:"description" binding:"omitempty,max=500"`
+}
+
+// PutDataDocumentRequest 写入文档请求，data 为任意 JSON 值
+type PutDataDocumentRequest struct {
+	Data interface{} `json:"data" binding:"required"`
+}
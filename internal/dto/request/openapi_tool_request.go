@@ -0,0 +1,16 @@
+package request
+
+import "auto-forge/pkg/utools/openapi"
+
+// ImportOpenAPIToolsRequest 解析或导入 OpenAPI 文档请求：spec 与 spec_url 二选一，operation_ids 为空时导入全部操作
+type ImportOpenAPIToolsRequest struct {
+	Spec         string   `json:"spec"`
+	SpecURL      string   `json:"spec_url"`
+	OperationIDs []string `json:"operation_ids"`
+	BaseURL      string   `json:"base_url"`
+}
+
+// UpdateOpenAPIToolRequest 更新 OpenAPI 工具定义请求
+type UpdateOpenAPIToolRequest struct {
+	Definition *openapi.Definition `json:"definition" binding:"required"`
+}
@@ -10,4 +10,21 @@ type UpdateToolSettingsRequest struct {
 	Enabled   bool `json:"enabled"`
 	Visible   bool `json:"visible"`
 	SortOrder int  `json:"sort_order"`
+	// AllowedRoles 允许使用的用户角色（1 超级管理员 / 2 管理员 / 3 普通用户），为空表示全部
+	AllowedRoles []int `json:"allowed_roles"`
+}
+
+// CreateToolConnectionRequest 创建工具凭证连接请求
+type CreateToolConnectionRequest struct {
+	ToolCode  string                 `json:"tool_code" binding:"required"`
+	Name      string                 `json:"name" binding:"required"`
+	Config    map[string]interface{} `json:"config" binding:"required"`
+	IsDefault bool                   `json:"is_default"`
+}
+
+// UpdateToolConnectionRequest 更新工具凭证连接请求，config 为空时保留原凭证
+type UpdateToolConnectionRequest struct {
+	Name      string                 `json:"name"`
+	Config    map[string]interface{} `json:"config"`
+	IsDefault *bool                  `json:"is_default"`
 }
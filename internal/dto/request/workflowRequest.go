@@ -62,6 +62,12 @@ type ImportWorkflowRequest struct {
 	WorkflowJSON string `json:"workflow_json" binding:"required"`
 }
 
+// GenerateWorkflowRequest 根据自然语言描述生成工作流请求
+type GenerateWorkflowRequest struct {
+	Description string `json:"description" binding:"required"` // 自动化需求描述
+	Model       string `json:"model"`                          // 生成使用的模型，默认 gpt-4o-mini
+}
+
 // ValidateWorkflowRequest 验证工作流请求
 type ValidateWorkflowRequest struct {
 	Nodes   []models.WorkflowNode   `json:"nodes" binding:"required"`
@@ -90,6 +96,11 @@ type UpdateAPITimeoutRequest struct {
 	Timeout int `json:"timeout" binding:"required,min=1,max=3600"`
 }
 
+// UpdateAgentCallableRequest 设置工作流是否可被 Agent 调用请求
+type UpdateAgentCallableRequest struct {
+	AgentCallable *bool `json:"agent_callable" binding:"required"`
+}
+
 // UpdateAPIWebhookRequest 更新 Webhook URL 请求
 type UpdateAPIWebhookRequest struct {
 	WebhookURL string `json:"webhook_url" binding:"omitempty,url"`
@@ -100,3 +111,16 @@ type InvokeWorkflowRequest struct {
 	Params     map[string]interface{} `json:"params"`               // 用户参数
 	WebhookURL string                 `json:"webhook_url,omitempty"` // Webhook 回调地址（异步模式）
 }
+
+// UpdateAlertPolicyRequest 更新告警策略请求
+type UpdateAlertPolicyRequest struct {
+	Enabled             bool                  `json:"enabled"`
+	OnFailure           bool                  `json:"on_failure"`
+	ConsecutiveFailures int                   `json:"consecutive_failures" binding:"min=0"`
+	OnRecovery          bool                  `json:"on_recovery"`
+	MaxDurationSeconds  int                   `json:"max_duration_seconds" binding:"min=0"`
+	Channels            []models.AlertChannel `json:"channels"`
+	DedupMinutes        int                   `json:"dedup_minutes" binding:"min=0"`
+	QuietHoursStart     string                `json:"quiet_hours_start"`
+	QuietHoursEnd       string                `json:"quiet_hours_end"`
+}
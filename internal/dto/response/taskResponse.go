@@ -49,6 +49,16 @@ type TaskExecutionListResponse struct {
 	PageSize int                     `json:"page_size"`
 }
 
+// AdminExecutionResponse 管理后台执行记录响应
+// 保留任务执行记录的字段，并附带所属任务与工作流，便于区分任务执行和普通工作流执行
+type AdminExecutionResponse struct {
+	TaskExecutionResponse
+	TaskName     string `json:"task_name"`
+	WorkflowID   string `json:"workflow_id"`
+	WorkflowName string `json:"workflow_name"`
+	TriggerType  string `json:"trigger_type"`
+}
+
 // ConvertTaskToResponse 转换任务模型到响应
 func ConvertTaskToResponse(task *models.Task) TaskResponse {
 	return TaskResponse{
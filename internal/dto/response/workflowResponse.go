@@ -24,6 +24,15 @@ type WorkflowResponse struct {
 	APITimeout    int                     `json:"api_timeout"`
 	APIWebhookURL string                  `json:"api_webhook_url,omitempty"`
 
+	// Agent 调用配置
+	AgentCallable bool `json:"agent_callable"`
+
+	// 入站 Webhook 配置
+	WebhookToken string `json:"webhook_token,omitempty"`
+
+	// 引用工具的策略问题（已禁用、无权使用、已废弃等），仅在保存和查看详情时返回
+	ToolIssues []WorkflowToolIssue `json:"tool_issues,omitempty"`
+
 	TotalExecutions int                     `json:"total_executions"`
 	SuccessCount    int                     `json:"success_count"`
 	FailedCount     int                     `json:"failed_count"`
@@ -58,6 +67,32 @@ type WorkflowExecutionResponse struct {
 	Error        string                      `json:"error,omitempty"`
 	CreatedAt    int64                       `json:"created_at"`
 	UpdatedAt    int64                       `json:"updated_at"`
+
+	// 链式触发信息
+	ParentExecutionID string `json:"parent_execution_id,omitempty"`
+	ParentWorkflowID  string `json:"parent_workflow_id,omitempty"`
+	ChainDepth        int    `json:"chain_depth"`
+
+	// Agent 调用信息
+	AgentMessageID string `json:"agent_message_id,omitempty"`
+}
+
+// ExecutionChainNode 执行链中的一个执行记录
+type ExecutionChainNode struct {
+	ExecutionID  string `json:"execution_id"`
+	WorkflowID   string `json:"workflow_id"`
+	WorkflowName string `json:"workflow_name"`
+	Status       string `json:"status"`
+	TriggerType  string `json:"trigger_type"`
+	ChainDepth   int    `json:"chain_depth"`
+	StartTime    *int64 `json:"start_time"`
+}
+
+// ExecutionChainResponse 执行触发链响应
+type ExecutionChainResponse struct {
+	Ancestors  []ExecutionChainNode `json:"ancestors"`  // 上游执行（从最早的根执行开始）
+	Current    ExecutionChainNode   `json:"current"`    // 当前执行
+	Downstream []ExecutionChainNode `json:"downstream"` // 由当前执行直接触发的下游执行
 }
 
 // ExecutionListResponse 执行历史列表响应
@@ -89,3 +124,30 @@ type ValidateWorkflowResponse struct {
 	Valid  bool     `json:"valid"`
 	Errors []string `json:"errors,omitempty"`
 }
+
+// WorkflowToolIssue 工作流节点引用的工具问题
+type WorkflowToolIssue struct {
+	NodeID   string `json:"node_id"`
+	NodeName string `json:"node_name"`
+	ToolCode string `json:"tool_code"`
+	Message  string `json:"message"`
+	Blocking bool   `json:"blocking"` // 为 true 时节点无法执行（工具不存在、已禁用或无权使用），否则仅为警告（如已废弃）
+}
+
+// WorkflowToolReport 引用了不可用工具的工作流（管理端报表）
+type WorkflowToolReport struct {
+	WorkflowID   string              `json:"workflow_id"`
+	WorkflowName string              `json:"workflow_name"`
+	UserID       string              `json:"user_id"`
+	Enabled      bool                `json:"enabled"`
+	Issues       []WorkflowToolIssue `json:"issues"`
+}
+
+// ToolVersionUpgrade 节点工具版本升级结果
+type ToolVersionUpgrade struct {
+	NodeID      string `json:"node_id"`
+	NodeName    string `json:"node_name"`
+	ToolCode    string `json:"tool_code"`
+	FromVersion string `json:"from_version"`
+	ToVersion   string `json:"to_version"`
+}
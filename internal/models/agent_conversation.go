@@ -15,6 +15,11 @@ type AgentConversation struct {
 	Title     string `gorm:"type:varchar(255);not null" json:"title"`
 	CreatedAt int64  `gorm:"not null" json:"created_at"`
 	UpdatedAt int64  `gorm:"not null" json:"updated_at"`
+
+	// 对话记忆：超出上下文预算的较早轮次滚动合并为摘要，避免每次重新计算
+	Summary          string `gorm:"type:text" json:"summary,omitempty"`
+	SummarizedCount  int    `gorm:"not null;default:0" json:"summarized_count"` // 已合并进摘要的消息数（按时间顺序的前 N 条）
+	SummaryUpdatedAt int64  `json:"summary_updated_at,omitempty"`
 }
 
 // TableName 指定表名
@@ -47,18 +52,21 @@ func (c *AgentConversation) BeforeUpdate(tx *gorm.DB) error {
 
 // AgentMessage Agent 消息
 type AgentMessage struct {
-	ID             string       `gorm:"type:varchar(36);primaryKey" json:"id"`
-	ConversationID string       `gorm:"type:varchar(36);not null;index" json:"conversation_id"`
-	Role           string       `gorm:"type:varchar(20);not null" json:"role"` // user/agent/system
-	Content        string       `gorm:"type:text;not null" json:"content"`
-	Files          AgentFiles   `gorm:"type:json" json:"files,omitempty"`        // 用户上传的文件
-	Trace          *AgentTrace  `gorm:"type:json" json:"trace,omitempty"`        // Agent 执行轨迹
-	Plan           *AgentPlan   `gorm:"type:json" json:"plan,omitempty"`         // Agent 执行计划
-	Config         *AgentConfig `gorm:"type:json" json:"config,omitempty"`       // Agent 配置
-	TokenUsage     *TokenUsage  `gorm:"type:json" json:"token_usage,omitempty"`  // Token 使用情况
-	Status         string       `gorm:"type:varchar(20);not null" json:"status"` // pending/running/completed/failed
-	Error          string       `gorm:"type:text" json:"error,omitempty"`        // 错误信息
-	CreatedAt      int64        `gorm:"not null" json:"created_at"`
+	ID              string                `gorm:"type:varchar(36);primaryKey" json:"id"`
+	ConversationID  string                `gorm:"type:varchar(36);not null;index" json:"conversation_id"`
+	Role            string                `gorm:"type:varchar(20);not null" json:"role"` // user/agent/system
+	Content         string                `gorm:"type:text;not null" json:"content"`
+	Files           AgentFiles            `gorm:"type:json" json:"files,omitempty"`            // 用户上传的文件
+	Trace           *AgentTrace           `gorm:"type:json" json:"trace,omitempty"`            // Agent 执行轨迹
+	Plan            *AgentPlan            `gorm:"type:json" json:"plan,omitempty"`             // Agent 执行计划
+	Config          *AgentConfig          `gorm:"type:json" json:"config,omitempty"`           // Agent 配置
+	TokenUsage      *TokenUsage           `gorm:"type:json" json:"token_usage,omitempty"`      // Token 使用情况
+	Cost            float64               `gorm:"not null;default:0" json:"cost"`              // 按价格表计算的费用（美元）
+	Status          string                `gorm:"type:varchar(20);not null" json:"status"`     // pending/running/awaiting_approval/completed/failed
+	PendingApproval *AgentPendingApproval `gorm:"type:json" json:"pending_approval,omitempty"` // 等待用户确认的工具调用
+	Checkpoint      string                `gorm:"type:longtext" json:"-"`                      // 执行暂停时的检查点，用于恢复执行
+	Error           string                `gorm:"type:text" json:"error,omitempty"`            // 错误信息
+	CreatedAt       int64                 `gorm:"not null" json:"created_at"`
 }
 
 // TableName 指定表名
@@ -89,6 +97,9 @@ type AgentConfig struct {
 	MaxSteps     int      `json:"max_steps,omitempty"`     // 最大步骤数
 	Temperature  float64  `json:"temperature,omitempty"`   // 温度
 	AllowedTools []string `json:"allowed_tools,omitempty"` // 允许的工具列表
+
+	// 工具审批策略：工具名 → auto/confirm/deny，"*" 为未单独配置工具的默认策略
+	ToolApprovals map[string]string `json:"tool_approvals,omitempty"`
 }
 
 // Scan 实现 sql.Scanner 接口
@@ -105,7 +116,7 @@ func (c *AgentConfig) Scan(value interface{}) error {
 
 // Value 实现 driver.Valuer 接口
 func (c AgentConfig) Value() (driver.Value, error) {
-	if c.Model == "" && c.Mode == "" {
+	if c.Model == "" && c.Mode == "" && len(c.ToolApprovals) == 0 {
 		return nil, nil
 	}
 	return json.Marshal(c)
@@ -147,10 +158,21 @@ func (f AgentFiles) Value() (driver.Value, error) {
 type AgentTrace struct {
 	Steps        []AgentStep            `json:"steps"`
 	FinalAnswer  string                 `json:"final_answer"`
-	FinishReason string                 `json:"finish_reason"` // final/max_steps/timeout/error
+	FinishReason string                 `json:"finish_reason"` // final/max_steps/budget_exceeded/timeout/error/approval_required
 	UsedTools    map[string]interface{} `json:"used_tools,omitempty"`
 	TotalMs      int64                  `json:"total_ms"`
 	TokenUsage   *TokenUsage            `json:"token_usage,omitempty"` // Token 使用统计
+	ToolStats    *AgentToolStats        `json:"tool_stats,omitempty"`  // 工具执行统计（缓存、重试）
+}
+
+// AgentToolStats Agent 工具执行统计
+type AgentToolStats struct {
+	Calls       int `json:"calls"`        // 工具调用次数
+	CacheHits   int `json:"cache_hits"`   // 缓存命中次数
+	CacheMisses int `json:"cache_misses"` // 启用缓存但未命中的次数
+	Retries     int `json:"retries"`      // 重试次数（不含首次尝试）
+	Failures    int `json:"failures"`     // 最终失败次数
+	Timeouts    int `json:"timeouts"`     // 超时次数
 }
 
 // TokenUsage Token 使用统计
@@ -185,7 +207,9 @@ type AgentStep struct {
 	ToolOutput  map[string]interface{} `json:"tool_output,omitempty"`
 	ElapsedMs   int64                  `json:"elapsed_ms"`
 	Timestamp   string                 `json:"timestamp"`
-	Error       string                 `json:"error,omitempty"` // 错误信息
+	Error       string                 `json:"error,omitempty"`    // 错误信息
+	Attempts    int                    `json:"attempts,omitempty"` // 工具执行尝试次数
+	Cached      bool                   `json:"cached,omitempty"`   // 结果是否来自缓存
 }
 
 // AgentAction Agent 动作
@@ -195,6 +219,32 @@ type AgentAction struct {
 	Args map[string]interface{} `json:"args,omitempty"`
 }
 
+// AgentPendingApproval 等待用户确认的工具调用
+type AgentPendingApproval struct {
+	ToolCallID  string                 `json:"tool_call_id"`
+	Tool        string                 `json:"tool"`
+	Args        map[string]interface{} `json:"args"`
+	Step        int                    `json:"step"`
+	RequestedAt string                 `json:"requested_at"`
+}
+
+// Scan 实现 sql.Scanner 接口
+func (p *AgentPendingApproval) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(bytes, p)
+}
+
+// Value 实现 driver.Valuer 接口
+func (p AgentPendingApproval) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
 // AgentPlan Agent 执行计划（Plan 模式）
 type AgentPlan struct {
 	Steps       []AgentPlanStep `json:"steps"`
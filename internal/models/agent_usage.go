@@ -0,0 +1,20 @@
+package models
+
+// AgentUsage Agent 消息的 Token 用量与费用
+// 独立于消息保存：删除对话后用量仍计入预算与统计报表
+type AgentUsage struct {
+	MessageID        string  `gorm:"type:varchar(36);primaryKey" json:"message_id"`
+	ConversationID   string  `gorm:"type:varchar(36);not null;index" json:"conversation_id"`
+	UserID           string  `gorm:"type:varchar(36);not null;index:idx_agent_usage_user_time" json:"user_id"`
+	Model            string  `gorm:"type:varchar(100);index" json:"model"`
+	PromptTokens     int64   `gorm:"not null;default:0" json:"prompt_tokens"`
+	CompletionTokens int64   `gorm:"not null;default:0" json:"completion_tokens"`
+	TotalTokens      int64   `gorm:"not null;default:0" json:"total_tokens"`
+	Cost             float64 `gorm:"not null;default:0" json:"cost"` // 按价格表计算的费用（美元）
+	CreatedAt        int64   `gorm:"not null;index;index:idx_agent_usage_user_time" json:"created_at"`
+}
+
+// TableName 指定表名
+func (AgentUsage) TableName() string {
+	return "agent_usages"
+}
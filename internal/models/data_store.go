@@ -0,0 +1,30 @@
+package models
+
+// DataCollection 用户的数据集合，存放工作流持久化的 JSON 文档（如已处理的 ID、去重集合、查找表）
+type DataCollection struct {
+	BaseModel
+	UserID        string `gorm:"type:varchar(36);not null;uniqueIndex:idx_data_collection_name,priority:1;comment:所属用户" json:"user_id"`
+	Name          string `gorm:"type:varchar(100);not null;uniqueIndex:idx_data_collection_name,priority:2;comment:集合名称" json:"name"`
+	Description   string `gorm:"type:varchar(500);comment:集合描述" json:"description"`
+	DocumentCount int64  `gorm:"-" json:"document_count"`
+}
+
+// TableName 指定表名
+func (DataCollection) TableName() string {
+	return "data_collection"
+}
+
+// DataDocument 集合中的文档，按键唯一
+type DataDocument struct {
+	BaseModel
+	CollectionID string `gorm:"type:char(36);not null;uniqueIndex:idx_data_document_key,priority:1;comment:所属集合" json:"collection_id"`
+	UserID       string `gorm:"type:varchar(36);not null;index;comment:所属用户" json:"user_id"`
+	Key          string `gorm:"column:doc_key;type:varchar(255);not null;uniqueIndex:idx_data_document_key,priority:2;comment:文档键" json:"key"`
+	Data         string `gorm:"type:longtext;comment:文档内容JSON" json:"-"`
+	Size         int    `gorm:"default:0;comment:文档大小(字节)" json:"size"`
+}
+
+// TableName 指定表名
+func (DataDocument) TableName() string {
+	return "data_document"
+}
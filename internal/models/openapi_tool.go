@@ -0,0 +1,16 @@
+package models
+
+// OpenAPITool 从 OpenAPI 文档导入的 HTTP 工具，Definition 保存可编辑的工具定义（JSON），启动时注册到工具注册表；启用状态沿用工具配置
+type OpenAPITool struct {
+	BaseModel
+	ToolCode    string `gorm:"type:varchar(50);uniqueIndex;not null;comment:工具代码" json:"tool_code"`
+	Name        string `gorm:"type:varchar(100);not null;comment:工具名称" json:"name"`
+	SpecTitle   string `gorm:"type:varchar(200);comment:来源文档标题" json:"spec_title"`
+	OperationID string `gorm:"type:varchar(200);comment:来源操作ID" json:"operation_id"`
+	Definition  string `gorm:"type:text;not null;comment:工具定义JSON" json:"-"`
+}
+
+// TableName 指定表名
+func (OpenAPITool) TableName() string {
+	return "openapi_tool"
+}
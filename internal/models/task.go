@@ -37,7 +37,7 @@ func (kva KeyValueArray) Value() (driver.Value, error) {
 	return json.Marshal(kva)
 }
 
-// Task 定时任务模型 - 直接基于工具执行
+// Task 定时任务模型 - 单工具任务，由关联的单节点工作流实际调度和执行
 type Task struct {
 	BaseModel
 	UserID      string  `gorm:"type:char(36);not null;index:idx_user_id" json:"user_id"` // 关联User表的UUID
@@ -52,8 +52,13 @@ type Task struct {
 	ScheduleValue string `gorm:"size:100;not null" json:"schedule_value"`                // 调度值
 	Enabled       bool   `gorm:"default:true;index:idx_enabled_next_run" json:"enabled"` // 是否启用
 	NextRunTime   *int64 `gorm:"index:idx_enabled_next_run" json:"next_run_time"`        // 下次执行时间(Unix timestamp)
+
+	WorkflowID string `gorm:"type:char(36);index:idx_task_workflow_id" json:"workflow_id"` // 承载任务的单节点工作流ID
 }
 
+// TaskToolNodeID 任务对应工作流中工具节点的固定ID
+const TaskToolNodeID = "task_tool"
+
 // TableName 指定表名
 func (Task) TableName() string {
 	return "task"
@@ -67,3 +72,24 @@ func (t *Task) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+// WorkflowNodes 将任务转换为单个工具节点，供工作流引擎执行
+func (t *Task) WorkflowNodes() (WorkflowNodes, error) {
+	config := make(map[string]interface{})
+	if t.Config != "" {
+		if err := json.Unmarshal([]byte(t.Config), &config); err != nil {
+			return nil, err
+		}
+	}
+
+	return WorkflowNodes{
+		{
+			ID:       TaskToolNodeID,
+			Type:     "tool",
+			ToolCode: t.ToolCode,
+			Name:     t.Name,
+			Config:   config,
+			Position: map[string]float64{"x": 250, "y": 100},
+		},
+	}, nil
+}
@@ -5,6 +5,7 @@ import (
 )
 
 // TaskExecution 任务执行记录模型
+// Deprecated: 任务已统一由工作流引擎执行，新记录写入 WorkflowExecution，此表仅保留历史数据
 type TaskExecution struct {
 	BaseModel
 	TaskID         string        `gorm:"type:char(36);not null;index:idx_task_id_started" json:"task_id"`
@@ -9,6 +9,7 @@ type Tool struct {
 	Description        string                           `json:"description"`
 	Category           string                           `json:"category"`
 	Version            string                           `json:"version"`
+	Versions           []string                         `json:"versions,omitempty"` // 已注册的全部版本，节点可固定其中之一
 	Author             string                           `json:"author"`
 	Icon               string                           `json:"icon"`
 	ConfigSchema       string                           `json:"config_schema"`
@@ -20,6 +20,7 @@ type ToolConfig struct {
 	Author       string    `gorm:"type:varchar(100);comment:工具作者" json:"author"`
 	Tags         string    `gorm:"type:varchar(500);comment:工具标签(逗号分隔)" json:"tags"`
 	SortOrder    int       `gorm:"default:0;comment:排序" json:"sort_order"`
+	AllowedRoles string    `gorm:"type:varchar(50);comment:允许使用的用户角色(逗号分隔,空表示全部)" json:"allowed_roles"`
 	LastSyncAt   time.Time `gorm:"comment:最后同步时间" json:"last_sync_at"`
 	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
@@ -0,0 +1,16 @@
+package models
+
+// ToolConnection 用户保存的工具凭证（连接），加密存储，执行时代替管理员的全局配置注入到工具中
+type ToolConnection struct {
+	BaseModel
+	UserID     string `gorm:"type:varchar(36);not null;uniqueIndex:idx_tool_connection_name,priority:1;comment:所属用户" json:"user_id"`
+	ToolCode   string `gorm:"type:varchar(50);not null;uniqueIndex:idx_tool_connection_name,priority:2;comment:工具代码" json:"tool_code"`
+	Name       string `gorm:"type:varchar(100);not null;uniqueIndex:idx_tool_connection_name,priority:3;comment:连接名称" json:"name"`
+	ConfigJSON string `gorm:"type:text;comment:凭证JSON(加密)" json:"-"` // 不返回给前端
+	IsDefault  bool   `gorm:"default:false;comment:是否为该工具的默认连接" json:"is_default"`
+}
+
+// TableName 指定表名
+func (ToolConnection) TableName() string {
+	return "tool_connection"
+}
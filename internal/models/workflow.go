@@ -9,14 +9,15 @@ import (
 
 // WorkflowNode 工作流节点
 type WorkflowNode struct {
-	ID       string                 `json:"id"`
-	Type     string                 `json:"type"`     // tool/trigger/condition/delay/switch
-	ToolCode string                 `json:"toolCode"` // 工具代码（仅当type=tool时）
-	Name     string                 `json:"name"`
-	Config   map[string]interface{} `json:"config"`
-	Retry    *NodeRetryConfig       `json:"retry,omitempty"`
-	Position map[string]float64     `json:"position"`
-	Data     map[string]interface{} `json:"data,omitempty"` // 保留兼容性
+	ID          string                 `json:"id"`
+	Type        string                 `json:"type"`                  // tool/trigger/condition/delay/switch
+	ToolCode    string                 `json:"toolCode"`              // 工具代码（仅当type=tool时）
+	ToolVersion string                 `json:"toolVersion,omitempty"` // 固定的工具版本，为空时使用最新版本
+	Name        string                 `json:"name"`
+	Config      map[string]interface{} `json:"config"`
+	Retry       *NodeRetryConfig       `json:"retry,omitempty"`
+	Position    map[string]float64     `json:"position"`
+	Data        map[string]interface{} `json:"data,omitempty"` // 保留兼容性
 }
 
 // NodeRetryConfig 节点重试配置
@@ -203,6 +204,12 @@ type Workflow struct {
 	APILastCalledAt *int64            `gorm:"index" json:"api_last_called_at"`                          // 最后一次 API 调用时间
 	APIWebhookURL   string            `gorm:"size:500" json:"api_webhook_url,omitempty"`                // Webhook 回调地址（异步模式）
 
+	// Agent 调用配置
+	AgentCallable bool `gorm:"default:false" json:"agent_callable"` // 是否可作为 Agent 工具调用（参数取自 APIParams）
+
+	// 入站 Webhook 触发配置
+	WebhookToken string `gorm:"size:64;index:idx_webhook_token" json:"webhook_token,omitempty"` // 入站 Webhook 令牌（用于生成唯一 URL）
+
 	// 统计信息
 	TotalExecutions int    `gorm:"default:0" json:"total_executions"`
 	SuccessCount    int    `gorm:"default:0" json:"success_count"`
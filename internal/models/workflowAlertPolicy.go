@@ -0,0 +1,125 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+
+	"gorm.io/gorm"
+)
+
+// 告警通知渠道
+const (
+	AlertChannelEmail   = "email"   // 邮件（系统邮件服务）
+	AlertChannelFeishu  = "feishu"  // 飞书机器人
+	AlertChannelWebhook = "webhook" // 通用 Webhook（POST JSON）
+)
+
+// 告警事件类型
+const (
+	AlertEventFailure             = "failure"              // 执行失败
+	AlertEventConsecutiveFailures = "consecutive_failures" // 连续失败达到阈值
+	AlertEventRecovery            = "recovery"             // 失败后恢复成功
+	AlertEventSlow                = "slow"                 // 执行耗时超过阈值
+)
+
+// AlertChannel 告警通知渠道配置
+type AlertChannel struct {
+	Type   string `json:"type"`             // email/feishu/webhook
+	Target string `json:"target"`           // 邮箱地址 / 飞书机器人 Webhook / 回调地址
+	Secret string `json:"secret,omitempty"` // 飞书签名密钥（可选）
+}
+
+// AlertChannels 告警通知渠道数组
+type AlertChannels []AlertChannel
+
+// Scan 实现 sql.Scanner 接口
+func (ac *AlertChannels) Scan(value interface{}) error {
+	if value == nil {
+		*ac = AlertChannels{}
+		return nil
+	}
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, ac)
+	case string:
+		return json.Unmarshal([]byte(v), ac)
+	}
+	return nil
+}
+
+// Value 实现 driver.Valuer 接口
+func (ac AlertChannels) Value() (driver.Value, error) {
+	if len(ac) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(ac)
+}
+
+// AlertTimestamps 各告警事件最近一次发送时间
+type AlertTimestamps map[string]int64
+
+// Scan 实现 sql.Scanner 接口
+func (at *AlertTimestamps) Scan(value interface{}) error {
+	if value == nil {
+		*at = AlertTimestamps{}
+		return nil
+	}
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, at)
+	case string:
+		return json.Unmarshal([]byte(v), at)
+	}
+	return nil
+}
+
+// Value 实现 driver.Valuer 接口
+func (at AlertTimestamps) Value() (driver.Value, error) {
+	if len(at) == 0 {
+		return "{}", nil
+	}
+	return json.Marshal(at)
+}
+
+// WorkflowAlertPolicy 工作流失败告警策略（任务通过其承载工作流配置）
+type WorkflowAlertPolicy struct {
+	BaseModel
+	WorkflowID string `gorm:"type:char(36);not null;uniqueIndex:idx_alert_workflow_id" json:"workflow_id"`
+	UserID     string `gorm:"type:char(36);not null;index" json:"user_id"`
+	Enabled    bool   `json:"enabled"`
+
+	// 告警规则
+	OnFailure           bool `json:"on_failure"`                            // 每次失败都告警
+	ConsecutiveFailures int  `gorm:"default:0" json:"consecutive_failures"` // 连续失败 N 次时告警（0 表示关闭）
+	OnRecovery          bool `json:"on_recovery"`                           // 失败后恢复成功时告警
+	MaxDurationSeconds  int  `gorm:"default:0" json:"max_duration_seconds"` // 单次执行超过该时长时告警（0 表示关闭）
+
+	// 通知与降噪
+	Channels        AlertChannels `gorm:"type:json" json:"channels"`
+	DedupMinutes    int           `json:"dedup_minutes"`                   // 同类告警去重窗口（分钟，0 表示不去重）
+	QuietHoursStart string        `gorm:"size:5" json:"quiet_hours_start"` // 免打扰开始时间 HH:MM
+	QuietHoursEnd   string        `gorm:"size:5" json:"quiet_hours_end"`   // 免打扰结束时间 HH:MM
+
+	// 运行状态
+	ConsecutiveFailureCount int             `gorm:"default:0" json:"consecutive_failure_count"`
+	LastAlertAt             AlertTimestamps `gorm:"type:json" json:"last_alert_at"`
+}
+
+// TableName 指定表名
+func (WorkflowAlertPolicy) TableName() string {
+	return "workflow_alert_policy"
+}
+
+// BeforeCreate 创建前的钩子
+func (p *WorkflowAlertPolicy) BeforeCreate(tx *gorm.DB) error {
+	if err := p.BaseModel.BeforeCreate(tx); err != nil {
+		return err
+	}
+	if p.Channels == nil {
+		p.Channels = AlertChannels{}
+	}
+	if p.LastAlertAt == nil {
+		p.LastAlertAt = AlertTimestamps{}
+	}
+	return nil
+}
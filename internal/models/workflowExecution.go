@@ -46,6 +46,7 @@ type NodeExecutionLog struct {
 	RetryCount   int                    `json:"retry_count"`
 	ToolCode     string                 `json:"tool_code,omitempty"`
 	ToolVersion  string                 `json:"tool_version,omitempty"`
+	Logs         []string               `json:"logs,omitempty"` // 工具运行期间的输出
 }
 
 // NodeExecutionLogs 节点执行日志数组
@@ -80,7 +81,7 @@ type WorkflowExecution struct {
 	UserID       string            `gorm:"type:char(36);not null;index:idx_user_id" json:"user_id"`
 	User         *User             `gorm:"-" json:"user,omitempty"`
 	Status       string            `gorm:"size:20;not null;index:idx_status" json:"status"`
-	TriggerType  string            `gorm:"size:20" json:"trigger_type"` // manual/schedule/webhook
+	TriggerType  string            `gorm:"size:20" json:"trigger_type"` // manual/schedule/webhook/poll/workflow/agent
 	StartTime    *int64            `gorm:"index:idx_start_time" json:"start_time"`
 	EndTime      *int64            `json:"end_time"`
 	DurationMs   int64             `json:"duration_ms"`
@@ -90,6 +91,14 @@ type WorkflowExecution struct {
 	SkippedNodes int               `gorm:"default:0" json:"skipped_nodes"`
 	NodeLogs     NodeExecutionLogs `gorm:"type:json" json:"node_logs"`
 	Error        string            `gorm:"type:text" json:"error,omitempty"`
+
+	// 工作流链式触发信息
+	ParentExecutionID string `gorm:"type:char(36);index:idx_parent_execution_id" json:"parent_execution_id,omitempty"` // 触发本次执行的上游执行ID
+	ParentWorkflowID  string `gorm:"type:char(36)" json:"parent_workflow_id,omitempty"`                                // 触发本次执行的上游工作流ID
+	ChainDepth        int    `gorm:"default:0" json:"chain_depth"`                                                      // 链式触发深度（0 表示非链式触发）
+
+	// Agent 调用信息
+	AgentMessageID string `gorm:"type:varchar(36);index:idx_agent_message_id" json:"agent_message_id,omitempty"` // 调用本次执行的 Agent 消息ID
 }
 
 // TableName 指定表名
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+
+	"gorm.io/gorm"
+)
+
+// PollSeenKeys 轮询触发器已处理条目的去重键列表（按处理顺序，最新在后）
+type PollSeenKeys []string
+
+// Scan 实现 sql.Scanner 接口
+func (k *PollSeenKeys) Scan(value interface{}) error {
+	if value == nil {
+		*k = PollSeenKeys{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		if str, isStr := value.(string); isStr {
+			bytes = []byte(str)
+		} else {
+			return nil
+		}
+	}
+	return json.Unmarshal(bytes, k)
+}
+
+// Value 实现 driver.Valuer 接口
+func (k PollSeenKeys) Value() (driver.Value, error) {
+	if len(k) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(k)
+}
+
+// WorkflowPollState 工作流轮询触发器状态（游标与去重键）
+type WorkflowPollState struct {
+	BaseModel
+	WorkflowID      string       `gorm:"type:char(36);not null;uniqueIndex:idx_poll_workflow_id" json:"workflow_id"`
+	NodeID          string       `gorm:"size:100" json:"node_id"`
+	SeenKeys        PollSeenKeys `gorm:"type:json" json:"seen_keys"`
+	Initialized     bool         `gorm:"default:false" json:"initialized"`                 // 是否已完成首次轮询（首次轮询仅记录游标）
+	PollCount       int          `gorm:"default:0" json:"poll_count"`                      // 累计轮询次数
+	TriggerCount    int          `gorm:"default:0" json:"trigger_count"`                   // 累计触发次数
+	LastPolledAt    *int64       `json:"last_polled_at"`                                   // 最后一次轮询时间
+	LastTriggeredAt *int64       `json:"last_triggered_at"`                                // 最后一次触发时间
+	LastNewItems    int          `gorm:"default:0" json:"last_new_items"`                  // 最后一次轮询发现的新条目数
+	LastError       string       `gorm:"type:text" json:"last_error,omitempty"`            // 最后一次轮询错误
+	LastExecutionID string       `gorm:"type:char(36)" json:"last_execution_id,omitempty"` // 最后一次触发的执行ID
+}
+
+// TableName 指定表名
+func (WorkflowPollState) TableName() string {
+	return "workflow_poll_state"
+}
+
+// BeforeCreate 创建前的钩子
+func (s *WorkflowPollState) BeforeCreate(tx *gorm.DB) error {
+	if err := s.BaseModel.BeforeCreate(tx); err != nil {
+		return err
+	}
+	if s.SeenKeys == nil {
+		s.SeenKeys = PollSeenKeys{}
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package repositories
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/pkg/database"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrDocumentQuotaExceeded 新建文档时用户文档数已达上限
+	ErrDocumentQuotaExceeded = errors.New("document quota exceeded")
+	// ErrDuplicateDocument 并发新建同一文档键，违反唯一索引
+	ErrDuplicateDocument = errors.New("duplicate document key")
+)
+
+// DataStoreRepository 用户数据存储仓储接口
+type DataStoreRepository interface {
+	CreateCollection(collection *models.DataCollection) error
+	UpdateCollection(collection *models.DataCollection) error
+	FindCollectionByID(id, userID string) (*models.DataCollection, error)
+	FindCollectionByName(userID, name string) (*models.DataCollection, error)
+	FindCollectionsByUser(userID string) ([]*models.DataCollection, error)
+	CountCollections(userID string) (int64, error)
+	DeleteCollection(id string) error
+
+	UpsertDocument(document *models.DataDocument, maxDocuments int64) (*models.DataDocument, bool, error)
+	FindDocument(collectionID, key string) (*models.DataDocument, error)
+	FindDocuments(collectionID string) ([]*models.DataDocument, error)
+	QueryDocuments(collectionID string, conds []clause.Expr, order clause.Expr, offset, limit int) ([]*models.DataDocument, int64, error)
+	CountDocumentsByCollection(userID string) (map[string]int64, error)
+	DeleteDocument(collectionID, key string) (bool, error)
+	Dialect() string
+}
+
+type dataStoreRepository struct {
+	db *gorm.DB
+}
+
+// NewDataStoreRepository 创建用户数据存储仓储实例
+func NewDataStoreRepository() DataStoreRepository {
+	return &dataStoreRepository{db: database.GetDB()}
+}
+
+// CreateCollection 创建集合
+func (r *dataStoreRepository) CreateCollection(collection *models.DataCollection) error {
+	return r.db.Create(collection).Error
+}
+
+// UpdateCollection 更新集合
+func (r *dataStoreRepository) UpdateCollection(collection *models.DataCollection) error {
+	return r.db.Save(collection).Error
+}
+
+// FindCollectionByID 根据 ID 查找用户的集合
+func (r *dataStoreRepository) FindCollectionByID(id, userID string) (*models.DataCollection, error) {
+	var collection models.DataCollection
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&collection).Error; err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// FindCollectionByName 根据名称查找用户的集合
+func (r *dataStoreRepository) FindCollectionByName(userID, name string) (*models.DataCollection, error) {
+	var collection models.DataCollection
+	if err := r.db.Where("user_id = ? AND name = ?", userID, name).First(&collection).Error; err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// FindCollectionsByUser 获取用户的全部集合
+func (r *dataStoreRepository) FindCollectionsByUser(userID string) ([]*models.DataCollection, error) {
+	var collections []*models.DataCollection
+	err := r.db.Where("user_id = ?", userID).Order("name ASC").Find(&collections).Error
+	return collections, err
+}
+
+// CountCollections 统计用户的集合数
+func (r *dataStoreRepository) CountCollections(userID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.DataCollection{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// DeleteCollection 删除集合及其全部文档（物理删除，释放名称唯一索引）
+func (r *dataStoreRepository) DeleteCollection(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("collection_id = ?", id).Delete(&models.DataDocument{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id = ?", id).Delete(&models.DataCollection{}).Error
+	})
+}
+
+// UpsertDocument 在事务内写入文档：键已存在则更新内容，否则在用户文档数未达 maxDocuments 时新建。
+// 返回写入后的文档与是否为新建；并发新建同一键时返回 ErrDuplicateDocument
+func (r *dataStoreRepository) UpsertDocument(document *models.DataDocument, maxDocuments int64) (*models.DataDocument, bool, error) {
+	saved := document
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var existing models.DataDocument
+		err := tx.Where("collection_id = ? AND doc_key = ?", document.CollectionID, document.Key).First(&existing).Error
+		if err == nil {
+			existing.Data = document.Data
+			existing.Size = document.Size
+			saved = &existing
+			return tx.Save(&existing).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.DataDocument{}).Where("user_id = ?", document.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= maxDocuments {
+			return ErrDocumentQuotaExceeded
+		}
+		created = true
+		return tx.Create(document).Error
+	})
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return nil, false, ErrDuplicateDocument
+		}
+		return nil, false, err
+	}
+	return saved, created, nil
+}
+
+// isDuplicateKeyError 判断是否违反唯一索引（SQLite 与 MySQL 的错误信息不同）
+func isDuplicateKeyError(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "UNIQUE constraint failed") || strings.Contains(msg, "Duplicate entry")
+}
+
+// FindDocument 根据键查找文档
+func (r *dataStoreRepository) FindDocument(collectionID, key string) (*models.DataDocument, error) {
+	var document models.DataDocument
+	if err := r.db.Where("collection_id = ? AND doc_key = ?", collectionID, key).First(&document).Error; err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+// FindDocuments 获取集合的全部文档（按键排序）
+func (r *dataStoreRepository) FindDocuments(collectionID string) ([]*models.DataDocument, error) {
+	var documents []*models.DataDocument
+	err := r.db.Where("collection_id = ?", collectionID).Order("doc_key ASC").Find(&documents).Error
+	return documents, err
+}
+
+// QueryDocuments 按条件分页查询集合的文档，返回当页文档与满足条件的文档总数
+func (r *dataStoreRepository) QueryDocuments(collectionID string, conds []clause.Expr, order clause.Expr, offset, limit int) ([]*models.DataDocument, int64, error) {
+	query := r.db.Model(&models.DataDocument{}).Where("data_document.collection_id = ?", collectionID)
+	for _, cond := range conds {
+		query = query.Where(cond)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var documents []*models.DataDocument
+	err := query.Clauses(clause.OrderBy{Expression: order}).Offset(offset).Limit(limit).Find(&documents).Error
+	return documents, total, err
+}
+
+// CountDocumentsByCollection 按集合统计用户的文档数
+func (r *dataStoreRepository) CountDocumentsByCollection(userID string) (map[string]int64, error) {
+	var rows []struct {
+		CollectionID string
+		Count        int64
+	}
+	err := r.db.Model(&models.DataDocument{}).
+		Select("collection_id, COUNT(*) AS count").
+		Where("user_id = ?", userID).
+		Group("collection_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.CollectionID] = row.Count
+	}
+	return counts, nil
+}
+
+// DeleteDocument 删除文档（物理删除），返回文档是否存在
+func (r *dataStoreRepository) DeleteDocument(collectionID, key string) (bool, error) {
+	result := r.db.Unscoped().Where("collection_id = ? AND doc_key = ?", collectionID, key).Delete(&models.DataDocument{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Dialect 返回数据库类型（sqlite/mysql），用于生成 JSON 查询表达式
+func (r *dataStoreRepository) Dialect() string {
+	return r.db.Dialector.Name()
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// OpenAPIToolRepository OpenAPI 工具仓储接口
+type OpenAPIToolRepository interface {
+	Create(tool *models.OpenAPITool) error
+	Update(tool *models.OpenAPITool) error
+	FindByID(id string) (*models.OpenAPITool, error)
+	FindAll() ([]*models.OpenAPITool, error)
+	ExistsByCode(toolCode string) (bool, error)
+	Delete(id string) error
+}
+
+type openAPIToolRepository struct {
+	db *gorm.DB
+}
+
+// NewOpenAPIToolRepository 创建 OpenAPI 工具仓储实例
+func NewOpenAPIToolRepository() OpenAPIToolRepository {
+	return &openAPIToolRepository{db: database.GetDB()}
+}
+
+// Create 创建工具
+func (r *openAPIToolRepository) Create(tool *models.OpenAPITool) error {
+	return r.db.Create(tool).Error
+}
+
+// Update 更新工具
+func (r *openAPIToolRepository) Update(tool *models.OpenAPITool) error {
+	return r.db.Save(tool).Error
+}
+
+// FindByID 根据 ID 查找
+func (r *openAPIToolRepository) FindByID(id string) (*models.OpenAPITool, error) {
+	var tool models.OpenAPITool
+	if err := r.db.Where("id = ?", id).First(&tool).Error; err != nil {
+		return nil, err
+	}
+	return &tool, nil
+}
+
+// FindAll 获取全部工具
+func (r *openAPIToolRepository) FindAll() ([]*models.OpenAPITool, error) {
+	var tools []*models.OpenAPITool
+	err := r.db.Order("created_at ASC").Find(&tools).Error
+	return tools, err
+}
+
+// ExistsByCode 检查工具代码是否已被使用
+func (r *openAPIToolRepository) ExistsByCode(toolCode string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.OpenAPITool{}).Where("tool_code = ?", toolCode).Count(&count).Error
+	return count > 0, err
+}
+
+// Delete 删除工具（物理删除，释放工具代码）
+func (r *openAPIToolRepository) Delete(id string) error {
+	return r.db.Unscoped().Where("id = ?", id).Delete(&models.OpenAPITool{}).Error
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// ToolConnectionRepository 工具凭证连接仓储接口
+type ToolConnectionRepository interface {
+	Create(connection *models.ToolConnection) error
+	Update(connection *models.ToolConnection) error
+	FindByID(id string) (*models.ToolConnection, error)
+	FindByUser(userID, toolCode string) ([]*models.ToolConnection, error)
+	FindDefault(userID, toolCode string) (*models.ToolConnection, error)
+	ExistsByName(userID, toolCode, name, excludeID string) (bool, error)
+	ClearDefault(userID, toolCode, exceptID string) error
+	Delete(id string) error
+}
+
+type toolConnectionRepository struct {
+	db *gorm.DB
+}
+
+// NewToolConnectionRepository 创建工具凭证连接仓储实例
+func NewToolConnectionRepository() ToolConnectionRepository {
+	return &toolConnectionRepository{db: database.GetDB()}
+}
+
+// Create 创建连接
+func (r *toolConnectionRepository) Create(connection *models.ToolConnection) error {
+	return r.db.Create(connection).Error
+}
+
+// Update 更新连接
+func (r *toolConnectionRepository) Update(connection *models.ToolConnection) error {
+	return r.db.Save(connection).Error
+}
+
+// FindByID 根据 ID 查找
+func (r *toolConnectionRepository) FindByID(id string) (*models.ToolConnection, error) {
+	var connection models.ToolConnection
+	if err := r.db.Where("id = ?", id).First(&connection).Error; err != nil {
+		return nil, err
+	}
+	return &connection, nil
+}
+
+// FindByUser 查找用户的连接，toolCode 为空时返回全部工具的连接
+func (r *toolConnectionRepository) FindByUser(userID, toolCode string) ([]*models.ToolConnection, error) {
+	query := r.db.Where("user_id = ?", userID)
+	if toolCode != "" {
+		query = query.Where("tool_code = ?", toolCode)
+	}
+
+	var connections []*models.ToolConnection
+	err := query.Order("tool_code ASC, is_default DESC, created_at ASC").Find(&connections).Error
+	return connections, err
+}
+
+// FindDefault 查找用户某个工具的默认连接，不存在时返回 nil
+func (r *toolConnectionRepository) FindDefault(userID, toolCode string) (*models.ToolConnection, error) {
+	var connections []*models.ToolConnection
+	err := r.db.Where("user_id = ? AND tool_code = ? AND is_default = ?", userID, toolCode, true).
+		Limit(1).Find(&connections).Error
+	if err != nil || len(connections) == 0 {
+		return nil, err
+	}
+	return connections[0], nil
+}
+
+// ExistsByName 检查同一工具下是否已有同名连接
+func (r *toolConnectionRepository) ExistsByName(userID, toolCode, name, excludeID string) (bool, error) {
+	query := r.db.Model(&models.ToolConnection{}).
+		Where("user_id = ? AND tool_code = ? AND name = ?", userID, toolCode, name)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+
+	var count int64
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// ClearDefault 取消用户某个工具除 exceptID 外的默认连接
+func (r *toolConnectionRepository) ClearDefault(userID, toolCode, exceptID string) error {
+	return r.db.Model(&models.ToolConnection{}).
+		Where("user_id = ? AND tool_code = ? AND id <> ?", userID, toolCode, exceptID).
+		Update("is_default", false).Error
+}
+
+// Delete 删除连接（物理删除，释放名称唯一索引）
+func (r *toolConnectionRepository) Delete(id string) error {
+	return r.db.Unscoped().Where("id = ?", id).Delete(&models.ToolConnection{}).Error
+}
@@ -33,6 +33,8 @@ func RegisterAdminRoutes(r *gin.RouterGroup) {
 
 		// 统计数据
 		auth.GET("/stats", adminController.GetStats)
+		auth.GET("/agent-usage", adminController.GetAgentUsage)
+		auth.GET("/tool-policy-report", adminController.GetToolPolicyReport)
 
 		// 用户管理
 		auth.GET("/users", adminController.GetUsers)
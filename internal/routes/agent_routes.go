@@ -21,7 +21,16 @@ func RegisterAgentRoutes(r *gin.Engine) {
 		agentGroup.DELETE("/conversations/:id", agentController.DeleteConversation) // 删除对话
 
 		// 消息管理
-		agentGroup.GET("/conversations/:id/messages", agentController.GetMessages)  // 获取消息列表
-		agentGroup.POST("/conversations/:id/messages", agentController.SendMessage) // 发送消息（支持流式）
+		agentGroup.GET("/conversations/:id/messages", agentController.GetMessages)     // 获取消息列表
+		agentGroup.POST("/conversations/:id/messages", agentController.SendMessage)    // 发送消息（支持流式）
+		agentGroup.POST("/messages/:id/approval", agentController.ResolveToolApproval) // 确认/修改/拒绝待确认的工具调用（支持流式）
+
+		// 用量统计
+		agentGroup.GET("/usage", agentController.GetUsage) // 获取当前用户的用量、费用与预算
+
+		// 工作流工具
+		agentGroup.GET("/workflow-tools", agentController.GetWorkflowTools)                  // 获取可被 Agent 调用的工作流（可按 conversation_id 校验对话归属）
+		agentGroup.GET("/messages/:id/executions", agentController.GetMessageExecutions)     // 获取消息触发的工作流执行
+		agentGroup.POST("/messages/:id/workflow", agentController.CreateWorkflowFromMessage) // 将 Agent 执行过程转换为工作流
 	}
 }
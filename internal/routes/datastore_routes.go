@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"auto-forge/internal/controllers"
+	"auto-forge/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterDataStoreRoutes 注册用户数据存储路由
+func RegisterDataStoreRoutes(r *gin.RouterGroup) {
+	collections := r.Group("/datastore/collections")
+	collections.Use(middleware.RequireAuth())
+	{
+		collections.GET("", controllers.ListDataCollections)             // 获取集合列表
+		collections.POST("", controllers.CreateDataCollection)           // 创建集合
+		collections.PUT("/:id", controllers.UpdateDataCollection)        // 更新集合
+		collections.DELETE("/:id", controllers.DeleteDataCollection)     // 删除集合
+		collections.GET("/:id/export", controllers.ExportDataCollection) // 导出集合（format=json|csv）
+
+		collections.GET("/:id/documents", controllers.ListDataDocuments)          // 浏览文档
+		collections.POST("/:id/query", controllers.QueryDataDocuments)            // 按条件查询文档
+		collections.GET("/:id/documents/:key", controllers.GetDataDocument)       // 获取文档
+		collections.PUT("/:id/documents/:key", controllers.PutDataDocument)       // 创建或覆盖文档
+		collections.DELETE("/:id/documents/:key", controllers.DeleteDataDocument) // 删除文档
+	}
+}
@@ -12,5 +12,8 @@ func RegisterPublicWorkflowRoutes(rg *gin.RouterGroup) {
 	{
 		// 工作流调用接口（通过 API Key 认证）
 		public.POST("/invoke", workflowController.InvokeWorkflow)
+
+		// 入站 Webhook 触发接口（通过 URL 中的令牌定位工作流，可选签名校验）
+		public.Any("/webhook/:token", workflowController.ReceiveWebhook)
 	}
 }
@@ -63,6 +63,9 @@ func RegisterRoutes(r *gin.Engine) {
 		// Agent 对话路由
 		RegisterAgentRoutes(r)
 
+		// 用户数据存储路由
+		RegisterDataStoreRoutes(version)
+
 		// 在这里添加其他模块路由
 		// 例如：
 		// productRoutes := api.Group("/product")
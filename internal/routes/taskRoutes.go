@@ -25,6 +25,8 @@ func RegisterTaskRoutes(r *gin.RouterGroup) {
 		tasks.GET("/:id/executions", taskController.GetTaskExecutions)   // 获取任务执行记录
 		tasks.DELETE("/:id/executions", taskController.DeleteAllExecutions) // 删除任务的所有执行记录
 		tasks.POST("/test", taskController.TestTask)                     // 测试任务配置
+		tasks.GET("/:id/alert-policy", taskController.GetTaskAlertPolicy)    // 获取任务告警策略
+		tasks.PUT("/:id/alert-policy", taskController.UpdateTaskAlertPolicy) // 保存任务告警策略
 	}
 
 	// 执行记录路由也需要认证
@@ -23,4 +23,26 @@ func RegisterToolConfigRoutes(router *gin.Engine) {
 		admin.DELETE("/:id", controllers.DeleteTool)                   // 删除工具配置
 		admin.POST("/sync", controllers.SyncTools)                     // 同步工具定义
 	}
+
+	// 管理端路由（OpenAPI 工具）
+	openapiTools := router.Group("/api/v1/admin/openapi-tools")
+	openapiTools.Use(middleware.RequireAdmin())
+	{
+		openapiTools.POST("/parse", controllers.ParseOpenAPISpec)    // 解析文档
+		openapiTools.POST("/import", controllers.ImportOpenAPITools) // 导入选中的操作
+		openapiTools.GET("", controllers.GetOpenAPITools)            // 获取工具列表
+		openapiTools.GET("/:id", controllers.GetOpenAPITool)         // 获取工具详情
+		openapiTools.PUT("/:id", controllers.UpdateOpenAPITool)      // 更新工具定义
+		openapiTools.DELETE("/:id", controllers.DeleteOpenAPITool)   // 删除工具
+	}
+
+	// 用户端路由（工具凭证连接）
+	connections := router.Group("/api/v1/connections")
+	connections.Use(middleware.RequireAuth())
+	{
+		connections.GET("", controllers.ListToolConnections)         // 获取连接列表
+		connections.POST("", controllers.CreateToolConnection)       // 创建连接
+		connections.PUT("/:id", controllers.UpdateToolConnection)    // 更新连接
+		connections.DELETE("/:id", controllers.DeleteToolConnection) // 删除连接
+	}
 }
@@ -21,6 +21,7 @@ func RegisterWorkflowRoutes(r *gin.RouterGroup) {
 		workflows.DELETE("/:id", workflowController.DeleteWorkflow)        // 删除工作流
 		workflows.PATCH("/:id/toggle", workflowController.ToggleEnabled)   // 切换启用状态
 		workflows.GET("/:id/stats", workflowController.GetWorkflowStats)   // 获取统计信息
+		workflows.POST("/:id/upgrade-tools", workflowController.UpgradeToolVersions) // 升级节点固定的工具版本
 
 		// 工作流执行
 		workflows.POST("/:id/execute", workflowController.ExecuteWorkflow)                         // 执行工作流
@@ -28,9 +29,11 @@ func RegisterWorkflowRoutes(r *gin.RouterGroup) {
 		workflows.GET("/:id/executions/:executionId", workflowController.GetExecutionDetail)       // 获取执行详情
 		workflows.DELETE("/:id/executions/:executionId", workflowController.DeleteExecution)       // 删除执行记录
 		workflows.POST("/:id/executions/:executionId/stop", workflowController.StopExecution)      // 停止执行
+		workflows.GET("/:id/executions/:executionId/chain", workflowController.GetExecutionChain)  // 获取执行触发链
 
 		// 工作流验证
 		workflows.POST("/validate", workflowController.ValidateWorkflow)   // 验证工作流配置
+		workflows.POST("/generate", workflowController.GenerateWorkflow)   // 根据自然语言描述生成工作流草稿
 
 		// API 管理
 		workflows.POST("/:id/api/enable", workflowController.EnableAPI)              // 启用 API
@@ -39,5 +42,22 @@ func RegisterWorkflowRoutes(r *gin.RouterGroup) {
 		workflows.PUT("/:id/api/params", workflowController.UpdateAPIParams)         // 更新 API 参数配置
 		workflows.PUT("/:id/api/timeout", workflowController.UpdateAPITimeout)       // 更新 API 超时时间
 		workflows.PUT("/:id/api/webhook", workflowController.UpdateAPIWebhook)       // 更新 Webhook URL
+
+		// Agent 调用
+		workflows.PUT("/:id/agent-callable", workflowController.UpdateAgentCallable) // 设置是否可被 Agent 调用
+
+		// 入站 Webhook 触发
+		workflows.POST("/:id/webhook/regenerate", workflowController.RegenerateWebhookToken) // 重新生成 Webhook 地址
+
+		// 轮询触发
+		workflows.GET("/:id/poll-state", workflowController.GetPollState)      // 获取轮询状态
+		workflows.DELETE("/:id/poll-state", workflowController.ResetPollState) // 重置轮询状态
+		workflows.POST("/:id/poll", workflowController.PollNow)                // 立即轮询一次
+
+		// 失败告警
+		workflows.GET("/:id/alert-policy", workflowController.GetAlertPolicy)         // 获取告警策略
+		workflows.PUT("/:id/alert-policy", workflowController.UpdateAlertPolicy)      // 保存告警策略
+		workflows.DELETE("/:id/alert-policy", workflowController.DeleteAlertPolicy)   // 删除告警策略
+		workflows.POST("/:id/alert-policy/test", workflowController.TestAlertPolicy)  // 发送测试告警
 	}
 }
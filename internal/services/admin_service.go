@@ -7,7 +7,11 @@ import (
 	"time"
 
 	"auto-forge/internal/cron"
+	"auto-forge/internal/dto/response"
 	"auto-forge/internal/models"
+	"auto-forge/internal/services/agent"
+	"auto-forge/internal/services/task"
+	"auto-forge/internal/services/workflow"
 	"auto-forge/pkg/config"
 	"auto-forge/pkg/database"
 	"auto-forge/pkg/errors"
@@ -142,17 +146,17 @@ func (s *AdminService) UpdateTaskStatus(taskID string, enabled bool) error {
 		return errors.New(errors.CodeNotFound, "任务不存在")
 	}
 
+	if task.WorkflowID != "" {
+		if _, err := workflow.NewWorkflowService().ToggleEnabled(task.WorkflowID, task.UserID, enabled); err != nil {
+			return err
+		}
+	}
+
 	task.Enabled = enabled
 	if err := db.Save(&task).Error; err != nil {
 		return err
 	}
 
-
-	scheduler := cron.GetTaskScheduler()
-	if scheduler != nil {
-		scheduler.ReloadTasks()
-	}
-
 	return nil
 }
 
@@ -166,8 +170,13 @@ func (s *AdminService) DeleteTask(taskID string) error {
 	}
 
 
-	if err := db.Where("task_id = ?", taskID).Delete(&models.TaskExecution{}).Error; err != nil {
-		return err
+	if task.WorkflowID != "" {
+		if err := db.Where("workflow_id = ?", task.WorkflowID).Delete(&models.WorkflowExecution{}).Error; err != nil {
+			return err
+		}
+		if err := workflow.NewWorkflowService().DeleteWorkflow(task.WorkflowID, task.UserID); err != nil {
+			return err
+		}
 	}
 
 
@@ -175,12 +184,6 @@ func (s *AdminService) DeleteTask(taskID string) error {
 		return err
 	}
 
-
-	scheduler := cron.GetTaskScheduler()
-	if scheduler != nil {
-		scheduler.ReloadTasks()
-	}
-
 	return nil
 }
 
@@ -206,20 +209,29 @@ func (s *AdminService) GetStats() (map[string]interface{}, error) {
 	stats["active_tasks"] = activeTasks
 
 
+	// 执行统计统一基于工作流执行记录（任务也由工作流引擎执行）
 	todayStart := time.Now().Truncate(24 * time.Hour).Unix()
 	var todayExecutions int64
-	db.Model(&models.TaskExecution{}).Where("started_at >= ?", todayStart).Count(&todayExecutions)
+	db.Model(&models.WorkflowExecution{}).Where("start_time >= ?", todayStart).Count(&todayExecutions)
 	stats["today_executions"] = todayExecutions
 
 
-	var successCount int64
-	var totalCount int64
-	db.Model(&models.TaskExecution{}).Order("id DESC").Limit(100).Count(&totalCount)
-	db.Model(&models.TaskExecution{}).Where("status = ?", "success").Order("id DESC").Limit(100).Count(&successCount)
+	// 最近 100 次已结束执行的成功率
+	var recentStatuses []string
+	db.Model(&models.WorkflowExecution{}).
+		Where("status IN ?", []string{models.ExecutionStatusSuccess, models.ExecutionStatusFailed}).
+		Order("created_at DESC").Limit(100).Pluck("status", &recentStatuses)
+
+	successCount := 0
+	for _, status := range recentStatuses {
+		if status == models.ExecutionStatusSuccess {
+			successCount++
+		}
+	}
 
 	successRate := 0.0
-	if totalCount > 0 {
-		successRate = float64(successCount) / float64(totalCount) * 100
+	if len(recentStatuses) > 0 {
+		successRate = float64(successCount) / float64(len(recentStatuses)) * 100
 	}
 	stats["success_rate"] = successRate
 
@@ -233,6 +245,19 @@ func (s *AdminService) GetStats() (map[string]interface{}, error) {
 	db.Model(&models.WorkflowTemplate{}).Count(&totalTemplates)
 	stats["total_templates"] = totalTemplates
 
+	// Agent 今日用量与费用
+	agentToday, err := agent.NewAgentService().GetUsageReport(todayStart, 0, "model", "")
+	if err == nil {
+		var agentTokens int64
+		var agentCost float64
+		for _, group := range agentToday {
+			agentTokens += group.TotalTokens
+			agentCost += group.Cost
+		}
+		stats["agent_today_tokens"] = agentTokens
+		stats["agent_today_cost"] = agentCost
+	}
+
 	type UserActivity struct {
 		UserID      string
 		TaskCount   int64
@@ -242,7 +267,8 @@ func (s *AdminService) GetStats() (map[string]interface{}, error) {
 	var recentUsers []UserActivity
 	db.Raw(`
 		SELECT user_id, COUNT(*) as task_count, MAX(updated_at) as last_active
-		FROM tasks
+		FROM workflow
+		WHERE deleted_at IS NULL
 		GROUP BY user_id
 		ORDER BY last_active DESC
 		LIMIT 5
@@ -253,6 +279,23 @@ func (s *AdminService) GetStats() (map[string]interface{}, error) {
 	return stats, nil
 }
 
+// GetAgentUsage 按用户/模型/对话/日期统计 Agent 用量与费用
+func (s *AdminService) GetAgentUsage(start, end int64, groupBy, userID string) ([]agent.UsageGroup, error) {
+	groups, err := agent.NewAgentService().GetUsageReport(start, end, groupBy, userID)
+	if err != nil {
+		return nil, errors.New(errors.CodeInvalidParameter, err.Error())
+	}
+	return groups, nil
+}
+
+// GetToolPolicyReport 列出受工具禁用、角色限制或废弃影响的工作流
+func (s *AdminService) GetToolPolicyReport() ([]response.WorkflowToolReport, error) {
+	reports, err := workflow.NewWorkflowService().GetToolPolicyReport()
+	if err != nil {
+		return nil, errors.New(errors.CodeQueryFailed, "获取工具策略报表失败")
+	}
+	return reports, nil
+}
 
 func (s *AdminService) ExecuteTask(taskID string) error {
 	db := database.GetDB()
@@ -263,33 +306,43 @@ func (s *AdminService) ExecuteTask(taskID string) error {
 	}
 
 
-	scheduler := cron.GetTaskScheduler()
+	if task.WorkflowID == "" {
+		return errors.New(errors.CodeInternal, "任务未关联工作流")
+	}
+
+	var wf models.Workflow
+	if err := db.Where("id = ?", task.WorkflowID).First(&wf).Error; err != nil {
+		return errors.New(errors.CodeNotFound, "任务工作流不存在")
+	}
+
+	scheduler := cron.GetWorkflowScheduler()
 	if scheduler == nil {
-		return errors.New(errors.CodeInternal, "任务调度器未初始化")
+		return errors.New(errors.CodeInternal, "工作流调度器未初始化")
 	}
 
-	scheduler.ExecuteTaskNow(&task)
+	scheduler.ExecuteWorkflowNow(&wf)
 	return nil
 }
 
 
-func (s *AdminService) GetAllExecutions(page, pageSize int, userID, taskID, status string) ([]models.TaskExecution, int64, error) {
+func (s *AdminService) GetAllExecutions(page, pageSize int, userID, taskID, status string) ([]response.AdminExecutionResponse, int64, error) {
 	db := database.GetDB()
 
 
-	query := db.Model(&models.TaskExecution{})
+	query := db.Model(&models.WorkflowExecution{})
 
 
 	if userID != "" {
 		query = query.Where("user_id = ?", userID)
 	}
 	if taskID != "" {
-		query = query.Where("task_id = ?", taskID)
+		// 任务ID对应其承载工作流的执行记录
+		query = query.Where("workflow_id IN (?)", db.Model(&models.Task{}).Select("workflow_id").Where("id = ?", taskID))
 	}
 	if status == "success" {
-		query = query.Where("status = ?", "success")
+		query = query.Where("status = ?", models.ExecutionStatusSuccess)
 	} else if status == "failed" {
-		query = query.Where("status != ?", "success")
+		query = query.Where("status = ?", models.ExecutionStatusFailed)
 	}
 
 
@@ -299,13 +352,56 @@ func (s *AdminService) GetAllExecutions(page, pageSize int, userID, taskID, stat
 	}
 
 
-	var executions []models.TaskExecution
+	var executions []models.WorkflowExecution
 	offset := (page - 1) * pageSize
-	if err := query.Preload("Task").Order("started_at DESC").Offset(offset).Limit(pageSize).Find(&executions).Error; err != nil {
+	if err := query.Select("id, created_at, updated_at, workflow_id, user_id, status, trigger_type, start_time, end_time, duration_ms, error, node_logs").
+		Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&executions).Error; err != nil {
 		return nil, 0, err
 	}
 
-	return executions, total, nil
+	return s.toAdminExecutions(executions), total, nil
+}
+
+// toAdminExecutions 转换为兼容任务执行记录格式的响应，并补充所属任务与工作流名称
+func (s *AdminService) toAdminExecutions(executions []models.WorkflowExecution) []response.AdminExecutionResponse {
+	db := database.GetDB()
+
+	workflowIDs := make([]string, 0, len(executions))
+	for i := range executions {
+		workflowIDs = append(workflowIDs, executions[i].WorkflowID)
+	}
+
+	tasks := make(map[string]*models.Task)
+	workflowNames := make(map[string]string)
+	if len(workflowIDs) > 0 {
+		var taskList []models.Task
+		db.Where("workflow_id IN ?", workflowIDs).Find(&taskList)
+		for i := range taskList {
+			tasks[taskList[i].WorkflowID] = &taskList[i]
+		}
+
+		var workflows []models.Workflow
+		db.Unscoped().Select("id, name").Where("id IN ?", workflowIDs).Find(&workflows)
+		for i := range workflows {
+			workflowNames[workflows[i].GetID()] = workflows[i].Name
+		}
+	}
+
+	result := make([]response.AdminExecutionResponse, 0, len(executions))
+	for i := range executions {
+		execution := &executions[i]
+		item := response.AdminExecutionResponse{
+			TaskExecutionResponse: response.ConvertTaskExecutionToResponse(task.ToTaskExecution(tasks[execution.WorkflowID], execution)),
+			WorkflowID:            execution.WorkflowID,
+			WorkflowName:          workflowNames[execution.WorkflowID],
+			TriggerType:           execution.TriggerType,
+		}
+		if t := tasks[execution.WorkflowID]; t != nil {
+			item.TaskName = t.Name
+		}
+		result = append(result, item)
+	}
+	return result
 }
 
 
@@ -313,7 +409,7 @@ func (s *AdminService) DeleteExecution(executionID string) error {
 	db := database.GetDB()
 
 
-	var execution models.TaskExecution
+	var execution models.WorkflowExecution
 	if err := db.Where("id = ?", executionID).First(&execution).Error; err != nil {
 		return errors.New(errors.CodeNotFound, "执行记录不存在")
 	}
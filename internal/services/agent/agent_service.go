@@ -2,18 +2,23 @@ package agent
 
 import (
 	"auto-forge/internal/models"
+	"auto-forge/internal/services/tool_config"
+	"auto-forge/internal/services/workflow"
+	"auto-forge/pkg/agent/attachment"
 	"auto-forge/pkg/agent/executor"
 	"auto-forge/pkg/agent/llm"
+	"auto-forge/pkg/agent/memory"
 	"auto-forge/pkg/agent/registry"
 	"auto-forge/pkg/common"
 	"auto-forge/pkg/config"
 	"auto-forge/pkg/database"
 	log "auto-forge/pkg/logger"
+	"auto-forge/pkg/utools"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"strings"
 	"time"
 )
 
@@ -243,8 +248,10 @@ func (s *AgentService) GetMessages(conversationID string) ([]models.AgentMessage
 	db := database.GetDB()
 
 	var messages []models.AgentMessage
+	// 同一秒内创建的用户消息排在 Agent 消息之前，保证轮次顺序稳定
 	if err := db.Where("conversation_id = ?", conversationID).
 		Order("created_at ASC").
+		Order("CASE WHEN role = 'user' THEN 0 ELSE 1 END").
 		Find(&messages).Error; err != nil {
 		return nil, fmt.Errorf("查询消息列表失败: %w", err)
 	}
@@ -264,46 +271,105 @@ func (s *AgentService) GetMessageByID(messageID string) (*models.AgentMessage, e
 	return &message, nil
 }
 
-// BuildContextFromMessages 从历史消息构建上下文（用于对话记忆）
-func (s *AgentService) BuildContextFromMessages(messages []models.AgentMessage, maxMessages int) string {
-	if len(messages) == 0 {
-		return ""
+// loadConversationMemory 加载对话记忆
+// 未摘要的历史消息按轮次还原为角色化的 LLM 消息；超出模型上下文预算的较早轮次合并进摘要并持久化到对话
+func (s *AgentService) loadConversationMemory(ctx context.Context, llmClient llm.LLMClient, messageID string) []llm.Message {
+	db := database.GetDB()
+
+	current, err := s.GetMessageByID(messageID)
+	if err != nil {
+		log.Warn("加载对话记忆失败: %v", err)
+		return nil
+	}
+
+	var conversation models.AgentConversation
+	if err := db.Where("id = ?", current.ConversationID).First(&conversation).Error; err != nil {
+		log.Warn("加载对话记忆失败: 对话不存在: %v", err)
+		return nil
 	}
 
-	// 只取最近的 N 条消息
-	start := 0
-	if len(messages) > maxMessages {
-		start = len(messages) - maxMessages
+	messages, err := s.GetMessages(conversation.ID)
+	if err != nil {
+		log.Warn("加载对话记忆失败: %v", err)
+		return nil
 	}
 
-	var contextParts []string
-	for _, msg := range messages[start:] {
-		if msg.Role == "user" {
-			contextParts = append(contextParts, fmt.Sprintf("User: %s", msg.Content))
-		} else if msg.Role == "agent" && msg.Content != "" {
-			contextParts = append(contextParts, fmt.Sprintf("Assistant: %s", msg.Content))
+	// 历史截止到当前 Agent 消息之前，并排除本轮的用户消息（由执行器单独追加）
+	end := len(messages)
+	for i := range messages {
+		if messages[i].ID == messageID {
+			end = i
+			break
 		}
 	}
+	if end > 0 && messages[end-1].Role == "user" {
+		end--
+	}
+	start := conversation.SummarizedCount
+	if start > end {
+		start = end
+	}
 
-	return strings.Join(contextParts, "\n\n")
+	memoryConfig := memory.DefaultConfig()
+	turns := memory.BuildTurns(messages[start:end], memoryConfig.MaxObservationChars)
+	result := memory.NewManager(llmClient, memoryConfig).Build(ctx, conversation.Summary, turns)
+
+	if result.Summarized {
+		if err := db.Model(&models.AgentConversation{}).Where("id = ?", conversation.ID).Updates(map[string]interface{}{
+			"summary":            result.Summary,
+			"summarized_count":   start + result.FoldedMessages,
+			"summary_updated_at": time.Now().Unix(),
+		}).Error; err != nil {
+			log.Error("保存对话摘要失败: %v", err)
+		}
+	}
+
+	log.Info("对话记忆加载完成: ConversationID=%s, 历史消息=%d, 估算Token=%d, 摘要更新=%v",
+		conversation.ID, len(result.Messages), result.Tokens, result.Summarized)
+
+	return result.Messages
 }
 
-// ExecuteAgent 执行 Agent（核心方法）
+// buildAttachmentMessage 将上传的文件转换为附件消息，文件文本最多占用上下文窗口的 1/4
+func buildAttachmentMessage(llmClient llm.LLMClient, files []models.AgentFile) *llm.Message {
+	if len(files) == 0 {
+		return nil
+	}
+
+	modelInfo := llmClient.GetModelInfo()
+	options := attachment.DefaultOptions()
+	options.SupportVision = modelInfo.SupportVision
+	if limit := modelInfo.MaxTokens / 4; limit > 0 && limit < options.MaxContextTokens {
+		options.MaxContextTokens = limit
+	}
+
+	msg := attachment.BuildMessage(files, options)
+	log.Info("附件处理完成: 文件=%d, 图片=%d, 支持视觉=%v", len(files), len(msg.Parts), modelInfo.SupportVision)
+	return msg
+}
+
+// ExecuteAgent 执行 Agent（核心方法），userID 必须是消息所属对话的用户
 func (s *AgentService) ExecuteAgent(
 	ctx context.Context,
 	messageID string,
+	userID string,
 	userMessage string,
 	files []models.AgentFile,
 	config *models.AgentConfig,
-	conversationHistory string,
 	streamCallback func(event AgentStreamEvent) error,
 ) error {
+	// 执行会使用对话所属用户的凭证连接、可调用工作流与用量预算，只允许对话所属用户发起
+	if _, err := s.GetOwnedMessage(messageID, userID); err != nil {
+		return err
+	}
+
 	// 获取配置（使用默认值）
 	model := "gpt-4o-mini"
 	mode := "direct" // direct / plan
 	maxSteps := 10
 	temperature := 0.7
 	var allowedTools []string
+	var toolApprovals map[string]string
 
 	if config != nil {
 		if config.Model != "" {
@@ -321,19 +387,37 @@ func (s *AgentService) ExecuteAgent(
 		if len(config.AllowedTools) > 0 {
 			allowedTools = config.AllowedTools
 		}
+		toolApprovals = config.ToolApprovals
 	}
 
 	log.Info("Agent 开始执行: MessageID=%s, Model=%s, Mode=%s, MaxSteps=%d", messageID, model, mode, maxSteps)
 
+	// 执行前检查用量预算，执行中的每次 LLM 调用都经过计量
+	meter, err := s.newUsageMeter(messageID, model)
+	if err != nil {
+		return s.finishExecution(messageID, err)
+	}
+
 	// 更新状态为 running
 	if err := s.UpdateMessageStatus(messageID, "running", ""); err != nil {
 		return err
 	}
 
+	// 工具按消息所属用户解析凭证连接
+	ctx = utools.ContextWithUserID(ctx, userID)
+
 	// 使用新的执行器
-	err := s.executeWithNewEngine(ctx, messageID, userMessage, model, mode, maxSteps, temperature, allowedTools, conversationHistory, streamCallback)
+	err = s.executeWithNewEngine(ctx, messageID, userMessage, files, model, mode, maxSteps, temperature, allowedTools, toolApprovals, meter, streamCallback)
 
 	// 更新最终状态
+	return s.finishExecution(messageID, err)
+}
+
+// finishExecution 根据执行结果更新消息最终状态；等待确认时状态已在保存结果时更新
+func (s *AgentService) finishExecution(messageID string, err error) error {
+	if errors.Is(err, ErrAwaitingApproval) {
+		return nil
+	}
 	if err != nil {
 		s.UpdateMessageStatus(messageID, "failed", err.Error())
 		return err
@@ -384,51 +468,163 @@ func getStringArrayFromConfig(config map[string]interface{}, key string) []strin
 	return nil
 }
 
+// registerWorkflowTools 将消息所属用户的可调用工作流注册为工具，返回注册的工具名
+func (s *AgentService) registerWorkflowTools(toolRegistry *registry.ToolRegistry, messageID string) []string {
+	userID, err := s.getMessageUserID(messageID)
+	if err != nil {
+		log.Warn("获取消息所属用户失败，跳过工作流工具: MessageID=%s, Error=%v", messageID, err)
+		return nil
+	}
+
+	tools, err := workflow.NewWorkflowService().GetAgentTools(userID, messageID)
+	if err != nil {
+		log.Warn("加载工作流工具失败: %v", err)
+		return nil
+	}
+
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		if err := toolRegistry.Register(tool); err != nil {
+			log.Warn("注册工作流工具失败: %v", err)
+			continue
+		}
+		names = append(names, tool.GetMetadata().Code)
+	}
+	return names
+}
+
+// getMessageUserID 获取消息所属对话的用户ID
+func (s *AgentService) getMessageUserID(messageID string) (string, error) {
+	db := database.GetDB()
+
+	var conversation models.AgentConversation
+	err := db.Model(&models.AgentConversation{}).
+		Joins("JOIN agent_messages ON agent_messages.conversation_id = agent_conversations.id").
+		Where("agent_messages.id = ?", messageID).
+		First(&conversation).Error
+	if err != nil {
+		return "", err
+	}
+	return conversation.UserID, nil
+}
+
+// llmProviderConfigs 读取各 LLM 提供商配置，配置文件未设置时回退到环境变量
+func llmProviderConfigs() map[string]llm.ProviderConfig {
+	cfg := config.GetConfig().Agent
+	withEnv := func(value, envKey string) string {
+		if value != "" {
+			return value
+		}
+		return os.Getenv(envKey)
+	}
+
+	return map[string]llm.ProviderConfig{
+		llm.ProviderOpenAI: {
+			APIKey:  withEnv(cfg.OpenAI.APIKey, "OPENAI_API_KEY"),
+			BaseURL: withEnv(cfg.OpenAI.BaseURL, "OPENAI_BASE_URL"),
+		},
+		llm.ProviderAnthropic: {
+			APIKey:  withEnv(cfg.Anthropic.APIKey, "ANTHROPIC_API_KEY"),
+			BaseURL: withEnv(cfg.Anthropic.BaseURL, "ANTHROPIC_BASE_URL"),
+		},
+		llm.ProviderGemini: {
+			APIKey:  withEnv(cfg.Gemini.APIKey, "GEMINI_API_KEY"),
+			BaseURL: withEnv(cfg.Gemini.BaseURL, "GEMINI_BASE_URL"),
+		},
+		llm.ProviderOllama: {
+			APIKey:  withEnv(cfg.Ollama.APIKey, "OLLAMA_API_KEY"),
+			BaseURL: withEnv(cfg.Ollama.BaseURL, "OLLAMA_BASE_URL"),
+		},
+	}
+}
+
 // executeWithNewEngine 使用新的执行引擎
 func (s *AgentService) executeWithNewEngine(
 	ctx context.Context,
 	messageID string,
 	userMessage string,
+	files []models.AgentFile,
 	model string,
 	mode string,
 	maxSteps int,
 	temperature float64,
 	allowedTools []string,
-	conversationHistory string,
+	toolApprovals map[string]string,
+	meter *usageMeter,
 	streamCallback func(event AgentStreamEvent) error,
 ) error {
-	// 1. 初始化 LLM 客户端
-	cfg := config.GetConfig()
-	apiKey := cfg.Agent.OpenAI.APIKey
-	baseURL := cfg.Agent.OpenAI.BaseURL
+	// 1. 初始化 LLM 客户端（model 可带提供商前缀，如 anthropic/claude-sonnet-4-5、ollama/llama3）
+	llmClient, err := llm.NewClientForModel(model, llmProviderConfigs())
+	if err != nil {
+		return err
+	}
+	llmClient = meter.Wrap(llmClient)
 
-	// 如果配置文件中没有设置，尝试从环境变量读取
-	if apiKey == "" {
-		apiKey = os.Getenv("OPENAI_API_KEY")
+	// 2. 加载对话记忆（按模型上下文窗口控制历史长度）
+	history := s.loadConversationMemory(ctx, llmClient, messageID)
+
+	// 本轮上传的文件作为一条附件消息放在用户输入之前
+	if attachmentMsg := buildAttachmentMessage(llmClient, files); attachmentMsg != nil {
+		history = append(history, *attachmentMsg)
 	}
-	if baseURL == "" {
-		baseURL = os.Getenv("OPENAI_BASE_URL")
+
+	// 3. 初始化工具注册表（含用户标记为可被 Agent 调用的工作流）
+	toolRegistry, allowedTools, err := s.newToolRegistry(messageID, allowedTools)
+	if err != nil {
+		return err
 	}
-	if baseURL == "" {
-		baseURL = "https://api.openai.com/v1"
+
+	// 4. 创建执行器回调适配器
+	executorCallback := toExecutorCallback(streamCallback)
+
+	// 5. 根据模式选择执行器
+	var result *executor.ExecutionResult
+
+	if mode == "plan" {
+		// Plan 模式
+		planExecutor := executor.NewPlanExecutorV2(llmClient, toolRegistry, temperature)
+		planExecutor.SetApprovalPolicies(toolApprovals)
+		result, err = planExecutor.Execute(ctx, userMessage, history, allowedTools, maxSteps, executorCallback)
+	} else {
+		// ReAct 模式（默认）
+		reactExecutor := executor.NewReActExecutor(llmClient, toolRegistry, maxSteps, temperature)
+		reactExecutor.SetApprovalPolicies(toolApprovals)
+		reactExecutor.SetBudgetGuard(meter.Guard)
+		result, err = reactExecutor.Execute(ctx, userMessage, history, allowedTools, executorCallback)
 	}
 
-	if apiKey == "" {
-		return fmt.Errorf("OpenAI API Key 未配置，请在 config.yaml 中设置 agent.openai.api_key 或设置环境变量 OPENAI_API_KEY")
+	if err != nil {
+		return fmt.Errorf("执行失败: %w", err)
 	}
 
-	llmClient := llm.NewOpenAIClient(model, apiKey, baseURL)
+	// 6. 保存执行结果
+	return s.saveExecutionResult(messageID, result, meter)
+}
 
-	// 2. 初始化工具注册表
+// newToolRegistry 创建本次执行的工具注册表，并注册消息所属用户的可调用工作流
+// allowedTools 非空时追加工作流工具名后返回
+func (s *AgentService) newToolRegistry(messageID string, allowedTools []string) (*registry.ToolRegistry, []string, error) {
 	toolRegistry := registry.NewToolRegistry()
+	userID, _ := s.getMessageUserID(messageID)
+	toolRegistry.SetPolicy(func(toolCode string) error {
+		return tool_config.CheckToolPolicy(toolCode, userID)
+	})
 	if err := toolRegistry.RegisterFromUTools(); err != nil {
-		return fmt.Errorf("注册工具失败: %w", err)
+		return nil, nil, fmt.Errorf("注册工具失败: %w", err)
+	}
+
+	workflowTools := s.registerWorkflowTools(toolRegistry, messageID)
+	if len(allowedTools) > 0 {
+		allowedTools = append(allowedTools, workflowTools...)
 	}
 
-	log.Info("工具注册完成，共 %d 个工具", len(toolRegistry.ListTools()))
+	log.Info("工具注册完成，共 %d 个工具（其中工作流 %d 个）", len(toolRegistry.ListTools()), len(workflowTools))
+	return toolRegistry, allowedTools, nil
+}
 
-	// 3. 创建执行器回调适配器
-	executorCallback := func(event executor.StreamEvent) {
+// toExecutorCallback 将服务层流式回调适配为执行器回调
+func toExecutorCallback(streamCallback func(event AgentStreamEvent) error) func(event executor.StreamEvent) {
+	return func(event executor.StreamEvent) {
 		if streamCallback == nil {
 			return
 		}
@@ -441,26 +637,10 @@ func (s *AgentService) executeWithNewEngine(
 
 		streamCallback(agentEvent)
 	}
+}
 
-	// 4. 根据模式选择执行器
-	var result *executor.ExecutionResult
-	var err error
-
-	if mode == "plan" {
-		// Plan 模式
-		planExecutor := executor.NewPlanExecutor(llmClient, toolRegistry, temperature)
-		result, err = planExecutor.Execute(ctx, userMessage, conversationHistory, allowedTools, maxSteps, executorCallback)
-	} else {
-		// ReAct 模式（默认）
-		reactExecutor := executor.NewReActExecutor(llmClient, toolRegistry, maxSteps, temperature)
-		result, err = reactExecutor.Execute(ctx, userMessage, conversationHistory, allowedTools, executorCallback)
-	}
-
-	if err != nil {
-		return fmt.Errorf("执行失败: %w", err)
-	}
-
-	// 5. 保存执行结果
+// saveExecutionResult 保存执行结果（最终答案、轨迹与 Token 使用，费用已由计量器记录）；执行暂停时保存待确认的工具调用并返回 ErrAwaitingApproval
+func (s *AgentService) saveExecutionResult(messageID string, result *executor.ExecutionResult, meter *usageMeter) error {
 	if result.Trace != nil {
 		// 更新消息内容为最终答案
 		if err := s.UpdateMessageContent(messageID, result.Trace.FinalAnswer); err != nil {
@@ -472,12 +652,17 @@ func (s *AgentService) executeWithNewEngine(
 			log.Error("保存执行轨迹失败: %v", err)
 		}
 
-		// 保存 Token 使用情况
-		if result.Trace.TokenUsage != nil {
-			if err := s.UpdateMessageTokenUsage(messageID, result.Trace.TokenUsage); err != nil {
-				log.Error("保存 Token 使用情况失败: %v", err)
-			}
+		// 保存 Token 使用情况（含记忆摘要、结构化输出修复等执行器之外的调用）
+		if err := s.UpdateMessageTokenUsage(messageID, meter.Total()); err != nil {
+			log.Error("保存 Token 使用情况失败: %v", err)
+		}
+	}
+
+	if result.Pending != nil {
+		if err := s.savePendingApproval(messageID, result.Pending, result.Checkpoint); err != nil {
+			return fmt.Errorf("保存待确认的工具调用失败: %w", err)
 		}
+		return ErrAwaitingApproval
 	}
 
 	if !result.Success {
@@ -0,0 +1,162 @@
+package agent
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/pkg/agent/executor"
+	"auto-forge/pkg/agent/llm"
+	"auto-forge/pkg/database"
+	log "auto-forge/pkg/logger"
+	"auto-forge/pkg/utools"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// MessageStatusAwaitingApproval 消息状态：等待用户确认工具调用
+const MessageStatusAwaitingApproval = "awaiting_approval"
+
+// ErrAwaitingApproval 执行已暂停，等待用户确认工具调用
+var ErrAwaitingApproval = errors.New("等待用户确认工具调用")
+
+// savePendingApproval 保存待确认的工具调用与执行检查点，并将消息置为等待确认
+func (s *AgentService) savePendingApproval(messageID string, pending *models.AgentPendingApproval, checkpoint string) error {
+	db := database.GetDB()
+	return db.Model(&models.AgentMessage{}).Where("id = ?", messageID).Updates(map[string]interface{}{
+		"pending_approval": pending,
+		"checkpoint":       checkpoint,
+		"status":           MessageStatusAwaitingApproval,
+	}).Error
+}
+
+// ResumeAgent 根据用户的决定（批准、修改参数或拒绝）恢复等待确认的 Agent 执行
+func (s *AgentService) ResumeAgent(
+	ctx context.Context,
+	messageID string,
+	userID string,
+	decision executor.ApprovalDecision,
+	streamCallback func(event AgentStreamEvent) error,
+) error {
+	switch decision.Action {
+	case executor.ApprovalApprove, executor.ApprovalReject:
+	case executor.ApprovalEdit:
+		if decision.Args == nil {
+			return fmt.Errorf("修改参数时必须提供新的参数")
+		}
+	default:
+		return fmt.Errorf("不支持的操作: %s", decision.Action)
+	}
+
+	message, err := s.GetOwnedMessage(messageID, userID)
+	if err != nil {
+		return err
+	}
+	pending := message.PendingApproval
+	if message.Status != MessageStatusAwaitingApproval || pending == nil {
+		return fmt.Errorf("消息没有待确认的工具调用")
+	}
+
+	// 预算不足时保留等待确认状态，预算恢复后仍可继续
+	model := configModel(message.Config)
+	meter, err := s.newUsageMeter(messageID, model)
+	if err != nil {
+		return err
+	}
+
+	// 以状态作为锁，防止同一调用被重复处理
+	db := database.GetDB()
+	result := db.Model(&models.AgentMessage{}).
+		Where("id = ? AND status = ?", messageID, MessageStatusAwaitingApproval).
+		Updates(map[string]interface{}{
+			"status":           "running",
+			"pending_approval": nil,
+			"checkpoint":       "",
+		})
+	if result.Error != nil {
+		return fmt.Errorf("更新消息状态失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("该工具调用已被处理")
+	}
+
+	log.Info("Agent 恢复执行: MessageID=%s, Tool=%s, Decision=%s", messageID, pending.Tool, decision.Action)
+
+	ctx = utools.ContextWithUserID(ctx, userID)
+	err = s.resumeWithNewEngine(ctx, message, pending, decision, model, meter, streamCallback)
+	return s.finishExecution(messageID, err)
+}
+
+// resumeWithNewEngine 按消息原有配置重建执行器并从检查点继续执行
+func (s *AgentService) resumeWithNewEngine(
+	ctx context.Context,
+	message *models.AgentMessage,
+	pending *models.AgentPendingApproval,
+	decision executor.ApprovalDecision,
+	model string,
+	meter *usageMeter,
+	streamCallback func(event AgentStreamEvent) error,
+) error {
+	mode := "direct"
+	maxSteps := 10
+	temperature := 0.7
+	var toolApprovals map[string]string
+
+	if config := message.Config; config != nil {
+		if config.Mode != "" {
+			mode = config.Mode
+		}
+		if config.MaxSteps > 0 {
+			maxSteps = config.MaxSteps
+		}
+		if config.Temperature > 0 {
+			temperature = config.Temperature
+		}
+		toolApprovals = config.ToolApprovals
+	}
+
+	llmClient, err := llm.NewClientForModel(model, llmProviderConfigs())
+	if err != nil {
+		return err
+	}
+	llmClient = meter.Wrap(llmClient)
+
+	// 允许的工具列表已保存在检查点中，这里只需注册全部工具
+	toolRegistry, _, err := s.newToolRegistry(message.ID, nil)
+	if err != nil {
+		return err
+	}
+
+	// 按暂停时的模式恢复：Plan 模式的检查点保存剩余的计划步骤
+	var result *executor.ExecutionResult
+	if mode == "plan" {
+		planExecutor := executor.NewPlanExecutorV2(llmClient, toolRegistry, temperature)
+		planExecutor.SetApprovalPolicies(toolApprovals)
+		result, err = planExecutor.Resume(ctx, pending, message.Checkpoint, decision, toExecutorCallback(streamCallback))
+	} else {
+		reactExecutor := executor.NewReActExecutor(llmClient, toolRegistry, maxSteps, temperature)
+		reactExecutor.SetApprovalPolicies(toolApprovals)
+		reactExecutor.SetBudgetGuard(meter.Guard)
+		result, err = reactExecutor.Resume(ctx, pending, message.Checkpoint, decision, toExecutorCallback(streamCallback))
+	}
+	if err != nil {
+		return fmt.Errorf("执行失败: %w", err)
+	}
+
+	return s.saveExecutionResult(message.ID, result, meter)
+}
+
+// configModel 消息配置中的模型，未配置时使用默认模型
+func configModel(config *models.AgentConfig) string {
+	if config != nil && config.Model != "" {
+		return config.Model
+	}
+	return "gpt-4o-mini"
+}
+
+// GetOwnedMessage 获取属于该用户的消息，通过所属对话校验 UserID
+func (s *AgentService) GetOwnedMessage(messageID, userID string) (*models.AgentMessage, error) {
+	ownerID, err := s.getMessageUserID(messageID)
+	if err != nil || ownerID != userID {
+		return nil, fmt.Errorf("消息不存在")
+	}
+	return s.GetMessageByID(messageID)
+}
@@ -0,0 +1,408 @@
+package agent
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/pkg/agent/llm"
+	"auto-forge/pkg/config"
+	"auto-forge/pkg/database"
+	log "auto-forge/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded 超出 Agent 用量预算
+var ErrBudgetExceeded = errors.New("已超出 Agent 用量预算")
+
+// UsageTotals 用量汇总
+type UsageTotals struct {
+	Messages         int64   `json:"messages"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	Cost             float64 `json:"cost"` // 美元
+}
+
+// UsageGroup 按用户/模型/对话/日期分组的用量
+type UsageGroup struct {
+	Key string `json:"key"`
+	UsageTotals
+}
+
+// UserUsageReport 用户的用量与预算
+type UserUsageReport struct {
+	Today         UsageTotals  `json:"today"`
+	Month         UsageTotals  `json:"month"`
+	Budget        UserBudget   `json:"budget"`
+	Conversations []UsageGroup `json:"conversations"` // 本月按对话汇总，费用从高到低
+}
+
+// UserBudget 用户预算上限，0 表示不限制
+type UserBudget struct {
+	DailyTokens   int64   `json:"daily_tokens"`
+	MonthlyTokens int64   `json:"monthly_tokens"`
+	DailyCost     float64 `json:"daily_cost"`
+	MonthlyCost   float64 `json:"monthly_cost"`
+}
+
+// usageSnapshot 预算检查所需的用户与全局用量
+type usageSnapshot struct {
+	UserDaily     UsageTotals
+	UserMonthly   UsageTotals
+	GlobalDaily   UsageTotals
+	GlobalMonthly UsageTotals
+}
+
+// usageGroupColumns 报表允许的分组方式
+var usageGroupColumns = map[string]string{
+	"user":         "user_id",
+	"model":        "model",
+	"conversation": "conversation_id",
+	"day":          "created_at - (created_at % 86400)",
+}
+
+const usageSumColumns = "COUNT(*) AS messages, COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens, " +
+	"COALESCE(SUM(completion_tokens), 0) AS completion_tokens, COALESCE(SUM(total_tokens), 0) AS total_tokens, " +
+	"COALESCE(SUM(cost), 0) AS cost"
+
+// priceForModel 查找模型价格：先精确匹配，再按最长前缀匹配（如 gpt-4o-mini-2024-07-18 使用 gpt-4o-mini 的价格）
+func priceForModel(pricing map[string]config.AgentModelPrice, model string) (config.AgentModelPrice, bool) {
+	if price, ok := pricing[model]; ok {
+		return price, true
+	}
+
+	var best config.AgentModelPrice
+	bestLen := 0
+	for prefix, price := range pricing {
+		if len(prefix) > bestLen && strings.HasPrefix(model, prefix) {
+			best, bestLen = price, len(prefix)
+		}
+	}
+	return best, bestLen > 0
+}
+
+// calculateCost 按价格表（美元 / 百万 Token）计算费用，未配置价格的模型按 0 计算
+func calculateCost(pricing map[string]config.AgentModelPrice, model string, usage *models.TokenUsage) float64 {
+	if usage == nil {
+		return 0
+	}
+	price, ok := priceForModel(pricing, model)
+	if !ok {
+		return 0
+	}
+	return (float64(usage.PromptTokens)*price.Input + float64(usage.CompletionTokens)*price.Output) / 1_000_000
+}
+
+// CalculateCost 按配置的价格表计算一次执行的费用（美元）
+func CalculateCost(model string, usage *models.TokenUsage) float64 {
+	return calculateCost(config.GetConfig().Agent.Pricing, model, usage)
+}
+
+// sumUsage 汇总 since 之后的用量；userID 为空时统计全部用户，excludeMessageID 用于排除正在执行的消息
+func sumUsage(userID string, since int64, excludeMessageID string) (UsageTotals, error) {
+	query := database.GetDB().Model(&models.AgentUsage{}).Select(usageSumColumns).Where("created_at >= ?", since)
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if excludeMessageID != "" {
+		query = query.Where("message_id <> ?", excludeMessageID)
+	}
+
+	var totals UsageTotals
+	err := query.Scan(&totals).Error
+	return totals, err
+}
+
+// budgetWindows 今日与本月的起始时间（服务器本地时区）
+func budgetWindows(now time.Time) (day, month int64) {
+	day = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Unix()
+	month = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).Unix()
+	return day, month
+}
+
+// loadUsageSnapshot 读取用户与全局的今日、本月用量（不含正在执行的消息）
+func loadUsageSnapshot(userID, messageID string) (*usageSnapshot, error) {
+	day, month := budgetWindows(time.Now())
+	snapshot := &usageSnapshot{}
+
+	queries := []struct {
+		target *UsageTotals
+		userID string
+		since  int64
+	}{
+		{&snapshot.UserDaily, userID, day},
+		{&snapshot.UserMonthly, userID, month},
+		{&snapshot.GlobalDaily, "", day},
+		{&snapshot.GlobalMonthly, "", month},
+	}
+	for _, q := range queries {
+		totals, err := sumUsage(q.userID, q.since, messageID)
+		if err != nil {
+			return nil, err
+		}
+		*q.target = totals
+	}
+	return snapshot, nil
+}
+
+// checkBudget 检查已有用量加上本次执行的用量是否达到预算上限
+func checkBudget(budget config.AgentBudgetConfig, snapshot *usageSnapshot, runTokens int64, runCost float64) error {
+	tokenLimits := []struct {
+		name  string
+		used  int64
+		limit int64
+	}{
+		{"用户今日 Token", snapshot.UserDaily.TotalTokens, budget.UserDailyTokens},
+		{"用户本月 Token", snapshot.UserMonthly.TotalTokens, budget.UserMonthlyTokens},
+		{"全局今日 Token", snapshot.GlobalDaily.TotalTokens, budget.GlobalDailyTokens},
+		{"全局本月 Token", snapshot.GlobalMonthly.TotalTokens, budget.GlobalMonthlyTokens},
+	}
+	for _, item := range tokenLimits {
+		if item.limit > 0 && item.used+runTokens >= item.limit {
+			return fmt.Errorf("%w: %s用量 %d 已达上限 %d", ErrBudgetExceeded, item.name, item.used+runTokens, item.limit)
+		}
+	}
+
+	costLimits := []struct {
+		name  string
+		used  float64
+		limit float64
+	}{
+		{"用户今日", snapshot.UserDaily.Cost, budget.UserDailyCost},
+		{"用户本月", snapshot.UserMonthly.Cost, budget.UserMonthlyCost},
+		{"全局今日", snapshot.GlobalDaily.Cost, budget.GlobalDailyCost},
+		{"全局本月", snapshot.GlobalMonthly.Cost, budget.GlobalMonthlyCost},
+	}
+	for _, item := range costLimits {
+		if item.limit > 0 && item.used+runCost >= item.limit {
+			return fmt.Errorf("%w: %s费用 $%.4f 已达上限 $%.2f", ErrBudgetExceeded, item.name, item.used+runCost, item.limit)
+		}
+	}
+
+	return nil
+}
+
+// usageMeter 包装本次执行使用的 LLM 客户端：每次调用前重新读取已存储的用量检查预算，
+// 调用后累计 Token 并立即写入用量记录，记忆摘要、结构化输出修复等所有调用都会计入，
+// 并发执行的其他消息也能在下一次检查时看到本次已消耗的用量
+type usageMeter struct {
+	model   string
+	budget  config.AgentBudgetConfig
+	pricing map[string]config.AgentModelPrice
+
+	// snapshot 读取其他消息的用量；persist 保存本消息的累计用量
+	snapshot func() (*usageSnapshot, error)
+	persist  func(total models.TokenUsage, cost float64) error
+
+	mu      sync.Mutex
+	total   models.TokenUsage // 本消息累计用量（含暂停前已记录的部分）
+	checked bool              // 自上次调用后已检查过预算
+}
+
+// newUsageMeter 创建消息的用量计量器并在执行前检查一次预算
+func (s *AgentService) newUsageMeter(messageID, model string) (*usageMeter, error) {
+	db := database.GetDB()
+
+	var message models.AgentMessage
+	if err := db.Select("id", "conversation_id", "created_at").Where("id = ?", messageID).First(&message).Error; err != nil {
+		return nil, fmt.Errorf("消息不存在: %w", err)
+	}
+	userID, err := s.getMessageUserID(messageID)
+	if err != nil {
+		return nil, fmt.Errorf("获取消息所属用户失败: %w", err)
+	}
+
+	cfg := config.GetConfig().Agent
+	meter := &usageMeter{
+		model:   model,
+		budget:  cfg.Budget,
+		pricing: cfg.Pricing,
+		snapshot: func() (*usageSnapshot, error) {
+			return loadUsageSnapshot(userID, messageID)
+		},
+		persist: func(total models.TokenUsage, cost float64) error {
+			record := &models.AgentUsage{
+				MessageID:        messageID,
+				ConversationID:   message.ConversationID,
+				UserID:           userID,
+				Model:            model,
+				PromptTokens:     int64(total.PromptTokens),
+				CompletionTokens: int64(total.CompletionTokens),
+				TotalTokens:      int64(total.TotalTokens),
+				Cost:             cost,
+				CreatedAt:        message.CreatedAt,
+			}
+			if err := db.Save(record).Error; err != nil {
+				return fmt.Errorf("保存用量记录失败: %w", err)
+			}
+			return db.Model(&models.AgentMessage{}).Where("id = ?", messageID).Update("cost", cost).Error
+		},
+	}
+
+	// 暂停后恢复执行时从已记录的用量继续累计
+	var existing models.AgentUsage
+	if err := db.Where("message_id = ?", messageID).First(&existing).Error; err == nil {
+		meter.total = models.TokenUsage{
+			PromptTokens:     int(existing.PromptTokens),
+			CompletionTokens: int(existing.CompletionTokens),
+			TotalTokens:      int(existing.TotalTokens),
+		}
+	}
+
+	if err := meter.Check(); err != nil {
+		return nil, err
+	}
+	return meter, nil
+}
+
+// Check 重新读取已存储的用量，检查加上本消息累计用量后是否达到预算上限
+func (m *usageMeter) Check() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.budget == (config.AgentBudgetConfig{}) || m.checked {
+		return nil
+	}
+	snapshot, err := m.snapshot()
+	if err != nil {
+		// 统计失败不阻塞执行
+		log.Error("读取 Agent 用量失败，跳过预算检查: %v", err)
+		return nil
+	}
+	if err := checkBudget(m.budget, snapshot, int64(m.total.TotalTokens), calculateCost(m.pricing, m.model, &m.total)); err != nil {
+		return err
+	}
+	m.checked = true
+	return nil
+}
+
+// Guard 供执行器在每一步调用 LLM 前检查预算
+func (m *usageMeter) Guard(*models.TokenUsage) error {
+	return m.Check()
+}
+
+// Total 本消息累计用量
+func (m *usageMeter) Total() *models.TokenUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	total := m.total
+	return &total
+}
+
+// add 累计一次调用的用量并保存
+func (m *usageMeter) add(usage llm.TokenUsage) {
+	m.mu.Lock()
+	m.total.PromptTokens += usage.PromptTokens
+	m.total.CompletionTokens += usage.CompletionTokens
+	m.total.TotalTokens += usage.TotalTokens
+	m.checked = false
+	total := m.total
+	m.mu.Unlock()
+
+	if err := m.persist(total, calculateCost(m.pricing, m.model, &total)); err != nil {
+		log.Error("保存用量记录失败: %v", err)
+	}
+}
+
+// Wrap 返回经过计量的 LLM 客户端
+func (m *usageMeter) Wrap(client llm.LLMClient) llm.LLMClient {
+	return &meteredClient{LLMClient: client, meter: m}
+}
+
+// meteredClient 调用前检查预算、调用后累计用量的 LLM 客户端
+type meteredClient struct {
+	llm.LLMClient
+	meter *usageMeter
+}
+
+func (c *meteredClient) Call(ctx context.Context, messages []llm.Message, options *llm.CallOptions) (*llm.Response, error) {
+	if err := c.meter.Check(); err != nil {
+		return nil, err
+	}
+	response, err := c.LLMClient.Call(ctx, messages, options)
+	if response != nil {
+		c.meter.add(response.Usage)
+	}
+	return response, err
+}
+
+// Stream 流式响应不返回用量，只做预算检查
+func (c *meteredClient) Stream(ctx context.Context, messages []llm.Message, options *llm.CallOptions) (<-chan llm.StreamChunk, error) {
+	if err := c.meter.Check(); err != nil {
+		return nil, err
+	}
+	return c.LLMClient.Stream(ctx, messages, options)
+}
+
+// GetUserUsageReport 获取用户今日、本月的用量与预算，以及本月各对话的用量
+func (s *AgentService) GetUserUsageReport(userID string) (*UserUsageReport, error) {
+	day, month := budgetWindows(time.Now())
+	budget := config.GetConfig().Agent.Budget
+
+	report := &UserUsageReport{
+		Budget: UserBudget{
+			DailyTokens:   budget.UserDailyTokens,
+			MonthlyTokens: budget.UserMonthlyTokens,
+			DailyCost:     budget.UserDailyCost,
+			MonthlyCost:   budget.UserMonthlyCost,
+		},
+	}
+
+	var err error
+	if report.Today, err = sumUsage(userID, day, ""); err != nil {
+		return nil, fmt.Errorf("统计用量失败: %w", err)
+	}
+	if report.Month, err = sumUsage(userID, month, ""); err != nil {
+		return nil, fmt.Errorf("统计用量失败: %w", err)
+	}
+	if report.Conversations, err = s.GetUsageReport(month, 0, "conversation", userID); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// GetUsageReport 按用户/模型/对话/日期分组统计 [start, end) 内的用量，end 为 0 表示不限，userID 为空表示全部用户
+func (s *AgentService) GetUsageReport(start, end int64, groupBy, userID string) ([]UsageGroup, error) {
+	column, ok := usageGroupColumns[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("不支持的分组方式: %s", groupBy)
+	}
+
+	query := database.GetDB().Model(&models.AgentUsage{}).
+		Select(column+" AS group_key, "+usageSumColumns).
+		Where("created_at >= ?", start)
+	if end > 0 {
+		query = query.Where("created_at < ?", end)
+	}
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	var rows []struct {
+		GroupKey string
+		UsageTotals
+	}
+	order := "cost DESC"
+	if groupBy == "day" {
+		order = column
+	}
+	if err := query.Group(column).Order(order).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("统计用量失败: %w", err)
+	}
+
+	groups := make([]UsageGroup, 0, len(rows))
+	for _, row := range rows {
+		key := row.GroupKey
+		if groupBy == "day" {
+			var dayStart int64
+			fmt.Sscan(key, &dayStart)
+			key = time.Unix(dayStart, 0).UTC().Format("2006-01-02")
+		}
+		groups = append(groups, UsageGroup{Key: key, UsageTotals: row.UsageTotals})
+	}
+	return groups, nil
+}
@@ -0,0 +1,147 @@
+package agent
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/pkg/agent/llm"
+	"auto-forge/pkg/config"
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestPriceForModel(t *testing.T) {
+	pricing := map[string]config.AgentModelPrice{
+		"gpt-4o":      {Input: 2.5, Output: 10},
+		"gpt-4o-mini": {Input: 0.15, Output: 0.6},
+	}
+
+	tests := []struct {
+		model string
+		want  float64
+		found bool
+	}{
+		{"gpt-4o", 2.5, true},
+		{"gpt-4o-mini", 0.15, true},
+		{"gpt-4o-mini-2024-07-18", 0.15, true},
+		{"gpt-4o-2024-08-06", 2.5, true},
+		{"claude-3-haiku", 0, false},
+	}
+	for _, tt := range tests {
+		price, ok := priceForModel(pricing, tt.model)
+		if ok != tt.found || price.Input != tt.want {
+			t.Errorf("priceForModel(%q) = %v, %v; want input %v, %v", tt.model, price, ok, tt.want, tt.found)
+		}
+	}
+}
+
+func TestCalculateCost(t *testing.T) {
+	pricing := map[string]config.AgentModelPrice{"gpt-4o": {Input: 2.5, Output: 10}}
+	usage := &models.TokenUsage{PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500}
+
+	if got := calculateCost(pricing, "gpt-4o", usage); math.Abs(got-0.0075) > 1e-12 {
+		t.Errorf("expected cost 0.0075, got %v", got)
+	}
+	if got := calculateCost(pricing, "unknown", usage); got != 0 {
+		t.Errorf("unpriced model should cost 0, got %v", got)
+	}
+	if got := calculateCost(pricing, "gpt-4o", nil); got != 0 {
+		t.Errorf("nil usage should cost 0, got %v", got)
+	}
+}
+
+func TestCheckBudget(t *testing.T) {
+	snapshot := &usageSnapshot{
+		UserDaily:     UsageTotals{TotalTokens: 900, Cost: 0.5},
+		UserMonthly:   UsageTotals{TotalTokens: 5000, Cost: 3},
+		GlobalDaily:   UsageTotals{TotalTokens: 20000, Cost: 10},
+		GlobalMonthly: UsageTotals{TotalTokens: 90000, Cost: 40},
+	}
+
+	tests := []struct {
+		name     string
+		budget   config.AgentBudgetConfig
+		tokens   int64
+		cost     float64
+		exceeded bool
+	}{
+		{"no limits", config.AgentBudgetConfig{}, 1_000_000, 100, false},
+		{"under user daily tokens", config.AgentBudgetConfig{UserDailyTokens: 1000}, 50, 0, false},
+		{"reaches user daily tokens", config.AgentBudgetConfig{UserDailyTokens: 1000}, 100, 0, true},
+		{"global monthly cost", config.AgentBudgetConfig{GlobalMonthlyCost: 40.5}, 0, 0.5, true},
+		{"user monthly cost already spent", config.AgentBudgetConfig{UserMonthlyCost: 3}, 0, 0, true},
+	}
+	for _, tt := range tests {
+		err := checkBudget(tt.budget, snapshot, tt.tokens, tt.cost)
+		if (err != nil) != tt.exceeded {
+			t.Errorf("%s: checkBudget() = %v, want exceeded=%v", tt.name, err, tt.exceeded)
+		}
+		if err != nil && !errors.Is(err, ErrBudgetExceeded) {
+			t.Errorf("%s: expected ErrBudgetExceeded, got %v", tt.name, err)
+		}
+	}
+}
+
+// usageClient 每次调用返回固定 Token 用量的 LLM 客户端
+type usageClient struct {
+	scriptedClient
+	tokens int
+}
+
+func (c *usageClient) Call(ctx context.Context, messages []llm.Message, options *llm.CallOptions) (*llm.Response, error) {
+	response, err := c.scriptedClient.Call(ctx, messages, options)
+	if response != nil {
+		response.Usage = llm.TokenUsage{PromptTokens: c.tokens, TotalTokens: c.tokens}
+	}
+	return response, err
+}
+
+func newTestMeter(budget config.AgentBudgetConfig, used *int64, persisted *models.TokenUsage) *usageMeter {
+	return &usageMeter{
+		model:  "test-model",
+		budget: budget,
+		snapshot: func() (*usageSnapshot, error) {
+			return &usageSnapshot{UserDaily: UsageTotals{TotalTokens: *used}}, nil
+		},
+		persist: func(total models.TokenUsage, cost float64) error {
+			*persisted = total
+			return nil
+		},
+	}
+}
+
+func TestUsageMeterMetersStructuredRepairs(t *testing.T) {
+	var used int64
+	var persisted models.TokenUsage
+	meter := newTestMeter(config.AgentBudgetConfig{}, &used, &persisted)
+	client := meter.Wrap(&usageClient{scriptedClient: scriptedClient{replies: []string{"not json", `{"ok": true}`}}, tokens: 40})
+
+	if _, err := llm.CallStructured(context.Background(), client, nil, nil, 1); err != nil {
+		t.Fatalf("CallStructured: %v", err)
+	}
+	if got := meter.Total().TotalTokens; got != 80 {
+		t.Errorf("expected repair call metered (80 tokens), got %d", got)
+	}
+	if persisted.TotalTokens != 80 {
+		t.Errorf("expected usage persisted after each call, got %d", persisted.TotalTokens)
+	}
+}
+
+func TestUsageMeterRereadsUsageBeforeEachCall(t *testing.T) {
+	used := int64(50)
+	var persisted models.TokenUsage
+	meter := newTestMeter(config.AgentBudgetConfig{UserDailyTokens: 100}, &used, &persisted)
+	client := meter.Wrap(&usageClient{scriptedClient: scriptedClient{replies: []string{"a", "b", "c"}}, tokens: 20})
+
+	if _, err := client.Call(context.Background(), nil, nil); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	// 其他并发执行在两次调用之间消耗了用量
+	used = 85
+	if _, err := client.Call(context.Background(), nil, nil); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected budget exceeded after re-reading usage, got %v", err)
+	}
+	if got := meter.Total().TotalTokens; got != 20 {
+		t.Errorf("rejected call should not be metered, got %d", got)
+	}
+}
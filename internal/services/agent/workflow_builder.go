@@ -0,0 +1,472 @@
+package agent
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/internal/services/workflow"
+	"auto-forge/pkg/agent/llm"
+	"auto-forge/pkg/agent/prompt"
+	log "auto-forge/pkg/logger"
+	"auto-forge/pkg/utools"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxWorkflowRepairs 生成的工作流校验失败时最多让模型修复的次数
+const maxWorkflowRepairs = 2
+
+// 生成的工作流允许使用的节点类型（调度由 schedule 字段表达，不生成触发器节点）
+var builderNodeTypes = map[string]bool{
+	"tool":             true,
+	"delay":            true,
+	"condition":        true,
+	"external_trigger": true,
+}
+
+var (
+	nodeRefPattern     = regexp.MustCompile(`\{\{nodes\.([^.}]+)`)
+	externalRefPattern = regexp.MustCompile(`\{\{external\.([^.}]+)`)
+)
+
+// GeneratedWorkflow 由自然语言描述生成的工作流草稿（未保存），字段与创建工作流请求一致
+type GeneratedWorkflow struct {
+	Name          string                  `json:"name"`
+	Description   string                  `json:"description"`
+	Nodes         []models.WorkflowNode   `json:"nodes"`
+	Edges         []models.WorkflowEdge   `json:"edges"`
+	EnvVars       []models.WorkflowEnvVar `json:"env_vars"`
+	ScheduleType  string                  `json:"schedule_type"`
+	ScheduleValue string                  `json:"schedule_value"`
+	Valid         bool                    `json:"valid"`
+	Errors        []string                `json:"errors,omitempty"` // 修复后仍未通过的校验项
+	Attempts      int                     `json:"attempts"`         // 调用模型的次数
+}
+
+// generatedGraph 模型返回的工作流 JSON
+type generatedGraph struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Nodes       []models.WorkflowNode `json:"nodes"`
+	Edges       []models.WorkflowEdge `json:"edges"`
+	Schedule    struct {
+		Type  string      `json:"type"`
+		Value interface{} `json:"value"`
+	} `json:"schedule"`
+	EnvVars []string `json:"env_vars"`
+}
+
+// GenerateWorkflow 根据自然语言描述生成工作流草稿
+// 以工具注册表的配置 Schema 与输出字段作为依据，校验失败时把错误反馈给模型修复
+func (s *AgentService) GenerateWorkflow(ctx context.Context, description, model string) (*GeneratedWorkflow, error) {
+	if model == "" {
+		model = "gpt-4o-mini"
+	}
+	llmClient, err := llm.NewClientForModel(model, llmProviderConfigs())
+	if err != nil {
+		return nil, err
+	}
+	return generateWorkflow(ctx, llmClient, description)
+}
+
+// generateWorkflow 调用模型生成工作流，并在校验失败时带上错误信息要求修复
+func generateWorkflow(ctx context.Context, llmClient llm.LLMClient, description string) (*GeneratedWorkflow, error) {
+	messages := []llm.Message{
+		{Role: "system", Content: prompt.WorkflowBuilderPrompt.Render(map[string]string{"tools": buildToolCatalog()})},
+		{Role: "user", Content: description},
+	}
+	options := &llm.CallOptions{Temperature: 0.2, ResponseFormat: llm.JSONObjectFormat()}
+
+	var graph *generatedGraph
+	var problems []string
+	attempts := 0
+
+	for attempts <= maxWorkflowRepairs {
+		attempts++
+		response, err := llmClient.Call(ctx, messages, options)
+		if err != nil {
+			return nil, fmt.Errorf("调用模型失败: %w", err)
+		}
+		messages = append(messages, llm.Message{Role: "assistant", Content: response.Content})
+
+		parsed, err := parseGeneratedWorkflow(response.Content)
+		if err != nil {
+			problems = []string{err.Error()}
+		} else {
+			graph = parsed
+			problems = validateGeneratedWorkflow(graph)
+		}
+		if len(problems) == 0 {
+			break
+		}
+
+		log.Warn("生成的工作流未通过校验（第 %d 次）: %s", attempts, strings.Join(problems, "; "))
+		messages = append(messages, llm.Message{
+			Role:    "user",
+			Content: prompt.WorkflowRepairPrompt.Render(map[string]string{"errors": "- " + strings.Join(problems, "\n- ")}),
+		})
+	}
+
+	if graph == nil {
+		return nil, fmt.Errorf("模型未返回有效的工作流: %s", strings.Join(problems, "; "))
+	}
+
+	layoutNodes(graph.Nodes, graph.Edges)
+
+	result := &GeneratedWorkflow{
+		Name:          graph.Name,
+		Description:   graph.Description,
+		Nodes:         graph.Nodes,
+		Edges:         graph.Edges,
+		ScheduleType:  graph.Schedule.Type,
+		ScheduleValue: scheduleValueString(graph.Schedule.Value),
+		Valid:         len(problems) == 0,
+		Errors:        problems,
+		Attempts:      attempts,
+	}
+	if result.ScheduleType == "" {
+		result.ScheduleType = "manual"
+	}
+	for _, key := range graph.EnvVars {
+		result.EnvVars = append(result.EnvVars, models.WorkflowEnvVar{Key: key, Description: "由工作流生成器添加，请填写取值"})
+	}
+
+	return result, nil
+}
+
+// buildToolCatalog 将已注册工具的配置 Schema 与输出字段整理为提示词中的工具目录
+func buildToolCatalog() string {
+	tools := utools.GetRegistry().GetAllTools()
+	codes := make([]string, 0, len(tools))
+	for code := range tools {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	var builder strings.Builder
+	for _, code := range codes {
+		tool := tools[code]
+		metadata := tool.GetMetadata()
+		entry := map[string]interface{}{
+			"toolCode":    code,
+			"name":        metadata.Name,
+			"description": metadata.Description,
+		}
+
+		if schema := tool.GetSchema(); schema != nil {
+			properties := make(map[string]interface{}, len(schema.Properties))
+			for key, prop := range schema.Properties {
+				field := map[string]interface{}{"type": prop.Type}
+				if prop.Description != "" {
+					field["description"] = prop.Description
+				} else if prop.Title != "" {
+					field["description"] = prop.Title
+				}
+				if len(prop.Enum) > 0 {
+					field["enum"] = prop.Enum
+				}
+				if prop.Default != nil {
+					field["default"] = prop.Default
+				}
+				properties[key] = field
+			}
+			entry["config"] = properties
+			entry["required"] = schema.Required
+		}
+
+		if len(metadata.OutputFieldsSchema) > 0 {
+			var fields []string
+			flattenOutputFields("", metadata.OutputFieldsSchema, &fields)
+			sort.Strings(fields)
+			entry["outputs"] = fields
+		}
+
+		data, _ := json.Marshal(entry)
+		builder.Write(data)
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+// flattenOutputFields 将输出字段定义展开为 "path (type): label" 列表
+func flattenOutputFields(prefix string, defs map[string]utools.OutputFieldDef, fields *[]string) {
+	for key, def := range defs {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		*fields = append(*fields, fmt.Sprintf("%s (%s): %s", path, def.Type, def.Label))
+		if len(def.Children) > 0 {
+			flattenOutputFields(path, def.Children, fields)
+		}
+	}
+}
+
+// parseGeneratedWorkflow 解析模型返回的 JSON（兼容 Markdown 代码块包裹）
+func parseGeneratedWorkflow(content string) (*generatedGraph, error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("返回内容不是 JSON 对象")
+	}
+
+	var graph generatedGraph
+	if err := json.Unmarshal([]byte(content[start:end+1]), &graph); err != nil {
+		return nil, fmt.Errorf("JSON 解析失败: %v", err)
+	}
+	return &graph, nil
+}
+
+// validateGeneratedWorkflow 校验生成的工作流：图结构、节点类型、工具配置、变量引用与调度
+func validateGeneratedWorkflow(graph *generatedGraph) []string {
+	var problems []string
+
+	if err := workflow.NewWorkflowService().ValidateWorkflowConfig(graph.Nodes, graph.Edges); err != nil {
+		return []string{err.Error()}
+	}
+
+	nodeIDs := make(map[string]bool, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		nodeIDs[node.ID] = true
+	}
+	ancestors, err := nodeAncestors(graph.Nodes, graph.Edges)
+	if err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	externalParams := make(map[string]bool)
+	for _, node := range graph.Nodes {
+		if node.Type != "external_trigger" {
+			continue
+		}
+		params, _ := node.Config["params"].([]interface{})
+		for _, item := range params {
+			if param, ok := item.(map[string]interface{}); ok {
+				if key, _ := param["key"].(string); key != "" {
+					externalParams[key] = true
+				}
+			}
+		}
+	}
+
+	for _, node := range graph.Nodes {
+		if !builderNodeTypes[node.Type] {
+			problems = append(problems, fmt.Sprintf("节点 %s: 不支持的节点类型 %q", node.ID, node.Type))
+			continue
+		}
+
+		if node.Type == "tool" {
+			tool, err := utools.Get(node.ToolCode)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("节点 %s: 工具 %q 不存在", node.ID, node.ToolCode))
+				continue
+			}
+			if err := tool.Validate(placeholderConfig(tool.GetSchema(), node.Config)); err != nil {
+				problems = append(problems, fmt.Sprintf("节点 %s (%s): 配置无效: %v", node.ID, node.ToolCode, err))
+			}
+		}
+
+		data, _ := json.Marshal(node.Config)
+		for _, match := range nodeRefPattern.FindAllStringSubmatch(string(data), -1) {
+			ref := match[1]
+			if !nodeIDs[ref] {
+				problems = append(problems, fmt.Sprintf("节点 %s: 引用了不存在的节点 %s", node.ID, ref))
+			} else if ancestors != nil && !ancestors[node.ID][ref] {
+				problems = append(problems, fmt.Sprintf("节点 %s: 引用的节点 %s 不在其上游", node.ID, ref))
+			}
+		}
+		for _, match := range externalRefPattern.FindAllStringSubmatch(string(data), -1) {
+			if !externalParams[match[1]] {
+				problems = append(problems, fmt.Sprintf("节点 %s: 引用的外部参数 %s 未在 external_trigger 中定义", node.ID, match[1]))
+			}
+		}
+	}
+
+	if err := validateSchedule(graph.Schedule.Type, scheduleValueString(graph.Schedule.Value)); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	return problems
+}
+
+// placeholderConfig 将完整的变量引用替换为符合字段类型的占位值，使运行时才确定的字段能通过工具的静态校验
+func placeholderConfig(schema *utools.ConfigSchema, config map[string]interface{}) map[string]interface{} {
+	if schema == nil {
+		return config
+	}
+
+	result := make(map[string]interface{}, len(config))
+	for key, value := range config {
+		result[key] = value
+
+		str, ok := value.(string)
+		prop, known := schema.Properties[key]
+		if !ok || !known || !strings.HasPrefix(str, "{{") || !strings.HasSuffix(str, "}}") || strings.Count(str, "{{") != 1 {
+			continue
+		}
+
+		switch {
+		case len(prop.Enum) > 0:
+			result[key] = prop.Enum[0]
+		case prop.Type == "number" || prop.Type == "integer":
+			placeholder := 0.0
+			if prop.Minimum != nil {
+				placeholder = *prop.Minimum
+			}
+			result[key] = placeholder
+		case prop.Type == "boolean":
+			result[key] = false
+		case prop.Type == "array":
+			result[key] = []interface{}{}
+		case prop.Type == "object":
+			result[key] = map[string]interface{}{}
+		}
+	}
+	return result
+}
+
+// nodeAncestors 计算每个节点的全部上游节点，图中存在环时返回错误
+func nodeAncestors(nodes []models.WorkflowNode, edges []models.WorkflowEdge) (map[string]map[string]bool, error) {
+	parents := make(map[string][]string)
+	inDegree := make(map[string]int, len(nodes))
+	children := make(map[string][]string)
+	for _, node := range nodes {
+		inDegree[node.ID] = 0
+	}
+	for _, edge := range edges {
+		parents[edge.Target] = append(parents[edge.Target], edge.Source)
+		children[edge.Source] = append(children[edge.Source], edge.Target)
+		inDegree[edge.Target]++
+	}
+
+	var queue []string
+	for _, node := range nodes {
+		if inDegree[node.ID] == 0 {
+			queue = append(queue, node.ID)
+		}
+	}
+
+	ancestors := make(map[string]map[string]bool, len(nodes))
+	visited := 0
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		visited++
+
+		set := make(map[string]bool)
+		for _, parent := range parents[id] {
+			set[parent] = true
+			for ancestor := range ancestors[parent] {
+				set[ancestor] = true
+			}
+		}
+		ancestors[id] = set
+
+		for _, child := range children[id] {
+			inDegree[child]--
+			if inDegree[child] == 0 {
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	if visited != len(nodes) {
+		return nil, fmt.Errorf("工作流存在循环依赖")
+	}
+	return ancestors, nil
+}
+
+// validateSchedule 校验调度类型与调度值格式（与 CalculateNextRunTime 的解析规则一致）
+func validateSchedule(scheduleType, value string) error {
+	parseTime := func(layout, text string) error {
+		if _, err := time.Parse(layout, text); err != nil {
+			return fmt.Errorf("调度时间 %q 格式错误", text)
+		}
+		return nil
+	}
+	splitPrefix := func(text string) (string, string) {
+		if i := strings.Index(text, ":"); i >= 0 {
+			return text[:i], text[i+1:]
+		}
+		return text, ""
+	}
+
+	switch scheduleType {
+	case "", "manual":
+		return nil
+	case "daily":
+		return parseTime("15:04:05", value)
+	case "weekly":
+		days, rest := splitPrefix(value)
+		for _, day := range strings.Split(days, ",") {
+			if n, err := strconv.Atoi(day); err != nil || n < 0 || n > 6 {
+				return fmt.Errorf("每周调度的星期 %q 无效（0-6，0 为周日）", day)
+			}
+		}
+		return parseTime("15:04:05", rest)
+	case "monthly":
+		day, rest := splitPrefix(value)
+		if n, err := strconv.Atoi(day); err != nil || n < 1 || n > 31 {
+			return fmt.Errorf("每月调度的日期 %q 无效", day)
+		}
+		return parseTime("15:04:05", rest)
+	case "hourly":
+		return parseTime("04:05", value)
+	case "interval":
+		if seconds, err := strconv.Atoi(value); err != nil || seconds <= 0 {
+			return fmt.Errorf("间隔调度的秒数 %q 无效", value)
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的调度类型 %q", scheduleType)
+	}
+}
+
+// scheduleValueString 调度值统一为字符串（模型可能把间隔秒数返回为数字）
+func scheduleValueString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// layoutNodes 为没有坐标的节点按层级自动布局
+func layoutNodes(nodes []models.WorkflowNode, edges []models.WorkflowEdge) {
+	depth := make(map[string]int, len(nodes))
+	// 最长路径分层，迭代次数以节点数为上限以免环导致死循环
+	for i := 0; i < len(nodes); i++ {
+		changed := false
+		for _, edge := range edges {
+			if depth[edge.Target] < depth[edge.Source]+1 {
+				depth[edge.Target] = depth[edge.Source] + 1
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	column := make(map[int]int)
+	for i := range nodes {
+		if len(nodes[i].Position) > 0 {
+			continue
+		}
+		level := depth[nodes[i].ID]
+		nodes[i].Position = map[string]float64{
+			"x": float64(250 + column[level]*300),
+			"y": float64(100 + level*draftNodeSpacing),
+		}
+		column[level]++
+	}
+}
@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"auto-forge/pkg/agent/llm"
+	"auto-forge/pkg/utools"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// scriptedClient 按顺序返回预设回复的 LLM 客户端
+type scriptedClient struct {
+	replies  []string
+	messages [][]llm.Message
+}
+
+func (c *scriptedClient) Call(ctx context.Context, messages []llm.Message, options *llm.CallOptions) (*llm.Response, error) {
+	c.messages = append(c.messages, messages)
+	if len(c.messages) > len(c.replies) {
+		return nil, errors.New("no more replies")
+	}
+	return &llm.Response{Content: c.replies[len(c.messages)-1]}, nil
+}
+
+func (c *scriptedClient) Stream(ctx context.Context, messages []llm.Message, options *llm.CallOptions) (<-chan llm.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *scriptedClient) GetModelInfo() llm.ModelInfo {
+	return llm.ModelInfo{Provider: "stub", Model: "stub", MaxTokens: 8192}
+}
+
+func registerBuilderTestTool(t *testing.T) {
+	t.Helper()
+	if _, err := utools.Get("builder_test_fetch"); err == nil {
+		return
+	}
+	tool := utools.NewBaseTool(&utools.ToolMetadata{
+		Code: "builder_test_fetch",
+		Name: "Fetch",
+		OutputFieldsSchema: map[string]utools.OutputFieldDef{
+			"items": {Type: "array", Label: "条目"},
+		},
+	}, &utools.ConfigSchema{
+		Type: "object",
+		Properties: map[string]utools.PropertySchema{
+			"limit": {Type: "number", Description: "条数"},
+			"text":  {Type: "string", Description: "内容"},
+		},
+		Required: []string{"limit"},
+	})
+	if err := utools.Register(&builderTestTool{BaseTool: tool}); err != nil {
+		t.Fatalf("register tool: %v", err)
+	}
+}
+
+type builderTestTool struct {
+	*utools.BaseTool
+}
+
+func (t *builderTestTool) Execute(ctx *utools.ExecutionContext, config map[string]interface{}) (*utools.ExecutionResult, error) {
+	return &utools.ExecutionResult{Success: true}, nil
+}
+
+func TestGenerateWorkflowRepairsInvalidGraph(t *testing.T) {
+	registerBuilderTestTool(t)
+
+	invalid := `{"name": "热榜", "nodes": [
+		{"id": "node_1", "type": "tool", "toolCode": "builder_test_fetch", "config": {}},
+		{"id": "node_2", "type": "tool", "toolCode": "builder_test_fetch", "config": {"limit": 5, "text": "{{nodes.node_9.items}}"}}
+	], "edges": [{"id": "edge_1", "source": "node_1", "target": "node_2"}],
+	"schedule": {"type": "weekly", "value": "1-5:09:00:00"}}`
+	valid := "```json\n" + `{"name": "热榜", "nodes": [
+		{"id": "node_1", "type": "tool", "toolCode": "builder_test_fetch", "config": {"limit": 10}},
+		{"id": "node_2", "type": "tool", "toolCode": "builder_test_fetch", "config": {"limit": "{{nodes.node_1.count}}", "text": "{{nodes.node_1.items}}"}}
+	], "edges": [{"id": "edge_1", "source": "node_1", "target": "node_2"}],
+	"schedule": {"type": "weekly", "value": "1,2,3,4,5:09:00:00"}, "env_vars": ["FEISHU_WEBHOOK"]}` + "\n```"
+
+	client := &scriptedClient{replies: []string{invalid, valid}}
+	result, err := generateWorkflow(context.Background(), client, "每个工作日 9 点抓取热榜")
+	if err != nil {
+		t.Fatalf("generateWorkflow: %v", err)
+	}
+
+	if !result.Valid || result.Attempts != 2 {
+		t.Fatalf("expected valid result after one repair, got valid=%v attempts=%d errors=%v", result.Valid, result.Attempts, result.Errors)
+	}
+	if result.ScheduleType != "weekly" || result.ScheduleValue != "1,2,3,4,5:09:00:00" {
+		t.Errorf("unexpected schedule: %s %s", result.ScheduleType, result.ScheduleValue)
+	}
+	if len(result.EnvVars) != 1 || result.EnvVars[0].Key != "FEISHU_WEBHOOK" {
+		t.Errorf("unexpected env vars: %+v", result.EnvVars)
+	}
+	if result.Nodes[1].Position["y"] <= result.Nodes[0].Position["y"] {
+		t.Errorf("downstream node should be laid out below: %+v", result.Nodes)
+	}
+
+	repair := client.messages[1][len(client.messages[1])-1].Content
+	for _, want := range []string{"node_1", "limit", "node_9", "星期"} {
+		if !strings.Contains(repair, want) {
+			t.Errorf("repair prompt should mention %q:\n%s", want, repair)
+		}
+	}
+}
+
+func TestGenerateWorkflowReturnsLastErrors(t *testing.T) {
+	registerBuilderTestTool(t)
+
+	reply := `{"name": "x", "nodes": [{"id": "node_1", "type": "webhook_trigger", "config": {}}], "edges": []}`
+	client := &scriptedClient{replies: []string{reply, reply, reply}}
+
+	result, err := generateWorkflow(context.Background(), client, "x")
+	if err != nil {
+		t.Fatalf("generateWorkflow: %v", err)
+	}
+	if result.Valid || result.Attempts != maxWorkflowRepairs+1 || len(result.Errors) == 0 {
+		t.Errorf("expected invalid result after all repairs, got %+v", result)
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	valid := map[string]string{
+		"manual":   "",
+		"daily":    "09:00:00",
+		"weekly":   "1,2,3,4,5:09:00:00",
+		"monthly":  "1:08:30:00",
+		"hourly":   "05:00",
+		"interval": "3600",
+	}
+	for scheduleType, value := range valid {
+		if err := validateSchedule(scheduleType, value); err != nil {
+			t.Errorf("%s %q: unexpected error %v", scheduleType, value, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"daily":    "9am",
+		"weekly":   "7:09:00:00",
+		"monthly":  "32:00:00:00",
+		"interval": "-1",
+		"cron":     "0 9 * * 1-5",
+	}
+	for scheduleType, value := range invalid {
+		if err := validateSchedule(scheduleType, value); err == nil {
+			t.Errorf("%s %q: expected error", scheduleType, value)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package agent
+
+import (
+	"auto-forge/internal/dto/request"
+	"auto-forge/internal/models"
+	"auto-forge/internal/services/workflow"
+	"auto-forge/pkg/utools"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	draftNodeSpacing   = 150 // 草稿节点的纵向间距
+	minRefValueLen     = 8   // 参数值与上游输出完全相同时视为数据引用的最短长度
+	minRefSubstringLen = 24  // 上游输出作为片段嵌入参数时视为数据引用的最短长度
+	draftTriggerNodeID = "trigger"
+)
+
+// WorkflowDraft 由 Agent 执行记录转换出的工作流草稿
+type WorkflowDraft struct {
+	Nodes   []models.WorkflowNode
+	Edges   []models.WorkflowEdge
+	Skipped []string // 未转换的步骤及原因
+}
+
+// draftStep 待转换为节点的一次工具调用
+type draftStep struct {
+	Tool   string
+	Name   string
+	Args   map[string]interface{}
+	Output map[string]interface{}
+}
+
+// outputLeaf 上游节点输出中的一个字符串值及其引用路径
+type outputLeaf struct {
+	Ref   string // 如 nodes.node_1.data.title
+	Value string
+}
+
+// draftBuilder 转换过程中的状态：已产生的上游输出与已提取的 API 参数
+type draftBuilder struct {
+	userInput  string
+	leaves     map[string]string // 输出值 -> 引用路径（后出现的节点优先）
+	fragments  []outputLeaf      // 可作为片段匹配的较长输出值
+	params     []interface{}     // external_trigger 节点的参数配置
+	paramKeys  map[string]string // 参数值标识 -> 参数名
+	usedParams map[string]bool
+}
+
+// BuildWorkflowDraft 将 Agent 消息的执行轨迹（没有可用轨迹时回退到执行计划）转换为工作流草稿
+// 工具调用按顺序成为节点；参数中引用上游输出的值改写为 {{nodes.x.y}}，取自用户输入的字面值提取为外部参数 {{external.key}}
+// isTool 判断工具能否作为工作流节点（工作流工具等仅 Agent 可用的工具不能）
+func BuildWorkflowDraft(message *models.AgentMessage, userInput string, isTool func(code string) bool) *WorkflowDraft {
+	draft := &WorkflowDraft{}
+	steps := collectTraceSteps(message.Trace, isTool, draft)
+	if len(steps) == 0 && message.Plan != nil {
+		// 计划只有工具没有参数，节点配置留给用户补全
+		for _, planStep := range message.Plan.Steps {
+			if planStep.Tool == "" {
+				continue
+			}
+			if !isTool(planStep.Tool) {
+				draft.Skipped = append(draft.Skipped, fmt.Sprintf("计划步骤 %d (%s): 不是工作流可用的工具", planStep.Step, planStep.Tool))
+				continue
+			}
+			steps = append(steps, draftStep{Tool: planStep.Tool, Name: planStep.Description})
+		}
+	}
+
+	b := &draftBuilder{
+		userInput:  userInput,
+		leaves:     make(map[string]string),
+		paramKeys:  make(map[string]string),
+		usedParams: make(map[string]bool),
+	}
+
+	var nodes []models.WorkflowNode
+	for i, step := range steps {
+		nodeID := fmt.Sprintf("node_%d", i+1)
+		name := step.Name
+		if name == "" {
+			name = step.Tool
+		}
+		nodes = append(nodes, models.WorkflowNode{
+			ID:       nodeID,
+			Type:     "tool",
+			ToolCode: step.Tool,
+			Name:     name,
+			Config:   b.rewriteArgs(step.Tool, step.Args),
+		})
+		b.addOutput("nodes."+nodeID, step.Output)
+	}
+
+	if len(b.params) > 0 {
+		trigger := models.WorkflowNode{
+			ID:       draftTriggerNodeID,
+			Type:     "external_trigger",
+			ToolCode: "external_trigger",
+			Name:     "外部 API 触发",
+			Config:   map[string]interface{}{"params": b.params},
+		}
+		nodes = append([]models.WorkflowNode{trigger}, nodes...)
+	}
+
+	for i := range nodes {
+		nodes[i].Position = map[string]float64{"x": 250, "y": float64(100 + i*draftNodeSpacing)}
+		if i > 0 {
+			draft.Edges = append(draft.Edges, models.WorkflowEdge{
+				ID:     fmt.Sprintf("edge_%d", i),
+				Source: nodes[i-1].ID,
+				Target: nodes[i].ID,
+			})
+		}
+	}
+	draft.Nodes = nodes
+
+	return draft
+}
+
+// collectTraceSteps 从执行轨迹中挑出成功的工具调用，跳过失败、被拒绝和重复的调用
+func collectTraceSteps(trace *models.AgentTrace, isTool func(code string) bool, draft *WorkflowDraft) []draftStep {
+	if trace == nil {
+		return nil
+	}
+
+	var steps []draftStep
+	seen := make(map[string]bool)
+	for _, step := range trace.Steps {
+		if step.Action == nil || step.Action.Tool == "" {
+			continue
+		}
+		tool := step.Action.Tool
+		if step.Error != "" {
+			draft.Skipped = append(draft.Skipped, fmt.Sprintf("步骤 %d (%s): 执行失败或未执行", step.Step, tool))
+			continue
+		}
+		if !isTool(tool) {
+			draft.Skipped = append(draft.Skipped, fmt.Sprintf("步骤 %d (%s): 不是工作流可用的工具", step.Step, tool))
+			continue
+		}
+
+		argsJSON, _ := json.Marshal(step.Action.Args)
+		key := tool + ":" + string(argsJSON)
+		if seen[key] {
+			draft.Skipped = append(draft.Skipped, fmt.Sprintf("步骤 %d (%s): 与之前的调用重复", step.Step, tool))
+			continue
+		}
+		seen[key] = true
+
+		steps = append(steps, draftStep{Tool: tool, Args: step.Action.Args, Output: step.ToolOutput})
+	}
+	return steps
+}
+
+// rewriteArgs 将一次工具调用的参数转换为节点配置
+func (b *draftBuilder) rewriteArgs(tool string, args map[string]interface{}) map[string]interface{} {
+	config := make(map[string]interface{}, len(args))
+
+	keys := make([]string, 0, len(args))
+	for key := range args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := args[key]
+		if param := b.paramFor(tool, key, value); param != "" {
+			config[key] = "{{external." + param + "}}"
+			continue
+		}
+		config[key] = b.rewriteValue(value)
+	}
+	return config
+}
+
+// paramFor 顶层参数取自用户输入（或为上传的文件）时提取为外部参数，返回参数名；否则返回空
+func (b *draftBuilder) paramFor(tool, key string, value interface{}) string {
+	var paramType, identity string
+	var defaultValue interface{}
+
+	switch v := value.(type) {
+	case string:
+		if len([]rune(strings.TrimSpace(v))) < 2 || !strings.Contains(b.userInput, v) {
+			return ""
+		}
+		paramType, identity, defaultValue = "string", "s:"+v, v
+	case float64:
+		text := strconv.FormatFloat(v, 'f', -1, 64)
+		if !containsNumber(b.userInput, text) {
+			return ""
+		}
+		paramType, identity, defaultValue = "number", "n:"+text, v
+	case map[string]interface{}:
+		// 对话中上传的文件只在本次对话有效，作为必填的文件参数由调用方传入
+		path, _ := v["path"].(string)
+		if v["type"] != "file" || path == "" || b.leaves[path] != "" {
+			return ""
+		}
+		paramType, identity = "file", "f:"+path
+	default:
+		return ""
+	}
+
+	if name, ok := b.paramKeys[identity]; ok {
+		return name
+	}
+
+	name := key
+	for i := 2; b.usedParams[name]; i++ {
+		name = fmt.Sprintf("%s_%d", key, i)
+	}
+	b.usedParams[name] = true
+	b.paramKeys[identity] = name
+
+	param := map[string]interface{}{
+		"key":         name,
+		"type":        paramType,
+		"required":    paramType == "file",
+		"description": fmt.Sprintf("%s 的 %s 参数（取自对话输入）", tool, key),
+	}
+	if defaultValue != nil {
+		param["defaultValue"] = defaultValue
+		param["example"] = defaultValue
+	}
+	b.params = append(b.params, param)
+
+	return name
+}
+
+// rewriteValue 将参数中与上游输出相同的值改写为节点输出引用
+func (b *draftBuilder) rewriteValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if ref, ok := b.leaves[v]; ok && len([]rune(v)) >= minRefValueLen {
+			return "{{" + ref + "}}"
+		}
+		for _, leaf := range b.fragments {
+			if strings.Contains(v, leaf.Value) {
+				v = strings.ReplaceAll(v, leaf.Value, "{{"+leaf.Ref+"}}")
+			}
+		}
+		return v
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = b.rewriteValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = b.rewriteValue(item)
+		}
+		return result
+	}
+	return value
+}
+
+// addOutput 记录节点输出中的字符串值，供后续节点参数匹配
+func (b *draftBuilder) addOutput(prefix string, output map[string]interface{}) {
+	var leaves []outputLeaf
+	collectLeaves(prefix, output, &leaves)
+
+	for _, leaf := range leaves {
+		if len([]rune(leaf.Value)) < minRefValueLen {
+			continue
+		}
+		b.leaves[leaf.Value] = leaf.Ref
+	}
+
+	// 片段按长度从长到短匹配，避免较短的值先替换掉较长值的一部分
+	b.fragments = b.fragments[:0]
+	for value, ref := range b.leaves {
+		if len([]rune(value)) >= minRefSubstringLen {
+			b.fragments = append(b.fragments, outputLeaf{Ref: ref, Value: value})
+		}
+	}
+	sort.Slice(b.fragments, func(i, j int) bool {
+		if len(b.fragments[i].Value) != len(b.fragments[j].Value) {
+			return len(b.fragments[i].Value) > len(b.fragments[j].Value)
+		}
+		return b.fragments[i].Ref < b.fragments[j].Ref
+	})
+}
+
+// collectLeaves 递归收集输出中的字符串值
+func collectLeaves(path string, value interface{}, leaves *[]outputLeaf) {
+	switch v := value.(type) {
+	case string:
+		if strings.TrimSpace(v) != "" {
+			*leaves = append(*leaves, outputLeaf{Ref: path, Value: v})
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			collectLeaves(path+"."+key, item, leaves)
+		}
+	case []interface{}:
+		for i, item := range v {
+			collectLeaves(fmt.Sprintf("%s.%d", path, i), item, leaves)
+		}
+	}
+}
+
+// containsNumber 数字是否作为独立的数值出现在文本中（"10" 不匹配 "100"）
+func containsNumber(text, number string) bool {
+	re := regexp.MustCompile(`(^|[^0-9.])` + regexp.QuoteMeta(number) + `([^0-9.]|$)`)
+	return re.MatchString(text)
+}
+
+// CreateWorkflowFromMessage 将已完成的 Agent 消息转换为工作流并保存（未启用、手动触发），返回工作流与未转换的步骤
+func (s *AgentService) CreateWorkflowFromMessage(messageID, userID, name, description string) (*models.Workflow, []string, error) {
+	message, err := s.GetOwnedMessage(messageID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if message.Role != "agent" || message.Status != "completed" {
+		return nil, nil, fmt.Errorf("只能转换已完成的 Agent 消息")
+	}
+
+	userInput := s.findUserInput(message)
+	draft := BuildWorkflowDraft(message, userInput, isWorkflowTool)
+	if len(draft.Nodes) == 0 {
+		return nil, draft.Skipped, fmt.Errorf("该消息没有可转换为工作流节点的工具调用")
+	}
+
+	if name == "" {
+		name = truncateRunes(strings.TrimSpace(userInput), 50)
+		if name == "" {
+			name = "Agent 生成的工作流"
+		}
+	}
+	if description == "" {
+		description = "由 Agent 对话生成：" + truncateRunes(userInput, 500)
+	}
+
+	wf, err := workflow.NewWorkflowService().CreateWorkflow(userID, &request.CreateWorkflowRequest{
+		Name:         name,
+		Description:  description,
+		Nodes:        draft.Nodes,
+		Edges:        draft.Edges,
+		ScheduleType: "manual",
+	})
+	if err != nil {
+		return nil, draft.Skipped, err
+	}
+
+	return wf, draft.Skipped, nil
+}
+
+// findUserInput 查找触发该 Agent 消息的用户消息内容
+func (s *AgentService) findUserInput(message *models.AgentMessage) string {
+	messages, err := s.GetMessages(message.ConversationID)
+	if err != nil {
+		return ""
+	}
+
+	for i := range messages {
+		if messages[i].ID != message.ID {
+			continue
+		}
+		for j := i - 1; j >= 0; j-- {
+			if messages[j].Role == "user" {
+				return messages[j].Content
+			}
+		}
+		break
+	}
+	return ""
+}
+
+// isWorkflowTool 工具是否已注册为可在工作流节点中使用的 UTool
+func isWorkflowTool(code string) bool {
+	_, err := utools.Get(code)
+	return err == nil
+}
+
+// truncateRunes 按字符截断
+func truncateRunes(text string, maxRunes int) string {
+	runes := []rune(text)
+	if len(runes) <= maxRunes {
+		return text
+	}
+	return string(runes[:maxRunes])
+}
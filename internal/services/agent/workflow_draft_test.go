@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"auto-forge/internal/models"
+	"testing"
+)
+
+func knownTools(codes ...string) func(string) bool {
+	return func(code string) bool {
+		for _, c := range codes {
+			if c == code {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func toolStep(step int, tool string, args, output map[string]interface{}) models.AgentStep {
+	return models.AgentStep{
+		Step:       step,
+		Action:     &models.AgentAction{Type: "action", Tool: tool, Args: args},
+		ToolOutput: output,
+	}
+}
+
+func TestBuildWorkflowDraftFromTrace(t *testing.T) {
+	summary := "Top stories today: Go 1.24 released with generic type aliases."
+	message := &models.AgentMessage{
+		Trace: &models.AgentTrace{Steps: []models.AgentStep{
+			toolStep(1, "http_request", map[string]interface{}{
+				"url":    "https://hacker-news.firebaseio.com/v0/topstories.json",
+				"method": "GET",
+			}, map[string]interface{}{"body": "[1,2,3]"}),
+			{Step: 2, Action: &models.AgentAction{Type: "action", Tool: "feishu_bot"}, Error: "用户拒绝执行"},
+			toolStep(3, "openai_chatgpt", map[string]interface{}{
+				"prompt": "Summarize the top 10 stories",
+				"limit":  float64(10),
+			}, map[string]interface{}{"content": summary}),
+			toolStep(4, "workflow_abc", map[string]interface{}{}, nil),
+			toolStep(5, "feishu_bot", map[string]interface{}{
+				"content": summary,
+				"title":   "Daily digest: " + summary,
+			}, nil),
+		}},
+	}
+	userInput := "fetch HN top 10 from https://hacker-news.firebaseio.com/v0/topstories.json, summarize, send to Feishu"
+
+	draft := BuildWorkflowDraft(message, userInput, knownTools("http_request", "openai_chatgpt", "feishu_bot"))
+
+	if len(draft.Nodes) != 4 {
+		t.Fatalf("expected trigger + 3 tool nodes, got %d", len(draft.Nodes))
+	}
+	if len(draft.Edges) != 3 || draft.Edges[0].Source != draftTriggerNodeID || draft.Edges[2].Target != "node_3" {
+		t.Fatalf("unexpected edges: %+v", draft.Edges)
+	}
+	if len(draft.Skipped) != 2 {
+		t.Errorf("expected 2 skipped steps, got %v", draft.Skipped)
+	}
+
+	trigger := draft.Nodes[0]
+	params, _ := trigger.Config["params"].([]interface{})
+	if trigger.Type != "external_trigger" || len(params) != 2 {
+		t.Fatalf("expected external trigger with 2 params, got %+v", trigger)
+	}
+
+	fetch := draft.Nodes[1].Config
+	if fetch["url"] != "{{external.url}}" || fetch["method"] != "GET" {
+		t.Errorf("unexpected fetch config: %v", fetch)
+	}
+
+	summarize := draft.Nodes[2].Config
+	if summarize["limit"] != "{{external.limit}}" {
+		t.Errorf("expected limit to become an API param, got %v", summarize["limit"])
+	}
+	if summarize["prompt"] != "Summarize the top 10 stories" {
+		t.Errorf("prompt should stay literal, got %v", summarize["prompt"])
+	}
+
+	send := draft.Nodes[3].Config
+	if send["content"] != "{{nodes.node_2.content}}" {
+		t.Errorf("expected whole-value reference, got %v", send["content"])
+	}
+	if send["title"] != "Daily digest: {{nodes.node_2.content}}" {
+		t.Errorf("expected embedded reference, got %v", send["title"])
+	}
+}
+
+func TestBuildWorkflowDraftFromPlan(t *testing.T) {
+	message := &models.AgentMessage{
+		Plan: &models.AgentPlan{Steps: []models.AgentPlanStep{
+			{Step: 1, Description: "抓取网页", Tool: "http_request"},
+			{Step: 2, Description: "整理结果"},
+		}},
+	}
+
+	draft := BuildWorkflowDraft(message, "", knownTools("http_request"))
+
+	if len(draft.Nodes) != 1 || draft.Nodes[0].Name != "抓取网页" || len(draft.Edges) != 0 {
+		t.Fatalf("unexpected draft: %+v", draft)
+	}
+}
+
+func TestContainsNumber(t *testing.T) {
+	if !containsNumber("top 10 stories", "10") {
+		t.Error("expected 10 to match")
+	}
+	if containsNumber("top 100 stories", "10") || containsNumber("version 1.10", "10") {
+		t.Error("10 should not match inside another number")
+	}
+}
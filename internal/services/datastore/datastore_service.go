@@ -0,0 +1,358 @@
+package datastore
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/internal/repositories"
+	"auto-forge/pkg/common"
+	"auto-forge/pkg/config"
+	"auto-forge/pkg/errors"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 配额默认值
+const (
+	defaultMaxCollections  = 20
+	defaultMaxDocuments    = 10000
+	defaultMaxDocumentSize = 64 // KB
+
+	defaultQueryLimit = 100
+	maxQueryLimit     = 1000
+)
+
+// DocumentView 文档及其解析后的内容
+type DocumentView struct {
+	Key       string          `json:"key"`
+	Data      interface{}     `json:"data"`
+	Size      int             `json:"size"`
+	CreatedAt common.JSONTime `json:"created_at"`
+	UpdatedAt common.JSONTime `json:"updated_at"`
+}
+
+// QueryResult 文档查询结果
+type QueryResult struct {
+	Total     int             `json:"total"`
+	Documents []*DocumentView `json:"documents"`
+}
+
+// DataStoreService 用户数据存储服务接口
+type DataStoreService interface {
+	ListCollections(userID string) ([]*models.DataCollection, error)
+	CreateCollection(userID, name, description string) (*models.DataCollection, error)
+	UpdateCollection(id, userID string, name, description *string) (*models.DataCollection, error)
+	DeleteCollection(id, userID string) error
+	GetCollection(id, userID string) (*models.DataCollection, error)
+	ResolveCollection(userID, name string, create bool) (*models.DataCollection, error)
+
+	UpsertDocument(userID, collectionID, key string, data interface{}) (*DocumentView, bool, error)
+	GetDocument(userID, collectionID, key string) (*DocumentView, error)
+	DeleteDocument(userID, collectionID, key string) (bool, error)
+	QueryDocuments(userID, collectionID string, query *Query) (*QueryResult, error)
+	ExportCollection(userID, collectionID, format string) ([]byte, string, error)
+}
+
+type dataStoreService struct {
+	repo repositories.DataStoreRepository
+}
+
+// NewDataStoreService 创建用户数据存储服务实例
+func NewDataStoreService() DataStoreService {
+	return &dataStoreService{
+		repo: repositories.NewDataStoreRepository(),
+	}
+}
+
+// ListCollections 获取用户的集合及各集合的文档数
+func (s *dataStoreService) ListCollections(userID string) ([]*models.DataCollection, error) {
+	collections, err := s.repo.FindCollectionsByUser(userID)
+	if err != nil {
+		return nil, errors.New(errors.CodeQueryFailed, "获取集合列表失败")
+	}
+	counts, err := s.repo.CountDocumentsByCollection(userID)
+	if err != nil {
+		return nil, errors.New(errors.CodeQueryFailed, "统计文档数失败")
+	}
+
+	for _, collection := range collections {
+		collection.DocumentCount = counts[collection.GetID()]
+	}
+	return collections, nil
+}
+
+// CreateCollection 创建集合
+func (s *dataStoreService) CreateCollection(userID, name, description string) (*models.DataCollection, error) {
+	name = strings.TrimSpace(name)
+	if err := checkCollectionName(name); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.FindCollectionByName(userID, name); err == nil {
+		return nil, errors.New(errors.CodeConflict, "集合已存在: "+name)
+	}
+
+	count, err := s.repo.CountCollections(userID)
+	if err != nil {
+		return nil, errors.New(errors.CodeQueryFailed, "统计集合数失败")
+	}
+	if limit := quotas().MaxCollections; count >= int64(limit) {
+		return nil, errors.New(errors.CodeForbidden, fmt.Sprintf("集合数已达上限 %d", limit))
+	}
+
+	collection := &models.DataCollection{UserID: userID, Name: name, Description: description}
+	if err := s.repo.CreateCollection(collection); err != nil {
+		return nil, errors.New(errors.CodeInternal, "创建集合失败")
+	}
+	return collection, nil
+}
+
+// UpdateCollection 修改集合名称或描述
+func (s *dataStoreService) UpdateCollection(id, userID string, name, description *string) (*models.DataCollection, error) {
+	collection, err := s.GetCollection(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if name != nil {
+		newName := strings.TrimSpace(*name)
+		if err := checkCollectionName(newName); err != nil {
+			return nil, err
+		}
+		if existing, err := s.repo.FindCollectionByName(userID, newName); err == nil && existing.GetID() != collection.GetID() {
+			return nil, errors.New(errors.CodeConflict, "集合已存在: "+newName)
+		}
+		collection.Name = newName
+	}
+	if description != nil {
+		collection.Description = *description
+	}
+
+	if err := s.repo.UpdateCollection(collection); err != nil {
+		return nil, errors.New(errors.CodeInternal, "更新集合失败")
+	}
+	return collection, nil
+}
+
+// DeleteCollection 删除集合及其全部文档
+func (s *dataStoreService) DeleteCollection(id, userID string) error {
+	collection, err := s.GetCollection(id, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteCollection(collection.GetID()); err != nil {
+		return errors.New(errors.CodeInternal, "删除集合失败")
+	}
+	return nil
+}
+
+// GetCollection 获取用户的集合
+func (s *dataStoreService) GetCollection(id, userID string) (*models.DataCollection, error) {
+	collection, err := s.repo.FindCollectionByID(id, userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.CodeNotFound, "集合不存在")
+		}
+		return nil, errors.New(errors.CodeQueryFailed, "获取集合失败")
+	}
+	return collection, nil
+}
+
+// ResolveCollection 按名称获取用户的集合，create 为 true 时不存在则创建（供工具使用）
+func (s *dataStoreService) ResolveCollection(userID, name string, create bool) (*models.DataCollection, error) {
+	name = strings.TrimSpace(name)
+	collection, err := s.repo.FindCollectionByName(userID, name)
+	if err == nil {
+		return collection, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, errors.New(errors.CodeQueryFailed, "获取集合失败")
+	}
+	if !create {
+		return nil, errors.New(errors.CodeNotFound, "集合不存在: "+name)
+	}
+	return s.CreateCollection(userID, name, "")
+}
+
+// UpsertDocument 写入文档，返回文档与是否为新建
+func (s *dataStoreService) UpsertDocument(userID, collectionID, key string, data interface{}) (*DocumentView, bool, error) {
+	if err := checkDocumentKey(key); err != nil {
+		return nil, false, err
+	}
+	collection, err := s.GetCollection(collectionID, userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, false, errors.New(errors.CodeInvalidParameter, "文档内容不是有效的 JSON")
+	}
+	if limit := quotas().MaxDocumentSize; len(encoded) > limit*1024 {
+		return nil, false, errors.New(errors.CodeForbidden, fmt.Sprintf("文档超过大小上限 %d KB", limit))
+	}
+
+	document := &models.DataDocument{
+		CollectionID: collection.GetID(),
+		UserID:       userID,
+		Key:          key,
+		Data:         string(encoded),
+		Size:         len(encoded),
+	}
+	limit := quotas().MaxDocuments
+	saved, created, err := s.repo.UpsertDocument(document, int64(limit))
+	if err == repositories.ErrDuplicateDocument {
+		// 并发写入同一键时对方已创建文档，重试一次即转为更新
+		saved, created, err = s.repo.UpsertDocument(document, int64(limit))
+	}
+	switch {
+	case err == repositories.ErrDocumentQuotaExceeded:
+		return nil, false, errors.New(errors.CodeForbidden, fmt.Sprintf("文档数已达上限 %d", limit))
+	case err == repositories.ErrDuplicateDocument:
+		return nil, false, errors.New(errors.CodeDBDuplicate, "文档正在被并发写入，请重试: "+key)
+	case err != nil:
+		return nil, false, errors.New(errors.CodeInternal, "保存文档失败")
+	}
+	return toDocumentView(saved), created, nil
+}
+
+// GetDocument 获取文档
+func (s *dataStoreService) GetDocument(userID, collectionID, key string) (*DocumentView, error) {
+	collection, err := s.GetCollection(collectionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := s.repo.FindDocument(collection.GetID(), key)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.CodeNotFound, "文档不存在: "+key)
+		}
+		return nil, errors.New(errors.CodeQueryFailed, "获取文档失败")
+	}
+	return toDocumentView(document), nil
+}
+
+// DeleteDocument 删除文档，返回文档是否存在
+func (s *dataStoreService) DeleteDocument(userID, collectionID, key string) (bool, error) {
+	collection, err := s.GetCollection(collectionID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := s.repo.DeleteDocument(collection.GetID(), key)
+	if err != nil {
+		return false, errors.New(errors.CodeInternal, "删除文档失败")
+	}
+	return deleted, nil
+}
+
+// QueryDocuments 按过滤条件查询文档，过滤、排序与分页均在数据库中完成
+func (s *dataStoreService) QueryDocuments(userID, collectionID string, query *Query) (*QueryResult, error) {
+	if query == nil {
+		query = &Query{}
+	}
+	if err := validateFilters(query.Filters); err != nil {
+		return nil, errors.New(errors.CodeInvalidParameter, err.Error())
+	}
+	if err := validateField(query.SortBy); err != nil {
+		return nil, errors.New(errors.CodeInvalidParameter, err.Error())
+	}
+
+	collection, err := s.GetCollection(collectionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultQueryLimit
+	}
+	if limit > maxQueryLimit {
+		limit = maxQueryLimit
+	}
+	offset := query.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	dialect := newSQLDialect(s.repo.Dialect())
+	conds := make([]clause.Expr, 0, len(query.Filters))
+	for _, filter := range query.Filters {
+		conds = append(conds, dialect.filterExpr(filter))
+	}
+
+	documents, total, err := s.repo.QueryDocuments(collection.GetID(), conds, dialect.orderExpr(query.SortBy, query.Desc), offset, limit)
+	if err != nil {
+		return nil, errors.New(errors.CodeQueryFailed, "查询文档失败")
+	}
+
+	docs := make([]*DocumentView, 0, len(documents))
+	for _, document := range documents {
+		docs = append(docs, toDocumentView(document))
+	}
+	return &QueryResult{Total: int(total), Documents: docs}, nil
+}
+
+// loadDocuments 读取集合的全部文档（用于导出）
+func (s *dataStoreService) loadDocuments(userID, collectionID string) ([]*DocumentView, error) {
+	collection, err := s.GetCollection(collectionID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := s.repo.FindDocuments(collection.GetID())
+	if err != nil {
+		return nil, errors.New(errors.CodeQueryFailed, "获取文档失败")
+	}
+
+	docs := make([]*DocumentView, 0, len(documents))
+	for _, document := range documents {
+		docs = append(docs, toDocumentView(document))
+	}
+	return docs, nil
+}
+
+// toDocumentView 解析文档内容
+func toDocumentView(document *models.DataDocument) *DocumentView {
+	var data interface{}
+	_ = json.Unmarshal([]byte(document.Data), &data)
+	return &DocumentView{
+		Key:       document.Key,
+		Data:      data,
+		Size:      document.Size,
+		CreatedAt: document.CreatedAt,
+		UpdatedAt: document.UpdatedAt,
+	}
+}
+
+func checkCollectionName(name string) error {
+	if name == "" || utf8.RuneCountInString(name) > 100 {
+		return errors.New(errors.CodeInvalidParameter, "集合名称不能为空且不超过 100 个字符")
+	}
+	return nil
+}
+
+func checkDocumentKey(key string) error {
+	if key == "" || len(key) > 255 {
+		return errors.New(errors.CodeInvalidParameter, "文档键不能为空且不超过 255 字节")
+	}
+	return nil
+}
+
+// quotas 读取配额配置，未配置的项使用默认值
+func quotas() config.DataStoreConfig {
+	cfg := config.GetConfig().DataStore
+	if cfg.MaxCollections <= 0 {
+		cfg.MaxCollections = defaultMaxCollections
+	}
+	if cfg.MaxDocuments <= 0 {
+		cfg.MaxDocuments = defaultMaxDocuments
+	}
+	if cfg.MaxDocumentSize <= 0 {
+		cfg.MaxDocumentSize = defaultMaxDocumentSize
+	}
+	return cfg
+}
@@ -0,0 +1,122 @@
+package datastore
+
+import (
+	"auto-forge/pkg/errors"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// 导出格式
+const (
+	ExportJSON = "json"
+	ExportCSV  = "csv"
+)
+
+// ExportCollection 导出集合的全部文档，返回文件内容与 Content-Type
+func (s *dataStoreService) ExportCollection(userID, collectionID, format string) ([]byte, string, error) {
+	if format == "" {
+		format = ExportJSON
+	}
+	if format != ExportJSON && format != ExportCSV {
+		return nil, "", errors.New(errors.CodeInvalidParameter, "不支持的导出格式: "+format)
+	}
+
+	docs, err := s.loadDocuments(userID, collectionID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if format == ExportCSV {
+		data, err := exportCSV(docs)
+		if err != nil {
+			return nil, "", errors.New(errors.CodeInternal, "导出失败")
+		}
+		return data, "text/csv; charset=utf-8", nil
+	}
+
+	data, err := json.MarshalIndent(docs, "", "  ")
+	if err != nil {
+		return nil, "", errors.New(errors.CodeInternal, "导出失败")
+	}
+	return data, "application/json; charset=utf-8", nil
+}
+
+// exportCSV 每个文档一行：对象文档的顶层字段各占一列，嵌套值以 JSON 文本写入；非对象文档写入 value 列
+func exportCSV(docs []*DocumentView) ([]byte, error) {
+	fieldSet := make(map[string]bool)
+	hasScalar := false
+	for _, doc := range docs {
+		object, ok := doc.Data.(map[string]interface{})
+		if !ok {
+			hasScalar = true
+			continue
+		}
+		for field := range object {
+			fieldSet[field] = true
+		}
+	}
+
+	fields := make([]string, 0, len(fieldSet))
+	for field := range fieldSet {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	header := []string{"key"}
+	if hasScalar {
+		header = append(header, "value")
+	}
+	header = append(header, fields...)
+	header = append(header, "updated_at")
+
+	var buf bytes.Buffer
+	// 写入 BOM，便于 Excel 正确识别中文
+	buf.WriteString("\xEF\xBB\xBF")
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+
+	for _, doc := range docs {
+		row := []string{doc.Key}
+		object, isObject := doc.Data.(map[string]interface{})
+		if hasScalar {
+			if isObject {
+				row = append(row, "")
+			} else {
+				row = append(row, csvValue(doc.Data))
+			}
+		}
+		for _, field := range fields {
+			if value, ok := object[field]; ok {
+				row = append(row, csvValue(value))
+			} else {
+				row = append(row, "")
+			}
+		}
+		row = append(row, time.Time(doc.UpdatedAt).Format("2006-01-02 15:04:05"))
+		if err := writer.Write(row); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+func csvValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,398 @@
+package datastore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// 过滤操作符
+const (
+	OpEq       = "eq"
+	OpNe       = "ne"
+	OpGt       = "gt"
+	OpGte      = "gte"
+	OpLt       = "lt"
+	OpLte      = "lte"
+	OpIn       = "in"
+	OpContains = "contains"
+	OpPrefix   = "prefix"
+	OpExists   = "exists"
+)
+
+// 特殊字段：按文档键与更新时间过滤或排序
+const (
+	FieldKey       = "_key"
+	FieldUpdatedAt = "_updated_at"
+)
+
+// Filter 文档过滤条件，Field 为文档内的点分路径（如 user.id），为空表示整个文档
+type Filter struct {
+	Field string      `json:"field"`
+	Op    string      `json:"op"`
+	Value interface{} `json:"value"`
+}
+
+// Query 文档查询条件
+type Query struct {
+	Filters []Filter `json:"filters"`
+	SortBy  string   `json:"sort_by"`
+	Desc    bool     `json:"desc"`
+	Limit   int      `json:"limit"`
+	Offset  int      `json:"offset"`
+}
+
+// updatedAtLayout 按更新时间过滤时的时间格式
+const updatedAtLayout = "2006-01-02 15:04:05"
+
+// validateFilters 检查过滤条件
+func validateFilters(filters []Filter) error {
+	for _, filter := range filters {
+		if err := validateField(filter.Field); err != nil {
+			return err
+		}
+		switch filter.Op {
+		case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpContains, OpPrefix, OpExists:
+		case OpIn:
+			if _, ok := filter.Value.([]interface{}); !ok {
+				return fmt.Errorf("in 操作的值必须是数组: %s", filter.Field)
+			}
+		default:
+			return fmt.Errorf("不支持的过滤操作: %s", filter.Op)
+		}
+		if filter.Field == FieldUpdatedAt && filter.Op != OpExists {
+			if filter.Op == OpContains || filter.Op == OpPrefix {
+				return fmt.Errorf("%s 不支持 %s 操作", FieldUpdatedAt, filter.Op)
+			}
+			values := []interface{}{filter.Value}
+			if filter.Op == OpIn {
+				values = filter.Value.([]interface{})
+			}
+			for _, value := range values {
+				if _, err := parseUpdatedAt(value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateField 检查字段路径，路径各段会作为 JSON 路径的带引号键名写入 SQL
+func validateField(field string) error {
+	if field == "" || field == FieldKey || field == FieldUpdatedAt {
+		return nil
+	}
+	for _, part := range strings.Split(field, ".") {
+		if part == "" || strings.ContainsAny(part, `"\`) {
+			return fmt.Errorf("字段路径无效: %s", field)
+		}
+	}
+	return nil
+}
+
+func parseUpdatedAt(value interface{}) (time.Time, error) {
+	str, _ := value.(string)
+	t, err := time.ParseInLocation(updatedAtLayout, str, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s 的值必须是 %s 格式的时间", FieldUpdatedAt, updatedAtLayout)
+	}
+	return t, nil
+}
+
+// sqlDialect 将过滤与排序条件转换为 SQL，文档内容以 JSON 文本存储，SQLite 与 MySQL 的 JSON 函数不同。
+// 比较按 JSON 类型进行：数字只与数字比较，字符串只与字符串比较（数字字符串不视为数字）
+type sqlDialect struct {
+	mysql bool
+}
+
+func newSQLDialect(name string) sqlDialect {
+	return sqlDialect{mysql: name == "mysql"}
+}
+
+var sqlOperators = map[string]string{
+	OpEq:  "=",
+	OpGt:  ">",
+	OpGte: ">=",
+	OpLt:  "<",
+	OpLte: "<=",
+}
+
+var (
+	matchNone = clause.Expr{SQL: "1 = 0"}
+	matchAll  = clause.Expr{SQL: "1 = 1"}
+)
+
+// jsonValue JSON 路径上的值：typ 为 JSON 类型表达式（字段不存在时为 NULL），value 为值表达式
+type jsonValue struct {
+	typ   clause.Expr
+	value clause.Expr
+	text  clause.Expr // 字符串值的文本表达式
+}
+
+func (d sqlDialect) pathValue(field string) jsonValue {
+	path := jsonPath(field)
+	if d.mysql {
+		return jsonValue{
+			typ:   clause.Expr{SQL: "JSON_TYPE(JSON_EXTRACT(data_document.data, ?))", Vars: []interface{}{path}},
+			value: clause.Expr{SQL: "JSON_EXTRACT(data_document.data, ?)", Vars: []interface{}{path}},
+			text:  clause.Expr{SQL: "JSON_UNQUOTE(JSON_EXTRACT(data_document.data, ?))", Vars: []interface{}{path}},
+		}
+	}
+	return jsonValue{
+		typ:   clause.Expr{SQL: "json_type(data_document.data, ?)", Vars: []interface{}{path}},
+		value: clause.Expr{SQL: "json_extract(data_document.data, ?)", Vars: []interface{}{path}},
+		text:  clause.Expr{SQL: "json_extract(data_document.data, ?)", Vars: []interface{}{path}},
+	}
+}
+
+// jsonPath 生成 JSON 路径，各段加引号以支持任意键名
+func jsonPath(field string) string {
+	if field == "" {
+		return "$"
+	}
+	var b strings.Builder
+	b.WriteString("$")
+	for _, part := range strings.Split(field, ".") {
+		b.WriteString(`."` + part + `"`)
+	}
+	return b.String()
+}
+
+// valueKind 过滤值的 JSON 类型
+func valueKind(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case float64, float32, int, int64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return "container"
+}
+
+// typeNames 各 JSON 类型在数据库中的类型名
+func (d sqlDialect) typeNames(kind string) []interface{} {
+	if d.mysql {
+		switch kind {
+		case "string":
+			return []interface{}{"STRING"}
+		case "number":
+			return []interface{}{"INTEGER", "UNSIGNED INTEGER", "DOUBLE", "DECIMAL"}
+		case "boolean":
+			return []interface{}{"BOOLEAN"}
+		case "null":
+			return []interface{}{"NULL"}
+		}
+		return []interface{}{"ARRAY", "OBJECT"}
+	}
+	switch kind {
+	case "string":
+		return []interface{}{"text"}
+	case "number":
+		return []interface{}{"integer", "real"}
+	case "boolean":
+		return []interface{}{"true", "false"}
+	case "null":
+		return []interface{}{"null"}
+	}
+	return []interface{}{"array", "object"}
+}
+
+func (d sqlDialect) typeIn(typ clause.Expr, kind string) clause.Expr {
+	return joinExpr(" IN ", typ, clause.Expr{SQL: "?", Vars: []interface{}{d.typeNames(kind)}})
+}
+
+// compareJSON 比较 JSON 值与过滤值，类型不同时不匹配
+func (d sqlDialect) compareJSON(v jsonValue, op string, value interface{}) clause.Expr {
+	kind := valueKind(value)
+	if op != OpEq && kind != "string" && kind != "number" {
+		return matchNone
+	}
+	operator := " " + sqlOperators[op] + " "
+
+	if d.mysql {
+		encoded, _ := json.Marshal(value)
+		return andExpr(d.typeIn(v.typ, kind),
+			joinExpr(operator, v.value, clause.Expr{SQL: "CAST(? AS JSON)", Vars: []interface{}{string(encoded)}}))
+	}
+
+	switch kind {
+	case "boolean":
+		name := "false"
+		if value.(bool) {
+			name = "true"
+		}
+		return joinExpr(" = ", v.typ, clause.Expr{SQL: "?", Vars: []interface{}{name}})
+	case "null":
+		return d.typeIn(v.typ, kind)
+	case "container":
+		encoded, _ := json.Marshal(value)
+		return andExpr(d.typeIn(v.typ, kind),
+			joinExpr(" = ", v.value, clause.Expr{SQL: "json(?)", Vars: []interface{}{string(encoded)}}))
+	}
+	return andExpr(d.typeIn(v.typ, kind), joinExpr(operator, v.value, clause.Expr{SQL: "?", Vars: []interface{}{value}}))
+}
+
+// arrayContains 数组字段是否包含与过滤值相等的元素
+func (d sqlDialect) arrayContains(field string, value interface{}) clause.Expr {
+	path := jsonPath(field)
+	if d.mysql {
+		encoded, _ := json.Marshal(value)
+		return clause.Expr{
+			SQL:  "(JSON_TYPE(JSON_EXTRACT(data_document.data, ?)) = 'ARRAY' AND JSON_CONTAINS(JSON_EXTRACT(data_document.data, ?), CAST(? AS JSON)))",
+			Vars: []interface{}{path, path, string(encoded)},
+		}
+	}
+	element := jsonValue{typ: clause.Expr{SQL: "e.type"}, value: clause.Expr{SQL: "e.value"}}
+	match := d.compareJSON(element, OpEq, value)
+	return clause.Expr{
+		SQL:  "(json_type(data_document.data, ?) = 'array' AND EXISTS (SELECT 1 FROM json_each(data_document.data, ?) AS e WHERE " + match.SQL + "))",
+		Vars: append([]interface{}{path, path}, match.Vars...),
+	}
+}
+
+// containsText 文本包含子串（区分大小写）
+func (d sqlDialect) containsText(text clause.Expr, sub string) clause.Expr {
+	if d.mysql {
+		return clause.Expr{SQL: "LOCATE(?, " + text.SQL + ") > 0", Vars: append([]interface{}{sub}, text.Vars...)}
+	}
+	return clause.Expr{SQL: "instr(" + text.SQL + ", ?) > 0", Vars: append(append([]interface{}{}, text.Vars...), sub)}
+}
+
+// hasPrefix 文本以指定前缀开头（区分大小写）
+func (d sqlDialect) hasPrefix(text clause.Expr, prefix string) clause.Expr {
+	sql := "substr(" + text.SQL + ", 1, length(?)) = ?"
+	if d.mysql {
+		sql = "LEFT(" + text.SQL + ", CHAR_LENGTH(?)) = ?"
+	}
+	return clause.Expr{SQL: sql, Vars: append(append([]interface{}{}, text.Vars...), prefix, prefix)}
+}
+
+// filterExpr 将单个过滤条件转换为 SQL 条件
+func (d sqlDialect) filterExpr(filter Filter) clause.Expr {
+	if filter.Field == FieldKey || filter.Field == FieldUpdatedAt {
+		return d.columnFilter(filter)
+	}
+
+	v := d.pathValue(filter.Field)
+	switch filter.Op {
+	case OpExists:
+		want, ok := filter.Value.(bool)
+		if !ok {
+			want = true
+		}
+		if want {
+			return clause.Expr{SQL: v.typ.SQL + " IS NOT NULL", Vars: v.typ.Vars}
+		}
+		return clause.Expr{SQL: v.typ.SQL + " IS NULL", Vars: v.typ.Vars}
+	case OpNe:
+		// 缺少字段的文档也满足 ne
+		eq := d.compareJSON(v, OpEq, filter.Value)
+		return clause.Expr{SQL: "(" + v.typ.SQL + " IS NULL OR NOT (" + eq.SQL + "))", Vars: append(append([]interface{}{}, v.typ.Vars...), eq.Vars...)}
+	case OpIn:
+		items, _ := filter.Value.([]interface{})
+		exprs := make([]clause.Expr, 0, len(items))
+		for _, item := range items {
+			exprs = append(exprs, d.compareJSON(v, OpEq, item))
+		}
+		return orExpr(exprs...)
+	case OpContains:
+		return orExpr(
+			d.arrayContains(filter.Field, filter.Value),
+			andExpr(d.typeIn(v.typ, "string"), d.containsText(v.text, fmt.Sprint(filter.Value))),
+		)
+	case OpPrefix:
+		return andExpr(d.typeIn(v.typ, "string"), d.hasPrefix(v.text, fmt.Sprint(filter.Value)))
+	}
+	return d.compareJSON(v, filter.Op, filter.Value)
+}
+
+// columnFilter 按文档键或更新时间过滤，两者总是存在
+func (d sqlDialect) columnFilter(filter Filter) clause.Expr {
+	column := clause.Expr{SQL: "data_document.doc_key"}
+	convert := func(value interface{}) interface{} { return fmt.Sprint(value) }
+	if filter.Field == FieldUpdatedAt {
+		column = clause.Expr{SQL: "data_document.updated_at"}
+		convert = func(value interface{}) interface{} {
+			t, _ := parseUpdatedAt(value)
+			return t
+		}
+	}
+
+	switch filter.Op {
+	case OpExists:
+		if want, ok := filter.Value.(bool); ok && !want {
+			return matchNone
+		}
+		return matchAll
+	case OpNe:
+		return clause.Expr{SQL: column.SQL + " <> ?", Vars: []interface{}{convert(filter.Value)}}
+	case OpIn:
+		items, _ := filter.Value.([]interface{})
+		if len(items) == 0 {
+			return matchNone
+		}
+		values := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			values = append(values, convert(item))
+		}
+		return clause.Expr{SQL: column.SQL + " IN ?", Vars: []interface{}{values}}
+	case OpContains:
+		return d.containsText(column, fmt.Sprint(filter.Value))
+	case OpPrefix:
+		return d.hasPrefix(column, fmt.Sprint(filter.Value))
+	}
+	return clause.Expr{SQL: column.SQL + " " + sqlOperators[filter.Op] + " ?", Vars: []interface{}{convert(filter.Value)}}
+}
+
+// orderExpr 生成排序表达式，缺少排序字段的文档排在最后，同值按文档键排序
+func (d sqlDialect) orderExpr(sortBy string, desc bool) clause.Expr {
+	direction := " ASC"
+	if desc {
+		direction = " DESC"
+	}
+	switch sortBy {
+	case "", FieldKey:
+		return clause.Expr{SQL: "data_document.doc_key" + direction}
+	case FieldUpdatedAt:
+		return clause.Expr{SQL: "data_document.updated_at" + direction + ", data_document.doc_key ASC"}
+	}
+
+	v := d.pathValue(sortBy)
+	return clause.Expr{
+		SQL:  "CASE WHEN " + v.typ.SQL + " IS NULL THEN 1 ELSE 0 END ASC, " + v.value.SQL + direction + ", data_document.doc_key ASC",
+		Vars: append(append([]interface{}{}, v.typ.Vars...), v.value.Vars...),
+	}
+}
+
+func joinExpr(sep string, left, right clause.Expr) clause.Expr {
+	return clause.Expr{SQL: left.SQL + sep + right.SQL, Vars: append(append([]interface{}{}, left.Vars...), right.Vars...)}
+}
+
+func andExpr(exprs ...clause.Expr) clause.Expr {
+	return combineExpr(" AND ", matchAll, exprs)
+}
+
+func orExpr(exprs ...clause.Expr) clause.Expr {
+	return combineExpr(" OR ", matchNone, exprs)
+}
+
+func combineExpr(sep string, empty clause.Expr, exprs []clause.Expr) clause.Expr {
+	if len(exprs) == 0 {
+		return empty
+	}
+	parts := make([]string, 0, len(exprs))
+	var vars []interface{}
+	for _, expr := range exprs {
+		parts = append(parts, "("+expr.SQL+")")
+		vars = append(vars, expr.Vars...)
+	}
+	return clause.Expr{SQL: "(" + strings.Join(parts, sep) + ")", Vars: vars}
+}
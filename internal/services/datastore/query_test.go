@@ -0,0 +1,162 @@
+package datastore
+
+import (
+	"auto-forge/internal/models"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	_ "modernc.org/sqlite"
+)
+
+func testDocs(t *testing.T) []*DocumentView {
+	t.Helper()
+	raw := map[string]string{
+		"a": `{"name":"alice","age":30,"tags":["admin","dev"],"profile":{"city":"Beijing"}}`,
+		"b": `{"name":"bob","age":"25","tags":["dev"]}`,
+		"c": `{"name":"carol","age":41,"profile":{"city":"Shanghai"}}`,
+		"d": `"plain"`,
+	}
+	docs := make([]*DocumentView, 0, len(raw))
+	for _, key := range []string{"a", "b", "c", "d"} {
+		var data interface{}
+		if err := json.Unmarshal([]byte(raw[key]), &data); err != nil {
+			t.Fatalf("invalid fixture %s: %v", key, err)
+		}
+		docs = append(docs, &DocumentView{Key: key, Data: data})
+	}
+	return docs
+}
+
+// openTestDB 打开内存 SQLite 并写入 testDocs 的文档
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Dialector{DriverName: "sqlite", DSN: "file::memory:"}, &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&models.DataDocument{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	for _, doc := range testDocs(t) {
+		data, _ := json.Marshal(doc.Data)
+		document := &models.DataDocument{CollectionID: "c1", UserID: "u1", Key: doc.Key, Data: string(data), Size: len(data)}
+		if err := db.Create(document).Error; err != nil {
+			t.Fatalf("insert %s: %v", doc.Key, err)
+		}
+	}
+	return db
+}
+
+func queryKeys(t *testing.T, db *gorm.DB, filters []Filter, sortBy string, desc bool) string {
+	t.Helper()
+	dialect := newSQLDialect(db.Dialector.Name())
+	query := db.Model(&models.DataDocument{}).Where("collection_id = ?", "c1")
+	for _, filter := range filters {
+		query = query.Where(dialect.filterExpr(filter))
+	}
+
+	var keys []string
+	err := query.Clauses(clause.OrderBy{Expression: dialect.orderExpr(sortBy, desc)}).Pluck("doc_key", &keys).Error
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	return strings.Join(keys, ",")
+}
+
+func TestFilterExpr(t *testing.T) {
+	db := openTestDB(t)
+	tests := []struct {
+		name    string
+		filters []Filter
+		want    string
+	}{
+		{"no filters", nil, "a,b,c,d"},
+		{"eq string", []Filter{{Field: "name", Op: OpEq, Value: "bob"}}, "b"},
+		{"eq number only matches numbers", []Filter{{Field: "age", Op: OpEq, Value: 25.0}}, ""},
+		{"eq numeric string", []Filter{{Field: "age", Op: OpEq, Value: "25"}}, "b"},
+		{"ne includes missing", []Filter{{Field: "name", Op: OpNe, Value: "bob"}}, "a,c,d"},
+		{"gt", []Filter{{Field: "age", Op: OpGt, Value: 26.0}}, "a,c"},
+		{"lte", []Filter{{Field: "age", Op: OpLte, Value: 30.0}}, "a"},
+		{"in", []Filter{{Field: "name", Op: OpIn, Value: []interface{}{"alice", "carol"}}}, "a,c"},
+		{"empty in", []Filter{{Field: "name", Op: OpIn, Value: []interface{}{}}}, ""},
+		{"contains array", []Filter{{Field: "tags", Op: OpContains, Value: "admin"}}, "a"},
+		{"contains string", []Filter{{Field: "name", Op: OpContains, Value: "o"}}, "b,c"},
+		{"prefix", []Filter{{Field: "name", Op: OpPrefix, Value: "ca"}}, "c"},
+		{"nested field", []Filter{{Field: "profile.city", Op: OpEq, Value: "Shanghai"}}, "c"},
+		{"exists false", []Filter{{Field: "profile", Op: OpExists, Value: false}}, "b,d"},
+		{"key prefix", []Filter{{Field: FieldKey, Op: OpPrefix, Value: "c"}}, "c"},
+		{"key in", []Filter{{Field: FieldKey, Op: OpIn, Value: []interface{}{"a", "d"}}}, "a,d"},
+		{"whole document", []Filter{{Field: "", Op: OpEq, Value: "plain"}}, "d"},
+		{"combined", []Filter{{Field: "tags", Op: OpContains, Value: "dev"}, {Field: "age", Op: OpGt, Value: 20.0}}, "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queryKeys(t, db, tt.filters, "", false); got != tt.want {
+				t.Errorf("matched %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateFilters(t *testing.T) {
+	if err := validateFilters([]Filter{{Field: "x", Op: "like"}}); err == nil {
+		t.Error("expected error for unsupported operator")
+	}
+	if err := validateFilters([]Filter{{Field: "x", Op: OpIn, Value: "a"}}); err == nil {
+		t.Error("expected error for non-array in value")
+	}
+	if err := validateFilters([]Filter{{Field: `a"b`, Op: OpEq, Value: "x"}}); err == nil {
+		t.Error("expected error for quoted field path")
+	}
+	if err := validateFilters([]Filter{{Field: FieldUpdatedAt, Op: OpGt, Value: "yesterday"}}); err == nil {
+		t.Error("expected error for invalid updated_at value")
+	}
+	if err := validateFilters([]Filter{{Field: "x", Op: OpIn, Value: []interface{}{"a"}}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestOrderExpr(t *testing.T) {
+	db := openTestDB(t)
+
+	// 缺少排序字段的文档排在最后，不同 JSON 类型按数据库的类型顺序排列（SQLite 中字符串大于数字）
+	if got := queryKeys(t, db, nil, "age", true); got != "b,c,a,d" {
+		t.Errorf("sorted %q, want %q", got, "b,c,a,d")
+	}
+	if got := queryKeys(t, db, nil, "profile.city", false); got != "a,c,b,d" {
+		t.Errorf("sorted %q, want %q", got, "a,c,b,d")
+	}
+	if got := queryKeys(t, db, nil, FieldKey, true); got != "d,c,b,a" {
+		t.Errorf("sorted %q, want %q", got, "d,c,b,a")
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	docs := testDocs(t)
+	data, err := exportCSV(docs)
+	if err != nil {
+		t.Fatalf("exportCSV: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(strings.TrimPrefix(string(data), "\xEF\xBB\xBF")), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected header and 4 rows, got %d lines", len(lines))
+	}
+	if lines[0] != "key,value,age,name,profile,tags,updated_at" {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], `a,,30,alice,"{""city"":""Beijing""}","[""admin"",""dev""]",`) {
+		t.Errorf("unexpected row %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[4], "d,plain,,,,,") {
+		t.Errorf("unexpected scalar row %q", lines[4])
+	}
+}
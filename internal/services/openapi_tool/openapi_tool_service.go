@@ -0,0 +1,328 @@
+package openapi_tool
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/internal/repositories"
+	"auto-forge/internal/services/tool_config"
+	"auto-forge/pkg/errors"
+	log "auto-forge/pkg/logger"
+	"auto-forge/pkg/utools"
+	"auto-forge/pkg/utools/openapi"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxSpecSize 导入文档的大小上限
+const maxSpecSize = 10 * 1024 * 1024
+
+var toolCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// OpenAPIToolView 工具及其定义
+type OpenAPIToolView struct {
+	*models.OpenAPITool
+	Definition *openapi.Definition `json:"definition"`
+}
+
+// OpenAPIToolService OpenAPI 工具服务接口
+type OpenAPIToolService interface {
+	ParseSpec(spec []byte, specURL string) (*openapi.Document, error)
+	ImportTools(spec []byte, specURL string, operationIDs []string, baseURL string) ([]*OpenAPIToolView, error)
+	ListTools() ([]*OpenAPIToolView, error)
+	GetTool(id string) (*OpenAPIToolView, error)
+	UpdateTool(id string, def *openapi.Definition) (*OpenAPIToolView, error)
+	DeleteTool(id string) error
+}
+
+type openAPIToolService struct {
+	repo repositories.OpenAPIToolRepository
+}
+
+// NewOpenAPIToolService 创建 OpenAPI 工具服务实例
+func NewOpenAPIToolService() OpenAPIToolService {
+	return &openAPIToolService{
+		repo: repositories.NewOpenAPIToolRepository(),
+	}
+}
+
+// LoadOpenAPITools 启动时将已保存的 OpenAPI 工具注册到工具注册表（需在同步工具定义之前调用）
+func LoadOpenAPITools() {
+	tools, err := repositories.NewOpenAPIToolRepository().FindAll()
+	if err != nil {
+		log.Error("加载 OpenAPI 工具失败: %v", err)
+		return
+	}
+
+	for _, tool := range tools {
+		def, err := decodeDefinition(tool)
+		if err != nil {
+			log.Error("解析 OpenAPI 工具 [%s] 定义失败: %v", tool.ToolCode, err)
+			continue
+		}
+		if err := utools.Register(openapi.NewTool(def)); err != nil {
+			log.Error("注册 OpenAPI 工具 [%s] 失败: %v", tool.ToolCode, err)
+		}
+	}
+}
+
+// ParseSpec 解析文档（内容或 URL），返回可导入的操作列表
+func (s *openAPIToolService) ParseSpec(spec []byte, specURL string) (*openapi.Document, error) {
+	if len(spec) == 0 {
+		if specURL == "" {
+			return nil, errors.New(errors.CodeInvalidParameter, "请提供文档内容或文档地址")
+		}
+		var err error
+		if spec, err = fetchSpec(specURL); err != nil {
+			return nil, errors.New(errors.CodeInvalidParameter, "获取文档失败: "+err.Error())
+		}
+	}
+
+	doc, err := openapi.Parse(spec)
+	if err != nil {
+		return nil, errors.New(errors.CodeInvalidParameter, err.Error())
+	}
+	return doc, nil
+}
+
+// ImportTools 将文档中选中的操作导入为工具；operationIDs 为空时导入全部操作
+func (s *openAPIToolService) ImportTools(spec []byte, specURL string, operationIDs []string, baseURL string) ([]*OpenAPIToolView, error) {
+	doc, err := s.ParseSpec(spec, specURL)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool, len(operationIDs))
+	for _, id := range operationIDs {
+		selected[id] = true
+	}
+
+	defs := make([]*openapi.Definition, 0)
+	codes := make(map[string]bool)
+	for _, def := range doc.Operations {
+		if len(selected) > 0 && !selected[def.OperationID] {
+			continue
+		}
+		if baseURL != "" {
+			def.BaseURL = strings.TrimRight(baseURL, "/")
+		}
+		if err := s.checkDefinition(def, ""); err != nil {
+			return nil, err
+		}
+		if codes[def.Code] {
+			return nil, errors.New(errors.CodeConflict, "多个操作生成了相同的工具代码: "+def.Code)
+		}
+		codes[def.Code] = true
+		defs = append(defs, def)
+	}
+	if len(defs) == 0 {
+		return nil, errors.New(errors.CodeInvalidParameter, "没有可导入的操作")
+	}
+
+	views := make([]*OpenAPIToolView, 0, len(defs))
+	for _, def := range defs {
+		data, _ := json.Marshal(def)
+		tool := &models.OpenAPITool{
+			ToolCode:    def.Code,
+			Name:        def.Name,
+			SpecTitle:   doc.Title,
+			OperationID: def.OperationID,
+			Definition:  string(data),
+		}
+		if err := s.repo.Create(tool); err != nil {
+			return views, errors.New(errors.CodeInternal, "保存工具失败: "+def.Code)
+		}
+		if err := utools.Register(openapi.NewTool(def)); err != nil {
+			return views, errors.New(errors.CodeInternal, "注册工具失败: "+err.Error())
+		}
+		views = append(views, &OpenAPIToolView{OpenAPITool: tool, Definition: def})
+	}
+
+	syncTools()
+	return views, nil
+}
+
+// ListTools 获取全部 OpenAPI 工具
+func (s *openAPIToolService) ListTools() ([]*OpenAPIToolView, error) {
+	tools, err := s.repo.FindAll()
+	if err != nil {
+		return nil, errors.New(errors.CodeQueryFailed, "获取工具列表失败")
+	}
+
+	views := make([]*OpenAPIToolView, 0, len(tools))
+	for _, tool := range tools {
+		def, _ := decodeDefinition(tool)
+		views = append(views, &OpenAPIToolView{OpenAPITool: tool, Definition: def})
+	}
+	return views, nil
+}
+
+// GetTool 获取工具详情
+func (s *openAPIToolService) GetTool(id string) (*OpenAPIToolView, error) {
+	tool, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, errors.New(errors.CodeNotFound, "工具不存在")
+	}
+	def, err := decodeDefinition(tool)
+	if err != nil {
+		return nil, errors.New(errors.CodeInternal, "工具定义已损坏")
+	}
+	return &OpenAPIToolView{OpenAPITool: tool, Definition: def}, nil
+}
+
+// UpdateTool 更新工具定义并重新注册；工具代码不可修改，以保留其配置与凭证连接
+func (s *openAPIToolService) UpdateTool(id string, def *openapi.Definition) (*OpenAPIToolView, error) {
+	tool, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, errors.New(errors.CodeNotFound, "工具不存在")
+	}
+	def.Code = tool.ToolCode
+	if err := s.checkDefinition(def, tool.ToolCode); err != nil {
+		return nil, err
+	}
+
+	data, _ := json.Marshal(def)
+	tool.Name = def.Name
+	tool.OperationID = def.OperationID
+	tool.Definition = string(data)
+	if err := s.repo.Update(tool); err != nil {
+		return nil, errors.New(errors.CodeInternal, "保存工具失败")
+	}
+
+	utools.GetRegistry().Unregister(tool.ToolCode)
+	if err := utools.Register(openapi.NewTool(def)); err != nil {
+		return nil, errors.New(errors.CodeInternal, "注册工具失败: "+err.Error())
+	}
+
+	syncTools()
+	return &OpenAPIToolView{OpenAPITool: tool, Definition: def}, nil
+}
+
+// DeleteTool 删除工具并从注册表移除，其工具配置会在同步时标记为废弃
+func (s *openAPIToolService) DeleteTool(id string) error {
+	tool, err := s.repo.FindByID(id)
+	if err != nil {
+		return errors.New(errors.CodeNotFound, "工具不存在")
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return errors.New(errors.CodeInternal, "删除工具失败")
+	}
+
+	utools.GetRegistry().Unregister(tool.ToolCode)
+	syncTools()
+	return nil
+}
+
+// checkDefinition 校验工具定义；currentCode 为正在编辑的工具代码
+func (s *openAPIToolService) checkDefinition(def *openapi.Definition, currentCode string) error {
+	if !toolCodePattern.MatchString(def.Code) {
+		return errors.New(errors.CodeInvalidParameter, "工具代码只能包含小写字母、数字和下划线，且以字母开头: "+def.Code)
+	}
+	if currentCode == "" {
+		if _, err := utools.Get(def.Code); err == nil {
+			return errors.New(errors.CodeConflict, "工具代码已被占用: "+def.Code)
+		}
+		exists, err := s.repo.ExistsByCode(def.Code)
+		if err != nil {
+			return errors.New(errors.CodeQueryFailed, "检查工具代码失败")
+		}
+		if exists {
+			return errors.New(errors.CodeConflict, "工具代码已被占用: "+def.Code)
+		}
+	}
+
+	if strings.TrimSpace(def.Name) == "" {
+		return errors.New(errors.CodeInvalidParameter, "工具名称不能为空")
+	}
+	def.Method = strings.ToUpper(def.Method)
+	switch def.Method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead, http.MethodOptions:
+	default:
+		return errors.New(errors.CodeInvalidParameter, "不支持的请求方法: "+def.Method)
+	}
+	if !strings.HasPrefix(def.Path, "/") {
+		return errors.New(errors.CodeInvalidParameter, "接口路径必须以 / 开头")
+	}
+	if def.BaseURL != "" {
+		parsed, err := url.Parse(def.BaseURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return errors.New(errors.CodeInvalidParameter, "接口地址必须是完整的 http(s) URL: "+def.BaseURL)
+		}
+	}
+	if def.Category == "" {
+		def.Category = utools.CategoryNetwork
+	}
+
+	names := make(map[string]bool, len(def.Parameters))
+	for _, param := range def.Parameters {
+		switch param.In {
+		case openapi.InPath, openapi.InQuery, openapi.InHeader, openapi.InBody, openapi.InRaw:
+		default:
+			return errors.New(errors.CodeInvalidParameter, fmt.Sprintf("参数 %s 的位置无效: %s", param.Name, param.In))
+		}
+		if param.Name == "" || param.Key == "" || names[param.Name] {
+			return errors.New(errors.CodeInvalidParameter, "参数名为空或重复: "+param.Name)
+		}
+		names[param.Name] = true
+	}
+	if def.Auth != nil {
+		switch def.Auth.Type {
+		case openapi.AuthAPIKey:
+			if def.Auth.Name == "" {
+				return errors.New(errors.CodeInvalidParameter, "API Key 认证需要指定参数名")
+			}
+		case openapi.AuthBearer, openapi.AuthBasic:
+		default:
+			return errors.New(errors.CodeInvalidParameter, "不支持的认证方式: "+def.Auth.Type)
+		}
+	}
+	return nil
+}
+
+// decodeDefinition 解析保存的工具定义
+func decodeDefinition(tool *models.OpenAPITool) (*openapi.Definition, error) {
+	var def openapi.Definition
+	if err := json.Unmarshal([]byte(tool.Definition), &def); err != nil {
+		return nil, err
+	}
+	def.Code = tool.ToolCode
+	return &def, nil
+}
+
+// syncTools 同步工具定义，使新工具出现在工具配置与工作流编辑器中
+func syncTools() {
+	if err := tool_config.NewToolConfigService().SyncToolsFromRegistry(); err != nil {
+		log.Error("同步工具定义失败: %v", err)
+	}
+}
+
+// fetchSpec 下载远程文档
+func fetchSpec(specURL string) ([]byte, error) {
+	parsed, err := url.Parse(specURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("文档地址必须是 http(s) URL")
+	}
+
+	client := utools.NewHTTPClient(utools.HTTPClientOptions{Timeout: 30 * time.Second, MaxResponseSize: maxSpecSize + 1})
+	resp, err := client.Get(specURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("状态码 %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSpecSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSpecSize {
+		return nil, fmt.Errorf("文档超过 %d MB", maxSpecSize/1024/1024)
+	}
+	return data, nil
+}
@@ -1,19 +1,26 @@
 package task
 
 import (
-	"strconv"
-	"strings"
+	"auto-forge/internal/dto/request"
 	"auto-forge/internal/models"
 	"auto-forge/internal/repositories/task"
+	"auto-forge/internal/services/tool_config"
+	"auto-forge/internal/services/workflow"
+	"auto-forge/pkg/database"
 	"auto-forge/pkg/errors"
-	"time"
+	log "auto-forge/pkg/logger"
+	"auto-forge/pkg/utools"
+	"encoding/json"
+	"strconv"
+	"strings"
 )
 
-
+// TaskService 定时任务服务
+// 任务是单工具工作流的兼容外观：调度、执行与执行记录均由工作流引擎负责
 type TaskService struct {
-	taskRepo          *task.TaskRepository
-	executionRepo     *task.TaskExecutionRepository
-	schedulerCallback func()
+	taskRepo         *task.TaskRepository
+	workflowService  *workflow.WorkflowService
+	executionService *workflow.ExecutionService
 }
 
 var taskService *TaskService
@@ -21,8 +28,9 @@ var taskService *TaskService
 
 func InitTaskService() {
 	taskService = &TaskService{
-		taskRepo:      task.NewTaskRepository(),
-		executionRepo: task.NewTaskExecutionRepository(),
+		taskRepo:         task.NewTaskRepository(),
+		workflowService:  workflow.NewWorkflowService(),
+		executionService: workflow.NewExecutionService(),
 	}
 }
 
@@ -32,19 +40,18 @@ func GetTaskService() *TaskService {
 }
 
 
-func (s *TaskService) SetSchedulerCallback(callback func()) {
-	s.schedulerCallback = callback
-}
-
-
 func (s *TaskService) CreateTask(userID, name, description, toolCode, config, scheduleType, scheduleValue string) (*models.Task, error) {
 
 	if err := s.validateSchedule(scheduleType, scheduleValue); err != nil {
 		return nil, err
 	}
 
-
-	nextRunTime := s.calculateNextRunTime(scheduleType, scheduleValue)
+	if _, err := utools.Get(toolCode); err != nil {
+		return nil, errors.New(errors.CodeInvalidParameter, "工具不存在: "+toolCode)
+	}
+	if err := tool_config.CheckToolPolicy(toolCode, userID); err != nil {
+		return nil, err
+	}
 
 	task := &models.Task{
 		UserID:        userID,
@@ -55,16 +62,34 @@ func (s *TaskService) CreateTask(userID, name, description, toolCode, config, sc
 		ScheduleType:  scheduleType,
 		ScheduleValue: scheduleValue,
 		Enabled:       true,
-		NextRunTime:   &nextRunTime,
 	}
 
-	if err := s.taskRepo.DB.Create(task).Error; err != nil {
-		return nil, errors.Wrap(err, errors.CodeQueryFailed)
+	nodes, err := task.WorkflowNodes()
+	if err != nil {
+		return nil, errors.New(errors.CodeInvalidParameter, "工具配置格式错误")
+	}
+
+	wf, err := s.workflowService.CreateWorkflow(userID, &request.CreateWorkflowRequest{
+		Name:          name,
+		Description:   description,
+		Nodes:         nodes,
+		Edges:         []models.WorkflowEdge{},
+		ScheduleType:  scheduleType,
+		ScheduleValue: scheduleValue,
+		Enabled:       true,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.CodeInvalidParameter)
 	}
 
+	task.WorkflowID = wf.GetID()
+	task.NextRunTime = wf.NextRunTime
 
-	if s.schedulerCallback != nil {
-		s.schedulerCallback()
+	if err := s.taskRepo.DB.Create(task).Error; err != nil {
+		if delErr := s.workflowService.DeleteWorkflow(wf.GetID(), userID); delErr != nil {
+			log.Error("回滚任务工作流失败: WorkflowID=%s, Error=%v", wf.GetID(), delErr)
+		}
+		return nil, errors.Wrap(err, errors.CodeQueryFailed)
 	}
 
 	return task, nil
@@ -72,12 +97,25 @@ func (s *TaskService) CreateTask(userID, name, description, toolCode, config, sc
 
 
 func (s *TaskService) GetTaskList(userID string, page, pageSize int) ([]models.Task, int64, error) {
-	return s.taskRepo.FindByUserID(userID, page, pageSize)
+	tasks, total, err := s.taskRepo.FindByUserID(userID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.syncWorkflowState(tasks)
+	return tasks, total, nil
 }
 
 
 func (s *TaskService) GetTaskByID(id, userID string) (*models.Task, error) {
-	return s.taskRepo.FindByIDAndUserID(id, userID)
+	task, err := s.taskRepo.FindByIDAndUserID(id, userID)
+	if err != nil {
+		return nil, errors.New(errors.CodeNotFound, "任务不存在")
+	}
+
+	tasks := []models.Task{*task}
+	s.syncWorkflowState(tasks)
+	return &tasks[0], nil
 }
 
 
@@ -93,8 +131,12 @@ func (s *TaskService) UpdateTask(id, userID, name, description, toolCode, config
 		return nil, err
 	}
 
-
-	nextRunTime := s.calculateNextRunTime(scheduleType, scheduleValue)
+	if _, err := utools.Get(toolCode); err != nil {
+		return nil, errors.New(errors.CodeInvalidParameter, "工具不存在: "+toolCode)
+	}
+	if err := tool_config.CheckToolPolicy(toolCode, userID); err != nil {
+		return nil, err
+	}
 
 
 	existingTask.Name = name
@@ -103,15 +145,29 @@ func (s *TaskService) UpdateTask(id, userID, name, description, toolCode, config
 	existingTask.Config = config
 	existingTask.ScheduleType = scheduleType
 	existingTask.ScheduleValue = scheduleValue
-	existingTask.NextRunTime = &nextRunTime
 
-	if err := s.taskRepo.UpdateTask(existingTask); err != nil {
-		return nil, errors.Wrap(err, errors.CodeQueryFailed)
+	nodes, err := existingTask.WorkflowNodes()
+	if err != nil {
+		return nil, errors.New(errors.CodeInvalidParameter, "工具配置格式错误")
 	}
+	edges := []models.WorkflowEdge{}
+
+	wf, err := s.workflowService.UpdateWorkflow(existingTask.WorkflowID, userID, &request.UpdateWorkflowRequest{
+		Name:          &name,
+		Description:   &description,
+		Nodes:         (*[]models.WorkflowNode)(&nodes),
+		Edges:         &edges,
+		ScheduleType:  &scheduleType,
+		ScheduleValue: &scheduleValue,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.CodeInvalidParameter)
+	}
+	existingTask.Enabled = wf.Enabled
+	existingTask.NextRunTime = wf.NextRunTime
 
-
-	if s.schedulerCallback != nil {
-		s.schedulerCallback()
+	if err := s.taskRepo.UpdateTask(existingTask); err != nil {
+		return nil, errors.Wrap(err, errors.CodeQueryFailed)
 	}
 
 	return existingTask, nil
@@ -119,13 +175,19 @@ func (s *TaskService) UpdateTask(id, userID, name, description, toolCode, config
 
 
 func (s *TaskService) DeleteTask(id, userID string) error {
-	if err := s.taskRepo.DeleteByIDAndUserID(id, userID); err != nil {
-		return errors.Wrap(err, errors.CodeQueryFailed)
+	task, err := s.taskRepo.FindByIDAndUserID(id, userID)
+	if err != nil {
+		return errors.New(errors.CodeNotFound, "任务不存在")
 	}
 
+	if task.WorkflowID != "" {
+		if err := s.workflowService.DeleteWorkflow(task.WorkflowID, userID); err != nil {
+			log.Warn("删除任务工作流失败: WorkflowID=%s, Error=%v", task.WorkflowID, err)
+		}
+	}
 
-	if s.schedulerCallback != nil {
-		s.schedulerCallback()
+	if err := s.taskRepo.DeleteByIDAndUserID(id, userID); err != nil {
+		return errors.Wrap(err, errors.CodeQueryFailed)
 	}
 
 	return nil
@@ -133,44 +195,147 @@ func (s *TaskService) DeleteTask(id, userID string) error {
 
 
 func (s *TaskService) EnableTask(id, userID string) error {
-	if err := s.taskRepo.UpdateEnabled(id, userID, true); err != nil {
-		return errors.Wrap(err, errors.CodeQueryFailed)
+	return s.setEnabled(id, userID, true)
+}
+
+
+func (s *TaskService) DisableTask(id, userID string) error {
+	return s.setEnabled(id, userID, false)
+}
+
+func (s *TaskService) setEnabled(id, userID string, enabled bool) error {
+	task, err := s.taskRepo.FindByIDAndUserID(id, userID)
+	if err != nil {
+		return errors.New(errors.CodeNotFound, "任务不存在")
 	}
 
+	if _, err := s.workflowService.ToggleEnabled(task.WorkflowID, userID, enabled); err != nil {
+		return errors.Wrap(err, errors.CodeQueryFailed)
+	}
 
-	if s.schedulerCallback != nil {
-		s.schedulerCallback()
+	if err := s.taskRepo.UpdateEnabled(id, userID, enabled); err != nil {
+		return errors.Wrap(err, errors.CodeQueryFailed)
 	}
 
 	return nil
 }
 
 
-func (s *TaskService) DisableTask(id, userID string) error {
-	if err := s.taskRepo.UpdateEnabled(id, userID, false); err != nil {
-		return errors.Wrap(err, errors.CodeQueryFailed)
+func (s *TaskService) GetTaskExecutions(taskID, userID string, page, pageSize int) ([]models.TaskExecution, int64, error) {
+	task, err := s.taskRepo.FindByIDAndUserID(taskID, userID)
+	if err != nil {
+		return nil, 0, errors.New(errors.CodeNotFound, "任务不存在")
 	}
 
+	db := database.GetDB()
+	query := db.Model(&models.WorkflowExecution{}).Where("workflow_id = ? AND user_id = ?", task.WorkflowID, userID)
 
-	if s.schedulerCallback != nil {
-		s.schedulerCallback()
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, errors.Wrap(err, errors.CodeQueryFailed)
 	}
 
-	return nil
+	var executions []models.WorkflowExecution
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&executions).Error; err != nil {
+		return nil, 0, errors.Wrap(err, errors.CodeQueryFailed)
+	}
+
+	result := make([]models.TaskExecution, 0, len(executions))
+	for i := range executions {
+		result = append(result, *ToTaskExecution(task, &executions[i]))
+	}
+
+	return result, total, nil
 }
 
 
-func (s *TaskService) GetTaskExecutions(taskID string, page, pageSize int) ([]models.TaskExecution, int64, error) {
-	return s.executionRepo.FindByTaskID(taskID, page, pageSize)
+func (s *TaskService) GetExecutionByID(id, userID string) (*models.TaskExecution, error) {
+	execution, err := s.executionService.GetExecutionByID(id, userID)
+	if err != nil {
+		return nil, errors.New(errors.CodeNotFound, "执行记录不存在")
+	}
+
+	var task models.Task
+	if err := s.taskRepo.DB.Where("workflow_id = ? AND user_id = ?", execution.WorkflowID, userID).First(&task).Error; err != nil {
+		return nil, errors.New(errors.CodeNotFound, "执行记录不存在")
+	}
+
+	return ToTaskExecution(&task, execution), nil
 }
 
+// ToTaskExecution 将工作流执行记录转换为任务执行记录格式，保持 /tasks 接口兼容
+// task 为空时表示该执行不属于任何任务，TaskID 留空
+func ToTaskExecution(task *models.Task, execution *models.WorkflowExecution) *models.TaskExecution {
+	result := &models.TaskExecution{
+		BaseModel:    execution.BaseModel,
+		UserID:       execution.UserID,
+		Status:       execution.Status,
+		DurationMs:   execution.DurationMs,
+		ErrorMessage: execution.Error,
+	}
+	if task != nil {
+		result.TaskID = task.GetID()
+	}
+	if execution.StartTime != nil {
+		result.StartedAt = *execution.StartTime
+	}
+	if execution.EndTime != nil {
+		result.CompletedAt = *execution.EndTime
+	}
 
-func (s *TaskService) GetExecutionByID(id string) (*models.TaskExecution, error) {
-	var execution models.TaskExecution
-	if err := s.executionRepo.DB.Where("id = ?", id).First(&execution).Error; err != nil {
-		return nil, errors.Wrap(err, errors.CodeQueryFailed)
+	for _, nodeLog := range execution.NodeLogs {
+		if nodeLog.NodeID != models.TaskToolNodeID || nodeLog.Output == nil {
+			continue
+		}
+		switch code := nodeLog.Output["status_code"].(type) {
+		case float64:
+			result.ResponseStatus = int(code)
+		case int:
+			result.ResponseStatus = code
+		}
+		if body, ok := nodeLog.Output["body"].(string); ok {
+			result.ResponseBody = body
+		} else if data, err := json.Marshal(nodeLog.Output); err == nil {
+			result.ResponseBody = string(data)
+		}
+	}
+
+	return result
+}
+
+// syncWorkflowState 以工作流上的启用状态和调度信息为准
+func (s *TaskService) syncWorkflowState(tasks []models.Task) {
+	ids := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		if t.WorkflowID != "" {
+			ids = append(ids, t.WorkflowID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	var workflows []models.Workflow
+	if err := database.GetDB().Select("id, enabled, next_run_time, schedule_type, schedule_value").
+		Where("id IN ?", ids).Find(&workflows).Error; err != nil {
+		log.Error("查询任务工作流状态失败: %v", err)
+		return
+	}
+
+	byID := make(map[string]*models.Workflow, len(workflows))
+	for i := range workflows {
+		byID[workflows[i].GetID()] = &workflows[i]
+	}
+
+	for i := range tasks {
+		if wf, ok := byID[tasks[i].WorkflowID]; ok {
+			tasks[i].Enabled = wf.Enabled
+			tasks[i].NextRunTime = wf.NextRunTime
+			tasks[i].ScheduleType = wf.ScheduleType
+			tasks[i].ScheduleValue = wf.ScheduleValue
+		}
 	}
-	return &execution, nil
 }
 
 
@@ -264,137 +429,6 @@ func (s *TaskService) validateSchedule(scheduleType, scheduleValue string) error
 }
 
 
-func (s *TaskService) calculateNextRunTime(scheduleType, scheduleValue string) int64 {
-	now := time.Now()
-
-	switch scheduleType {
-	case "daily":
-
-		parts := strings.Split(scheduleValue, ":")
-		hour, _ := strconv.Atoi(parts[0])
-		minute, _ := strconv.Atoi(parts[1])
-		second, _ := strconv.Atoi(parts[2])
-
-
-		next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, second, 0, now.Location())
-
-
-		if next.Before(now) {
-			next = next.Add(24 * time.Hour)
-		}
-		return next.Unix()
-
-	case "weekly":
-
-		parts := strings.Split(scheduleValue, ":")
-		dayStrs := strings.Split(parts[0], ",")
-		hour, _ := strconv.Atoi(parts[1])
-		minute, _ := strconv.Atoi(parts[2])
-		second, _ := strconv.Atoi(parts[3])
-
-
-		var weekdays []int
-		for _, dayStr := range dayStrs {
-			day, _ := strconv.Atoi(dayStr)
-			weekdays = append(weekdays, day)
-		}
-
-
-		currentWeekday := int(now.Weekday())
-		next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, second, 0, now.Location())
-
-
-		minDaysToAdd := 7
-		for _, targetWeekday := range weekdays {
-			daysToAdd := (targetWeekday - currentWeekday + 7) % 7
-
-			if daysToAdd == 0 && next.After(now) {
-				minDaysToAdd = 0
-				break
-			}
-
-			if daysToAdd == 0 {
-				daysToAdd = 7
-			}
-			if daysToAdd < minDaysToAdd {
-				minDaysToAdd = daysToAdd
-			}
-		}
-
-		next = next.Add(time.Duration(minDaysToAdd) * 24 * time.Hour)
-		return next.Unix()
-
-	case "monthly":
-
-		parts := strings.Split(scheduleValue, ":")
-		day, _ := strconv.Atoi(parts[0])
-		hour, _ := strconv.Atoi(parts[1])
-		minute, _ := strconv.Atoi(parts[2])
-		second, _ := strconv.Atoi(parts[3])
-
-
-		next := time.Date(now.Year(), now.Month(), day, hour, minute, second, 0, now.Location())
-
-
-		if next.Before(now) {
-			next = next.AddDate(0, 1, 0)
-		}
-
-
-		for next.Day() != day {
-			next = next.AddDate(0, 1, 0)
-			next = time.Date(next.Year(), next.Month(), day, hour, minute, second, 0, next.Location())
-		}
-
-		return next.Unix()
-
-	case "hourly":
-
-		parts := strings.Split(scheduleValue, ":")
-		minute, _ := strconv.Atoi(parts[0])
-		second, _ := strconv.Atoi(parts[1])
-
-
-		next := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), minute, second, 0, now.Location())
-
-
-		if next.Before(now) {
-			next = next.Add(1 * time.Hour)
-		}
-		return next.Unix()
-
-	case "interval":
-
-		seconds, _ := strconv.ParseInt(scheduleValue, 10, 64)
-		return now.Add(time.Duration(seconds) * time.Second).Unix()
-
-	case "cron":
-
-
-		return now.Add(1 * time.Minute).Unix()
-
-	default:
-		return now.Unix()
-	}
-}
-
-
-func (s *TaskService) GetAllEnabledTasks() ([]models.Task, error) {
-	return s.taskRepo.FindEnabledTasks()
-}
-
-
-func (s *TaskService) RecordExecution(execution *models.TaskExecution) error {
-	return s.executionRepo.Create(execution)
-}
-
-
-func (s *TaskService) UpdateNextRunTime(taskID string, scheduleType, scheduleValue string) error {
-	nextRunTime := s.calculateNextRunTime(scheduleType, scheduleValue)
-	return s.taskRepo.UpdateNextRunTime(taskID, nextRunTime)
-}
-
-
 func (s *TaskService) TriggerTask(id, userID string) error {
 
 	task, err := s.taskRepo.FindByIDAndUserID(id, userID)
@@ -402,28 +436,28 @@ func (s *TaskService) TriggerTask(id, userID string) error {
 		return errors.New(errors.CodeNotFound, "任务不存在")
 	}
 
+	execution, err := s.executionService.CreateExecution(task.WorkflowID, userID, "manual")
+	if err != nil {
+		return errors.Wrap(err, errors.CodeInternal)
+	}
 
-
-	_ = task
+	executionID := execution.GetID()
+	go func() {
+		if err := workflow.NewEngineService().ExecuteWorkflow(executionID, nil, nil); err != nil {
+			log.Error("任务执行失败: TaskID=%s, ExecutionID=%s, Error=%v", id, executionID, err)
+		}
+	}()
 
 	return nil
 }
 
 
 func (s *TaskService) DeleteExecution(id, userID string) error {
-
-	var execution models.TaskExecution
-	if err := s.executionRepo.DB.Where("id = ?", id).First(&execution).Error; err != nil {
-		return errors.New(errors.CodeNotFound, "执行记录不存在")
+	if _, err := s.GetExecutionByID(id, userID); err != nil {
+		return err
 	}
 
-
-	if execution.UserID != userID {
-		return errors.New(errors.CodeForbidden, "无权删除此记录")
-	}
-
-
-	if err := s.executionRepo.DB.Where("id = ?", id).Delete(&models.TaskExecution{}).Error; err != nil {
+	if err := s.executionService.DeleteExecution(id, userID); err != nil {
 		return errors.Wrap(err, errors.CodeQueryFailed)
 	}
 
@@ -439,10 +473,9 @@ func (s *TaskService) DeleteAllExecutions(taskID, userID string) error {
 	}
 
 
-	if err := s.executionRepo.DB.Where("task_id = ? AND user_id = ?", task.GetID(), userID).Delete(&models.TaskExecution{}).Error; err != nil {
+	if err := database.GetDB().Where("workflow_id = ? AND user_id = ?", task.WorkflowID, userID).Delete(&models.WorkflowExecution{}).Error; err != nil {
 		return errors.Wrap(err, errors.CodeQueryFailed)
 	}
 
 	return nil
 }
-
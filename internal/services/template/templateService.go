@@ -243,10 +243,13 @@ func (s *TemplateService) InstallTemplate(userID string, req *request.InstallTem
 		Enabled:       false,
 	}
 
-	// 生成 API Key
+	// 生成 API Key 与 Webhook 地址令牌
 	if apiKey, err := utils.GenerateWorkflowAPIKey(); err == nil {
 		workflow.APIKey = apiKey
 	}
+	if token, err := utils.GenerateWorkflowWebhookToken(); err == nil {
+		workflow.WebhookToken = token
+	}
 
 	if err := db.Create(workflow).Error; err != nil {
 		log.Error("安装模板失败: %v", err)
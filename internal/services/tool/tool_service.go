@@ -70,6 +70,7 @@ func (s *ToolService) ListTools() ([]models.Tool, error) {
 			Description:        config.Description,
 			Category:           config.Category,
 			Version:            config.Version,
+			Versions:           registry.Versions(config.ToolCode),
 			Author:             config.Author,
 			Icon:               "",
 			ConfigSchema:       string(schemaJSON),
@@ -114,6 +115,7 @@ func (s *ToolService) GetToolByCode(code string) (*models.Tool, error) {
 		Description:        config.Description,
 		Category:           config.Category,
 		Version:            config.Version,
+		Versions:           registry.Versions(code),
 		Author:             config.Author,
 		Icon:               "",
 		ConfigSchema:       string(schemaJSON),
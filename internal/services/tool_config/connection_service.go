@@ -0,0 +1,190 @@
+package tool_config
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/internal/repositories"
+	"auto-forge/pkg/errors"
+	"auto-forge/pkg/utils"
+	"auto-forge/pkg/utools"
+	"sort"
+	"strings"
+)
+
+// ConnectionView 连接信息（不含凭证值，只列出已保存的字段名）
+type ConnectionView struct {
+	*models.ToolConnection
+	Fields []string `json:"fields"`
+}
+
+// ConnectionService 用户工具凭证连接服务接口
+type ConnectionService interface {
+	ListConnections(userID, toolCode string) ([]*ConnectionView, error)
+	CreateConnection(userID, toolCode, name string, config map[string]interface{}, isDefault bool) (*ConnectionView, error)
+	UpdateConnection(id, userID string, name string, config map[string]interface{}, isDefault *bool) (*ConnectionView, error)
+	DeleteConnection(id, userID string) error
+	ResolveConnection(userID, toolCode, connectionID string) (*models.ToolConnection, error)
+}
+
+type connectionService struct {
+	repo repositories.ToolConnectionRepository
+}
+
+// NewConnectionService 创建连接服务实例
+func NewConnectionService() ConnectionService {
+	return &connectionService{
+		repo: repositories.NewToolConnectionRepository(),
+	}
+}
+
+// ListConnections 获取用户的连接列表，toolCode 为空时返回全部
+func (s *connectionService) ListConnections(userID, toolCode string) ([]*ConnectionView, error) {
+	connections, err := s.repo.FindByUser(userID, toolCode)
+	if err != nil {
+		return nil, errors.New(errors.CodeQueryFailed, "获取连接列表失败")
+	}
+
+	views := make([]*ConnectionView, 0, len(connections))
+	for _, connection := range connections {
+		views = append(views, toConnectionView(connection))
+	}
+	return views, nil
+}
+
+// CreateConnection 创建连接；用户在该工具下的第一个连接自动设为默认
+func (s *connectionService) CreateConnection(userID, toolCode, name string, config map[string]interface{}, isDefault bool) (*ConnectionView, error) {
+	if _, err := utools.Get(toolCode); err != nil {
+		return nil, errors.New(errors.CodeInvalidParameter, "工具不存在: "+toolCode)
+	}
+	name = strings.TrimSpace(name)
+	if err := s.checkName(userID, toolCode, name, ""); err != nil {
+		return nil, err
+	}
+
+	encrypted, err := utils.EncryptToolConfig(config)
+	if err != nil {
+		return nil, errors.New(errors.CodeInternal, "加密凭证失败")
+	}
+
+	existing, err := s.repo.FindByUser(userID, toolCode)
+	if err != nil {
+		return nil, errors.New(errors.CodeQueryFailed, "获取连接列表失败")
+	}
+
+	connection := &models.ToolConnection{
+		UserID:     userID,
+		ToolCode:   toolCode,
+		Name:       name,
+		ConfigJSON: encrypted,
+		IsDefault:  isDefault || len(existing) == 0,
+	}
+	if err := s.repo.Create(connection); err != nil {
+		return nil, errors.New(errors.CodeInternal, "保存连接失败")
+	}
+	if connection.IsDefault {
+		if err := s.repo.ClearDefault(userID, toolCode, connection.GetID()); err != nil {
+			return nil, errors.New(errors.CodeInternal, "更新默认连接失败")
+		}
+	}
+
+	return toConnectionView(connection), nil
+}
+
+// UpdateConnection 更新连接的名称、凭证或默认状态；config 为空时保留原凭证
+func (s *connectionService) UpdateConnection(id, userID string, name string, config map[string]interface{}, isDefault *bool) (*ConnectionView, error) {
+	connection, err := s.getOwned(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if name = strings.TrimSpace(name); name != "" && name != connection.Name {
+		if err := s.checkName(userID, connection.ToolCode, name, id); err != nil {
+			return nil, err
+		}
+		connection.Name = name
+	}
+	if len(config) > 0 {
+		encrypted, err := utils.EncryptToolConfig(config)
+		if err != nil {
+			return nil, errors.New(errors.CodeInternal, "加密凭证失败")
+		}
+		connection.ConfigJSON = encrypted
+	}
+	if isDefault != nil {
+		connection.IsDefault = *isDefault
+	}
+
+	if err := s.repo.Update(connection); err != nil {
+		return nil, errors.New(errors.CodeInternal, "保存连接失败")
+	}
+	if connection.IsDefault {
+		if err := s.repo.ClearDefault(userID, connection.ToolCode, connection.GetID()); err != nil {
+			return nil, errors.New(errors.CodeInternal, "更新默认连接失败")
+		}
+	}
+
+	return toConnectionView(connection), nil
+}
+
+// DeleteConnection 删除连接；引用它的节点执行时会报错，不会退回到其他凭证
+func (s *connectionService) DeleteConnection(id, userID string) error {
+	if _, err := s.getOwned(id, userID); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return errors.New(errors.CodeInternal, "删除连接失败")
+	}
+	return nil
+}
+
+// ResolveConnection 解析执行时使用的连接：指定的连接必须属于该用户且对应该工具；
+// 未指定时使用用户的默认连接，没有默认连接时返回 nil
+func (s *connectionService) ResolveConnection(userID, toolCode, connectionID string) (*models.ToolConnection, error) {
+	if connectionID == "" {
+		return s.repo.FindDefault(userID, toolCode)
+	}
+
+	connection, err := s.repo.FindByID(connectionID)
+	if err != nil || connection.UserID != userID {
+		return nil, errors.New(errors.CodeNotFound, "凭证连接不存在或无权使用: "+connectionID)
+	}
+	if connection.ToolCode != toolCode {
+		return nil, errors.New(errors.CodeInvalidParameter, "凭证连接不属于工具: "+toolCode)
+	}
+	return connection, nil
+}
+
+// getOwned 获取属于该用户的连接
+func (s *connectionService) getOwned(id, userID string) (*models.ToolConnection, error) {
+	connection, err := s.repo.FindByID(id)
+	if err != nil || connection.UserID != userID {
+		return nil, errors.New(errors.CodeNotFound, "连接不存在")
+	}
+	return connection, nil
+}
+
+// checkName 校验连接名称非空且在同一工具下唯一
+func (s *connectionService) checkName(userID, toolCode, name, excludeID string) error {
+	if name == "" {
+		return errors.New(errors.CodeInvalidParameter, "连接名称不能为空")
+	}
+	exists, err := s.repo.ExistsByName(userID, toolCode, name, excludeID)
+	if err != nil {
+		return errors.New(errors.CodeQueryFailed, "检查连接名称失败")
+	}
+	if exists {
+		return errors.New(errors.CodeConflict, "同名连接已存在: "+name)
+	}
+	return nil
+}
+
+// toConnectionView 转换为不含凭证值的视图
+func toConnectionView(connection *models.ToolConnection) *ConnectionView {
+	view := &ConnectionView{ToolConnection: connection, Fields: []string{}}
+	if config, err := utils.DecryptToolConfig(connection.ConfigJSON); err == nil {
+		for key := range config {
+			view.Fields = append(view.Fields, key)
+		}
+		sort.Strings(view.Fields)
+	}
+	return view
+}
@@ -19,7 +19,7 @@ type ToolConfigService interface {
 	GetToolConfig(toolCode string) (*models.ToolConfig, error)
 	GetToolConfigDecrypted(toolCode string) (map[string]interface{}, error)
 	UpdateToolConfig(toolCode string, configMap map[string]interface{}) error
-	UpdateToolSettings(toolCode string, enabled, visible bool, sortOrder int) error
+	UpdateToolSettings(toolCode string, enabled, visible bool, sortOrder int, allowedRoles []int) error
 	DeleteTool(id uint) error
 	SyncToolsFromRegistry() error
 }
@@ -80,8 +80,8 @@ func (s *toolConfigService) UpdateToolConfig(toolCode string, configMap map[stri
 	return s.repo.Update(toolConfig)
 }
 
-// UpdateToolSettings 更新工具设置（启用/禁用、可见性、排序）
-func (s *toolConfigService) UpdateToolSettings(toolCode string, enabled, visible bool, sortOrder int) error {
+// UpdateToolSettings 更新工具设置（启用/禁用、可见性、排序、允许使用的角色）
+func (s *toolConfigService) UpdateToolSettings(toolCode string, enabled, visible bool, sortOrder int, allowedRoles []int) error {
 	toolConfig, err := s.repo.FindByCode(toolCode)
 	if err != nil {
 		return err
@@ -90,6 +90,7 @@ func (s *toolConfigService) UpdateToolSettings(toolCode string, enabled, visible
 	toolConfig.Enabled = enabled
 	toolConfig.Visible = visible
 	toolConfig.SortOrder = sortOrder
+	toolConfig.AllowedRoles = FormatAllowedRoles(allowedRoles)
 	toolConfig.UpdatedAt = time.Now()
 
 	return s.repo.Update(toolConfig)
@@ -209,22 +210,66 @@ func (s *toolConfigService) SyncToolsFromRegistry() error {
 func GetToolConfigForExecution(toolCode string) (map[string]interface{}, error) {
 	service := NewToolConfigService()
 
-	// 获取工具配置
+	if err := checkToolAvailable(service, toolCode); err != nil {
+		return nil, err
+	}
+
+	// 解密并返回配置
+	return service.GetToolConfigDecrypted(toolCode)
+}
+
+// GetToolConfigForContext 获取本次执行使用的凭证：节点选择的连接 > 用户默认连接 > 管理员全局配置
+func GetToolConfigForContext(ctx *utools.ExecutionContext, toolCode string) (map[string]interface{}, error) {
+	var userID, connectionID string
+	if ctx != nil {
+		userID, connectionID = ctx.UserID, ctx.ConnectionID
+		if userID == "" {
+			userID = utools.UserIDFromContext(ctx.Context)
+		}
+	}
+
+	if userID == "" {
+		if connectionID != "" {
+			return nil, fmt.Errorf("无法确定执行用户，不能使用凭证连接")
+		}
+		return GetToolConfigForExecution(toolCode)
+	}
+
+	if err := checkToolAvailable(NewToolConfigService(), toolCode); err != nil {
+		return nil, err
+	}
+
+	connection, err := NewConnectionService().ResolveConnection(userID, toolCode, connectionID)
+	if err != nil {
+		return nil, err
+	}
+	if connection == nil {
+		return NewToolConfigService().GetToolConfigDecrypted(toolCode)
+	}
+
+	config, err := utils.DecryptToolConfig(connection.ConfigJSON)
+	if err != nil {
+		return nil, fmt.Errorf("解密凭证连接失败: %s", connection.Name)
+	}
+	return config, nil
+}
+
+// checkToolAvailable 检查工具已同步、已启用且未废弃
+func checkToolAvailable(service ToolConfigService, toolCode string) error {
 	toolConfig, err := service.GetToolConfig(toolCode)
 	if err != nil {
-		return nil, fmt.Errorf("工具配置不存在: %s", toolCode)
+		return fmt.Errorf("工具配置不存在: %s", toolCode)
 	}
 
 	// 检查是否启用
 	if !toolConfig.Enabled {
-		return nil, fmt.Errorf("工具未启用: %s", toolConfig.ToolName)
+		return fmt.Errorf("工具未启用: %s", toolConfig.ToolName)
 	}
 
 	// 检查是否废弃
 	if toolConfig.IsDeprecated {
-		return nil, fmt.Errorf("工具已废弃: %s", toolConfig.ToolName)
+		return fmt.Errorf("工具已废弃: %s", toolConfig.ToolName)
 	}
 
-	// 解密并返回配置
-	return service.GetToolConfigDecrypted(toolCode)
+	return nil
 }
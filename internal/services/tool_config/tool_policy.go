@@ -0,0 +1,94 @@
+package tool_config
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/pkg/database"
+	"auto-forge/pkg/errors"
+	log "auto-forge/pkg/logger"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// CheckToolPolicy 执行前检查管理员的工具策略：禁用的工具、角色不在允许列表内的用户都会被拒绝；
+// 已废弃但仍启用的工具允许执行，只记录警告。userID 为空时不检查角色
+func CheckToolPolicy(toolCode, userID string) error {
+	warning, err := EvaluateToolPolicy(toolCode, userID)
+	if warning != "" {
+		log.Warn("%s", warning)
+	}
+	return err
+}
+
+// EvaluateToolPolicy 评估工具策略，返回阻止使用的错误与不阻止使用的警告
+func EvaluateToolPolicy(toolCode, userID string) (string, error) {
+	toolConfig, err := NewToolConfigService().GetToolConfig(toolCode)
+	if err != nil {
+		// 未同步到工具配置的工具（如 Agent 的工作流工具）不受管理员策略约束
+		if err == gorm.ErrRecordNotFound {
+			return "", nil
+		}
+		return "", errors.New(errors.CodeQueryFailed, "获取工具配置失败: "+toolCode)
+	}
+
+	role := 0
+	if userID != "" && toolConfig.AllowedRoles != "" {
+		role = userRole(userID)
+	}
+	return evaluatePolicy(toolConfig, role)
+}
+
+// evaluatePolicy 按工具配置与用户角色（0 表示不检查角色）评估策略
+func evaluatePolicy(toolConfig *models.ToolConfig, role int) (string, error) {
+	if !toolConfig.Enabled {
+		return "", errors.New(errors.CodeForbidden, "工具已被管理员禁用: "+toolConfig.ToolName)
+	}
+	if role != 0 {
+		if roles := ParseAllowedRoles(toolConfig.AllowedRoles); len(roles) > 0 && !containsRole(roles, role) {
+			return "", errors.New(errors.CodeForbidden, "当前用户角色无权使用工具: "+toolConfig.ToolName)
+		}
+	}
+	if toolConfig.IsDeprecated {
+		return "工具已废弃，建议替换: " + toolConfig.ToolName, nil
+	}
+	return "", nil
+}
+
+// ParseAllowedRoles 解析逗号分隔的角色列表
+func ParseAllowedRoles(value string) []int {
+	roles := make([]int, 0)
+	for _, part := range strings.Split(value, ",") {
+		if role, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// FormatAllowedRoles 将角色列表格式化为逗号分隔的字符串
+func FormatAllowedRoles(roles []int) string {
+	parts := make([]string, 0, len(roles))
+	for _, role := range roles {
+		parts = append(parts, strconv.Itoa(role))
+	}
+	return strings.Join(parts, ",")
+}
+
+func containsRole(roles []int, role int) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// userRole 查询用户角色，查询失败时返回 -1（不匹配任何允许列表）
+func userRole(userID string) int {
+	var user models.User
+	if err := database.GetDB().Select("role").Where("id = ?", userID).First(&user).Error; err != nil {
+		return -1
+	}
+	return user.Role
+}
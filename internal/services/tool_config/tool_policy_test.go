@@ -0,0 +1,43 @@
+package tool_config
+
+import (
+	"auto-forge/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestEvaluatePolicy(t *testing.T) {
+	toolConfig := &models.ToolConfig{ToolName: "HTTP 请求", Enabled: true, AllowedRoles: "1,2"}
+
+	if warning, err := evaluatePolicy(toolConfig, 2); err != nil || warning != "" {
+		t.Fatalf("expected allowed role to pass, got %q %v", warning, err)
+	}
+	if _, err := evaluatePolicy(toolConfig, 3); err == nil {
+		t.Fatal("expected role outside allowlist to be rejected")
+	}
+	if _, err := evaluatePolicy(toolConfig, 0); err != nil {
+		t.Fatalf("expected role check to be skipped without user, got %v", err)
+	}
+
+	toolConfig.IsDeprecated = true
+	if warning, err := evaluatePolicy(toolConfig, 1); err != nil || warning == "" {
+		t.Fatalf("expected deprecated tool to run with warning, got %q %v", warning, err)
+	}
+
+	toolConfig.Enabled = false
+	if _, err := evaluatePolicy(toolConfig, 1); err == nil {
+		t.Fatal("expected disabled tool to be rejected")
+	}
+}
+
+func TestAllowedRoles(t *testing.T) {
+	if roles := ParseAllowedRoles(" 1, 3,x"); !reflect.DeepEqual(roles, []int{1, 3}) {
+		t.Fatalf("unexpected roles: %v", roles)
+	}
+	if roles := ParseAllowedRoles(""); len(roles) != 0 {
+		t.Fatalf("expected empty allowlist, got %v", roles)
+	}
+	if value := FormatAllowedRoles([]int{2, 3}); value != "2,3" {
+		t.Fatalf("unexpected format: %q", value)
+	}
+}
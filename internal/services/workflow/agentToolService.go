@@ -0,0 +1,247 @@
+package workflow
+
+import (
+	"auto-forge/internal/dto/response"
+	"auto-forge/internal/models"
+	"auto-forge/pkg/agent/tooling"
+	"auto-forge/pkg/database"
+	log "auto-forge/pkg/logger"
+	"auto-forge/pkg/utools"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AgentWorkflowToolPrefix 工作流工具名前缀
+const AgentWorkflowToolPrefix = "workflow_"
+
+// AgentToolName 工作流作为 Agent 工具时的函数名（满足 LLM 函数名 [a-zA-Z0-9_] 的限制）
+func AgentToolName(workflowID string) string {
+	return AgentWorkflowToolPrefix + strings.ReplaceAll(workflowID, "-", "")
+}
+
+// SetAgentCallable 设置工作流是否可被 Agent 调用
+func (s *WorkflowService) SetAgentCallable(workflowID, userID string, callable bool) error {
+	db := database.GetDB()
+
+	result := db.Model(&models.Workflow{}).
+		Where("id = ? AND user_id = ?", workflowID, userID).
+		Update("agent_callable", callable)
+	if result.Error != nil {
+		return fmt.Errorf("更新失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("工作流不存在")
+	}
+
+	log.Info("工作流 Agent 调用状态已更新: WorkflowID=%s, AgentCallable=%v", workflowID, callable)
+	return nil
+}
+
+// GetAgentCallableWorkflows 获取用户标记为可被 Agent 调用的工作流
+func (s *WorkflowService) GetAgentCallableWorkflows(userID string) ([]models.Workflow, error) {
+	db := database.GetDB()
+
+	var workflows []models.Workflow
+	if err := db.Where("user_id = ? AND agent_callable = ?", userID, true).
+		Order("updated_at DESC").
+		Find(&workflows).Error; err != nil {
+		return nil, err
+	}
+	return workflows, nil
+}
+
+// GetAgentTools 将用户可被 Agent 调用的工作流包装为工具，执行记录关联到 agentMessageID
+func (s *WorkflowService) GetAgentTools(userID, agentMessageID string) ([]utools.Tool, error) {
+	workflows, err := s.GetAgentCallableWorkflows(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tools := make([]utools.Tool, 0, len(workflows))
+	for i := range workflows {
+		tools = append(tools, NewWorkflowAgentTool(&workflows[i], agentMessageID))
+	}
+	return tools, nil
+}
+
+// GetExecutionsByAgentMessage 获取某条 Agent 消息调用工作流产生的执行记录
+func (s *ExecutionService) GetExecutionsByAgentMessage(agentMessageID, userID string) ([]response.WorkflowExecutionResponse, error) {
+	db := database.GetDB()
+
+	var executions []models.WorkflowExecution
+	if err := db.Where("agent_message_id = ? AND user_id = ?", agentMessageID, userID).
+		Order("created_at ASC").
+		Find(&executions).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]response.WorkflowExecutionResponse, len(executions))
+	for i := range executions {
+		result[i] = s.toExecutionResponse(&executions[i])
+	}
+	return result, nil
+}
+
+// WorkflowAgentTool 把已保存的工作流暴露为 Agent 函数工具
+type WorkflowAgentTool struct {
+	workflow       *models.Workflow
+	agentMessageID string
+}
+
+// NewWorkflowAgentTool 创建工作流工具
+func NewWorkflowAgentTool(wf *models.Workflow, agentMessageID string) *WorkflowAgentTool {
+	return &WorkflowAgentTool{
+		workflow:       wf,
+		agentMessageID: agentMessageID,
+	}
+}
+
+// GetMetadata 获取工具元数据
+func (t *WorkflowAgentTool) GetMetadata() *utools.ToolMetadata {
+	description := fmt.Sprintf("运行已保存的工作流「%s」", t.workflow.Name)
+	if t.workflow.Description != "" {
+		description += "：" + t.workflow.Description
+	}
+	description += "。返回工作流最后一个节点的输出。"
+
+	return &utools.ToolMetadata{
+		Code:        AgentToolName(t.workflow.GetID()),
+		Name:        t.workflow.Name,
+		Description: description,
+		Category:    "workflow",
+		AICallable:  true,
+		Tags:        []string{"workflow"},
+	}
+}
+
+// GetSchema 由工作流 API 参数生成参数 Schema
+func (t *WorkflowAgentTool) GetSchema() *utools.ConfigSchema {
+	return BuildAPIParamsSchema(t.workflow.APIParams)
+}
+
+// Validate 校验参数
+func (t *WorkflowAgentTool) Validate(config map[string]interface{}) error {
+	return NewWorkflowService().ValidateAPIParams(t.workflow, config)
+}
+
+// Execute 创建一次 Agent 触发的工作流执行并同步等待结果
+func (t *WorkflowAgentTool) Execute(ctx *utools.ExecutionContext, config map[string]interface{}) (*utools.ExecutionResult, error) {
+	startTime := time.Now()
+	wf := t.workflow
+
+	if config == nil {
+		config = map[string]interface{}{}
+	}
+	workflowSvc := NewWorkflowService()
+	if err := workflowSvc.ValidateAPIParams(wf, config); err != nil {
+		return nil, fmt.Errorf("参数错误: %w", err)
+	}
+
+	executionSvc := NewExecutionService()
+	execution, err := executionSvc.CreateExecution(wf.GetID(), wf.UserID, "agent")
+	if err != nil {
+		return nil, fmt.Errorf("创建执行记录失败: %w", err)
+	}
+	executionID := execution.GetID()
+
+	if t.agentMessageID != "" {
+		if err := database.GetDB().Model(&models.WorkflowExecution{}).
+			Where("id = ?", executionID).
+			Update("agent_message_id", t.agentMessageID).Error; err != nil {
+			log.Warn("关联 Agent 消息失败: ExecutionID=%s, Error=%v", executionID, err)
+		}
+	}
+
+	timeout := wf.APITimeout
+	if timeout <= 0 {
+		timeout = 300
+	}
+
+	log.Info("Agent 调用工作流: WorkflowID=%s, ExecutionID=%s, MessageID=%s", wf.GetID(), executionID, t.agentMessageID)
+	finalOutput, execErr := workflowSvc.ExecuteWorkflowSync(executionID, wf.UserID, timeout, config)
+
+	output := map[string]interface{}{
+		"execution_id": executionID,
+		"workflow_id":  wf.GetID(),
+		"workflow":     wf.Name,
+	}
+
+	if execErr != nil {
+		output["status"] = models.ExecutionStatusFailed
+		return &utools.ExecutionResult{
+			Success:    false,
+			Message:    "工作流执行失败",
+			Output:     output,
+			Error:      execErr.Error(),
+			DurationMs: time.Since(startTime).Milliseconds(),
+		}, fmt.Errorf("工作流「%s」执行失败: %w", wf.Name, execErr)
+	}
+
+	output["status"] = models.ExecutionStatusSuccess
+	output["output"] = finalOutput
+
+	return &utools.ExecutionResult{
+		Success:    true,
+		Message:    "工作流执行成功",
+		Output:     output,
+		DurationMs: time.Since(startTime).Milliseconds(),
+	}, nil
+}
+
+// GetExecutionConfig 工作流执行不做自动重试（避免重复产生副作用），超时跟随工作流 API 超时
+func (t *WorkflowAgentTool) GetExecutionConfig() *tooling.ExecutionConfig {
+	timeout := t.workflow.APITimeout
+	if timeout <= 0 {
+		timeout = 300
+	}
+	return &tooling.ExecutionConfig{
+		// 额外预留时间给执行记录的创建与查询
+		TimeoutSeconds: timeout + 10,
+	}
+}
+
+// BuildAPIParamsSchema 将工作流 API 参数转换为工具参数 Schema
+func BuildAPIParamsSchema(params models.WorkflowAPIParams) *utools.ConfigSchema {
+	schema := &utools.ConfigSchema{
+		Type:       "object",
+		Properties: make(map[string]utools.PropertySchema, len(params)),
+		Required:   []string{},
+	}
+
+	for _, param := range params {
+		if param.Key == "" {
+			continue
+		}
+
+		prop := utools.PropertySchema{
+			Type:        param.Type,
+			Title:       param.Key,
+			Description: param.Description,
+			Default:     param.DefaultValue,
+		}
+
+		switch param.Type {
+		case "string", "number", "boolean", "object", "array":
+		case "file":
+			prop.Type = "object"
+			prop.Description = strings.TrimSpace(prop.Description + " 文件对象：{\"type\":\"file\",\"path\":\"...\",\"filename\":\"...\",\"mime_type\":\"...\"}")
+		default:
+			prop.Type = "string"
+		}
+
+		if param.Example != nil {
+			if example, err := json.Marshal(param.Example); err == nil {
+				prop.Description = strings.TrimSpace(fmt.Sprintf("%s（示例：%s）", prop.Description, example))
+			}
+		}
+
+		schema.Properties[param.Key] = prop
+		if param.Required && param.DefaultValue == nil {
+			schema.Required = append(schema.Required, param.Key)
+		}
+	}
+
+	return schema
+}
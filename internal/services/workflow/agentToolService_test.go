@@ -0,0 +1,42 @@
+package workflow
+
+import (
+	"auto-forge/internal/models"
+	"regexp"
+	"testing"
+)
+
+func TestAgentToolName(t *testing.T) {
+	name := AgentToolName("3f2b8c1e-5a7d-4e9f-8b6a-1c2d3e4f5a6b")
+	if name != "workflow_3f2b8c1e5a7d4e9f8b6a1c2d3e4f5a6b" {
+		t.Fatalf("unexpected tool name: %s", name)
+	}
+	if !regexp.MustCompile(`^[a-zA-Z0-9_]{1,64}$`).MatchString(name) {
+		t.Errorf("tool name %q is not a valid function name", name)
+	}
+}
+
+func TestBuildAPIParamsSchema(t *testing.T) {
+	schema := BuildAPIParamsSchema(models.WorkflowAPIParams{
+		{Key: "city", Type: "string", Required: true, Description: "城市", Example: "北京"},
+		{Key: "days", Type: "number", Required: true, DefaultValue: 3},
+		{Key: "image", Type: "file"},
+		{Key: "", Type: "string"},
+	})
+
+	if len(schema.Properties) != 3 {
+		t.Fatalf("expected 3 properties, got %d", len(schema.Properties))
+	}
+	if len(schema.Required) != 1 || schema.Required[0] != "city" {
+		t.Errorf("only required params without default should be required, got %v", schema.Required)
+	}
+	if got := schema.Properties["city"].Description; got != `城市（示例："北京"）` {
+		t.Errorf("unexpected description: %s", got)
+	}
+	if schema.Properties["days"].Default != 3 {
+		t.Errorf("default value not propagated")
+	}
+	if schema.Properties["image"].Type != "object" {
+		t.Errorf("file params should be exposed as object, got %s", schema.Properties["image"].Type)
+	}
+}
@@ -0,0 +1,520 @@
+package workflow
+
+import (
+	"auto-forge/internal/dto/request"
+	"auto-forge/internal/models"
+	"auto-forge/pkg/config"
+	"auto-forge/pkg/database"
+	"auto-forge/pkg/email"
+	log "auto-forge/pkg/logger"
+	"auto-forge/pkg/utools"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// alertWebhookTimeout 通用 Webhook 告警的请求超时时间
+const alertWebhookTimeout = 10 * time.Second
+
+// alertLocks 同一工作流的告警评估互斥，保证连续失败计数准确
+var alertLocks sync.Map
+
+// slowAlertedExecutions 运行中已发送过超时告警的执行ID，避免周期检查与执行结束评估重复告警
+var slowAlertedExecutions sync.Map
+
+// AlertMessage 告警消息内容，通用 Webhook 渠道直接以 JSON 发送
+type AlertMessage struct {
+	Event               string `json:"event"`
+	Title               string `json:"title"`
+	WorkflowID          string `json:"workflow_id"`
+	WorkflowName        string `json:"workflow_name"`
+	ExecutionID         string `json:"execution_id"`
+	Status              string `json:"status"`
+	FailedNode          string `json:"failed_node,omitempty"`
+	Error               string `json:"error,omitempty"`
+	DurationMs          int64  `json:"duration_ms"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	Link                string `json:"link"`
+	Time                int64  `json:"time"`
+}
+
+// AlertService 工作流失败告警服务
+type AlertService struct{}
+
+func NewAlertService() *AlertService {
+	return &AlertService{}
+}
+
+// GetPolicy 获取工作流告警策略，未配置时返回默认（未启用）策略
+func (s *AlertService) GetPolicy(workflowID, userID string) (*models.WorkflowAlertPolicy, error) {
+	if _, err := NewWorkflowService().GetWorkflowByID(workflowID, userID); err != nil {
+		return nil, err
+	}
+
+	var policy models.WorkflowAlertPolicy
+	err := database.GetDB().Where("workflow_id = ? AND user_id = ?", workflowID, userID).First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.WorkflowAlertPolicy{
+			WorkflowID:   workflowID,
+			UserID:       userID,
+			OnFailure:    true,
+			DedupMinutes: 60,
+			Channels:     models.AlertChannels{},
+			LastAlertAt:  models.AlertTimestamps{},
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// SavePolicy 创建或更新工作流告警策略
+func (s *AlertService) SavePolicy(workflowID, userID string, req *request.UpdateAlertPolicyRequest) (*models.WorkflowAlertPolicy, error) {
+	if err := validateAlertPolicy(req); err != nil {
+		return nil, err
+	}
+
+	policy, err := s.GetPolicy(workflowID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	policy.Enabled = req.Enabled
+	policy.OnFailure = req.OnFailure
+	policy.ConsecutiveFailures = req.ConsecutiveFailures
+	policy.OnRecovery = req.OnRecovery
+	policy.MaxDurationSeconds = req.MaxDurationSeconds
+	policy.Channels = models.AlertChannels(req.Channels)
+	policy.DedupMinutes = req.DedupMinutes
+	policy.QuietHoursStart = req.QuietHoursStart
+	policy.QuietHoursEnd = req.QuietHoursEnd
+
+	if err := database.GetDB().Save(policy).Error; err != nil {
+		return nil, err
+	}
+
+	log.Info("用户 %s 更新工作流告警策略: WorkflowID=%s, Enabled=%v", userID, workflowID, policy.Enabled)
+	return policy, nil
+}
+
+// DeletePolicy 删除工作流告警策略
+func (s *AlertService) DeletePolicy(workflowID, userID string) error {
+	return database.GetDB().Unscoped().
+		Where("workflow_id = ? AND user_id = ?", workflowID, userID).
+		Delete(&models.WorkflowAlertPolicy{}).Error
+}
+
+// SendTestAlert 向策略配置的所有渠道发送一条测试告警
+func (s *AlertService) SendTestAlert(workflowID, userID string) error {
+	wf, err := NewWorkflowService().GetWorkflowByID(workflowID, userID)
+	if err != nil {
+		return err
+	}
+	policy, err := s.GetPolicy(workflowID, userID)
+	if err != nil {
+		return err
+	}
+	if len(policy.Channels) == 0 {
+		return errors.New("未配置通知渠道")
+	}
+
+	msg := &AlertMessage{
+		Event:        "test",
+		Title:        fmt.Sprintf("[测试] 工作流告警: %s", wf.Name),
+		WorkflowID:   workflowID,
+		WorkflowName: wf.Name,
+		Status:       "test",
+		Link:         executionLink(workflowID, ""),
+		Time:         time.Now().Unix(),
+	}
+
+	var failed []string
+	for _, channel := range policy.Channels {
+		if err := sendAlert(channel, msg); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", channel.Type, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("部分渠道发送失败: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// Evaluate 执行结束后按告警策略判断并发送告警
+func (s *AlertService) Evaluate(executionID string) {
+	db := database.GetDB()
+
+	var execution models.WorkflowExecution
+	if err := db.First(&execution, "id = ?", executionID).Error; err != nil {
+		log.Error("告警评估查询执行记录失败: ExecutionID=%s, Error=%v", executionID, err)
+		return
+	}
+
+	lock, _ := alertLocks.LoadOrStore(execution.WorkflowID, &sync.Mutex{})
+	mu := lock.(*sync.Mutex)
+	mu.Lock()
+	defer mu.Unlock()
+
+	var policy models.WorkflowAlertPolicy
+	if err := db.Where("workflow_id = ?", execution.WorkflowID).First(&policy).Error; err != nil {
+		return
+	}
+	if policy.LastAlertAt == nil {
+		policy.LastAlertAt = models.AlertTimestamps{}
+	}
+
+	events := detectAlertEvents(&policy, execution.Status, execution.DurationMs)
+	if _, alerted := slowAlertedExecutions.LoadAndDelete(executionID); alerted {
+		events = removeEvent(events, models.AlertEventSlow)
+	}
+
+	if policy.Enabled && len(events) > 0 {
+		var wf models.Workflow
+		if err := db.Select("id, name").First(&wf, "id = ?", execution.WorkflowID).Error; err != nil {
+			log.Error("告警评估查询工作流失败: WorkflowID=%s, Error=%v", execution.WorkflowID, err)
+			return
+		}
+
+		now := time.Now()
+		for _, event := range events {
+			if reason := suppressReason(&policy, event, now); reason != "" {
+				log.Info("告警已抑制: WorkflowID=%s, Event=%s, Reason=%s", execution.WorkflowID, event, reason)
+				continue
+			}
+
+			msg := buildAlertMessage(event, &wf, &execution, policy.ConsecutiveFailureCount)
+			if deliverAlert(policy.Channels, msg) {
+				policy.LastAlertAt[event] = now.Unix()
+			}
+		}
+	}
+
+	if err := db.Model(&policy).Updates(map[string]interface{}{
+		"consecutive_failure_count": policy.ConsecutiveFailureCount,
+		"last_alert_at":             policy.LastAlertAt,
+	}).Error; err != nil {
+		log.Error("更新告警状态失败: WorkflowID=%s, Error=%v", execution.WorkflowID, err)
+	}
+}
+
+// CheckRunningExecutions 检查仍在运行的执行是否超过策略的最长耗时，
+// 卡住不结束的执行不会进入 Evaluate，需要由定时任务周期调用
+func (s *AlertService) CheckRunningExecutions() {
+	db := database.GetDB()
+
+	var policies []models.WorkflowAlertPolicy
+	if err := db.Where("enabled = ? AND max_duration_seconds > ?", true, 0).Find(&policies).Error; err != nil {
+		log.Error("查询超时告警策略失败: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for i := range policies {
+		threshold := now.Unix() - int64(policies[i].MaxDurationSeconds)
+
+		var executions []models.WorkflowExecution
+		if err := db.Where("workflow_id = ? AND status = ? AND start_time < ?",
+			policies[i].WorkflowID, models.ExecutionStatusRunning, threshold).
+			Find(&executions).Error; err != nil {
+			log.Error("查询运行中执行失败: WorkflowID=%s, Error=%v", policies[i].WorkflowID, err)
+			continue
+		}
+
+		for j := range executions {
+			if _, alerted := slowAlertedExecutions.Load(executions[j].GetID()); alerted {
+				continue
+			}
+			s.alertSlowRunning(policies[i].WorkflowID, &executions[j], now)
+		}
+	}
+}
+
+// alertSlowRunning 为运行中的超时执行发送告警
+func (s *AlertService) alertSlowRunning(workflowID string, execution *models.WorkflowExecution, now time.Time) {
+	db := database.GetDB()
+
+	lock, _ := alertLocks.LoadOrStore(workflowID, &sync.Mutex{})
+	mu := lock.(*sync.Mutex)
+	mu.Lock()
+	defer mu.Unlock()
+
+	// 重新读取策略，避免覆盖并发 Evaluate 写入的状态
+	var policy models.WorkflowAlertPolicy
+	if err := db.Where("workflow_id = ?", workflowID).First(&policy).Error; err != nil || !policy.Enabled {
+		return
+	}
+	if policy.LastAlertAt == nil {
+		policy.LastAlertAt = models.AlertTimestamps{}
+	}
+
+	if reason := suppressReason(&policy, models.AlertEventSlow, now); reason != "" {
+		log.Info("告警已抑制: WorkflowID=%s, Event=%s, Reason=%s", workflowID, models.AlertEventSlow, reason)
+		return
+	}
+
+	var wf models.Workflow
+	if err := db.Select("id, name").First(&wf, "id = ?", workflowID).Error; err != nil {
+		log.Error("告警评估查询工作流失败: WorkflowID=%s, Error=%v", workflowID, err)
+		return
+	}
+
+	if execution.StartTime != nil {
+		execution.DurationMs = (now.Unix() - *execution.StartTime) * 1000
+	}
+	msg := buildAlertMessage(models.AlertEventSlow, &wf, execution, policy.ConsecutiveFailureCount)
+	if !deliverAlert(policy.Channels, msg) {
+		return
+	}
+
+	slowAlertedExecutions.Store(execution.GetID(), struct{}{})
+	policy.LastAlertAt[models.AlertEventSlow] = now.Unix()
+	if err := db.Model(&policy).Update("last_alert_at", policy.LastAlertAt).Error; err != nil {
+		log.Error("更新告警状态失败: WorkflowID=%s, Error=%v", workflowID, err)
+	}
+}
+
+// deliverAlert 向所有渠道发送告警，至少一个渠道成功时返回 true
+func deliverAlert(channels models.AlertChannels, msg *AlertMessage) bool {
+	delivered := false
+	for _, channel := range channels {
+		if err := sendAlert(channel, msg); err != nil {
+			log.Error("发送告警失败: WorkflowID=%s, Channel=%s, Error=%v", msg.WorkflowID, channel.Type, err)
+			continue
+		}
+		delivered = true
+	}
+	return delivered
+}
+
+func removeEvent(events []string, event string) []string {
+	filtered := events[:0]
+	for _, e := range events {
+		if e != event {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// detectAlertEvents 根据执行结果更新连续失败计数，并返回命中的告警事件
+func detectAlertEvents(policy *models.WorkflowAlertPolicy, status string, durationMs int64) []string {
+	var events []string
+
+	switch status {
+	case models.ExecutionStatusFailed:
+		policy.ConsecutiveFailureCount++
+		if policy.OnFailure {
+			events = append(events, models.AlertEventFailure)
+		}
+		if policy.ConsecutiveFailures > 0 && policy.ConsecutiveFailureCount == policy.ConsecutiveFailures {
+			events = append(events, models.AlertEventConsecutiveFailures)
+		}
+	case models.ExecutionStatusSuccess:
+		if policy.ConsecutiveFailureCount > 0 && policy.OnRecovery {
+			events = append(events, models.AlertEventRecovery)
+		}
+		policy.ConsecutiveFailureCount = 0
+	}
+
+	if policy.MaxDurationSeconds > 0 && durationMs > int64(policy.MaxDurationSeconds)*1000 {
+		events = append(events, models.AlertEventSlow)
+	}
+
+	return events
+}
+
+// suppressReason 返回告警被抑制的原因（去重窗口或免打扰时段），为空表示可以发送
+func suppressReason(policy *models.WorkflowAlertPolicy, event string, now time.Time) string {
+	if policy.DedupMinutes > 0 {
+		if last, ok := policy.LastAlertAt[event]; ok && now.Unix()-last < int64(policy.DedupMinutes)*60 {
+			return "去重窗口内"
+		}
+	}
+	if inQuietHours(policy.QuietHoursStart, policy.QuietHoursEnd, now) {
+		return "免打扰时段"
+	}
+	return ""
+}
+
+// inQuietHours 判断当前时间是否处于免打扰时段，支持跨午夜（如 22:00-08:00）
+func inQuietHours(start, end string, now time.Time) bool {
+	startMin, ok1 := parseClock(start)
+	endMin, ok2 := parseClock(end)
+	if !ok1 || !ok2 || startMin == endMin {
+		return false
+	}
+
+	current := now.Hour()*60 + now.Minute()
+	if startMin < endMin {
+		return current >= startMin && current < endMin
+	}
+	return current >= startMin || current < endMin
+}
+
+func parseClock(value string) (int, bool) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+func validateAlertPolicy(req *request.UpdateAlertPolicyRequest) error {
+	if (req.QuietHoursStart == "") != (req.QuietHoursEnd == "") {
+		return errors.New("免打扰时段需要同时设置开始和结束时间")
+	}
+	if req.QuietHoursStart != "" {
+		if _, ok := parseClock(req.QuietHoursStart); !ok {
+			return fmt.Errorf("免打扰开始时间格式错误: %s", req.QuietHoursStart)
+		}
+		if _, ok := parseClock(req.QuietHoursEnd); !ok {
+			return fmt.Errorf("免打扰结束时间格式错误: %s", req.QuietHoursEnd)
+		}
+	}
+
+	if req.Enabled && len(req.Channels) == 0 {
+		return errors.New("启用告警时至少需要配置一个通知渠道")
+	}
+	for _, channel := range req.Channels {
+		target := strings.TrimSpace(channel.Target)
+		switch channel.Type {
+		case models.AlertChannelEmail:
+			if !strings.Contains(target, "@") {
+				return fmt.Errorf("邮箱地址无效: %s", target)
+			}
+		case models.AlertChannelFeishu, models.AlertChannelWebhook:
+			if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+				return fmt.Errorf("%s 渠道地址必须是 http(s) URL", channel.Type)
+			}
+		default:
+			return fmt.Errorf("不支持的通知渠道: %s", channel.Type)
+		}
+	}
+
+	return nil
+}
+
+func buildAlertMessage(event string, wf *models.Workflow, execution *models.WorkflowExecution, consecutive int) *AlertMessage {
+	titles := map[string]string{
+		models.AlertEventFailure:             "工作流执行失败",
+		models.AlertEventConsecutiveFailures: fmt.Sprintf("工作流连续失败 %d 次", consecutive),
+		models.AlertEventRecovery:            "工作流已恢复",
+		models.AlertEventSlow:                "工作流执行超时",
+	}
+
+	msg := &AlertMessage{
+		Event:               event,
+		Title:               fmt.Sprintf("[%s] %s", titles[event], wf.Name),
+		WorkflowID:          wf.GetID(),
+		WorkflowName:        wf.Name,
+		ExecutionID:         execution.GetID(),
+		Status:              execution.Status,
+		Error:               execution.Error,
+		DurationMs:          execution.DurationMs,
+		ConsecutiveFailures: consecutive,
+		Link:                executionLink(wf.GetID(), execution.GetID()),
+		Time:                time.Now().Unix(),
+	}
+
+	for _, nodeLog := range execution.NodeLogs {
+		if nodeLog.Status == models.ExecutionStatusFailed {
+			msg.FailedNode = fmt.Sprintf("%s (%s)", nodeLog.NodeName, nodeLog.NodeID)
+			if nodeLog.Error != "" {
+				msg.Error = nodeLog.Error
+			}
+			break
+		}
+	}
+
+	return msg
+}
+
+// executionLink 生成执行详情页链接，executionID 为空时指向执行历史
+func executionLink(workflowID, executionID string) string {
+	path := fmt.Sprintf("/workflows/%s/executions", workflowID)
+	if executionID != "" {
+		path += "/" + executionID
+	}
+	if cfg := config.GetConfig(); cfg != nil && cfg.App.BaseURL != "" {
+		return strings.TrimRight(cfg.App.BaseURL, "/") + path
+	}
+	return path
+}
+
+// text 生成纯文本告警内容
+func (m *AlertMessage) text() string {
+	lines := []string{m.Title, "", "工作流: " + m.WorkflowName}
+	if m.ExecutionID != "" {
+		lines = append(lines, "执行ID: "+m.ExecutionID, "状态: "+m.Status, fmt.Sprintf("耗时: %dms", m.DurationMs))
+	}
+	if m.FailedNode != "" {
+		lines = append(lines, "失败节点: "+m.FailedNode)
+	}
+	if m.Error != "" {
+		lines = append(lines, "错误: "+m.Error)
+	}
+	lines = append(lines, "详情: "+m.Link)
+	return strings.Join(lines, "\n")
+}
+
+func sendAlert(channel models.AlertChannel, msg *AlertMessage) error {
+	target := strings.TrimSpace(channel.Target)
+
+	switch channel.Type {
+	case models.AlertChannelEmail:
+		body := strings.ReplaceAll(html.EscapeString(msg.text()), "\n", "<br>")
+		return email.SendMail(target, msg.Title, body)
+
+	case models.AlertChannelFeishu:
+		tool, err := utools.Get("feishu_bot")
+		if err != nil {
+			return err
+		}
+		result, err := tool.Execute(&utools.ExecutionContext{
+			Context:   context.Background(),
+			Variables: make(map[string]interface{}),
+			Metadata:  make(map[string]interface{}),
+		}, map[string]interface{}{
+			"webhook_url": target,
+			"sign_secret": channel.Secret,
+			"msg_type":    "text",
+			"content":     msg.text(),
+		})
+		if err != nil {
+			return err
+		}
+		if !result.Success {
+			return errors.New(result.Message)
+		}
+		return nil
+
+	case models.AlertChannelWebhook:
+		payload, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		client := utools.NewHTTPClient(utools.HTTPClientOptions{Timeout: alertWebhookTimeout})
+		resp, err := client.Post(target, "application/json", bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("不支持的通知渠道: %s", channel.Type)
+	}
+}
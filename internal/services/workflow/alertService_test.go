@@ -0,0 +1,84 @@
+package workflow
+
+import (
+	"auto-forge/internal/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDetectAlertEvents(t *testing.T) {
+	policy := &models.WorkflowAlertPolicy{ConsecutiveFailures: 2, OnRecovery: true, MaxDurationSeconds: 10}
+
+	if events := detectAlertEvents(policy, models.ExecutionStatusFailed, 1000); len(events) != 0 {
+		t.Fatalf("first failure should not alert without on_failure, got %v", events)
+	}
+	events := detectAlertEvents(policy, models.ExecutionStatusFailed, 1000)
+	if !reflect.DeepEqual(events, []string{models.AlertEventConsecutiveFailures}) {
+		t.Fatalf("expected consecutive failure alert, got %v", events)
+	}
+	if events := detectAlertEvents(policy, models.ExecutionStatusFailed, 1000); len(events) != 0 {
+		t.Fatalf("consecutive alert should fire only once, got %v", events)
+	}
+
+	events = detectAlertEvents(policy, models.ExecutionStatusSuccess, 20000)
+	if !reflect.DeepEqual(events, []string{models.AlertEventRecovery, models.AlertEventSlow}) {
+		t.Fatalf("expected recovery and slow alerts, got %v", events)
+	}
+	if policy.ConsecutiveFailureCount != 0 {
+		t.Fatalf("expected failure count reset, got %d", policy.ConsecutiveFailureCount)
+	}
+}
+
+func TestInQuietHours(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2024, 1, 1, h, m, 0, 0, time.Local) }
+
+	if !inQuietHours("22:00", "08:00", at(23, 30)) || !inQuietHours("22:00", "08:00", at(7, 59)) {
+		t.Fatal("expected overnight quiet hours to match")
+	}
+	if inQuietHours("22:00", "08:00", at(8, 0)) {
+		t.Fatal("end time should be exclusive")
+	}
+	if !inQuietHours("12:00", "13:00", at(12, 30)) || inQuietHours("12:00", "13:00", at(14, 0)) {
+		t.Fatal("unexpected same-day quiet hours result")
+	}
+	if inQuietHours("", "", at(12, 0)) {
+		t.Fatal("empty quiet hours should never match")
+	}
+}
+
+func TestSuppressReasonDedup(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local)
+	policy := &models.WorkflowAlertPolicy{
+		DedupMinutes: 30,
+		LastAlertAt:  models.AlertTimestamps{models.AlertEventFailure: now.Add(-10 * time.Minute).Unix()},
+	}
+
+	if suppressReason(policy, models.AlertEventFailure, now) == "" {
+		t.Fatal("expected failure alert to be deduplicated")
+	}
+	if suppressReason(policy, models.AlertEventRecovery, now) != "" {
+		t.Fatal("different event type should not be deduplicated")
+	}
+	if suppressReason(policy, models.AlertEventFailure, now.Add(30*time.Minute)) != "" {
+		t.Fatal("alert outside dedup window should be sent")
+	}
+}
+
+func TestDeliverAlertReportsFailure(t *testing.T) {
+	msg := &AlertMessage{Event: models.AlertEventSlow, WorkflowID: "wf"}
+
+	if deliverAlert(nil, msg) {
+		t.Fatal("expected no delivery without channels")
+	}
+	if deliverAlert(models.AlertChannels{{Type: "unknown", Target: "x"}}, msg) {
+		t.Fatal("expected failed channel to report no delivery")
+	}
+}
+
+func TestRemoveEvent(t *testing.T) {
+	events := removeEvent([]string{models.AlertEventFailure, models.AlertEventSlow}, models.AlertEventSlow)
+	if !reflect.DeepEqual(events, []string{models.AlertEventFailure}) {
+		t.Fatalf("expected slow event removed, got %v", events)
+	}
+}
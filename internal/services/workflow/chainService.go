@@ -0,0 +1,355 @@
+package workflow
+
+import (
+	"auto-forge/internal/dto/response"
+	"auto-forge/internal/models"
+	"auto-forge/pkg/database"
+	log "auto-forge/pkg/logger"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 工作流完成触发条件
+const (
+	ChainOnSuccess = "success" // 上游执行成功时触发
+	ChainOnFailure = "failure" // 上游执行失败时触发
+	ChainOnAny     = "any"     // 上游执行结束即触发
+)
+
+// ChainAnySource 监听当前用户所有工作流（自身除外）
+const ChainAnySource = "*"
+
+// maxChainDepth 链式触发的最大深度，防止失控的级联执行
+const maxChainDepth = 10
+
+// WorkflowTriggerConfig 工作流完成触发器节点配置
+type WorkflowTriggerConfig struct {
+	SourceWorkflowID string
+	On               string
+}
+
+// ParseWorkflowTriggerConfig 从节点配置解析工作流完成触发器配置，并填充默认值
+func ParseWorkflowTriggerConfig(config map[string]interface{}) *WorkflowTriggerConfig {
+	cfg := &WorkflowTriggerConfig{
+		SourceWorkflowID: getStringValue(config, "source_workflow_id"),
+		On:               getStringValue(config, "on"),
+	}
+	if cfg.On == "" {
+		cfg.On = ChainOnSuccess
+	}
+	return cfg
+}
+
+// ValidateWorkflowTriggerConfig 校验工作流完成触发器节点配置
+func ValidateWorkflowTriggerConfig(config map[string]interface{}) error {
+	cfg := ParseWorkflowTriggerConfig(config)
+
+	if cfg.SourceWorkflowID == "" {
+		return errors.New("必须配置上游工作流")
+	}
+	switch cfg.On {
+	case ChainOnSuccess, ChainOnFailure, ChainOnAny:
+	default:
+		return fmt.Errorf("不支持的触发条件: %s", cfg.On)
+	}
+
+	return nil
+}
+
+// matches 判断上游执行的最终状态是否满足触发条件
+func (cfg *WorkflowTriggerConfig) matches(status string) bool {
+	switch cfg.On {
+	case ChainOnAny:
+		return true
+	case ChainOnFailure:
+		return status == models.ExecutionStatusFailed
+	default:
+		return status == models.ExecutionStatusSuccess
+	}
+}
+
+// FindWorkflowTriggerNode 查找作为起始节点的工作流完成触发器
+func (s *WorkflowService) FindWorkflowTriggerNode(nodes []models.WorkflowNode, edges []models.WorkflowEdge) *models.WorkflowNode {
+	return findStartNodeByType(nodes, edges, "workflow_trigger")
+}
+
+// validateChainTrigger 校验链式触发配置：上游工作流必须属于当前用户，且不能形成触发环
+func (s *WorkflowService) validateChainTrigger(workflowID, userID string, nodes []models.WorkflowNode, edges []models.WorkflowEdge) error {
+	triggerNode := s.FindWorkflowTriggerNode(nodes, edges)
+	if triggerNode == nil {
+		return nil
+	}
+
+	cfg := ParseWorkflowTriggerConfig(triggerNode.Config)
+	if cfg.SourceWorkflowID == ChainAnySource {
+		return nil
+	}
+	if cfg.SourceWorkflowID == workflowID {
+		return errors.New("工作流不能由自身完成触发")
+	}
+	if _, err := s.GetWorkflowByID(cfg.SourceWorkflowID, userID); err != nil {
+		return fmt.Errorf("上游工作流不存在: %s", cfg.SourceWorkflowID)
+	}
+
+	// 新建的工作流尚未被任何工作流引用，不可能成环
+	if workflowID == "" {
+		return nil
+	}
+
+	var workflows []models.Workflow
+	if err := database.GetDB().Where("user_id = ?", userID).Find(&workflows).Error; err != nil {
+		return err
+	}
+
+	return s.detectChainCycle(workflowID, cfg.SourceWorkflowID, workflows)
+}
+
+// detectChainCycle 判断 workflowID 监听 sourceID 完成后，与用户其他工作流的链式触发是否成环
+func (s *WorkflowService) detectChainCycle(workflowID, sourceID string, workflows []models.Workflow) error {
+	// downstream[A] 为监听 A 完成的工作流列表
+	downstream := make(map[string][]string)
+	for i := range workflows {
+		id := workflows[i].GetID()
+		if id == workflowID {
+			continue
+		}
+		node := s.FindWorkflowTriggerNode(workflows[i].Nodes, workflows[i].Edges)
+		if node == nil {
+			continue
+		}
+		source := ParseWorkflowTriggerConfig(node.Config).SourceWorkflowID
+		if source != ChainAnySource {
+			downstream[source] = append(downstream[source], id)
+		}
+	}
+	downstream[sourceID] = append(downstream[sourceID], workflowID)
+
+	// 从当前工作流出发沿下游遍历，若能回到上游工作流则成环
+	visited := map[string]bool{workflowID: true}
+	queue := []string{workflowID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range downstream[current] {
+			if next == sourceID {
+				return errors.New("链式触发形成循环依赖")
+			}
+			if !visited[next] {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ChainService 工作流完成链式触发服务
+type ChainService struct {
+	workflowService  *WorkflowService
+	executionService *ExecutionService
+}
+
+func NewChainService() *ChainService {
+	return &ChainService{
+		workflowService:  NewWorkflowService(),
+		executionService: NewExecutionService(),
+	}
+}
+
+// DispatchCompletion 上游执行结束后，启动所有满足触发条件的下游工作流
+func (s *ChainService) DispatchCompletion(executionID string) {
+	db := database.GetDB()
+
+	var execution models.WorkflowExecution
+	if err := db.First(&execution, "id = ?", executionID).Error; err != nil {
+		log.Error("链式触发查询执行记录失败: ExecutionID=%s, Error=%v", executionID, err)
+		return
+	}
+
+	if execution.ChainDepth >= maxChainDepth {
+		log.Warn("链式触发深度超过上限，停止触发: ExecutionID=%s, Depth=%d", executionID, execution.ChainDepth)
+		return
+	}
+
+	var source models.Workflow
+	if err := db.First(&source, "id = ?", execution.WorkflowID).Error; err != nil {
+		log.Error("链式触发查询上游工作流失败: WorkflowID=%s, Error=%v", execution.WorkflowID, err)
+		return
+	}
+
+	var candidates []models.Workflow
+	if err := db.Where("user_id = ? AND enabled = ?", execution.UserID, true).Find(&candidates).Error; err != nil {
+		log.Error("链式触发查询下游工作流失败: %v", err)
+		return
+	}
+
+	ancestors := s.ancestorWorkflowIDs(&execution)
+	var triggerData map[string]interface{}
+
+	for i := range candidates {
+		wf := &candidates[i]
+		if wf.GetID() == source.GetID() {
+			continue
+		}
+
+		node := s.workflowService.FindWorkflowTriggerNode(wf.Nodes, wf.Edges)
+		if node == nil {
+			continue
+		}
+		cfg := ParseWorkflowTriggerConfig(node.Config)
+		if cfg.SourceWorkflowID != source.GetID() && cfg.SourceWorkflowID != ChainAnySource {
+			continue
+		}
+		if !cfg.matches(execution.Status) {
+			continue
+		}
+		// 运行时环检测：下游工作流已出现在本条触发链中时不再触发
+		if ancestors[wf.GetID()] {
+			log.Warn("链式触发检测到循环，跳过: WorkflowID=%s, ParentExecutionID=%s", wf.GetID(), executionID)
+			continue
+		}
+
+		if triggerData == nil {
+			triggerData = buildChainTriggerData(&source, &execution)
+		}
+
+		child, err := s.executionService.CreateChainedExecution(wf.GetID(), wf.UserID, "workflow", &execution)
+		if err != nil {
+			log.Error("链式触发创建执行记录失败: WorkflowID=%s, Error=%v", wf.GetID(), err)
+			continue
+		}
+
+		log.Info("链式触发工作流: %s -> %s (ExecutionID: %s)", source.GetID(), wf.GetID(), child.GetID())
+
+		childID := child.GetID()
+		go func() {
+			if err := NewEngineService().ExecuteWorkflowWithTrigger(childID, nil, nil, triggerData); err != nil {
+				log.Error("链式触发工作流执行失败: ExecutionID=%s, Error=%v", childID, err)
+			}
+		}()
+	}
+}
+
+// ancestorWorkflowIDs 沿父执行回溯，收集触发链上所有工作流ID（含当前执行）
+func (s *ChainService) ancestorWorkflowIDs(execution *models.WorkflowExecution) map[string]bool {
+	ids := map[string]bool{execution.WorkflowID: true}
+
+	parentID := execution.ParentExecutionID
+	for depth := 0; parentID != "" && depth < maxChainDepth; depth++ {
+		var parent models.WorkflowExecution
+		if err := database.GetDB().Select("id, workflow_id, parent_execution_id").First(&parent, "id = ?", parentID).Error; err != nil {
+			break
+		}
+		ids[parent.WorkflowID] = true
+		parentID = parent.ParentExecutionID
+	}
+
+	return ids
+}
+
+// buildChainTriggerData 将上游执行结果转换为 {{trigger.*}} 变量
+func buildChainTriggerData(source *models.Workflow, execution *models.WorkflowExecution) map[string]interface{} {
+	outputs := make(map[string]interface{}, len(execution.NodeLogs))
+	var finalOutput map[string]interface{}
+	failedNode := ""
+	for _, nodeLog := range execution.NodeLogs {
+		if nodeLog.Output != nil {
+			outputs[nodeLog.NodeID] = nodeLog.Output
+			finalOutput = nodeLog.Output
+		}
+		if nodeLog.Status == models.ExecutionStatusFailed && failedNode == "" {
+			failedNode = nodeLog.NodeID
+		}
+	}
+	if finalOutput == nil {
+		finalOutput = make(map[string]interface{})
+	}
+
+	return map[string]interface{}{
+		"type":          "workflow",
+		"workflow_id":   source.GetID(),
+		"workflow_name": source.Name,
+		"execution_id":  execution.GetID(),
+		"status":        execution.Status,
+		"error":         execution.Error,
+		"failed_node":   failedNode,
+		"duration_ms":   execution.DurationMs,
+		"outputs":       outputs,
+		"final_output":  finalOutput,
+		"finished_at":   time.Now().Unix(),
+	}
+}
+
+// GetExecutionChain 获取执行的触发链：上游执行与直接触发的下游执行
+func (s *ChainService) GetExecutionChain(executionID, userID string) (*response.ExecutionChainResponse, error) {
+	db := database.GetDB()
+	fields := "id, workflow_id, user_id, status, trigger_type, start_time, parent_execution_id, chain_depth"
+
+	var current models.WorkflowExecution
+	if err := db.Select(fields).Where("id = ? AND user_id = ?", executionID, userID).First(&current).Error; err != nil {
+		return nil, fmt.Errorf("执行记录不存在")
+	}
+
+	var ancestors []models.WorkflowExecution
+	parentID := current.ParentExecutionID
+	for depth := 0; parentID != "" && depth < maxChainDepth; depth++ {
+		var parent models.WorkflowExecution
+		if err := db.Select(fields).Where("id = ? AND user_id = ?", parentID, userID).First(&parent).Error; err != nil {
+			break
+		}
+		ancestors = append([]models.WorkflowExecution{parent}, ancestors...)
+		parentID = parent.ParentExecutionID
+	}
+
+	var downstream []models.WorkflowExecution
+	if err := db.Select(fields).Where("parent_execution_id = ? AND user_id = ?", executionID, userID).
+		Order("created_at ASC").Find(&downstream).Error; err != nil {
+		return nil, err
+	}
+
+	names := s.workflowNames(userID, append(append([]models.WorkflowExecution{current}, ancestors...), downstream...))
+
+	result := &response.ExecutionChainResponse{
+		Ancestors:  make([]response.ExecutionChainNode, 0, len(ancestors)),
+		Current:    toExecutionChainNode(&current, names),
+		Downstream: make([]response.ExecutionChainNode, 0, len(downstream)),
+	}
+	for i := range ancestors {
+		result.Ancestors = append(result.Ancestors, toExecutionChainNode(&ancestors[i], names))
+	}
+	for i := range downstream {
+		result.Downstream = append(result.Downstream, toExecutionChainNode(&downstream[i], names))
+	}
+
+	return result, nil
+}
+
+func (s *ChainService) workflowNames(userID string, executions []models.WorkflowExecution) map[string]string {
+	ids := make([]string, 0, len(executions))
+	for _, execution := range executions {
+		ids = append(ids, execution.WorkflowID)
+	}
+
+	var workflows []models.Workflow
+	database.GetDB().Unscoped().Select("id, name").Where("id IN ? AND user_id = ?", ids, userID).Find(&workflows)
+
+	names := make(map[string]string, len(workflows))
+	for _, wf := range workflows {
+		names[wf.GetID()] = wf.Name
+	}
+	return names
+}
+
+func toExecutionChainNode(execution *models.WorkflowExecution, names map[string]string) response.ExecutionChainNode {
+	return response.ExecutionChainNode{
+		ExecutionID:  execution.GetID(),
+		WorkflowID:   execution.WorkflowID,
+		WorkflowName: names[execution.WorkflowID],
+		Status:       execution.Status,
+		TriggerType:  execution.TriggerType,
+		ChainDepth:   execution.ChainDepth,
+		StartTime:    execution.StartTime,
+	}
+}
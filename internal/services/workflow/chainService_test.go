@@ -0,0 +1,95 @@
+package workflow
+
+import (
+	"auto-forge/internal/models"
+	"testing"
+)
+
+func TestWorkflowTriggerConfigMatches(t *testing.T) {
+	cases := []struct {
+		on      string
+		status  string
+		matches bool
+	}{
+		{"", models.ExecutionStatusSuccess, true},
+		{"", models.ExecutionStatusFailed, false},
+		{ChainOnFailure, models.ExecutionStatusFailed, true},
+		{ChainOnFailure, models.ExecutionStatusSuccess, false},
+		{ChainOnAny, models.ExecutionStatusFailed, true},
+	}
+
+	for _, tc := range cases {
+		cfg := ParseWorkflowTriggerConfig(map[string]interface{}{"source_workflow_id": "wf", "on": tc.on})
+		if got := cfg.matches(tc.status); got != tc.matches {
+			t.Errorf("on=%q status=%q: expected %v, got %v", tc.on, tc.status, tc.matches, got)
+		}
+	}
+}
+
+func TestValidateWorkflowTriggerConfig(t *testing.T) {
+	if err := ValidateWorkflowTriggerConfig(map[string]interface{}{}); err == nil {
+		t.Fatal("expected missing source workflow to fail")
+	}
+	if err := ValidateWorkflowTriggerConfig(map[string]interface{}{"source_workflow_id": "wf", "on": "done"}); err == nil {
+		t.Fatal("expected unsupported condition to fail")
+	}
+	if err := ValidateWorkflowTriggerConfig(map[string]interface{}{"source_workflow_id": "*", "on": "failure"}); err != nil {
+		t.Fatalf("expected valid config, got: %v", err)
+	}
+}
+
+// chainWorkflow 构造一个监听 sourceID 完成的工作流
+func chainWorkflow(t *testing.T, id, sourceID string) models.Workflow {
+	t.Helper()
+	var wf models.Workflow
+	if err := wf.SetID(id); err != nil {
+		t.Fatalf("invalid workflow id %s: %v", id, err)
+	}
+	wf.Nodes = models.WorkflowNodes{chainTriggerNode(sourceID)}
+	return wf
+}
+
+func chainTriggerNode(sourceID string) models.WorkflowNode {
+	return models.WorkflowNode{
+		ID:     "trigger",
+		Type:   "workflow_trigger",
+		Config: map[string]interface{}{"source_workflow_id": sourceID},
+	}
+}
+
+func TestValidateChainTriggerRejectsDirectCycle(t *testing.T) {
+	const a = "00000000-0000-0000-0000-00000000000a"
+
+	s := &WorkflowService{}
+	err := s.validateChainTrigger(a, "u1", []models.WorkflowNode{chainTriggerNode(a)}, nil)
+	if err == nil {
+		t.Fatal("expected A -> A to be rejected")
+	}
+}
+
+func TestValidateChainTriggerRejectsIndirectCycle(t *testing.T) {
+	const (
+		a = "00000000-0000-0000-0000-00000000000a"
+		b = "00000000-0000-0000-0000-00000000000b"
+		c = "00000000-0000-0000-0000-00000000000c"
+	)
+
+	s := &WorkflowService{}
+
+	// B 已监听 A，A 再监听 B 即形成 A -> B -> A
+	workflows := []models.Workflow{chainWorkflow(t, a, ""), chainWorkflow(t, b, a)}
+	if err := s.detectChainCycle(a, b, workflows); err == nil {
+		t.Fatal("expected A -> B -> A to be rejected")
+	}
+
+	// C 监听 B、B 监听 A，A 再监听 C 形成更长的环
+	workflows = append(workflows, chainWorkflow(t, c, b))
+	if err := s.detectChainCycle(a, c, workflows); err == nil {
+		t.Fatal("expected A -> B -> C -> A to be rejected")
+	}
+
+	// 没有回到 A 的链路可以保存
+	if err := s.detectChainCycle(c, a, []models.Workflow{chainWorkflow(t, b, a)}); err != nil {
+		t.Fatalf("expected acyclic chain to pass, got: %v", err)
+	}
+}
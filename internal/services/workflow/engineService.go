@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"auto-forge/internal/models"
+	"auto-forge/internal/services/tool_config"
 	"auto-forge/pkg/database"
 	log "auto-forge/pkg/logger"
 	"auto-forge/pkg/utools"
@@ -28,6 +29,11 @@ func NewEngineService() *EngineService {
 }
 
 func (s *EngineService) ExecuteWorkflow(executionID string, envVars map[string]string, externalParams map[string]interface{}) error {
+	return s.ExecuteWorkflowWithTrigger(executionID, envVars, externalParams, nil)
+}
+
+// ExecuteWorkflowWithTrigger 执行工作流，并注入触发器数据（可通过 {{trigger.*}} 引用）
+func (s *EngineService) ExecuteWorkflowWithTrigger(executionID string, envVars map[string]string, externalParams map[string]interface{}, triggerData map[string]interface{}) error {
 	db := database.GetDB()
 
 	var execution models.WorkflowExecution
@@ -95,7 +101,7 @@ func (s *EngineService) ExecuteWorkflow(executionID string, envVars map[string]s
 			continue
 		}
 
-		nodeLog, output, err := s.executeNode(executionID, node, envMap, nodeOutputs, externalParams)
+		nodeLog, output, err := s.executeNode(executionID, workflow.GetID(), workflow.UserID, node, envMap, nodeOutputs, externalParams, triggerData)
 		if err != nil {
 			success = false
 			execError = err
@@ -150,10 +156,13 @@ func (s *EngineService) ExecuteWorkflow(executionID string, envVars map[string]s
 
 func (s *EngineService) executeNode(
 	executionID string,
+	workflowID string,
+	userID string,
 	node models.WorkflowNode,
 	envMap map[string]string,
 	nodeOutputs map[string]map[string]interface{},
 	externalParams map[string]interface{},
+	triggerData map[string]interface{},
 ) (*models.NodeExecutionLog, map[string]interface{}, error) {
 	startTime := time.Now().Unix()
 
@@ -172,6 +181,9 @@ func (s *EngineService) executeNode(
 	if len(externalParamsForLog) > 0 {
 		inputData["external_params"] = externalParamsForLog
 	}
+	if len(triggerData) > 0 {
+		inputData["trigger"] = triggerData
+	}
 
 	nodeLog := &models.NodeExecutionLog{
 		NodeID:     node.ID,
@@ -189,7 +201,7 @@ func (s *EngineService) executeNode(
 	var err error
 
 	if node.Type == "tool" {
-		replacedConfig := s.replaceVariables(node.Config, envMap, nodeOutputs, externalParams)
+		replacedConfig := s.replaceVariables(node.Config, envMap, nodeOutputs, externalParams, triggerData)
 		if len(replacedConfig) > 0 {
 			inputData["resolved_config"] = replacedConfig
 		}
@@ -201,15 +213,15 @@ func (s *EngineService) executeNode(
 
 	switch node.Type {
 	case "tool":
-		output, outputRender, err = s.executeToolNode(node, envMap, nodeOutputs, externalParams)
-	case "trigger", "external_trigger":
-		output, err = s.executeTriggerNode(node)
+		output, outputRender, err = s.executeToolNode(executionID, workflowID, userID, node, nodeLog, envMap, nodeOutputs, externalParams, triggerData)
+	case "trigger", "external_trigger", "webhook_trigger", "poll_trigger", "workflow_trigger":
+		output, err = s.executeTriggerNode(node, triggerData)
 	case "condition":
 		output, err = s.executeConditionNode(node, nodeOutputs)
 	case "delay":
-		output, err = s.executeDelayNode(node, envMap, nodeOutputs, externalParams)
+		output, err = s.executeDelayNode(node, envMap, nodeOutputs, externalParams, triggerData)
 	case "switch":
-		output, err = s.executeSwitchNode(node, envMap, nodeOutputs, externalParams)
+		output, err = s.executeSwitchNode(node, envMap, nodeOutputs, externalParams, triggerData)
 	default:
 		err = fmt.Errorf("不支持的节点类型: %s", node.Type)
 	}
@@ -224,10 +236,15 @@ func (s *EngineService) executeNode(
 }
 
 func (s *EngineService) executeToolNode(
+	executionID string,
+	workflowID string,
+	userID string,
 	node models.WorkflowNode,
+	nodeLog *models.NodeExecutionLog,
 	envMap map[string]string,
 	nodeOutputs map[string]map[string]interface{},
 	externalParams map[string]interface{},
+	triggerData map[string]interface{},
 ) (map[string]interface{}, *models.OutputRenderConfig, error) {
 	toolCode := node.ToolCode
 	if toolCode == "" {
@@ -249,19 +266,33 @@ func (s *EngineService) executeToolNode(
 		return nil, nil, errors.New("工具配置格式错误")
 	}
 
-	config = s.replaceVariables(config, envMap, nodeOutputs, externalParams)
-
-	tool, err := utools.Get(toolCode)
+	// 使用节点固定的工具版本，该版本已移除时按迁移升级配置
+	tool, config, err := utools.ResolveTool(toolCode, node.ToolVersion, config)
 	if err != nil {
 		return nil, nil, fmt.Errorf("工具不存在: %s, %w", toolCode, err)
 	}
+	nodeLog.ToolCode = toolCode
+	nodeLog.ToolVersion = tool.GetMetadata().Version
+
+	config = s.replaceVariables(config, envMap, nodeOutputs, externalParams, triggerData)
+	// 凭证连接只传递 ID，由工具在执行时解析，凭证不进入节点配置与日志
+	connectionID, config := utools.SplitConnection(config)
+	if err := tool_config.CheckToolPolicy(toolCode, userID); err != nil {
+		return nil, nil, err
+	}
+	// 变量替换后的值都是字符串，按工具 Schema 转换为声明的类型
+	if config, err = utools.CoerceConfig(tool.GetSchema(), config); err != nil {
+		return nil, nil, fmt.Errorf("工具配置校验失败: %w", err)
+	}
 
 	ctx := &utools.ExecutionContext{
-		Context:   context.Background(),
-		TaskID:    "",
-		UserID:    "",
-		Variables: make(map[string]interface{}),
-		Metadata:  make(map[string]interface{}),
+		Context:      context.Background(),
+		TaskID:       executionID,
+		WorkflowID:   workflowID,
+		UserID:       userID,
+		ConnectionID: connectionID,
+		Variables:    make(map[string]interface{}),
+		Metadata:     make(map[string]interface{}),
 	}
 
 	nodeVariables := make(map[string]interface{}, len(nodeOutputs))
@@ -281,6 +312,10 @@ func (s *EngineService) executeToolNode(
 		ctx.Variables["external"] = externalParams
 	}
 
+	if triggerData != nil {
+		ctx.Variables["trigger"] = triggerData
+	}
+
 	ctx.Metadata["current"] = map[string]interface{}{
 		"nodeId":   node.ID,
 		"nodeType": node.Type,
@@ -289,6 +324,9 @@ func (s *EngineService) executeToolNode(
 	ctx.Variables["current"] = ctx.Metadata["current"]
 
 	result, err := tool.Execute(ctx, config)
+	if result != nil {
+		nodeLog.Logs = result.Logs
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -322,11 +360,17 @@ func (s *EngineService) executeToolNode(
 	return output, outputRender, nil
 }
 
-func (s *EngineService) executeTriggerNode(node models.WorkflowNode) (map[string]interface{}, error) {
-
-	return map[string]interface{}{
+func (s *EngineService) executeTriggerNode(node models.WorkflowNode, triggerData map[string]interface{}) (map[string]interface{}, error) {
+	output := map[string]interface{}{
 		"triggered": true,
-	}, nil
+	}
+
+	// 触发器数据同时作为触发节点的输出，便于通过 {{nodes.<id>.*}} 引用
+	for key, value := range triggerData {
+		output[key] = value
+	}
+
+	return output, nil
 }
 
 func (s *EngineService) executeConditionNode(
@@ -522,9 +566,10 @@ func (s *EngineService) executeDelayNode(
 	envMap map[string]string,
 	nodeOutputs map[string]map[string]interface{},
 	externalParams map[string]interface{},
+	triggerData map[string]interface{},
 ) (map[string]interface{}, error) {
 
-	config := s.replaceVariables(node.Config, envMap, nodeOutputs, externalParams)
+	config := s.replaceVariables(node.Config, envMap, nodeOutputs, externalParams, triggerData)
 
 	var duration float64 = 5
 	unit := "seconds"
@@ -569,9 +614,10 @@ func (s *EngineService) executeSwitchNode(
 	envMap map[string]string,
 	nodeOutputs map[string]map[string]interface{},
 	externalParams map[string]interface{},
+	triggerData map[string]interface{},
 ) (map[string]interface{}, error) {
 
-	config := s.replaceVariables(node.Config, envMap, nodeOutputs, externalParams)
+	config := s.replaceVariables(node.Config, envMap, nodeOutputs, externalParams, triggerData)
 
 	fieldValue := ""
 	if field, ok := config["field"].(string); ok {
@@ -678,6 +724,7 @@ func (s *EngineService) replaceVariables(
 	envMap map[string]string,
 	nodeOutputs map[string]map[string]interface{},
 	externalParams map[string]interface{},
+	triggerData map[string]interface{},
 ) map[string]interface{} {
 	result := make(map[string]interface{})
 
@@ -690,18 +737,18 @@ func (s *EngineService) replaceVariables(
 				// 检查是否是完整的变量引用（如 "{{external.image}}"）
 				if s.isCompleteVariableRef(v) {
 					// 直接解析变量，返回对象（不转字符串）
-					resolved := s.resolveVariable(v, envMap, nodeOutputs, externalParams)
+					resolved := s.resolveVariable(v, envMap, nodeOutputs, externalParams, triggerData)
 					result[key] = resolved
 				} else {
 					// 普通字符串，进行模板替换
-					result[key] = s.replaceStringVariables(v, envMap, nodeOutputs, externalParams)
+					result[key] = s.replaceStringVariables(v, envMap, nodeOutputs, externalParams, triggerData)
 				}
 			}
 		case map[string]interface{}:
-			result[key] = s.replaceVariables(v, envMap, nodeOutputs, externalParams)
+			result[key] = s.replaceVariables(v, envMap, nodeOutputs, externalParams, triggerData)
 		case []interface{}:
 
-			result[key] = s.replaceArray(v, envMap, nodeOutputs, externalParams)
+			result[key] = s.replaceArray(v, envMap, nodeOutputs, externalParams, triggerData)
 		default:
 			result[key] = value
 		}
@@ -715,16 +762,17 @@ func (s *EngineService) replaceArray(
 	envMap map[string]string,
 	nodeOutputs map[string]map[string]interface{},
 	externalParams map[string]interface{},
+	triggerData map[string]interface{},
 ) []interface{} {
 	result := make([]interface{}, len(arr))
 	for i, item := range arr {
 		switch v := item.(type) {
 		case string:
-			result[i] = s.replaceStringVariables(v, envMap, nodeOutputs, externalParams)
+			result[i] = s.replaceStringVariables(v, envMap, nodeOutputs, externalParams, triggerData)
 		case map[string]interface{}:
-			result[i] = s.replaceVariables(v, envMap, nodeOutputs, externalParams)
+			result[i] = s.replaceVariables(v, envMap, nodeOutputs, externalParams, triggerData)
 		case []interface{}:
-			result[i] = s.replaceArray(v, envMap, nodeOutputs, externalParams)
+			result[i] = s.replaceArray(v, envMap, nodeOutputs, externalParams, triggerData)
 		default:
 			result[i] = item
 		}
@@ -737,6 +785,7 @@ func (s *EngineService) replaceStringVariables(
 	envMap map[string]string,
 	nodeOutputs map[string]map[string]interface{},
 	externalParams map[string]interface{},
+	triggerData map[string]interface{},
 ) string {
 	// 处理环境变量 {{env.xxx}}
 	for key, value := range envMap {
@@ -759,6 +808,22 @@ func (s *EngineService) replaceStringVariables(
 		})
 	}
 
+	// 处理触发器数据 {{trigger.xxx}}，支持嵌套路径
+	if triggerData != nil {
+		re := regexp.MustCompile(`\{\{trigger\.([^}]+)\}\}`)
+		str = re.ReplaceAllStringFunc(str, func(match string) string {
+			path := strings.TrimPrefix(match, "{{trigger.")
+			path = strings.TrimSuffix(path, "}}")
+
+			value := s.getNestedValue(triggerData, path)
+			if value == nil {
+				return match
+			}
+
+			return stringifyValue(value)
+		})
+	}
+
 	// 处理节点输出 {{nodes.xxx.yyy}}
 	re := regexp.MustCompile(`\{\{nodes\.([^}]+)\}\}`)
 	str = re.ReplaceAllStringFunc(str, func(match string) string {
@@ -863,6 +928,7 @@ func (s *EngineService) resolveVariable(
 	envMap map[string]string,
 	nodeOutputs map[string]map[string]interface{},
 	externalParams map[string]interface{},
+	triggerData map[string]interface{},
 ) interface{} {
 	// 去掉 {{ 和 }}
 	varRef = strings.TrimSpace(varRef)
@@ -890,6 +956,12 @@ func (s *EngineService) resolveVariable(
 				return output
 			}
 		}
+	} else if strings.HasPrefix(varRef, "trigger.") {
+		// {{trigger.body.repository.name}}
+		path := strings.TrimPrefix(varRef, "trigger.")
+		if triggerData != nil {
+			return s.getNestedValue(triggerData, path)
+		}
 	} else if strings.HasPrefix(varRef, "env.") {
 		// {{env.api_key}}
 		key := strings.TrimPrefix(varRef, "env.")
@@ -906,6 +978,16 @@ func marshalJSON(v interface{}) string {
 	return string(bytes)
 }
 
+// stringifyValue 将变量值转换为字符串，对象和数组序列化为 JSON
+func stringifyValue(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return marshalJSON(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
 // cleanupExecutionFiles 清理执行记录的临时文件
 func (s *EngineService) cleanupExecutionFiles(executionID string) {
 	baseDir := "/tmp/workflow-files"
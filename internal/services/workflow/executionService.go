@@ -27,6 +27,11 @@ func NewExecutionService() *ExecutionService {
 
 
 func (s *ExecutionService) CreateExecution(workflowID, userID, triggerType string) (*models.WorkflowExecution, error) {
+	return s.CreateChainedExecution(workflowID, userID, triggerType, nil)
+}
+
+// CreateChainedExecution 创建执行记录，parent 不为空时记录链式触发的上游执行
+func (s *ExecutionService) CreateChainedExecution(workflowID, userID, triggerType string, parent *models.WorkflowExecution) (*models.WorkflowExecution, error) {
 	db := database.GetDB()
 
 
@@ -35,7 +40,8 @@ func (s *ExecutionService) CreateExecution(workflowID, userID, triggerType strin
 		return nil, err
 	}
 
-	if !workflow.Enabled && triggerType != "manual" {
+	// 手动执行与 Agent 调用（需显式标记为可调用）不要求工作流已启用
+	if !workflow.Enabled && triggerType != "manual" && triggerType != "agent" {
 		return nil, errors.New("工作流未启用")
 	}
 
@@ -53,6 +59,12 @@ func (s *ExecutionService) CreateExecution(workflowID, userID, triggerType strin
 		NodeLogs:     models.NodeExecutionLogs{},
 	}
 
+	if parent != nil {
+		execution.ParentExecutionID = parent.GetID()
+		execution.ParentWorkflowID = parent.WorkflowID
+		execution.ChainDepth = parent.ChainDepth + 1
+	}
+
 	if err := db.Create(execution).Error; err != nil {
 		return nil, err
 	}
@@ -116,7 +128,7 @@ func (s *ExecutionService) GetExecutionList(workflowID, userID string, query *re
 
 	var executions []models.WorkflowExecution
 	offset := (query.Page - 1) * query.PageSize
-	if err := queryDB.Select("id, created_at, updated_at, deleted_at, workflow_id, user_id, status, trigger_type, start_time, end_time, duration_ms, total_nodes, success_nodes, failed_nodes, skipped_nodes, error").
+	if err := queryDB.Select("id, created_at, updated_at, deleted_at, workflow_id, user_id, status, trigger_type, start_time, end_time, duration_ms, total_nodes, success_nodes, failed_nodes, skipped_nodes, error, parent_execution_id, parent_workflow_id, chain_depth, agent_message_id").
 		Order("created_at DESC").
 		Offset(offset).
 		Limit(query.PageSize).
@@ -146,6 +158,7 @@ func (s *ExecutionService) UpdateExecutionStatus(executionID, status string, err
 		"status": status,
 	}
 
+	finished := false
 	if status == models.ExecutionStatusSuccess || status == models.ExecutionStatusFailed || status == models.ExecutionStatusCancelled {
 		endTime := time.Now().Unix()
 		updates["end_time"] = endTime
@@ -157,6 +170,8 @@ func (s *ExecutionService) UpdateExecutionStatus(executionID, status string, err
 				durationMs := (endTime - *execution.StartTime) * 1000
 				updates["duration_ms"] = durationMs
 			}
+			// 仅在首次进入终态时触发下游工作流，避免取消后再次写入状态导致重复触发
+			finished = !isTerminalStatus(execution.Status)
 		}
 	}
 
@@ -171,9 +186,21 @@ func (s *ExecutionService) UpdateExecutionStatus(executionID, status string, err
 	}
 
 	log.Info("更新执行状态: ExecutionID=%s, Status=%s", executionID, status)
+
+	if finished && status != models.ExecutionStatusCancelled {
+		go NewAlertService().Evaluate(executionID)
+		go NewChainService().DispatchCompletion(executionID)
+	} else if finished {
+		slowAlertedExecutions.Delete(executionID)
+	}
+
 	return nil
 }
 
+func isTerminalStatus(status string) bool {
+	return status == models.ExecutionStatusSuccess || status == models.ExecutionStatusFailed || status == models.ExecutionStatusCancelled
+}
+
 
 func (s *ExecutionService) AddNodeLog(executionID string, nodeLog models.NodeExecutionLog) error {
 	db := database.GetDB()
@@ -313,5 +340,11 @@ func (s *ExecutionService) toExecutionResponse(execution *models.WorkflowExecuti
 		Error:        execution.Error,
 		CreatedAt:    execution.GetCreatedAt().Unix(),
 		UpdatedAt:    execution.GetUpdatedAt().Unix(),
+
+		ParentExecutionID: execution.ParentExecutionID,
+		ParentWorkflowID:  execution.ParentWorkflowID,
+		ChainDepth:        execution.ChainDepth,
+
+		AgentMessageID: execution.AgentMessageID,
 	}
 }
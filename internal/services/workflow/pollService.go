@@ -0,0 +1,417 @@
+package workflow
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/internal/services/tool_config"
+	"auto-forge/pkg/database"
+	log "auto-forge/pkg/logger"
+	"auto-forge/pkg/utools"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultPollInterval 默认轮询间隔（秒）
+	defaultPollInterval = 300
+	// minPollInterval 最小轮询间隔（秒）
+	minPollInterval = 30
+	// defaultPollMaxSeen 默认保留的去重键数量
+	defaultPollMaxSeen = 1000
+	// pollToolTimeout 单次轮询调用源工具的超时时间
+	pollToolTimeout = 2 * time.Minute
+)
+
+// defaultPollDedupKeys 未配置去重字段时依次尝试的条目字段
+var defaultPollDedupKeys = []string{"guid", "id", "link", "url"}
+
+// pollLocks 同一工作流的轮询互斥，避免慢速源工具导致重复触发
+var pollLocks sync.Map
+
+// PollTriggerConfig 轮询触发器节点配置
+type PollTriggerConfig struct {
+	ToolCode       string
+	ToolConfig     map[string]interface{}
+	Interval       int
+	ItemsPath      string
+	DedupKey       string
+	MaxSeen        int
+	MaxItems       int
+	EmitOnFirstRun bool
+}
+
+// ParsePollTriggerConfig 从节点配置解析轮询触发器配置，并填充默认值
+func ParsePollTriggerConfig(config map[string]interface{}) *PollTriggerConfig {
+	cfg := &PollTriggerConfig{
+		ToolCode:       getStringValue(config, "tool_code"),
+		ItemsPath:      getStringValue(config, "items_path"),
+		DedupKey:       getStringValue(config, "dedup_key"),
+		EmitOnFirstRun: getBoolValue(config, "emit_on_first_run"),
+		Interval:       getIntValue(config, "interval", defaultPollInterval),
+		MaxSeen:        getIntValue(config, "max_seen", defaultPollMaxSeen),
+		MaxItems:       getIntValue(config, "max_items", 0),
+	}
+
+	if toolConfig, ok := config["tool_config"].(map[string]interface{}); ok {
+		cfg.ToolConfig = toolConfig
+	} else {
+		cfg.ToolConfig = make(map[string]interface{})
+	}
+
+	return cfg
+}
+
+// ValidatePollTriggerConfig 校验轮询触发器节点配置
+func ValidatePollTriggerConfig(config map[string]interface{}) error {
+	cfg := ParsePollTriggerConfig(config)
+
+	if cfg.ToolCode == "" {
+		return errors.New("必须配置数据源工具")
+	}
+	if _, err := utools.Get(cfg.ToolCode); err != nil {
+		return fmt.Errorf("数据源工具不存在: %s", cfg.ToolCode)
+	}
+	if cfg.Interval < minPollInterval {
+		return fmt.Errorf("轮询间隔不能小于 %d 秒", minPollInterval)
+	}
+	if cfg.MaxSeen <= 0 {
+		return errors.New("去重键保留数量必须大于 0")
+	}
+
+	return nil
+}
+
+func getIntValue(m map[string]interface{}, key string, defaultValue int) int {
+	switch v := m[key].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		var n int
+		if _, err := fmt.Sscanf(v, "%d", &n); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
+// PollService 轮询触发器服务
+type PollService struct {
+	workflowService  *WorkflowService
+	executionService *ExecutionService
+	engineService    *EngineService
+}
+
+func NewPollService() *PollService {
+	return &PollService{
+		workflowService:  NewWorkflowService(),
+		executionService: NewExecutionService(),
+		engineService:    NewEngineService(),
+	}
+}
+
+// FindPollTriggerNode 查找作为起始节点的轮询触发器
+func (s *WorkflowService) FindPollTriggerNode(nodes []models.WorkflowNode, edges []models.WorkflowEdge) *models.WorkflowNode {
+	return findStartNodeByType(nodes, edges, "poll_trigger")
+}
+
+// PollResult 单次轮询结果
+type PollResult struct {
+	TotalItems  int    `json:"total_items"`
+	NewItems    int    `json:"new_items"`
+	Triggered   bool   `json:"triggered"`
+	ExecutionID string `json:"execution_id,omitempty"`
+	Seeded      bool   `json:"seeded"` // 首次轮询仅记录游标
+}
+
+// Poll 执行一次轮询：调用数据源工具，对比去重状态，有新条目时启动工作流
+func (s *PollService) Poll(wf *models.Workflow) (*PollResult, error) {
+	workflowID := wf.GetID()
+
+	lock, _ := pollLocks.LoadOrStore(workflowID, &sync.Mutex{})
+	mu := lock.(*sync.Mutex)
+	if !mu.TryLock() {
+		return nil, errors.New("上一次轮询尚未完成")
+	}
+	defer mu.Unlock()
+
+	node := s.workflowService.FindPollTriggerNode(wf.Nodes, wf.Edges)
+	if node == nil {
+		return nil, errors.New("工作流未配置轮询触发器")
+	}
+	cfg := ParsePollTriggerConfig(node.Config)
+
+	state, err := s.loadOrCreateState(workflowID, node.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	state.PollCount++
+	state.LastPolledAt = &now
+
+	items, err := s.fetchItems(wf, cfg)
+	if err != nil {
+		state.LastError = err.Error()
+		s.saveState(state)
+		return nil, err
+	}
+	state.LastError = ""
+
+	seen := make(map[string]bool, len(state.SeenKeys))
+	for _, key := range state.SeenKeys {
+		seen[key] = true
+	}
+
+	var newItems []interface{}
+	var newKeys []string
+	for _, item := range items {
+		key := pollItemKey(item, cfg.DedupKey)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		newItems = append(newItems, item)
+		newKeys = append(newKeys, key)
+	}
+
+	state.LastNewItems = len(newItems)
+
+	result := &PollResult{
+		TotalItems: len(items),
+		NewItems:   len(newItems),
+	}
+
+	firstRun := !state.Initialized
+
+	if len(newItems) == 0 || (firstRun && !cfg.EmitOnFirstRun) {
+		// 首次轮询仅记录游标：所有条目视为已处理
+		state.Initialized = true
+		result.Seeded = firstRun
+		appendSeenKeys(state, newKeys, cfg.MaxSeen)
+		if err := s.saveState(state); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	// 超出单次上限的条目不记入去重键，留给下一次轮询
+	if cfg.MaxItems > 0 && len(newItems) > cfg.MaxItems {
+		newItems = newItems[:cfg.MaxItems]
+		newKeys = newKeys[:cfg.MaxItems]
+	}
+
+	execution, err := s.executionService.CreateExecution(workflowID, wf.UserID, "poll")
+	if err != nil {
+		state.LastError = err.Error()
+		s.saveState(state)
+		return nil, err
+	}
+
+	triggerData := map[string]interface{}{
+		"type":      "poll",
+		"source":    cfg.ToolCode,
+		"items":     newItems,
+		"count":     len(newItems),
+		"polled_at": now,
+	}
+
+	execID := execution.GetID()
+	go func() {
+		if err := s.engineService.ExecuteWorkflowWithTrigger(execID, nil, nil, triggerData); err != nil {
+			log.Error("轮询触发工作流执行失败: WorkflowID=%s, ExecutionID=%s, Error=%v", workflowID, execID, err)
+		}
+	}()
+
+	// 执行已派发，再记录本次实际下发条目的去重键
+	state.Initialized = true
+	appendSeenKeys(state, newKeys, cfg.MaxSeen)
+	state.TriggerCount++
+	state.LastTriggeredAt = &now
+	state.LastExecutionID = execID
+	// 执行已启动，保存失败仅记录日志（saveState 内部已记录）
+	s.saveState(state)
+
+	log.Info("轮询触发工作流: WorkflowID=%s, 新条目=%d, ExecutionID=%s", workflowID, len(newItems), execID)
+
+	result.Triggered = true
+	result.ExecutionID = execID
+	return result, nil
+}
+
+// appendSeenKeys 追加已处理条目的去重键，仅保留最近 maxSeen 个
+func appendSeenKeys(state *models.WorkflowPollState, keys []string, maxSeen int) {
+	state.SeenKeys = append(state.SeenKeys, keys...)
+	if maxSeen > 0 && len(state.SeenKeys) > maxSeen {
+		state.SeenKeys = state.SeenKeys[len(state.SeenKeys)-maxSeen:]
+	}
+}
+
+// fetchItems 调用数据源工具并提取条目列表
+func (s *PollService) fetchItems(wf *models.Workflow, cfg *PollTriggerConfig) ([]interface{}, error) {
+	tool, err := utools.Get(cfg.ToolCode)
+	if err != nil {
+		return nil, fmt.Errorf("数据源工具不存在: %s", cfg.ToolCode)
+	}
+	if err := tool_config.CheckToolPolicy(cfg.ToolCode, wf.UserID); err != nil {
+		return nil, err
+	}
+
+	envMap := s.engineService.buildEnvMap(wf.EnvVars, nil)
+	toolConfig := s.engineService.replaceVariables(cfg.ToolConfig, envMap, nil, nil, nil)
+	connectionID, toolConfig := utools.SplitConnection(toolConfig)
+	if toolConfig, err = utools.CoerceConfig(tool.GetSchema(), toolConfig); err != nil {
+		return nil, fmt.Errorf("数据源工具配置校验失败: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pollToolTimeout)
+	defer cancel()
+
+	execCtx := &utools.ExecutionContext{
+		Context:      ctx,
+		WorkflowID:   wf.GetID(),
+		UserID:       wf.UserID,
+		ConnectionID: connectionID,
+		Variables:    map[string]interface{}{"env": envMap},
+		Metadata:     make(map[string]interface{}),
+	}
+
+	result, err := tool.Execute(execCtx, toolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("数据源工具执行失败: %w", err)
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("数据源工具执行失败: %s", result.Message)
+	}
+
+	// 通过 JSON 往返统一条目类型（工具可能返回结构体或强类型切片）
+	var output map[string]interface{}
+	raw, err := json.Marshal(result.Output)
+	if err != nil {
+		return nil, fmt.Errorf("序列化工具输出失败: %w", err)
+	}
+	if err := json.Unmarshal(raw, &output); err != nil {
+		return nil, fmt.Errorf("解析工具输出失败: %w", err)
+	}
+
+	var value interface{} = output
+	if cfg.ItemsPath != "" {
+		value = s.engineService.getNestedValue(output, cfg.ItemsPath)
+	} else if items, ok := output["items"]; ok {
+		value = items
+	}
+
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("条目路径 %q 未指向数组", cfg.ItemsPath)
+	}
+
+	return items, nil
+}
+
+// pollItemKey 计算条目的去重键：优先使用配置字段，其次常见标识字段，最后使用内容哈希
+func pollItemKey(item interface{}, dedupKey string) string {
+	if obj, ok := item.(map[string]interface{}); ok {
+		engine := &EngineService{}
+		if dedupKey != "" {
+			if v := engine.getNestedValue(obj, dedupKey); v != nil {
+				return fmt.Sprintf("%v", v)
+			}
+		} else {
+			for _, key := range defaultPollDedupKeys {
+				if v, exists := obj[key]; exists && v != nil && v != "" {
+					return fmt.Sprintf("%v", v)
+				}
+			}
+		}
+	}
+
+	raw, _ := json.Marshal(item)
+	sum := sha256.Sum256(raw)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (s *PollService) loadOrCreateState(workflowID, nodeID string) (*models.WorkflowPollState, error) {
+	db := database.GetDB()
+
+	var state models.WorkflowPollState
+	err := db.Where("workflow_id = ?", workflowID).First(&state).Error
+	if err == nil {
+		if state.NodeID != nodeID {
+			// 触发器节点被替换，重新开始记录游标
+			state.NodeID = nodeID
+			state.SeenKeys = models.PollSeenKeys{}
+			state.Initialized = false
+		}
+		return &state, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	state = models.WorkflowPollState{
+		WorkflowID: workflowID,
+		NodeID:     nodeID,
+		SeenKeys:   models.PollSeenKeys{},
+	}
+	if err := db.Create(&state).Error; err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (s *PollService) saveState(state *models.WorkflowPollState) error {
+	if err := database.GetDB().Save(state).Error; err != nil {
+		log.Error("保存轮询状态失败: WorkflowID=%s, Error=%v", state.WorkflowID, err)
+		return err
+	}
+	return nil
+}
+
+// GetPollState 获取工作流的轮询状态
+func (s *PollService) GetPollState(workflowID, userID string) (*models.WorkflowPollState, error) {
+	if _, err := s.workflowService.GetWorkflowByID(workflowID, userID); err != nil {
+		return nil, err
+	}
+
+	var state models.WorkflowPollState
+	if err := database.GetDB().Where("workflow_id = ?", workflowID).First(&state).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("轮询状态不存在")
+		}
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// ResetPollState 重置工作流的轮询状态，下一次轮询将重新记录游标
+func (s *PollService) ResetPollState(workflowID, userID string) error {
+	if _, err := s.workflowService.GetWorkflowByID(workflowID, userID); err != nil {
+		return err
+	}
+
+	if err := database.GetDB().Unscoped().Where("workflow_id = ?", workflowID).Delete(&models.WorkflowPollState{}).Error; err != nil {
+		return fmt.Errorf("重置轮询状态失败: %w", err)
+	}
+
+	log.Info("工作流轮询状态已重置: WorkflowID=%s", workflowID)
+	return nil
+}
+
+// PollNow 立即执行一次轮询（手动触发）
+func (s *PollService) PollNow(workflowID, userID string) (*PollResult, error) {
+	wf, err := s.workflowService.GetWorkflowByID(workflowID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Poll(wf)
+}
@@ -0,0 +1,44 @@
+package workflow
+
+import (
+	"auto-forge/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestPollItemKey(t *testing.T) {
+	item := map[string]interface{}{
+		"guid": "abc",
+		"link": "https://example.com/a",
+		"meta": map[string]interface{}{"id": 42.0},
+	}
+
+	if key := pollItemKey(item, ""); key != "abc" {
+		t.Fatalf("expected default key guid, got %q", key)
+	}
+	if key := pollItemKey(item, "meta.id"); key != "42" {
+		t.Fatalf("expected nested dedup key, got %q", key)
+	}
+
+	hashed := pollItemKey(map[string]interface{}{"title": "x"}, "")
+	if !strings.HasPrefix(hashed, "sha256:") {
+		t.Fatalf("expected content hash fallback, got %q", hashed)
+	}
+	if hashed != pollItemKey(map[string]interface{}{"title": "x"}, "") {
+		t.Fatal("content hash should be stable")
+	}
+}
+
+func TestAppendSeenKeys(t *testing.T) {
+	state := &models.WorkflowPollState{SeenKeys: models.PollSeenKeys{"a", "b"}}
+
+	appendSeenKeys(state, []string{"c", "d"}, 3)
+	if got := strings.Join(state.SeenKeys, ","); got != "b,c,d" {
+		t.Fatalf("expected oldest keys trimmed, got %q", got)
+	}
+
+	appendSeenKeys(state, nil, 3)
+	if len(state.SeenKeys) != 3 {
+		t.Fatalf("expected keys unchanged, got %v", state.SeenKeys)
+	}
+}
@@ -0,0 +1,103 @@
+package workflow
+
+import (
+	"auto-forge/internal/dto/response"
+	"auto-forge/internal/models"
+	"auto-forge/internal/services/tool_config"
+	"auto-forge/pkg/database"
+	"auto-forge/pkg/utools"
+)
+
+// nodeToolCode 节点引用的工具：工具节点的工具，或轮询触发器的数据源工具
+func nodeToolCode(node models.WorkflowNode) string {
+	switch node.Type {
+	case "tool":
+		if node.ToolCode != "" {
+			return node.ToolCode
+		}
+		toolCode, _ := node.Data["tool_code"].(string)
+		return toolCode
+	case "poll_trigger":
+		return ParsePollTriggerConfig(node.Config).ToolCode
+	}
+	return ""
+}
+
+// CheckToolReferences 按管理员的工具策略检查工作流引用的工具
+func (s *WorkflowService) CheckToolReferences(userID string, nodes []models.WorkflowNode) []response.WorkflowToolIssue {
+	return checkToolReferences(userID, nodes, tool_config.EvaluateToolPolicy)
+}
+
+// checkToolReferences 检查节点引用的工具，evaluate 返回工具的警告与阻止执行的错误
+func checkToolReferences(userID string, nodes []models.WorkflowNode, evaluate func(toolCode, userID string) (string, error)) []response.WorkflowToolIssue {
+	issues := make([]response.WorkflowToolIssue, 0)
+	for _, node := range nodes {
+		toolCode := nodeToolCode(node)
+		if toolCode == "" {
+			continue
+		}
+
+		issue := response.WorkflowToolIssue{NodeID: node.ID, NodeName: node.Name, ToolCode: toolCode}
+		if _, err := utools.Get(toolCode); err != nil {
+			issue.Message = "工具不存在: " + toolCode
+			issue.Blocking = true
+			issues = append(issues, issue)
+			continue
+		}
+
+		warning, err := evaluate(toolCode, userID)
+		if err != nil {
+			issue.Message = err.Error()
+			issue.Blocking = true
+		} else if warning != "" {
+			issue.Message = warning
+		} else if message, blocking := toolVersionIssue(node, toolCode); message != "" {
+			issue.Message = message
+			issue.Blocking = blocking
+		} else {
+			continue
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// GetToolPolicyReport 列出引用了不存在、已禁用、无权使用、已废弃或版本过期工具的工作流
+func (s *WorkflowService) GetToolPolicyReport() ([]response.WorkflowToolReport, error) {
+	var workflows []models.Workflow
+	if err := database.GetDB().Order("created_at DESC").Find(&workflows).Error; err != nil {
+		return nil, err
+	}
+
+	// 同一用户与工具的策略结果在报表内复用，避免重复查询
+	type result struct {
+		warning string
+		err     error
+	}
+	cache := make(map[string]result)
+	evaluate := func(toolCode, userID string) (string, error) {
+		key := userID + "|" + toolCode
+		if r, ok := cache[key]; ok {
+			return r.warning, r.err
+		}
+		warning, err := tool_config.EvaluateToolPolicy(toolCode, userID)
+		cache[key] = result{warning, err}
+		return warning, err
+	}
+
+	reports := make([]response.WorkflowToolReport, 0)
+	for _, wf := range workflows {
+		issues := checkToolReferences(wf.UserID, wf.Nodes, evaluate)
+		if len(issues) == 0 {
+			continue
+		}
+		reports = append(reports, response.WorkflowToolReport{
+			WorkflowID:   wf.GetID(),
+			WorkflowName: wf.Name,
+			UserID:       wf.UserID,
+			Enabled:      wf.Enabled,
+			Issues:       issues,
+		})
+	}
+	return reports, nil
+}
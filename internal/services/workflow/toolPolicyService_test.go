@@ -0,0 +1,56 @@
+package workflow
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/pkg/errors"
+	"auto-forge/pkg/utools"
+	"testing"
+)
+
+type policyTestTool struct {
+	*utools.BaseTool
+}
+
+func (t *policyTestTool) Execute(ctx *utools.ExecutionContext, config map[string]interface{}) (*utools.ExecutionResult, error) {
+	return &utools.ExecutionResult{Success: true}, nil
+}
+
+func TestCheckToolReferences(t *testing.T) {
+	for _, code := range []string{"policy_test_ok", "policy_test_disabled", "policy_test_deprecated"} {
+		tool := &policyTestTool{utools.NewBaseTool(&utools.ToolMetadata{Code: code, Name: code}, &utools.ConfigSchema{Type: "object"})}
+		if err := utools.Register(tool); err != nil {
+			t.Fatalf("register %s: %v", code, err)
+		}
+		defer utools.GetRegistry().Unregister(code)
+	}
+
+	evaluate := func(toolCode, userID string) (string, error) {
+		switch toolCode {
+		case "policy_test_disabled":
+			return "", errors.New(errors.CodeForbidden, "工具已被管理员禁用")
+		case "policy_test_deprecated":
+			return "工具已废弃", nil
+		}
+		return "", nil
+	}
+
+	nodes := []models.WorkflowNode{
+		{ID: "n1", Type: "tool", ToolCode: "policy_test_ok"},
+		{ID: "n2", Type: "tool", ToolCode: "policy_test_disabled"},
+		{ID: "n3", Type: "tool", Data: map[string]interface{}{"tool_code": "policy_test_deprecated"}},
+		{ID: "n4", Type: "tool", ToolCode: "policy_test_missing"},
+		{ID: "n5", Type: "condition"},
+	}
+
+	issues := checkToolReferences("user-1", nodes, evaluate)
+	if len(issues) != 3 {
+		t.Fatalf("expected 3 issues, got %+v", issues)
+	}
+	expected := map[string]bool{"n2": true, "n3": false, "n4": true}
+	for _, issue := range issues {
+		blocking, ok := expected[issue.NodeID]
+		if !ok || issue.Blocking != blocking {
+			t.Fatalf("unexpected issue: %+v", issue)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package workflow
+
+import (
+	"auto-forge/internal/dto/response"
+	"auto-forge/internal/models"
+	"auto-forge/pkg/database"
+	"auto-forge/pkg/utools"
+	"fmt"
+)
+
+// pinToolVersions 为未指定版本的工具节点固定当前最新版本，避免工具升级后已保存的工作流被静默改变
+func pinToolVersions(nodes []models.WorkflowNode) {
+	for i := range nodes {
+		if nodes[i].Type != "tool" || nodes[i].ToolVersion != "" {
+			continue
+		}
+		if tool, err := utools.Get(nodeToolCode(nodes[i])); err == nil {
+			nodes[i].ToolVersion = tool.GetMetadata().Version
+		}
+	}
+}
+
+// toolVersionIssue 检查节点固定的工具版本：有新版本时提示升级，版本已移除且无法迁移时阻止执行
+func toolVersionIssue(node models.WorkflowNode, toolCode string) (string, bool) {
+	if node.Type != "tool" || node.ToolVersion == "" {
+		return "", false
+	}
+
+	registry := utools.GetRegistry()
+	latest, err := registry.Get(toolCode)
+	if err != nil {
+		return "", false
+	}
+	latestVersion := latest.GetMetadata().Version
+	if node.ToolVersion == latestVersion {
+		return "", false
+	}
+
+	if _, err := registry.GetVersion(toolCode, node.ToolVersion); err == nil {
+		return fmt.Sprintf("工具 %s 有新版本 %s（当前固定 %s）", toolCode, latestVersion, node.ToolVersion), false
+	}
+	if registry.CanMigrate(toolCode, node.ToolVersion, latestVersion) {
+		return fmt.Sprintf("工具 %s 版本 %s 已移除，执行时将自动迁移到 %s", toolCode, node.ToolVersion, latestVersion), false
+	}
+	return fmt.Sprintf("工具 %s 版本 %s 已移除，且无法迁移到 %s", toolCode, node.ToolVersion, latestVersion), true
+}
+
+// UpgradeToolVersions 将工作流中固定了旧版本的工具节点升级到最新版本，并按迁移更新节点配置。
+// 任一节点迁移失败时不做任何修改
+func (s *WorkflowService) UpgradeToolVersions(workflowID, userID string) ([]response.ToolVersionUpgrade, error) {
+	wf, err := s.GetWorkflowByID(workflowID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := utools.GetRegistry()
+	nodes := make([]models.WorkflowNode, len(wf.Nodes))
+	copy(nodes, wf.Nodes)
+
+	upgrades := make([]response.ToolVersionUpgrade, 0)
+	for i, node := range nodes {
+		if node.Type != "tool" || node.ToolVersion == "" {
+			continue
+		}
+		toolCode := nodeToolCode(node)
+		latest, err := registry.Get(toolCode)
+		if err != nil {
+			continue
+		}
+		latestVersion := latest.GetMetadata().Version
+		if utools.CompareVersions(node.ToolVersion, latestVersion) >= 0 {
+			continue
+		}
+
+		config, err := registry.MigrateConfig(toolCode, node.ToolVersion, latestVersion, node.Config)
+		if err != nil {
+			// 没有迁移时，仅当旧版本配置可直接通过新版本校验才升级
+			if _, validateErr := utools.CoerceConfig(latest.GetSchema(), node.Config); validateErr != nil {
+				return nil, fmt.Errorf("节点 %s 无法升级到 %s %s: %w", node.ID, toolCode, latestVersion, err)
+			}
+			config = node.Config
+		}
+
+		nodes[i].Config = config
+		nodes[i].ToolVersion = latestVersion
+		upgrades = append(upgrades, response.ToolVersionUpgrade{
+			NodeID:      node.ID,
+			NodeName:    node.Name,
+			ToolCode:    toolCode,
+			FromVersion: node.ToolVersion,
+			ToVersion:   latestVersion,
+		})
+	}
+
+	if len(upgrades) == 0 {
+		return upgrades, nil
+	}
+	if err := database.GetDB().Model(wf).Update("nodes", models.WorkflowNodes(nodes)).Error; err != nil {
+		return nil, fmt.Errorf("保存工作流失败: %w", err)
+	}
+	return upgrades, nil
+}
@@ -0,0 +1,239 @@
+package workflow
+
+import (
+	"auto-forge/internal/models"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Webhook 签名校验方式
+const (
+	WebhookAuthNone   = "none"   // 不校验
+	WebhookAuthHMAC   = "hmac"   // 通用 HMAC 签名
+	WebhookAuthGitHub = "github" // GitHub X-Hub-Signature-256
+	WebhookAuthGitLab = "gitlab" // GitLab X-Gitlab-Token
+	WebhookAuthStripe = "stripe" // Stripe Stripe-Signature
+)
+
+// Webhook 响应模式
+const (
+	WebhookResponseImmediate = "immediate" // 立即返回执行ID
+	WebhookResponseSync      = "sync"      // 等待执行完成后返回最终输出
+)
+
+// stripeSignatureTolerance Stripe 签名时间戳允许的最大偏差
+const stripeSignatureTolerance = 5 * time.Minute
+
+// WebhookTriggerConfig 入站 Webhook 触发器节点配置
+type WebhookTriggerConfig struct {
+	AuthType        string
+	Secret          string
+	SignatureHeader string
+	Algorithm       string
+	Encoding        string
+	SignaturePrefix string
+	ResponseMode    string
+}
+
+// ParseWebhookTriggerConfig 从节点配置解析 Webhook 触发器配置，并填充默认值
+func ParseWebhookTriggerConfig(config map[string]interface{}) *WebhookTriggerConfig {
+	cfg := &WebhookTriggerConfig{
+		AuthType:        getStringValue(config, "auth_type"),
+		Secret:          getStringValue(config, "secret"),
+		SignatureHeader: getStringValue(config, "signature_header"),
+		Algorithm:       strings.ToLower(getStringValue(config, "algorithm")),
+		Encoding:        strings.ToLower(getStringValue(config, "encoding")),
+		SignaturePrefix: getStringValue(config, "signature_prefix"),
+		ResponseMode:    getStringValue(config, "response_mode"),
+	}
+
+	if cfg.AuthType == "" {
+		cfg.AuthType = WebhookAuthNone
+	}
+	if cfg.ResponseMode == "" {
+		cfg.ResponseMode = WebhookResponseImmediate
+	}
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = "sha256"
+	}
+	if cfg.Encoding == "" {
+		cfg.Encoding = "hex"
+	}
+	if cfg.SignatureHeader == "" && cfg.AuthType == WebhookAuthHMAC {
+		cfg.SignatureHeader = "X-Signature"
+	}
+
+	return cfg
+}
+
+// ValidateWebhookTriggerConfig 校验 Webhook 触发器节点配置
+func ValidateWebhookTriggerConfig(config map[string]interface{}) error {
+	cfg := ParseWebhookTriggerConfig(config)
+
+	switch cfg.AuthType {
+	case WebhookAuthNone:
+	case WebhookAuthHMAC, WebhookAuthGitHub, WebhookAuthGitLab, WebhookAuthStripe:
+		if cfg.Secret == "" {
+			return errors.New("启用签名校验时必须配置密钥")
+		}
+	default:
+		return fmt.Errorf("不支持的签名校验方式: %s", cfg.AuthType)
+	}
+
+	if cfg.Algorithm != "sha256" && cfg.Algorithm != "sha1" {
+		return fmt.Errorf("不支持的签名算法: %s", cfg.Algorithm)
+	}
+	if cfg.Encoding != "hex" && cfg.Encoding != "base64" {
+		return fmt.Errorf("不支持的签名编码: %s", cfg.Encoding)
+	}
+	if cfg.ResponseMode != WebhookResponseImmediate && cfg.ResponseMode != WebhookResponseSync {
+		return fmt.Errorf("不支持的响应模式: %s", cfg.ResponseMode)
+	}
+
+	return nil
+}
+
+// ResolveWebhookSecret 解析密钥，支持 {{env.xxx}} 引用工作流环境变量
+func ResolveWebhookSecret(secret string, envVars []models.WorkflowEnvVar) string {
+	ref := strings.TrimSpace(secret)
+	if !strings.HasPrefix(ref, "{{env.") || !strings.HasSuffix(ref, "}}") {
+		return secret
+	}
+
+	key := strings.TrimSuffix(strings.TrimPrefix(ref, "{{env."), "}}")
+	for _, envVar := range envVars {
+		if envVar.Key == key {
+			return envVar.Value
+		}
+	}
+	return ""
+}
+
+// VerifyWebhookSignature 按触发器配置校验请求签名
+func VerifyWebhookSignature(cfg *WebhookTriggerConfig, secret string, headers http.Header, body []byte) error {
+	if cfg.AuthType == WebhookAuthNone {
+		return nil
+	}
+	// 密钥引用的环境变量不存在时解析为空，空密钥的签名任何人都能伪造
+	if secret == "" {
+		return errors.New("签名密钥为空，请检查密钥引用的环境变量")
+	}
+
+	switch cfg.AuthType {
+
+	case WebhookAuthGitLab:
+		token := headers.Get("X-Gitlab-Token")
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			return errors.New("X-Gitlab-Token 校验失败")
+		}
+		return nil
+
+	case WebhookAuthGitHub:
+		signature := headers.Get("X-Hub-Signature-256")
+		if signature == "" {
+			return errors.New("缺少 X-Hub-Signature-256 请求头")
+		}
+		expected := "sha256=" + hex.EncodeToString(computeHMAC(sha256.New, secret, body))
+		if !hmac.Equal([]byte(signature), []byte(expected)) {
+			return errors.New("签名校验失败")
+		}
+		return nil
+
+	case WebhookAuthStripe:
+		return verifyStripeSignature(headers.Get("Stripe-Signature"), secret, body, time.Now())
+
+	case WebhookAuthHMAC:
+		signature := strings.TrimSpace(headers.Get(cfg.SignatureHeader))
+		if signature == "" {
+			return fmt.Errorf("缺少签名请求头: %s", cfg.SignatureHeader)
+		}
+		if cfg.SignaturePrefix != "" {
+			if !strings.HasPrefix(signature, cfg.SignaturePrefix) {
+				return errors.New("签名前缀不匹配")
+			}
+			signature = strings.TrimPrefix(signature, cfg.SignaturePrefix)
+		}
+
+		hashFunc := sha256.New
+		if cfg.Algorithm == "sha1" {
+			hashFunc = sha1.New
+		}
+		mac := computeHMAC(hashFunc, secret, body)
+
+		var expected string
+		if cfg.Encoding == "base64" {
+			expected = base64.StdEncoding.EncodeToString(mac)
+		} else {
+			expected = hex.EncodeToString(mac)
+			signature = strings.ToLower(signature)
+		}
+		if !hmac.Equal([]byte(signature), []byte(expected)) {
+			return errors.New("签名校验失败")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("不支持的签名校验方式: %s", cfg.AuthType)
+	}
+}
+
+// verifyStripeSignature 校验 Stripe 风格签名：t=<timestamp>,v1=<hex(hmac(t.body))>
+func verifyStripeSignature(header, secret string, body []byte, now time.Time) error {
+	if header == "" {
+		return errors.New("缺少 Stripe-Signature 请求头")
+	}
+
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case "v1":
+			signatures = append(signatures, kv[1])
+		}
+	}
+
+	if timestamp == "" || len(signatures) == 0 {
+		return errors.New("Stripe-Signature 格式错误")
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("Stripe-Signature 时间戳无效")
+	}
+	if diff := now.Sub(time.Unix(ts, 0)); diff > stripeSignatureTolerance || diff < -stripeSignatureTolerance {
+		return errors.New("Stripe-Signature 时间戳超出允许范围")
+	}
+
+	payload := append([]byte(timestamp+"."), body...)
+	expected := hex.EncodeToString(computeHMAC(sha256.New, secret, payload))
+	for _, sig := range signatures {
+		if hmac.Equal([]byte(sig), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return errors.New("签名校验失败")
+}
+
+func computeHMAC(hashFunc func() hash.Hash, secret string, data []byte) []byte {
+	mac := hmac.New(hashFunc, []byte(secret))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
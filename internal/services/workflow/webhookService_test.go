@@ -0,0 +1,81 @@
+package workflow
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func sign(secret string, data []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyWebhookSignature_GitHub(t *testing.T) {
+	body := []byte(`{"action":"opened"}`)
+	cfg := ParseWebhookTriggerConfig(map[string]interface{}{"auth_type": "github", "secret": "s3cret"})
+
+	headers := http.Header{}
+	headers.Set("X-Hub-Signature-256", "sha256="+sign("s3cret", body))
+	if err := VerifyWebhookSignature(cfg, "s3cret", headers, body); err != nil {
+		t.Fatalf("expected valid signature, got: %v", err)
+	}
+
+	headers.Set("X-Hub-Signature-256", "sha256="+sign("other", body))
+	if err := VerifyWebhookSignature(cfg, "s3cret", headers, body); err == nil {
+		t.Fatal("expected signature mismatch")
+	}
+}
+
+func TestVerifyWebhookSignature_HMACWithPrefix(t *testing.T) {
+	body := []byte("hello")
+	cfg := ParseWebhookTriggerConfig(map[string]interface{}{
+		"auth_type":        "hmac",
+		"secret":           "key",
+		"signature_header": "X-Sig",
+		"signature_prefix": "v1=",
+	})
+
+	headers := http.Header{}
+	headers.Set("X-Sig", "v1="+sign("key", body))
+	if err := VerifyWebhookSignature(cfg, "key", headers, body); err != nil {
+		t.Fatalf("expected valid signature, got: %v", err)
+	}
+
+	headers.Set("X-Sig", sign("key", body))
+	if err := VerifyWebhookSignature(cfg, "key", headers, body); err == nil {
+		t.Fatal("expected prefix mismatch")
+	}
+}
+
+func TestVerifyWebhookSignature_EmptySecret(t *testing.T) {
+	body := []byte("hello")
+	cfg := ParseWebhookTriggerConfig(map[string]interface{}{"auth_type": "hmac", "secret": "{{env.MISSING}}"})
+	secret := ResolveWebhookSecret(cfg.Secret, nil)
+
+	// 以空密钥签名的请求不能通过校验
+	headers := http.Header{}
+	headers.Set("X-Signature", sign("", body))
+	if err := VerifyWebhookSignature(cfg, secret, headers, body); err == nil {
+		t.Fatal("expected empty secret to be rejected")
+	}
+}
+
+func TestVerifyStripeSignature(t *testing.T) {
+	body := []byte(`{"id":"evt_1"}`)
+	now := time.Unix(1700000000, 0)
+	ts := fmt.Sprintf("%d", now.Unix())
+	header := fmt.Sprintf("t=%s,v1=%s", ts, sign("whsec", []byte(ts+"."+string(body))))
+
+	if err := verifyStripeSignature(header, "whsec", body, now); err != nil {
+		t.Fatalf("expected valid signature, got: %v", err)
+	}
+	if err := verifyStripeSignature(header, "whsec", body, now.Add(10*time.Minute)); err == nil {
+		t.Fatal("expected timestamp outside tolerance to fail")
+	}
+}
@@ -34,6 +34,9 @@ func (s *WorkflowService) CreateWorkflow(userID string, req *request.CreateWorkf
 	if err := s.ValidateWorkflowConfig(req.Nodes, req.Edges); err != nil {
 		return nil, fmt.Errorf("工作流配置无效: %w", err)
 	}
+	if err := s.validateChainTrigger("", userID, req.Nodes, req.Edges); err != nil {
+		return nil, fmt.Errorf("工作流配置无效: %w", err)
+	}
 
 	var nextRunTime *int64
 	if req.Enabled && req.ScheduleType != "" && req.ScheduleType != "manual" {
@@ -45,6 +48,7 @@ func (s *WorkflowService) CreateWorkflow(userID string, req *request.CreateWorkf
 	if err != nil {
 		return nil, fmt.Errorf("提取外部触发器参数失败: %w", err)
 	}
+	pinToolVersions(req.Nodes)
 
 	workflow := &models.Workflow{
 		UserID:        userID,
@@ -67,6 +71,12 @@ func (s *WorkflowService) CreateWorkflow(userID string, req *request.CreateWorkf
 		}
 	}
 
+	if workflow.WebhookToken == "" {
+		if token, err := utils.GenerateWorkflowWebhookToken(); err == nil {
+			workflow.WebhookToken = token
+		}
+	}
+
 	if err := db.Create(workflow).Error; err != nil {
 		log.Error("创建工作流失败: %v", err)
 		return nil, err
@@ -168,6 +178,10 @@ func (s *WorkflowService) UpdateWorkflow(workflowID, userID string, req *request
 		if err := s.ValidateWorkflowConfig(*req.Nodes, edges); err != nil {
 			return nil, fmt.Errorf("工作流配置无效: %w", err)
 		}
+		if err := s.validateChainTrigger(workflowID, userID, *req.Nodes, edges); err != nil {
+			return nil, fmt.Errorf("工作流配置无效: %w", err)
+		}
+		pinToolVersions(*req.Nodes)
 		updates["nodes"] = models.WorkflowNodes(*req.Nodes)
 
 		apiParams, err := s.ExtractExternalTriggerParams(*req.Nodes, edges)
@@ -184,6 +198,9 @@ func (s *WorkflowService) UpdateWorkflow(workflowID, userID string, req *request
 		if err := s.ValidateWorkflowConfig(nodes, *req.Edges); err != nil {
 			return nil, fmt.Errorf("工作流配置无效: %w", err)
 		}
+		if err := s.validateChainTrigger(workflowID, userID, nodes, *req.Edges); err != nil {
+			return nil, fmt.Errorf("工作流配置无效: %w", err)
+		}
 		updates["edges"] = models.WorkflowEdges(*req.Edges)
 
 		if req.Nodes == nil {
@@ -258,6 +275,11 @@ func (s *WorkflowService) DeleteWorkflow(workflowID, userID string) error {
 		return err
 	}
 
+	// 同时删除以该工作流为载体的任务
+	if err := db.Where("workflow_id = ?", workflowID).Delete(&models.Task{}).Error; err != nil {
+		log.Warn("删除工作流关联任务失败: WorkflowID=%s, Error=%v", workflowID, err)
+	}
+
 	log.Info("用户 %s 删除工作流: %s (ID: %s)", userID, workflow.Name, workflow.ID)
 
 	s.reloadScheduler()
@@ -315,31 +337,44 @@ func (s *WorkflowService) ValidateWorkflowConfig(nodes []models.WorkflowNode, ed
 		}
 	}
 
-	return nil
-}
-
-func (s *WorkflowService) ExtractExternalTriggerParams(nodes []models.WorkflowNode, edges []models.WorkflowEdge) (models.WorkflowAPIParams, error) {
-
-	targetNodes := make(map[string]bool)
-	for _, edge := range edges {
-		targetNodes[edge.Target] = true
-	}
-
-	var startNodes []models.WorkflowNode
+	webhookTriggers := 0
+	pollTriggers := 0
+	workflowTriggers := 0
 	for _, node := range nodes {
-		if !targetNodes[node.ID] {
-			startNodes = append(startNodes, node)
+		switch node.Type {
+		case "webhook_trigger":
+			webhookTriggers++
+			if webhookTriggers > 1 {
+				return errors.New("每个工作流只能包含一个 Webhook 触发器")
+			}
+			if err := ValidateWebhookTriggerConfig(node.Config); err != nil {
+				return fmt.Errorf("Webhook 触发器 %s 配置无效: %w", node.ID, err)
+			}
+		case "poll_trigger":
+			pollTriggers++
+			if pollTriggers > 1 {
+				return errors.New("每个工作流只能包含一个轮询触发器")
+			}
+			if err := ValidatePollTriggerConfig(node.Config); err != nil {
+				return fmt.Errorf("轮询触发器 %s 配置无效: %w", node.ID, err)
+			}
+		case "workflow_trigger":
+			workflowTriggers++
+			if workflowTriggers > 1 {
+				return errors.New("每个工作流只能包含一个工作流完成触发器")
+			}
+			if err := ValidateWorkflowTriggerConfig(node.Config); err != nil {
+				return fmt.Errorf("工作流完成触发器 %s 配置无效: %w", node.ID, err)
+			}
 		}
 	}
 
-	var externalTriggerNode *models.WorkflowNode
-	for _, node := range startNodes {
-		if node.Type == "external_trigger" {
-			externalTriggerNode = &node
-			break
-		}
-	}
+	return nil
+}
+
+func (s *WorkflowService) ExtractExternalTriggerParams(nodes []models.WorkflowNode, edges []models.WorkflowEdge) (models.WorkflowAPIParams, error) {
 
+	externalTriggerNode := findStartNodeByType(nodes, edges, "external_trigger")
 	if externalTriggerNode == nil {
 		return models.WorkflowAPIParams{}, nil
 	}
@@ -385,6 +420,28 @@ func (s *WorkflowService) ExtractExternalTriggerParams(nodes []models.WorkflowNo
 	return params, nil
 }
 
+// FindWebhookTriggerNode 查找作为起始节点的入站 Webhook 触发器
+func (s *WorkflowService) FindWebhookTriggerNode(nodes []models.WorkflowNode, edges []models.WorkflowEdge) *models.WorkflowNode {
+	return findStartNodeByType(nodes, edges, "webhook_trigger")
+}
+
+// findStartNodeByType 在起始节点（没有入边的节点）中查找指定类型的节点
+func findStartNodeByType(nodes []models.WorkflowNode, edges []models.WorkflowEdge, nodeType string) *models.WorkflowNode {
+	targetNodes := make(map[string]bool)
+	for _, edge := range edges {
+		targetNodes[edge.Target] = true
+	}
+
+	for i := range nodes {
+		if !targetNodes[nodes[i].ID] && nodes[i].Type == nodeType {
+			node := nodes[i]
+			return &node
+		}
+	}
+
+	return nil
+}
+
 func getStringValue(m map[string]interface{}, key string) string {
 	if val, ok := m[key]; ok {
 		if str, ok := val.(string); ok {
@@ -454,6 +511,8 @@ func (s *WorkflowService) toWorkflowResponse(workflow *models.Workflow) response
 		APIParams:       workflow.APIParams,
 		APITimeout:      workflow.APITimeout,
 		APIWebhookURL:   workflow.APIWebhookURL,
+		AgentCallable:   workflow.AgentCallable,
+		WebhookToken:    workflow.WebhookToken,
 		TotalExecutions: workflow.TotalExecutions,
 		SuccessCount:    workflow.SuccessCount,
 		FailedCount:     workflow.FailedCount,
@@ -832,13 +891,18 @@ func validateParamType(value interface{}, expectedType string) error {
 }
 
 func (s *WorkflowService) ExecuteWorkflowSync(executionID, userID string, timeoutSeconds int, externalParams map[string]interface{}) (map[string]interface{}, error) {
+	return s.ExecuteWorkflowSyncWithTrigger(executionID, userID, timeoutSeconds, externalParams, nil)
+}
+
+// ExecuteWorkflowSyncWithTrigger 同步执行工作流并注入触发器数据，返回最后一个节点的输出
+func (s *WorkflowService) ExecuteWorkflowSyncWithTrigger(executionID, userID string, timeoutSeconds int, externalParams map[string]interface{}, triggerData map[string]interface{}) (map[string]interface{}, error) {
 	engineSvc := NewEngineService()
 	executionSvc := NewExecutionService()
 
 	done := make(chan error, 1)
 
 	go func() {
-		err := engineSvc.ExecuteWorkflow(executionID, nil, externalParams)
+		err := engineSvc.ExecuteWorkflowWithTrigger(executionID, nil, externalParams, triggerData)
 		done <- err
 	}()
 
@@ -871,6 +935,41 @@ func (s *WorkflowService) ExecuteWorkflowSync(executionID, userID string, timeou
 	}
 }
 
+func (s *WorkflowService) GetWorkflowByWebhookToken(token string) (*models.Workflow, error) {
+	db := database.GetDB()
+
+	var workflow models.Workflow
+	if err := db.Where("webhook_token = ?", token).First(&workflow).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("无效的 Webhook 地址")
+		}
+		return nil, err
+	}
+
+	return &workflow, nil
+}
+
+func (s *WorkflowService) RegenerateWebhookToken(workflowID, userID string) (string, error) {
+	db := database.GetDB()
+
+	var workflow models.Workflow
+	if err := db.Where("id = ? AND user_id = ?", workflowID, userID).First(&workflow).Error; err != nil {
+		return "", fmt.Errorf("工作流不存在")
+	}
+
+	token, err := utils.GenerateWorkflowWebhookToken()
+	if err != nil {
+		return "", fmt.Errorf("生成 Webhook 令牌失败: %w", err)
+	}
+
+	if err := db.Model(&workflow).Update("webhook_token", token).Error; err != nil {
+		return "", fmt.Errorf("更新 Webhook 令牌失败: %w", err)
+	}
+
+	log.Info("工作流 Webhook 令牌已重新生成: WorkflowID=%s", workflowID)
+	return token, nil
+}
+
 func (s *WorkflowService) IncrementAPICallCount(workflowID string) error {
 	db := database.GetDB()
 	now := time.Now().Unix()
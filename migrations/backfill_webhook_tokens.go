@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"auto-forge/internal/models"
+	log "auto-forge/pkg/logger"
+	"auto-forge/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// BackfillWebhookTokens 为已有工作流生成入站 Webhook 令牌
+func BackfillWebhookTokens(db *gorm.DB) error {
+	var workflows []models.Workflow
+	if err := db.Select("id").Where("webhook_token IS NULL OR webhook_token = ?", "").Find(&workflows).Error; err != nil {
+		return err
+	}
+
+	for _, wf := range workflows {
+		token, err := utils.GenerateWorkflowWebhookToken()
+		if err != nil {
+			return err
+		}
+		if err := db.Model(&models.Workflow{}).Where("id = ?", wf.GetID()).Update("webhook_token", token).Error; err != nil {
+			return err
+		}
+	}
+
+	log.Info("已为 %d 个工作流生成 Webhook 令牌", len(workflows))
+	return nil
+}
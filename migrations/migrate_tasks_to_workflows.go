@@ -0,0 +1,136 @@
+package migrations
+
+import (
+	"auto-forge/internal/models"
+	log "auto-forge/pkg/logger"
+	"auto-forge/pkg/utils"
+	"encoding/json"
+
+	"gorm.io/gorm"
+)
+
+// MigrateTasksToWorkflows 将已有定时任务转换为单节点工作流，并迁移其执行记录
+func MigrateTasksToWorkflows(db *gorm.DB) error {
+	var tasks []models.Task
+	if err := db.Where("workflow_id IS NULL OR workflow_id = ?", "").Find(&tasks).Error; err != nil {
+		return err
+	}
+
+	migratedExecutions := 0
+	for i := range tasks {
+		task := &tasks[i]
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			wf, err := buildTaskWorkflow(task)
+			if err != nil {
+				log.Warn("任务 %s 配置解析失败，按空配置迁移: %v", task.GetID(), err)
+				task.Config = "{}"
+				if wf, err = buildTaskWorkflow(task); err != nil {
+					return err
+				}
+			}
+			if err := tx.Create(wf).Error; err != nil {
+				return err
+			}
+
+			var executions []models.TaskExecution
+			if err := tx.Where("task_id = ?", task.GetID()).Find(&executions).Error; err != nil {
+				return err
+			}
+			for j := range executions {
+				if err := tx.Create(convertTaskExecution(task, wf, &executions[j])).Error; err != nil {
+					return err
+				}
+			}
+			migratedExecutions += len(executions)
+
+			return tx.Model(&models.Task{}).Where("id = ?", task.GetID()).Update("workflow_id", wf.GetID()).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Info("已将 %d 个任务迁移为工作流，迁移执行记录 %d 条", len(tasks), migratedExecutions)
+	return nil
+}
+
+func buildTaskWorkflow(task *models.Task) (*models.Workflow, error) {
+	nodes, err := task.WorkflowNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	wf := &models.Workflow{
+		UserID:        task.UserID,
+		Name:          task.Name,
+		Description:   task.Description,
+		Nodes:         nodes,
+		Edges:         models.WorkflowEdges{},
+		EnvVars:       models.WorkflowEnvVars{},
+		ScheduleType:  task.ScheduleType,
+		ScheduleValue: task.ScheduleValue,
+		Enabled:       task.Enabled,
+		NextRunTime:   task.NextRunTime,
+	}
+
+	if key, err := utils.GenerateWorkflowAPIKey(); err == nil {
+		wf.APIKey = key
+	}
+	if token, err := utils.GenerateWorkflowWebhookToken(); err == nil {
+		wf.WebhookToken = token
+	}
+
+	return wf, nil
+}
+
+// convertTaskExecution 将旧的 HTTP 风格执行记录转换为单节点工作流执行记录，保留原记录ID
+func convertTaskExecution(task *models.Task, wf *models.Workflow, te *models.TaskExecution) *models.WorkflowExecution {
+	status := models.ExecutionStatusFailed
+	successNodes, failedNodes := 0, 1
+	if te.Status == "success" {
+		status = models.ExecutionStatusSuccess
+		successNodes, failedNodes = 1, 0
+	}
+
+	startTime := te.StartedAt
+	endTime := te.CompletedAt
+
+	output := map[string]interface{}{
+		"status_code": te.ResponseStatus,
+		"body":        te.ResponseBody,
+	}
+	var jsonBody map[string]interface{}
+	if err := json.Unmarshal([]byte(te.ResponseBody), &jsonBody); err == nil {
+		output["json"] = jsonBody
+	}
+
+	return &models.WorkflowExecution{
+		BaseModel:    te.BaseModel,
+		WorkflowID:   wf.GetID(),
+		UserID:       te.UserID,
+		Status:       status,
+		TriggerType:  "scheduled",
+		StartTime:    &startTime,
+		EndTime:      &endTime,
+		DurationMs:   te.DurationMs,
+		TotalNodes:   1,
+		SuccessNodes: successNodes,
+		FailedNodes:  failedNodes,
+		Error:        te.ErrorMessage,
+		NodeLogs: models.NodeExecutionLogs{
+			{
+				NodeID:     models.TaskToolNodeID,
+				NodeType:   "tool",
+				NodeName:   task.Name,
+				Status:     status,
+				StartTime:  &startTime,
+				EndTime:    &endTime,
+				DurationMs: te.DurationMs,
+				Output:     output,
+				Error:      te.ErrorMessage,
+				ToolCode:   task.ToolCode,
+			},
+		},
+	}
+}
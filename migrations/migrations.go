@@ -15,7 +15,9 @@ type migrationTask struct {
 
 // 注册的迁移列表
 var registeredMigrations = []migrationTask{
-	{"add_default_categories", AddDefaultCategories}, // 添加默认模板分类
+	{"add_default_categories", AddDefaultCategories},        // 添加默认模板分类
+	{"backfill_webhook_tokens", BackfillWebhookTokens},      // 为已有工作流生成 Webhook 令牌
+	{"migrate_tasks_to_workflows", MigrateTasksToWorkflows}, // 将定时任务转换为单节点工作流
 }
 
 // RunAllMigrations 执行所有迁移
@@ -0,0 +1,291 @@
+package attachment
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/pkg/agent/llm"
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// 文件类型
+const (
+	KindImage = "image"
+	KindPDF   = "pdf"
+	KindCSV   = "csv"
+	KindText  = "text"
+	KindOther = "other"
+)
+
+// textExtensions 按纯文本读取的扩展名
+var textExtensions = map[string]bool{
+	".txt": true, ".md": true, ".markdown": true, ".json": true, ".yaml": true, ".yml": true,
+	".xml": true, ".html": true, ".htm": true, ".log": true, ".ini": true, ".toml": true,
+	".sql": true, ".go": true, ".py": true, ".js": true, ".ts": true, ".java": true, ".sh": true,
+}
+
+// imageMimeTypes 可直接发送给视觉模型的图片类型
+var imageMimeTypes = map[string]bool{
+	"image/png": true, "image/jpeg": true, "image/gif": true, "image/webp": true,
+}
+
+// Options 附件处理选项
+type Options struct {
+	SupportVision    bool  // 模型是否支持图片输入
+	MaxContextTokens int   // 文件文本可占用的 token 数（所有文件合计）
+	ChunkChars       int   // 分段大小（字节数）
+	MaxImageBytes    int64 // 单张图片的最大字节数
+	MaxCSVRows       int   // CSV 最多读取的行数
+}
+
+// DefaultOptions 默认附件处理选项
+func DefaultOptions() Options {
+	return Options{
+		MaxContextTokens: 8000,
+		ChunkChars:       4000,
+		MaxImageBytes:    5 << 20,
+		MaxCSVRows:       200,
+	}
+}
+
+// Kind 判断文件类型
+func Kind(file models.AgentFile) string {
+	mimeType := strings.ToLower(strings.TrimSpace(strings.Split(file.MimeType, ";")[0]))
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if ext == "" {
+		ext = strings.ToLower(filepath.Ext(file.Path))
+	}
+
+	switch {
+	case imageMimeTypes[mimeType] || ext == ".png" || ext == ".jpg" || ext == ".jpeg" || ext == ".gif" || ext == ".webp":
+		return KindImage
+	case mimeType == "application/pdf" || ext == ".pdf":
+		return KindPDF
+	case mimeType == "text/csv" || ext == ".csv":
+		return KindCSV
+	case strings.HasPrefix(mimeType, "text/") || mimeType == "application/json" || textExtensions[ext]:
+		return KindText
+	}
+	return KindOther
+}
+
+// ToolFileObject 文件在工具参数中的表示（与工作流文件参数格式一致）
+func ToolFileObject(file models.AgentFile) map[string]interface{} {
+	return map[string]interface{}{
+		"type":      "file",
+		"path":      file.Path,
+		"filename":  file.Filename,
+		"size":      file.Size,
+		"mime_type": file.MimeType,
+	}
+}
+
+// BuildMessage 将用户上传的文件转换为一条用户消息
+// 文本类文件（文本、PDF、CSV）提取后分段放入上下文；图片在模型支持视觉时作为图片片段发送；
+// 所有文件都附带工具可用的文件对象，供 aliyun_oss、tencent_cos、pixelpunk_upload 等工具作为 file 参数使用
+func BuildMessage(files []models.AgentFile, opts Options) *llm.Message {
+	if len(files) == 0 {
+		return nil
+	}
+	defaults := DefaultOptions()
+	if opts.ChunkChars <= 0 {
+		opts.ChunkChars = defaults.ChunkChars
+	}
+	if opts.MaxImageBytes <= 0 {
+		opts.MaxImageBytes = defaults.MaxImageBytes
+	}
+	if opts.MaxCSVRows <= 0 {
+		opts.MaxCSVRows = defaults.MaxCSVRows
+	}
+
+	var builder strings.Builder
+	var images []llm.ContentPart
+
+	builder.WriteString("The user attached the following files. To pass a file to a tool (e.g. aliyun_oss, tencent_cos, pixelpunk_upload), use its file object as the `file` argument.\n")
+	for i, file := range files {
+		object, _ := json.Marshal(ToolFileObject(file))
+		fmt.Fprintf(&builder, "%d. %s: %s\n", i+1, file.Filename, object)
+	}
+
+	remaining := opts.MaxContextTokens * 4 // 约 4 个字符 1 个 token
+	for _, file := range files {
+		kind := Kind(file)
+
+		if kind == KindImage {
+			part, note := loadImage(file, opts)
+			if part != nil {
+				images = append(images, *part)
+			}
+			if note != "" {
+				fmt.Fprintf(&builder, "\n[%s] %s\n", file.Filename, note)
+			}
+			continue
+		}
+		if kind == KindOther {
+			continue
+		}
+
+		text, err := extractText(file, kind, opts)
+		if err != nil {
+			fmt.Fprintf(&builder, "\n[%s] 无法读取文件内容: %v\n", file.Filename, err)
+			continue
+		}
+
+		chunks := SplitText(text, opts.ChunkChars)
+		included := 0
+		for i, chunk := range chunks {
+			if remaining < len(chunk) {
+				break
+			}
+			remaining -= len(chunk)
+			included++
+			fmt.Fprintf(&builder, "\n--- %s (part %d/%d) ---\n%s\n", file.Filename, i+1, len(chunks), chunk)
+		}
+		if included < len(chunks) {
+			fmt.Fprintf(&builder, "\n[%s] 文件较长，上下文中仅包含前 %d/%d 段；可通过工具处理完整文件。\n", file.Filename, included, len(chunks))
+		}
+	}
+
+	return &llm.Message{
+		Role:    "user",
+		Content: strings.TrimSpace(builder.String()),
+		Parts:   images,
+	}
+}
+
+// loadImage 读取图片；模型不支持视觉或图片过大时返回说明
+func loadImage(file models.AgentFile, opts Options) (*llm.ContentPart, string) {
+	if !opts.SupportVision {
+		return nil, "当前模型不支持图片输入，无法查看图片内容，只能通过工具处理该文件。"
+	}
+
+	data, err := os.ReadFile(file.Path)
+	if err != nil {
+		return nil, fmt.Sprintf("无法读取图片: %v", err)
+	}
+	if int64(len(data)) > opts.MaxImageBytes {
+		return nil, fmt.Sprintf("图片超过 %d MB，未发送给模型，只能通过工具处理该文件。", opts.MaxImageBytes>>20)
+	}
+
+	mimeType := http.DetectContentType(data)
+	if !imageMimeTypes[mimeType] {
+		return nil, fmt.Sprintf("不支持的图片格式 %s，只能通过工具处理该文件。", mimeType)
+	}
+
+	return &llm.ContentPart{
+		Type:     "image",
+		MimeType: mimeType,
+		Data:     base64.StdEncoding.EncodeToString(data),
+	}, ""
+}
+
+// extractText 提取文本类文件的内容
+func extractText(file models.AgentFile, kind string, opts Options) (string, error) {
+	data, err := os.ReadFile(file.Path)
+	if err != nil {
+		return "", err
+	}
+
+	switch kind {
+	case KindPDF:
+		return ExtractPDFText(data)
+	case KindCSV:
+		return formatCSV(data, opts.MaxCSVRows)
+	}
+
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("文件不是 UTF-8 文本")
+	}
+	return normalizeText(string(data)), nil
+}
+
+// formatCSV 将 CSV 转换为以 | 分隔的文本表格，超出行数时注明总行数
+func formatCSV(data []byte, maxRows int) (string, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return "", fmt.Errorf("解析 CSV 失败: %w", err)
+	}
+
+	var builder strings.Builder
+	for i, record := range records {
+		if i > maxRows {
+			fmt.Fprintf(&builder, "...（共 %d 行数据，仅展示前 %d 行）\n", len(records)-1, maxRows)
+			break
+		}
+		builder.WriteString(strings.Join(record, " | "))
+		builder.WriteString("\n")
+	}
+	return strings.TrimSpace(builder.String()), nil
+}
+
+// SplitText 将文本按行聚合为不超过 chunkChars 字节的片段，单行过长时按字符切分
+func SplitText(text string, chunkChars int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if chunkChars <= 0 || len(text) <= chunkChars {
+		return []string{text}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			chunks = append(chunks, s)
+		}
+		current.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		for len(line) > chunkChars {
+			flush()
+			cut := chunkChars
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			chunks = append(chunks, strings.TrimSpace(line[:cut]))
+			line = line[cut:]
+		}
+		if current.Len()+len(line) > chunkChars {
+			flush()
+		}
+		current.WriteString(line)
+	}
+	flush()
+
+	return chunks
+}
+
+// normalizeText 统一换行并合并多余空行
+func normalizeText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+
+	result := make([]string, 0, len(lines))
+	blank := 0
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			blank++
+			if blank > 1 {
+				continue
+			}
+		} else {
+			blank = 0
+		}
+		result = append(result, line)
+	}
+	return strings.TrimSpace(strings.Join(result, "\n"))
+}
@@ -0,0 +1,122 @@
+package attachment
+
+import (
+	"auto-forge/internal/models"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildPDF 生成包含一个 FlateDecode 内容流的最小 PDF
+func buildPDF(t *testing.T, content string) []byte {
+	t.Helper()
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	writer.Write([]byte(content))
+	writer.Close()
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	pdf.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	fmt.Fprintf(&pdf, "4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", compressed.Len())
+	pdf.Write(compressed.Bytes())
+	pdf.WriteString("\nendstream\nendobj\n%%EOF\n")
+	return pdf.Bytes()
+}
+
+func writeFile(t *testing.T, name string, data []byte) models.AgentFile {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	return models.AgentFile{Path: path, Filename: name, Size: int64(len(data))}
+}
+
+func TestExtractPDFText(t *testing.T) {
+	data := buildPDF(t, "BT /F1 12 Tf 72 712 Td (Hello \\(PDF\\)) Tj 0 -14 Td [(Wor) -20 (ld) -300 (again)] TJ ET")
+
+	text, err := ExtractPDFText(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text != "Hello (PDF)\nWorld again" {
+		t.Errorf("unexpected text: %q", text)
+	}
+
+	if _, err := ExtractPDFText([]byte("not a pdf")); err == nil {
+		t.Error("expected error for invalid pdf")
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	text := strings.Repeat("line of text\n", 10) + strings.Repeat("长", 30)
+	chunks := SplitText(text, 40)
+	for _, chunk := range chunks {
+		if len(chunk) > 40 {
+			t.Errorf("chunk exceeds limit: %d bytes", len(chunk))
+		}
+	}
+	if joined := strings.Join(chunks, ""); strings.Count(joined, "长") != 30 {
+		t.Errorf("multibyte characters were split or lost")
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 32)...)
+	files := []models.AgentFile{
+		writeFile(t, "notes.txt", []byte("第一行\n\n\n\n第二行")),
+		writeFile(t, "data.csv", []byte("name,age\nalice,30\nbob,25\n")),
+		writeFile(t, "photo.png", png),
+		writeFile(t, "archive.zip", []byte("PK")),
+	}
+
+	msg := BuildMessage(files, Options{SupportVision: true, MaxContextTokens: 1000})
+	if msg == nil || msg.Role != "user" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	for _, want := range []string{"第一行\n\n第二行", "name | age\nalice | 30", `"path":"` + files[3].Path + `"`, "pixelpunk_upload"} {
+		if !strings.Contains(msg.Content, want) {
+			t.Errorf("message content missing %q:\n%s", want, msg.Content)
+		}
+	}
+	if len(msg.Parts) != 1 || msg.Parts[0].Type != "image" || msg.Parts[0].MimeType != "image/png" {
+		t.Errorf("expected one png image part, got %+v", msg.Parts)
+	}
+
+	noVision := BuildMessage(files[2:3], Options{})
+	if len(noVision.Parts) != 0 || !strings.Contains(noVision.Content, "不支持图片输入") {
+		t.Errorf("image should be described, not sent, for non-vision models: %+v", noVision)
+	}
+}
+
+func TestBuildMessage_ContextBudget(t *testing.T) {
+	file := writeFile(t, "long.md", []byte(strings.Repeat("paragraph text here\n", 200)))
+
+	msg := BuildMessage([]models.AgentFile{file}, Options{MaxContextTokens: 100, ChunkChars: 200})
+	if !strings.Contains(msg.Content, "part 1/") || strings.Contains(msg.Content, "part 3/") {
+		t.Errorf("expected only the first chunks within budget:\n%s", msg.Content)
+	}
+	if !strings.Contains(msg.Content, "仅包含前 2/") {
+		t.Errorf("expected truncation note:\n%s", msg.Content)
+	}
+}
+
+func TestKind(t *testing.T) {
+	cases := map[string]models.AgentFile{
+		KindImage: {Filename: "a.JPG"},
+		KindPDF:   {Filename: "report", MimeType: "application/pdf"},
+		KindCSV:   {Filename: "x.csv"},
+		KindText:  {Filename: "x", MimeType: "text/plain; charset=utf-8"},
+		KindOther: {Filename: "x.bin", MimeType: "application/octet-stream"},
+	}
+	for want, file := range cases {
+		if got := Kind(file); got != want {
+			t.Errorf("Kind(%+v) = %s, want %s", file, got, want)
+		}
+	}
+}
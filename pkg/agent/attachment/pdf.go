@@ -0,0 +1,281 @@
+package attachment
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ExtractPDFText 提取 PDF 中的文本（尽力而为）
+// 解析页面内容流（未压缩或 FlateDecode）中的文本绘制操作符（Tj、TJ、'、"），
+// 不支持扫描件、加密文档以及依赖自定义编码表（CMap）的字体
+func ExtractPDFText(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \r\n\t"), []byte("%PDF")) {
+		return "", fmt.Errorf("不是有效的 PDF 文件")
+	}
+
+	var builder strings.Builder
+	for _, stream := range pdfContentStreams(data) {
+		if text := parsePDFContent(stream); strings.TrimSpace(text) != "" {
+			builder.WriteString(text)
+			builder.WriteString("\n")
+		}
+	}
+
+	text := normalizeText(builder.String())
+	if text == "" {
+		return "", fmt.Errorf("未能从 PDF 中提取文本（可能是扫描件或使用了不支持的字体编码）")
+	}
+	return text, nil
+}
+
+// pdfContentStreams 找出所有可解码的流数据
+func pdfContentStreams(data []byte) [][]byte {
+	var streams [][]byte
+
+	offset := 0
+	for {
+		idx := bytes.Index(data[offset:], []byte("stream"))
+		if idx < 0 {
+			break
+		}
+		keyword := offset + idx
+		offset = keyword + len("stream")
+
+		// 排除 endstream 中的 stream
+		if keyword >= 3 && string(data[keyword-3:keyword]) == "end" {
+			continue
+		}
+
+		start := offset
+		if start < len(data) && data[start] == '\r' {
+			start++
+		}
+		if start < len(data) && data[start] == '\n' {
+			start++
+		}
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		end += start
+		offset = end + len("endstream")
+
+		dictStart := bytes.LastIndex(data[:keyword], []byte("obj"))
+		if dictStart < 0 {
+			dictStart = 0
+		}
+		dict := string(data[dictStart:keyword])
+		if strings.Contains(dict, "/Image") || strings.Contains(dict, "/XRef") || strings.Contains(dict, "/ObjStm") {
+			continue
+		}
+
+		raw := bytes.TrimRight(data[start:end], "\r\n")
+		switch {
+		case strings.Contains(dict, "/FlateDecode"):
+			if decoded, ok := inflate(raw); ok {
+				streams = append(streams, decoded)
+			}
+		case !strings.Contains(dict, "/Filter"):
+			streams = append(streams, raw)
+		}
+	}
+
+	return streams
+}
+
+// inflate 解压 FlateDecode 数据，截断的数据返回已解压部分
+func inflate(data []byte) ([]byte, bool) {
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, false
+	}
+	defer reader.Close()
+
+	decoded, err := io.ReadAll(reader)
+	if err != nil && len(decoded) == 0 {
+		return nil, false
+	}
+	return decoded, true
+}
+
+// parsePDFContent 解析内容流中的文本操作符
+func parsePDFContent(content []byte) string {
+	var builder strings.Builder
+	var operands []string // 当前操作数中的字符串
+	inText := false
+	inArray := false
+
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == '(':
+			str, next := readLiteralString(content, i)
+			operands = append(operands, str)
+			i = next
+			continue
+		case c == '<' && i+1 < len(content) && content[i+1] != '<':
+			str, next := readHexString(content, i)
+			operands = append(operands, str)
+			i = next
+			continue
+		case c == '[':
+			inArray = true
+			operands = operands[:0]
+		case c == ']':
+			inArray = false
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+			continue
+		case c == '-' && inArray:
+			// TJ 数组中较大的负偏移通常表示单词间距
+			j := i + 1
+			for j < len(content) && (content[j] >= '0' && content[j] <= '9' || content[j] == '.') {
+				j++
+			}
+			if j-i > 3 && len(operands) > 0 {
+				operands[len(operands)-1] += " "
+			}
+			i = j
+			continue
+		case isPDFLetter(c) || c == '\'' || c == '"' || c == '*':
+			j := i
+			for j < len(content) && (isPDFLetter(content[j]) || content[j] == '*' || content[j] == '\'' || content[j] == '"') {
+				j++
+			}
+			op := string(content[i:j])
+			i = j
+
+			switch op {
+			case "BT":
+				inText = true
+			case "ET":
+				inText = false
+				builder.WriteString("\n")
+			case "Tj", "TJ":
+				if inText {
+					builder.WriteString(strings.Join(operands, ""))
+				}
+			case "'", "\"":
+				if inText {
+					builder.WriteString("\n")
+					builder.WriteString(strings.Join(operands, ""))
+				}
+			case "Td", "TD", "T*":
+				if inText {
+					builder.WriteString("\n")
+				}
+			}
+			if !inArray {
+				operands = operands[:0]
+			}
+			continue
+		}
+		i++
+	}
+
+	return builder.String()
+}
+
+// isPDFLetter 操作符字符
+func isPDFLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// readLiteralString 读取 (...) 字符串，处理嵌套括号与转义
+func readLiteralString(content []byte, start int) (string, int) {
+	var buf bytes.Buffer
+	depth := 0
+
+	i := start
+	for ; i < len(content); i++ {
+		c := content[i]
+		switch c {
+		case '(':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ')':
+			depth--
+			if depth == 0 {
+				return buf.String(), i + 1
+			}
+		case '\\':
+			i++
+			if i >= len(content) {
+				break
+			}
+			switch e := content[i]; e {
+			case 'n':
+				buf.WriteByte('\n')
+			case 'r':
+				buf.WriteByte('\r')
+			case 't':
+				buf.WriteByte('\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// 续行
+			default:
+				if e >= '0' && e <= '7' {
+					value := 0
+					j := 0
+					for ; j < 3 && i+j < len(content) && content[i+j] >= '0' && content[i+j] <= '7'; j++ {
+						value = value*8 + int(content[i+j]-'0')
+					}
+					i += j - 1
+					buf.WriteByte(byte(value))
+				} else {
+					buf.WriteByte(e)
+				}
+			}
+			continue
+		}
+		buf.WriteByte(c)
+	}
+
+	return buf.String(), i
+}
+
+// readHexString 读取 <...> 字符串，仅保留可识别的单字节或 UTF-16BE 文本
+func readHexString(content []byte, start int) (string, int) {
+	end := bytes.IndexByte(content[start:], '>')
+	if end < 0 {
+		return "", len(content)
+	}
+	end += start
+
+	digits := strings.Map(func(r rune) rune {
+		if strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return r
+		}
+		return -1
+	}, string(content[start+1:end]))
+	if len(digits)%2 == 1 {
+		digits += "0"
+	}
+	raw, err := hex.DecodeString(digits)
+	if err != nil {
+		return "", end + 1
+	}
+
+	// UTF-16BE（高字节多为 0）按双字节解码
+	if len(raw)%2 == 0 && len(raw) > 0 && raw[0] == 0 {
+		runes := make([]rune, 0, len(raw)/2)
+		for k := 0; k+1 < len(raw); k += 2 {
+			runes = append(runes, rune(raw[k])<<8|rune(raw[k+1]))
+		}
+		return string(runes), end + 1
+	}
+	for _, b := range raw {
+		if b < 0x20 || b > 0x7e {
+			return "", end + 1
+		}
+	}
+	return string(raw), end + 1
+}
@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/pkg/agent/llm"
+	"encoding/json"
+	"fmt"
+)
+
+// 用户对待确认工具调用的决定
+const (
+	ApprovalApprove = "approve" // 按原参数执行
+	ApprovalEdit    = "edit"    // 使用修改后的参数执行
+	ApprovalReject  = "reject"  // 不执行，并把拒绝原因告知模型
+)
+
+// ApprovalDecision 用户对待确认工具调用的决定
+type ApprovalDecision struct {
+	Action string                 // approve/edit/reject
+	Args   map[string]interface{} // edit 时的新参数
+	Reason string                 // reject 时的原因
+}
+
+// ReActCheckpoint ReAct 循环暂停时的状态，持久化后用于恢复执行
+type ReActCheckpoint struct {
+	Messages     []llm.Message      `json:"messages"`
+	PendingCalls []llm.ToolCall     `json:"pending_calls"` // 本轮尚未执行的工具调用，第一个为待确认的调用
+	Step         int                `json:"step"`
+	AllowedTools []string           `json:"allowed_tools,omitempty"`
+	Trace        *models.AgentTrace `json:"trace"`
+	ElapsedMs    int64              `json:"elapsed_ms"` // 暂停前已耗时
+}
+
+// PlanCheckpoint Plan 执行暂停时的状态，持久化后用于恢复执行
+type PlanCheckpoint struct {
+	UserMessage string                 `json:"user_message"`
+	Plan        *models.AgentPlan      `json:"plan"`
+	Next        int                    `json:"next"`         // 下一个待执行步骤的下标，暂停时为待确认的步骤
+	PendingArgs map[string]interface{} `json:"pending_args"` // 待确认步骤已生成的工具参数
+	MaxSteps    int                    `json:"max_steps"`
+	Trace       *models.AgentTrace     `json:"trace"`
+	ElapsedMs   int64                  `json:"elapsed_ms"` // 暂停前已耗时
+}
+
+// planStepCallID Plan 步骤待确认时使用的调用 ID，恢复时用于校验检查点
+func planStepCallID(stepIndex int) string {
+	return fmt.Sprintf("plan_step_%d", stepIndex)
+}
+
+// updateToolCallArguments 用户修改参数后同步更新 assistant 消息中的工具调用，使模型看到实际执行的参数
+func updateToolCallArguments(messages []llm.Message, toolCallID string, args map[string]interface{}) {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		for j := range messages[i].ToolCalls {
+			if messages[i].ToolCalls[j].ID == toolCallID {
+				messages[i].ToolCalls[j].Function.Arguments = string(data)
+				return
+			}
+		}
+	}
+}
+
+// recordToolUsage 更新轨迹中的工具使用统计（兼容从检查点恢复后数值变为 float64 的情况）
+func recordToolUsage(trace *models.AgentTrace, toolName string, elapsedMs int64) {
+	stats, _ := trace.UsedTools[toolName].(map[string]interface{})
+	if stats == nil {
+		stats = map[string]interface{}{}
+	}
+	stats["count"] = toInt64(stats["count"]) + 1
+	stats["total_ms"] = toInt64(stats["total_ms"]) + elapsedMs
+	trace.UsedTools[toolName] = stats
+}
+
+// toInt64 数值转换
+func toInt64(value interface{}) int64 {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
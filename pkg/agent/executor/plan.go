@@ -44,7 +44,7 @@ func NewPlanExecutor(
 func (e *PlanExecutor) Execute(
 	ctx context.Context,
 	userMessage string,
-	conversationHistory string,
+	history []llm.Message,
 	allowedTools []string,
 	maxSteps int,
 	streamCallback func(event StreamEvent),
@@ -52,7 +52,7 @@ func (e *PlanExecutor) Execute(
 	startTime := time.Now()
 
 	// Step 1: 生成计划
-	plan, err := e.generatePlan(ctx, userMessage, conversationHistory, allowedTools)
+	plan, err := e.generatePlan(ctx, userMessage, history, allowedTools)
 	if err != nil {
 		return nil, fmt.Errorf("生成计划失败: %w", err)
 	}
@@ -197,7 +197,7 @@ func (e *PlanExecutor) Execute(
 func (e *PlanExecutor) generatePlan(
 	ctx context.Context,
 	userMessage string,
-	conversationHistory string,
+	history []llm.Message,
 	allowedTools []string,
 ) (*models.AgentPlan, error) {
 	// 获取工具定义
@@ -243,30 +243,20 @@ func (e *PlanExecutor) generatePlan(
 		},
 	}
 
-	response, err := e.llmClient.Call(ctx, messages, &llm.CallOptions{
+	result, err := llm.CallStructured(ctx, e.llmClient, messages, &llm.CallOptions{
 		Temperature:    e.temperature,
-		ResponseFormat: "json_object",
-	})
-
+		ResponseFormat: llm.JSONSchemaFormat("execution_plan", planResponseSchema(toolDefinitions)),
+	}, llm.DefaultMaxRepairs)
 	if err != nil {
-		log.Error(ctx, "LLM 调用失败: %v", err)
-		return nil, fmt.Errorf("LLM 调用失败: %w", err)
+		log.Error(ctx, "生成计划失败: %v, 原始内容: %s", err, result.Content)
+		return nil, fmt.Errorf("生成计划失败: %w", err)
 	}
 
-	log.Info(ctx, "LLM 返回内容长度: %d, 内容: %s", len(response.Content), response.Content)
+	log.Info(ctx, "LLM 返回内容长度: %d, 内容: %s", len(result.Content), result.Content)
 
 	// 解析计划
-	var planData struct {
-		Steps []struct {
-			Step        int    `json:"step"`
-			Description string `json:"description"`
-			Tool        string `json:"tool"`
-			Reasoning   string `json:"reasoning"`
-		} `json:"steps"`
-	}
-
-	if err := json.Unmarshal([]byte(response.Content), &planData); err != nil {
-		log.Error(ctx, "解析计划失败: %v, 原始内容: %s", err, response.Content)
+	var planData planOutput
+	if err := result.Decode(&planData); err != nil {
 		return nil, fmt.Errorf("解析计划失败: %w", err)
 	}
 
@@ -529,18 +519,17 @@ WRONG examples:
 		},
 	}
 
-	response, err := e.llmClient.Call(ctx, messages, &llm.CallOptions{
+	result, err := llm.CallStructured(ctx, e.llmClient, messages, &llm.CallOptions{
 		Temperature:    0.3, // 低温度以获得更确定的结果
-		ResponseFormat: "json_object",
-	})
-
+		ResponseFormat: llm.JSONSchemaFormat("tool_arguments", toolArgsSchema(e.toolRegistry, planStep.Tool)),
+	}, llm.DefaultMaxRepairs)
 	if err != nil {
 		return nil, err
 	}
 
 	// 解析参数
 	var args map[string]interface{}
-	if err := json.Unmarshal([]byte(response.Content), &args); err != nil {
+	if err := result.Decode(&args); err != nil {
 		return nil, fmt.Errorf("解析工具参数失败: %w", err)
 	}
 
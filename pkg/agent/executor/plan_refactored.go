@@ -10,6 +10,7 @@ import (
 	"auto-forge/pkg/utools"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -17,13 +18,14 @@ import (
 
 // PlanExecutorV2 Plan 执行器（重构版）
 type PlanExecutorV2 struct {
-	llmClient     llm.LLMClient
-	toolRegistry  *registry.ToolRegistry
-	stepExecutor  *StepExecutor
-	validator     *tooling.PlanValidator
-	temperature   float64
-	enableRetry   bool
-	enableTimeout bool
+	llmClient    llm.LLMClient
+	toolRegistry *registry.ToolRegistry
+	stepExecutor *StepExecutor
+	validator    *tooling.PlanValidator
+	toolRunner   *tooling.ToolRunner
+	temperature  float64
+	tokenUsage   *models.TokenUsage
+	approvals    map[string]string // 工具审批策略（AgentConfig.ToolApprovals）
 }
 
 // NewPlanExecutorV2 创建 Plan 执行器（重构版）
@@ -33,21 +35,26 @@ func NewPlanExecutorV2(
 	temperature float64,
 ) *PlanExecutorV2 {
 	return &PlanExecutorV2{
-		llmClient:     llmClient,
-		toolRegistry:  toolRegistry,
-		stepExecutor:  NewStepExecutor(toolRegistry),
-		validator:     tooling.NewPlanValidator(toolRegistry),
-		temperature:   temperature,
-		enableRetry:   true, // 默认启用重试
-		enableTimeout: true, // 默认启用超时
+		llmClient:    llmClient,
+		toolRegistry: toolRegistry,
+		stepExecutor: NewStepExecutor(toolRegistry),
+		validator:    tooling.NewPlanValidator(toolRegistry),
+		toolRunner:   tooling.NewToolRunner(toolRegistry),
+		temperature:  temperature,
+		tokenUsage:   &models.TokenUsage{},
 	}
 }
 
+// SetApprovalPolicies 设置工具审批策略（工具名 → auto/confirm/deny）
+func (e *PlanExecutorV2) SetApprovalPolicies(approvals map[string]string) {
+	e.approvals = approvals
+}
+
 // Execute 执行 Plan 模式（重构版）
 func (e *PlanExecutorV2) Execute(
 	ctx context.Context,
 	userMessage string,
-	conversationHistory string,
+	history []llm.Message,
 	allowedTools []string,
 	maxSteps int,
 	streamCallback func(event StreamEvent),
@@ -55,22 +62,24 @@ func (e *PlanExecutorV2) Execute(
 	startTime := time.Now()
 
 	// Step 1: 生成计划
-	plan, err := e.generatePlan(ctx, userMessage, conversationHistory, allowedTools)
+	plan, err := e.generatePlan(ctx, userMessage, history, allowedTools)
 	if err != nil {
 		return nil, fmt.Errorf("生成计划失败: %w", err)
 	}
 
 	logger.Info("生成计划成功，共 %d 步", len(plan.Steps))
 
-	// Step 2: 验证计划
-	validationResult := e.validator.Validate(plan)
+	// Step 2: 执行前验证计划（并修复可自动修复的问题）
+	plan, validationResult := e.validator.ValidateAndFix(plan)
+	if len(plan.Steps) == 0 {
+		return nil, fmt.Errorf("计划为空")
+	}
 	if !validationResult.Valid {
+		// 依赖不满足时记录错误但继续执行，由失败处理决定是否跳过后续步骤
 		logger.Warn("计划验证失败: %v", validationResult.Errors)
-		// 可以选择返回错误或继续执行
-		// 这里我们记录警告但继续执行
-		for _, warning := range validationResult.Warnings {
-			logger.Warn("计划警告: %s", warning)
-		}
+	}
+	for _, warning := range validationResult.Warnings {
+		logger.Warn("计划警告: %s", warning)
 	}
 
 	// 发送计划开始事件
@@ -85,14 +94,69 @@ func (e *PlanExecutorV2) Execute(
 	}
 
 	// Step 3: 执行计划
-	trace := &models.AgentTrace{
-		Steps:     []models.AgentStep{},
-		UsedTools: make(map[string]interface{}),
+	state := &PlanCheckpoint{
+		UserMessage: userMessage,
+		Plan:        plan,
+		MaxSteps:    maxSteps,
+		ElapsedMs:   time.Since(startTime).Milliseconds(), // 计入生成与验证计划的耗时
+		Trace: &models.AgentTrace{
+			Steps:      []models.AgentStep{},
+			UsedTools:  make(map[string]interface{}),
+			TokenUsage: e.tokenUsage,
+		},
 	}
+	return e.run(ctx, state, nil, streamCallback)
+}
 
-	for i, planStep := range plan.Steps {
-		if i >= maxSteps {
-			logger.Warn("达到最大步骤数 %d", maxSteps)
+// Resume 根据用户的决定恢复因等待确认而暂停的计划执行
+func (e *PlanExecutorV2) Resume(
+	ctx context.Context,
+	pending *models.AgentPendingApproval,
+	checkpoint string,
+	decision ApprovalDecision,
+	streamCallback func(event StreamEvent),
+) (*ExecutionResult, error) {
+	var state PlanCheckpoint
+	if err := json.Unmarshal([]byte(checkpoint), &state); err != nil {
+		return nil, fmt.Errorf("解析执行检查点失败: %w", err)
+	}
+	if state.Plan == nil || state.Next >= len(state.Plan.Steps) || planStepCallID(state.Next+1) != pending.ToolCallID {
+		return nil, fmt.Errorf("执行检查点与待确认的工具调用不一致")
+	}
+	if state.Trace == nil {
+		state.Trace = &models.AgentTrace{Steps: []models.AgentStep{}}
+	}
+	if state.Trace.UsedTools == nil {
+		state.Trace.UsedTools = make(map[string]interface{})
+	}
+	if state.Trace.TokenUsage != nil {
+		e.tokenUsage = state.Trace.TokenUsage
+	}
+	state.Trace.TokenUsage = e.tokenUsage
+	e.toolRunner.Restore(state.Trace.ToolStats)
+
+	logger.Info("恢复 Plan 执行: 步骤=%d, 工具=%s, 决定=%s", state.Next+1, pending.Tool, decision.Action)
+	return e.run(ctx, &state, &decision, streamCallback)
+}
+
+// run 从 state.Next 开始依次执行计划步骤，遇到需要确认的工具时暂停
+// decision 为恢复执行时用户对待确认步骤的决定
+func (e *PlanExecutorV2) run(
+	ctx context.Context,
+	state *PlanCheckpoint,
+	decision *ApprovalDecision,
+	streamCallback func(event StreamEvent),
+) (*ExecutionResult, error) {
+	startTime := time.Now()
+	plan := state.Plan
+	trace := state.Trace
+	userMessage := state.UserMessage
+
+	for ; state.Next < len(plan.Steps); state.Next++ {
+		i := state.Next
+		planStep := plan.Steps[i]
+		if i >= state.MaxSteps {
+			logger.Warn("达到最大步骤数 %d", state.MaxSteps)
 			break
 		}
 
@@ -108,8 +172,20 @@ func (e *PlanExecutorV2) Execute(
 			})
 		}
 
-		// 执行步骤（使用新的模块化执行器）
-		stepResult := e.executeStepV2(ctx, planStep, i+1, userMessage, trace.Steps, streamCallback)
+		// 执行步骤（使用新的模块化执行器）；恢复执行时按用户决定处理待确认的步骤
+		var stepResult *ExecuteStepResult
+		if decision != nil {
+			stepResult = e.resumeStep(ctx, planStep, i+1, state.PendingArgs, *decision, streamCallback)
+			decision = nil
+			state.PendingArgs = nil
+		} else {
+			stepResult = e.executeStepV2(ctx, planStep, i+1, userMessage, trace.Steps, streamCallback)
+		}
+		if stepResult.Pending != nil {
+			plan.Steps[i].Status = "awaiting_approval"
+			state.PendingArgs = stepResult.Pending.Args
+			return e.pause(state, stepResult.Pending, startTime, streamCallback)
+		}
 
 		if stepResult.Error != nil {
 			logger.Error("执行步骤 %d 失败: %v", i+1, stepResult.Error)
@@ -162,7 +238,8 @@ func (e *PlanExecutorV2) Execute(
 
 	trace.FinalAnswer = finalAnswer
 	trace.FinishReason = "final"
-	trace.TotalMs = time.Since(startTime).Milliseconds()
+	trace.TotalMs = state.ElapsedMs + time.Since(startTime).Milliseconds()
+	trace.ToolStats = e.toolRunner.Stats()
 
 	// 发送最终事件
 	if streamCallback != nil {
@@ -183,6 +260,77 @@ func (e *PlanExecutorV2) Execute(
 	}, nil
 }
 
+// pause 暂停计划执行：保存检查点（含剩余步骤）并发送 approval_required 事件
+func (e *PlanExecutorV2) pause(
+	state *PlanCheckpoint,
+	pending *models.AgentPendingApproval,
+	startTime time.Time,
+	streamCallback func(event StreamEvent),
+) (*ExecutionResult, error) {
+	trace := state.Trace
+	state.ElapsedMs += time.Since(startTime).Milliseconds()
+	trace.FinishReason = "approval_required"
+	trace.TotalMs = state.ElapsedMs
+	trace.ToolStats = e.toolRunner.Stats()
+
+	checkpoint, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("保存执行检查点失败: %w", err)
+	}
+
+	logger.Info("计划步骤 %d 等待用户确认: %s, 参数: %v", pending.Step, pending.Tool, pending.Args)
+
+	if streamCallback != nil {
+		streamCallback(StreamEvent{
+			Type: "approval_required",
+			Data: map[string]interface{}{
+				"step":         pending.Step,
+				"tool":         pending.Tool,
+				"tool_call_id": pending.ToolCallID,
+				"args":         pending.Args,
+				"trace":        trace,
+			},
+		})
+	}
+
+	return &ExecutionResult{
+		Trace:      trace,
+		Success:    false,
+		Error:      "等待用户确认工具调用",
+		Pending:    pending,
+		Checkpoint: string(checkpoint),
+	}, nil
+}
+
+// resumeStep 按用户决定处理待确认的步骤：批准或修改参数后执行，拒绝则记为失败步骤
+func (e *PlanExecutorV2) resumeStep(
+	ctx context.Context,
+	planStep models.AgentPlanStep,
+	stepIndex int,
+	args map[string]interface{},
+	decision ApprovalDecision,
+	streamCallback func(event StreamEvent),
+) *ExecuteStepResult {
+	switch decision.Action {
+	case ApprovalReject:
+		reason := "用户拒绝执行"
+		if decision.Reason != "" {
+			reason += ": " + decision.Reason
+		}
+		result := e.stepExecutor.createErrorStep(&ExecuteStepRequest{
+			PlanStep:  planStep,
+			StepIndex: stepIndex,
+		}, time.Now(), errors.New(reason))
+		result.Step.Action.Args = args
+		return result
+	case ApprovalEdit:
+		if decision.Args != nil {
+			args = decision.Args
+		}
+	}
+	return e.runTool(ctx, planStep, stepIndex, args, streamCallback)
+}
+
 // executeStepV2 执行单个步骤（使用新的模块化执行器）
 func (e *PlanExecutorV2) executeStepV2(
 	ctx context.Context,
@@ -211,16 +359,36 @@ func (e *PlanExecutorV2) executeStepV2(
 		}, time.Now(), err)
 	}
 
-	// 获取工具
-	tool, err := e.toolRegistry.GetTool(planStep.Tool)
-	if err != nil {
+	// 审批策略：需要确认的工具暂停执行，等待用户决定后从该步骤恢复
+	tool, _ := e.toolRegistry.GetTool(planStep.Tool)
+	switch tooling.ResolveApproval(planStep.Tool, tool, args, e.approvals) {
+	case utools.ApprovalDeny:
 		return e.stepExecutor.createErrorStep(&ExecuteStepRequest{
 			PlanStep:    planStep,
 			StepIndex:   stepIndex,
 			UserMessage: userMessage,
-		}, time.Now(), err)
-	}
+		}, time.Now(), fmt.Errorf("审批策略禁止调用工具 %s", planStep.Tool))
+	case utools.ApprovalConfirm:
+		return &ExecuteStepResult{Pending: &models.AgentPendingApproval{
+			ToolCallID:  planStepCallID(stepIndex),
+			Tool:        planStep.Tool,
+			Args:        args,
+			Step:        stepIndex,
+			RequestedAt: time.Now().Format(time.RFC3339),
+		}}
+	}
+
+	return e.runTool(ctx, planStep, stepIndex, args, streamCallback)
+}
 
+// runTool 执行步骤的工具调用
+func (e *PlanExecutorV2) runTool(
+	ctx context.Context,
+	planStep models.AgentPlanStep,
+	stepIndex int,
+	args map[string]interface{},
+	streamCallback func(event StreamEvent),
+) *ExecuteStepResult {
 	// 发送步骤开始事件
 	if streamCallback != nil {
 		streamCallback(StreamEvent{
@@ -232,17 +400,11 @@ func (e *PlanExecutorV2) executeStepV2(
 		})
 	}
 
-	// 获取工具配置
-	toolConfig := e.getToolConfig(tool)
-
-	// 创建工具执行器
-	toolExecutor := tooling.NewToolExecutor(toolConfig)
-
-	// 执行工具（带超时和重试）
+	// 执行工具（按工具配置的超时、重试、缓存）
 	logger.Info("执行工具: %s, 参数: %v", planStep.Tool, args)
 
 	stepStartTime := time.Now()
-	execResult := toolExecutor.ExecuteWithProgress(ctx, tool, args, func(attempt int, message string) {
+	execResult := e.toolRunner.Run(ctx, planStep.Tool, args, func(attempt int, message string) {
 		// 报告进度
 		if streamCallback != nil {
 			streamCallback(StreamEvent{
@@ -282,6 +444,8 @@ func (e *PlanExecutorV2) processExecutionResult(
 		},
 		ElapsedMs: elapsedMs,
 		Timestamp: time.Now().Format(time.RFC3339),
+		Attempts:  execResult.Attempts,
+		Cached:    execResult.FromCache,
 	}
 
 	// 处理错误
@@ -291,20 +455,11 @@ func (e *PlanExecutorV2) processExecutionResult(
 		logger.Error("工具执行失败（尝试 %d 次）: %v", execResult.Attempts, execResult.Error)
 	} else {
 		// 成功
-		toolOutput := execResult.Output
-		step.Observation = registry.FormatToolResult(toolOutput)
-
-		// 设置 ToolOutput
-		if toolOutput != nil {
-			if result, ok := toolOutput.(*utools.ExecutionResult); ok && result.Output != nil {
-				step.ToolOutput = result.Output
-			} else if outputMap, ok := toolOutput.(map[string]interface{}); ok {
-				step.ToolOutput = outputMap
-			}
-		}
+		step.Observation = registry.FormatToolResult(execResult.Output)
+		step.ToolOutput = extractToolOutput(execResult.Output)
 
-		logger.Info("工具执行成功（尝试 %d 次），结果长度: %d",
-			execResult.Attempts, len(step.Observation))
+		logger.Info("工具执行成功（尝试 %d 次，缓存: %v），结果长度: %d",
+			execResult.Attempts, execResult.FromCache, len(step.Observation))
 	}
 
 	// 发送步骤结束事件
@@ -317,6 +472,7 @@ func (e *PlanExecutorV2) processExecutionResult(
 				"observation": step.Observation,
 				"elapsed_ms":  elapsedMs,
 				"attempts":    execResult.Attempts,
+				"cached":      execResult.FromCache,
 			},
 		})
 	}
@@ -375,32 +531,18 @@ func (e *PlanExecutorV2) updateToolStats(trace *models.AgentTrace, step *models.
 	}
 }
 
-// getToolConfig 获取工具配置
-func (e *PlanExecutorV2) getToolConfig(tool interface{}) *tooling.ExecutionConfig {
-	// 尝试从工具获取配置
-	if configurable, ok := tool.(tooling.ConfigurableTool); ok {
-		return configurable.GetExecutionConfig()
-	}
-
-	// 返回默认配置
-	config := tooling.DefaultExecutionConfig()
-
-	// 根据全局设置调整配置
-	if !e.enableRetry {
-		config.Retry = nil
-	}
-	if !e.enableTimeout {
-		config.TimeoutSeconds = 0
-	}
-
-	return config
+// recordUsage 累计 LLM Token 使用
+func (e *PlanExecutorV2) recordUsage(usage llm.TokenUsage) {
+	e.tokenUsage.PromptTokens += usage.PromptTokens
+	e.tokenUsage.CompletionTokens += usage.CompletionTokens
+	e.tokenUsage.TotalTokens += usage.TotalTokens
 }
 
 // generatePlan 生成执行计划（保持原有逻辑）
 func (e *PlanExecutorV2) generatePlan(
 	ctx context.Context,
 	userMessage string,
-	conversationHistory string,
+	history []llm.Message,
 	allowedTools []string,
 ) (*models.AgentPlan, error) {
 	// 获取工具定义
@@ -437,42 +579,34 @@ func (e *PlanExecutorV2) generatePlan(
 	})
 	logger.Info("完整提示词长度: %d 字符", len(promptText))
 
-	// 调用 LLM
+	// 调用 LLM（对话历史放在规划请求之前，便于理解指代与上下文）
 	messages := []llm.Message{
 		{
 			Role:    "system",
 			Content: "You are a planning AI. Generate execution plans in JSON format.",
 		},
-		{
-			Role:    "user",
-			Content: promptText,
-		},
 	}
-
-	response, err := e.llmClient.Call(ctx, messages, &llm.CallOptions{
-		Temperature:    e.temperature,
-		ResponseFormat: "json_object",
+	messages = append(messages, history...)
+	messages = append(messages, llm.Message{
+		Role:    "user",
+		Content: promptText,
 	})
 
+	result, err := llm.CallStructured(ctx, e.llmClient, messages, &llm.CallOptions{
+		Temperature:    e.temperature,
+		ResponseFormat: llm.JSONSchemaFormat("execution_plan", planResponseSchema(toolDefinitions)),
+	}, llm.DefaultMaxRepairs)
+	e.recordUsage(result.Usage)
 	if err != nil {
-		logger.Error("LLM 调用失败: %v", err)
-		return nil, fmt.Errorf("LLM 调用失败: %w", err)
+		logger.Error("生成计划失败: %v, 原始内容: %s", err, result.Content)
+		return nil, fmt.Errorf("生成计划失败: %w", err)
 	}
 
-	logger.Info("LLM 返回内容长度: %d", len(response.Content))
+	logger.Info("LLM 返回内容长度: %d, 尝试次数: %d", len(result.Content), result.Attempts)
 
 	// 解析计划
-	var planData struct {
-		Steps []struct {
-			Step        int    `json:"step"`
-			Description string `json:"description"`
-			Tool        string `json:"tool"`
-			Reasoning   string `json:"reasoning"`
-		} `json:"steps"`
-	}
-
-	if err := json.Unmarshal([]byte(response.Content), &planData); err != nil {
-		logger.Error("解析计划失败: %v, 原始内容: %s", err, response.Content)
+	var planData planOutput
+	if err := result.Decode(&planData); err != nil {
 		return nil, fmt.Errorf("解析计划失败: %w", err)
 	}
 
@@ -582,20 +716,20 @@ WRONG examples:
 		},
 	}
 
-	response, err := e.llmClient.Call(ctx, messages, &llm.CallOptions{
+	result, err := llm.CallStructured(ctx, e.llmClient, messages, &llm.CallOptions{
 		Temperature:    0.1, // 使用较低的温度以获得更确定的结果
-		ResponseFormat: "json_object",
-	})
-
+		ResponseFormat: llm.JSONSchemaFormat("tool_arguments", toolArgsSchema(e.toolRegistry, planStep.Tool)),
+	}, llm.DefaultMaxRepairs)
+	e.recordUsage(result.Usage)
 	if err != nil {
-		return nil, fmt.Errorf("LLM 调用失败: %w", err)
+		return nil, fmt.Errorf("生成工具参数失败: %w", err)
 	}
 
-	logger.Info("LLM 生成的参数 JSON:\n%s", response.Content)
+	logger.Info("LLM 生成的参数 JSON:\n%s", result.Content)
 
 	// 解析参数
 	var args map[string]interface{}
-	if err := json.Unmarshal([]byte(response.Content), &args); err != nil {
+	if err := result.Decode(&args); err != nil {
 		return nil, fmt.Errorf("解析参数失败: %w", err)
 	}
 
@@ -645,6 +779,7 @@ func (e *PlanExecutorV2) generateFinalAnswer(
 	if err != nil {
 		return "", err
 	}
+	e.recordUsage(response.Usage)
 
 	return response.Content, nil
 }
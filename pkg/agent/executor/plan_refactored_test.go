@@ -0,0 +1,130 @@
+package executor
+
+import (
+	"auto-forge/pkg/agent/llm"
+	"auto-forge/pkg/agent/registry"
+	"auto-forge/pkg/utools"
+	"context"
+	"strings"
+	"testing"
+)
+
+// scriptedLLM 按请求类型返回固定内容：计划、工具参数或最终答案
+type scriptedLLM struct {
+	plan string
+	args string
+}
+
+func (c *scriptedLLM) Call(ctx context.Context, messages []llm.Message, options *llm.CallOptions) (*llm.Response, error) {
+	content := "done"
+	switch system := messages[0].Content; {
+	case strings.Contains(system, "planning AI"):
+		content = c.plan
+	case strings.Contains(system, "parameter generation AI"):
+		content = c.args
+	}
+	return &llm.Response{Content: content}, nil
+}
+
+func (c *scriptedLLM) Stream(ctx context.Context, messages []llm.Message, options *llm.CallOptions) (<-chan llm.StreamChunk, error) {
+	return nil, nil
+}
+
+func (c *scriptedLLM) GetModelInfo() llm.ModelInfo {
+	return llm.ModelInfo{Model: "scripted"}
+}
+
+// sendTool 需要确认的工具，记录实际执行时的参数
+type sendTool struct {
+	calls []map[string]interface{}
+}
+
+func (t *sendTool) GetMetadata() *utools.ToolMetadata {
+	return &utools.ToolMetadata{Code: "send_tool", Name: "Send", Description: "Send a message", Approval: utools.ApprovalConfirm}
+}
+
+func (t *sendTool) GetSchema() *utools.ConfigSchema {
+	return &utools.ConfigSchema{
+		Type:       "object",
+		Properties: map[string]utools.PropertySchema{"to": {Type: "string", Title: "To"}},
+	}
+}
+
+func (t *sendTool) Validate(config map[string]interface{}) error {
+	return nil
+}
+
+func (t *sendTool) Execute(ctx *utools.ExecutionContext, config map[string]interface{}) (*utools.ExecutionResult, error) {
+	t.calls = append(t.calls, config)
+	return &utools.ExecutionResult{Success: true, Output: map[string]interface{}{"sent": true}}, nil
+}
+
+func newConfirmPlanExecutor(t *testing.T, tool *sendTool) *PlanExecutorV2 {
+	t.Helper()
+	reg := registry.NewToolRegistry()
+	if err := reg.Register(tool); err != nil {
+		t.Fatalf("注册工具失败: %v", err)
+	}
+	client := &scriptedLLM{
+		plan: `{"steps":[{"step":1,"description":"send","tool":"send_tool"}]}`,
+		args: `{"to":"alice"}`,
+	}
+	return NewPlanExecutorV2(client, reg, 0.1)
+}
+
+// pausePlan 执行计划直到需要确认的步骤暂停
+func pausePlan(t *testing.T, tool *sendTool) *ExecutionResult {
+	t.Helper()
+	var events []string
+	result, err := newConfirmPlanExecutor(t, tool).Execute(context.Background(), "send it", nil, nil, 10, func(event StreamEvent) {
+		events = append(events, event.Type)
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if result.Pending == nil || result.Checkpoint == "" {
+		t.Fatalf("expected plan to pause for approval, got %+v", result)
+	}
+	if len(tool.calls) != 0 {
+		t.Fatalf("confirm tool ran before approval: %v", tool.calls)
+	}
+	if events[len(events)-1] != "approval_required" {
+		t.Errorf("expected approval_required event, got %v", events)
+	}
+	return result
+}
+
+func TestPlanExecutorPausesAndResumesWithEditedArgs(t *testing.T) {
+	tool := &sendTool{}
+	paused := pausePlan(t, tool)
+
+	decision := ApprovalDecision{Action: ApprovalEdit, Args: map[string]interface{}{"to": "bob"}}
+	result, err := newConfirmPlanExecutor(t, tool).Resume(context.Background(), paused.Pending, paused.Checkpoint, decision, nil)
+	if err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if !result.Success || result.Trace.FinishReason != "final" {
+		t.Fatalf("expected plan to finish, got %+v", result)
+	}
+	if len(tool.calls) != 1 || tool.calls[0]["to"] != "bob" {
+		t.Errorf("expected one call with edited args, got %v", tool.calls)
+	}
+}
+
+func TestPlanExecutorResumeRejectSkipsTool(t *testing.T) {
+	tool := &sendTool{}
+	paused := pausePlan(t, tool)
+
+	decision := ApprovalDecision{Action: ApprovalReject, Reason: "wrong recipient"}
+	result, err := newConfirmPlanExecutor(t, tool).Resume(context.Background(), paused.Pending, paused.Checkpoint, decision, nil)
+	if err != nil {
+		t.Fatalf("Resume failed: %v", err)
+	}
+	if len(tool.calls) != 0 {
+		t.Errorf("rejected tool should not run, got %v", tool.calls)
+	}
+	steps := result.Trace.Steps
+	if len(steps) != 1 || !strings.Contains(steps[0].Error, "wrong recipient") {
+		t.Errorf("expected rejected step in trace, got %+v", steps)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"auto-forge/pkg/agent/llm"
 	"auto-forge/pkg/agent/prompt"
 	"auto-forge/pkg/agent/registry"
+	"auto-forge/pkg/agent/tooling"
+	"auto-forge/pkg/utools"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -19,8 +21,11 @@ var log = logger.GetLogger()
 type ReActExecutor struct {
 	llmClient    llm.LLMClient
 	toolRegistry *registry.ToolRegistry
+	toolRunner   *tooling.ToolRunner
 	maxSteps     int
 	temperature  float64
+	approvals    map[string]string // 工具审批策略（AgentConfig.ToolApprovals）
+	budgetGuard  BudgetGuard
 }
 
 // NewReActExecutor 创建 ReAct 执行器
@@ -33,42 +38,110 @@ func NewReActExecutor(
 	return &ReActExecutor{
 		llmClient:    llmClient,
 		toolRegistry: toolRegistry,
+		toolRunner:   tooling.NewToolRunner(toolRegistry),
 		maxSteps:     maxSteps,
 		temperature:  temperature,
 	}
 }
 
+// SetApprovalPolicies 设置工具审批策略（工具名 → auto/confirm/deny）
+func (e *ReActExecutor) SetApprovalPolicies(approvals map[string]string) {
+	e.approvals = approvals
+}
+
+// SetBudgetGuard 设置用量预算检查，每一步调用 LLM 前执行
+func (e *ReActExecutor) SetBudgetGuard(guard BudgetGuard) {
+	e.budgetGuard = guard
+}
+
 // Execute 执行 ReAct 循环
 func (e *ReActExecutor) Execute(
 	ctx context.Context,
 	userMessage string,
-	conversationHistory string,
+	history []llm.Message,
 	allowedTools []string,
 	streamCallback func(event StreamEvent),
 ) (*ExecutionResult, error) {
-	startTime := time.Now()
+	state := &ReActCheckpoint{
+		Messages:     e.buildMessages(userMessage, history, allowedTools),
+		AllowedTools: allowedTools,
+		Trace: &models.AgentTrace{
+			Steps:     []models.AgentStep{},
+			UsedTools: make(map[string]interface{}),
+		},
+	}
+	return e.run(ctx, state, nil, streamCallback)
+}
+
+// Resume 根据用户的决定恢复因等待确认而暂停的执行
+func (e *ReActExecutor) Resume(
+	ctx context.Context,
+	pending *models.AgentPendingApproval,
+	checkpoint string,
+	decision ApprovalDecision,
+	streamCallback func(event StreamEvent),
+) (*ExecutionResult, error) {
+	var state ReActCheckpoint
+	if err := json.Unmarshal([]byte(checkpoint), &state); err != nil {
+		return nil, fmt.Errorf("解析执行检查点失败: %w", err)
+	}
+	if len(state.PendingCalls) == 0 || state.PendingCalls[0].ID != pending.ToolCallID {
+		return nil, fmt.Errorf("执行检查点与待确认的工具调用不一致")
+	}
+	if state.Trace == nil {
+		state.Trace = &models.AgentTrace{Steps: []models.AgentStep{}}
+	}
+	if state.Trace.UsedTools == nil {
+		state.Trace.UsedTools = make(map[string]interface{})
+	}
+	e.toolRunner.Restore(state.Trace.ToolStats)
 
-	// 构建消息列表
-	messages := e.buildMessages(userMessage, conversationHistory, allowedTools)
+	log.Info(ctx, "恢复 ReAct 执行: 工具=%s, 决定=%s", pending.Tool, decision.Action)
+	return e.run(ctx, &state, &decision, streamCallback)
+}
+
+// run ReAct 循环主体：先处理上一轮未执行完的工具调用，再继续调用 LLM
+// decision 为恢复执行时用户对第一个待执行工具调用的决定
+func (e *ReActExecutor) run(
+	ctx context.Context,
+	state *ReActCheckpoint,
+	decision *ApprovalDecision,
+	streamCallback func(event StreamEvent),
+) (*ExecutionResult, error) {
+	startTime := time.Now()
+	trace := state.Trace
 
 	// 获取工具定义
-	toolDefinitions := e.toolRegistry.GetToolDefinitions(allowedTools)
+	toolDefinitions := e.toolRegistry.GetToolDefinitions(state.AllowedTools)
+
+	for {
+		// 执行本轮尚未执行的工具调用
+		for len(state.PendingCalls) > 0 {
+			toolCall := state.PendingCalls[0]
+			if pending := e.handleToolCall(ctx, state, toolCall, decision, streamCallback); pending != nil {
+				return e.pause(ctx, state, pending, startTime, streamCallback)
+			}
+			decision = nil
+			state.PendingCalls = state.PendingCalls[1:]
+		}
 
-	// 执行轨迹
-	trace := &models.AgentTrace{
-		Steps:     []models.AgentStep{},
-		UsedTools: make(map[string]interface{}),
-	}
+		if state.Step >= e.maxSteps {
+			break
+		}
 
-	// ReAct 循环
-	currentStep := 0
-	for currentStep < e.maxSteps {
-		currentStep++
+		// 已有用量时检查预算（首次调用前的检查由服务层完成）
+		if e.budgetGuard != nil && trace.TokenUsage != nil {
+			if err := e.budgetGuard(trace.TokenUsage); err != nil {
+				log.Warn(ctx, "用量预算不足，停止执行: %v", err)
+				return e.stop(state, startTime, "budget_exceeded", "已达到用量预算上限，任务未完成。", err.Error(), streamCallback), nil
+			}
+		}
+		state.Step++
 
-		log.Info(ctx, "ReAct 步骤 %d 开始", currentStep)
+		log.Info(ctx, "ReAct 步骤 %d 开始", state.Step)
 
 		// 调用 LLM
-		response, err := e.llmClient.Call(ctx, messages, &llm.CallOptions{
+		response, err := e.llmClient.Call(ctx, state.Messages, &llm.CallOptions{
 			Temperature: e.temperature,
 			Tools:       toolDefinitions,
 			ToolChoice:  "auto",
@@ -93,7 +166,8 @@ func (e *ReActExecutor) Execute(
 
 			trace.FinalAnswer = response.Content
 			trace.FinishReason = "final"
-			trace.TotalMs = time.Since(startTime).Milliseconds()
+			trace.TotalMs = state.ElapsedMs + time.Since(startTime).Milliseconds()
+			trace.ToolStats = e.toolRunner.Stats()
 
 			// 发送最终事件
 			if streamCallback != nil {
@@ -114,143 +188,301 @@ func (e *ReActExecutor) Execute(
 			}, nil
 		}
 
-		// 处理工具调用
-		assistantMessage := llm.Message{
+		// 记录工具调用，下一轮循环逐个执行
+		state.Messages = append(state.Messages, llm.Message{
 			Role:      "assistant",
 			Content:   response.Content,
 			ToolCalls: response.ToolCalls,
-		}
-		messages = append(messages, assistantMessage)
-
-		// 执行每个工具调用
-		for _, toolCall := range response.ToolCalls {
-			stepStartTime := time.Now()
+		})
+		state.PendingCalls = response.ToolCalls
+	}
 
-			// 发送步骤开始事件
-			if streamCallback != nil {
-				streamCallback(StreamEvent{
-					Type: "step_start",
-					Data: map[string]interface{}{
-						"step": currentStep,
-						"tool": toolCall.Function.Name,
-					},
-				})
-			}
+	// 达到最大步骤数
+	log.Warn(ctx, "达到最大步骤数 %d", e.maxSteps)
+	return e.stop(state, startTime, "max_steps", "已达到最大步骤数限制，任务可能未完全完成。", "达到最大步骤数限制", streamCallback), nil
+}
 
-			// 解析参数
-			var args map[string]interface{}
-			if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
-				log.Error(ctx, "解析工具参数失败: %v", err)
-				args = make(map[string]interface{})
-			}
+// stop 未得到最终答案时结束执行（达到最大步骤数或超出预算），仍返回已有的执行轨迹
+func (e *ReActExecutor) stop(
+	state *ReActCheckpoint,
+	startTime time.Time,
+	finishReason string,
+	answer string,
+	errMsg string,
+	streamCallback func(event StreamEvent),
+) *ExecutionResult {
+	trace := state.Trace
+	trace.FinalAnswer = answer
+	trace.FinishReason = finishReason
+	trace.TotalMs = state.ElapsedMs + time.Since(startTime).Milliseconds()
+	trace.ToolStats = e.toolRunner.Stats()
 
-			log.Info(ctx, "执行工具: %s, 参数: %v", toolCall.Function.Name, args)
+	// 发送最终事件（即使未完全完成）
+	if streamCallback != nil {
+		streamCallback(StreamEvent{
+			Type: "final",
+			Data: map[string]interface{}{
+				"answer":        trace.FinalAnswer,
+				"finish_reason": finishReason,
+				"trace":         trace,
+				"token_usage":   trace.TokenUsage,
+			},
+		})
+	}
 
-			// 执行工具
-			toolResult, toolErr := e.toolRegistry.Execute(ctx, toolCall.Function.Name, args)
+	return &ExecutionResult{
+		Trace:   trace,
+		Success: false,
+		Error:   errMsg,
+	}
+}
 
-			// 格式化结果
-			var observation string
-			var toolOutput interface{}
+// handleToolCall 按审批策略处理一个工具调用：执行、拒绝，或返回待确认信息（此时不执行）
+func (e *ReActExecutor) handleToolCall(
+	ctx context.Context,
+	state *ReActCheckpoint,
+	toolCall llm.ToolCall,
+	decision *ApprovalDecision,
+	streamCallback func(event StreamEvent),
+) *models.AgentPendingApproval {
+	toolName := toolCall.Function.Name
+
+	// 解析参数
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
+		log.Error(ctx, "解析工具参数失败: %v", err)
+		args = make(map[string]interface{})
+	}
 
-			if toolErr != nil {
-				observation = fmt.Sprintf("Error: %s", toolErr.Error())
-				log.Error(ctx, "工具执行失败: %v", toolErr)
-			} else {
-				toolOutput = toolResult
-				observation = registry.FormatToolResult(toolResult)
-				log.Info(ctx, "工具执行成功，结果长度: %d", len(observation))
+	if decision != nil {
+		switch decision.Action {
+		case ApprovalReject:
+			observation := "The user rejected this tool call."
+			if decision.Reason != "" {
+				observation += " Reason: " + decision.Reason
+			}
+			e.recordSkippedCall(state, toolCall, args, observation, "用户拒绝执行", streamCallback)
+			return nil
+		case ApprovalEdit:
+			if decision.Args != nil {
+				args = decision.Args
+				updateToolCallArguments(state.Messages, toolCall.ID, args)
+			}
+		}
+	} else {
+		tool, _ := e.toolRegistry.GetTool(toolName)
+		switch tooling.ResolveApproval(toolName, tool, args, e.approvals) {
+		case utools.ApprovalDeny:
+			e.recordSkippedCall(state, toolCall, args, "This tool call is not allowed by the approval policy.", "审批策略禁止调用该工具", streamCallback)
+			return nil
+		case utools.ApprovalConfirm:
+			return &models.AgentPendingApproval{
+				ToolCallID:  toolCall.ID,
+				Tool:        toolName,
+				Args:        args,
+				Step:        state.Step,
+				RequestedAt: time.Now().Format(time.RFC3339),
 			}
+		}
+	}
+
+	e.executeToolCall(ctx, state, toolCall, args, streamCallback)
+	return nil
+}
 
-			elapsedMs := time.Since(stepStartTime).Milliseconds()
+// executeToolCall 执行工具并记录步骤与观察结果
+func (e *ReActExecutor) executeToolCall(
+	ctx context.Context,
+	state *ReActCheckpoint,
+	toolCall llm.ToolCall,
+	args map[string]interface{},
+	streamCallback func(event StreamEvent),
+) {
+	stepStartTime := time.Now()
+	currentStep := state.Step
+	toolName := toolCall.Function.Name
+
+	// 发送步骤开始事件
+	if streamCallback != nil {
+		streamCallback(StreamEvent{
+			Type: "step_start",
+			Data: map[string]interface{}{
+				"step": currentStep,
+				"tool": toolName,
+			},
+		})
+	}
 
-			// 记录步骤
-			step := models.AgentStep{
-				Step: currentStep,
-				Action: &models.AgentAction{
-					Type: "action",
-					Tool: toolCall.Function.Name,
-					Args: args,
+	log.Info(ctx, "执行工具: %s, 参数: %v", toolName, args)
+
+	// 执行工具（超时、重试、缓存）
+	execResult := e.toolRunner.Run(ctx, toolName, args, func(attempt int, message string) {
+		if streamCallback != nil {
+			streamCallback(StreamEvent{
+				Type: "tool_progress",
+				Data: map[string]interface{}{
+					"step":    currentStep,
+					"tool":    toolName,
+					"attempt": attempt,
+					"message": message,
 				},
-				Observation: observation,
-				ToolOutput:  toolOutput.(map[string]interface{}),
-				ElapsedMs:   elapsedMs,
-				Timestamp:   time.Now().Format(time.RFC3339),
-			}
+			})
+		}
+	})
+	toolErr := execResult.Error
+
+	// 格式化结果
+	var observation string
+	var toolOutput map[string]interface{}
+
+	if toolErr != nil {
+		observation = fmt.Sprintf("Error: %s", toolErr.Error())
+		log.Error(ctx, "工具执行失败（尝试 %d 次）: %v", execResult.Attempts, toolErr)
+	} else {
+		toolOutput = extractToolOutput(execResult.Output)
+		observation = registry.FormatToolResult(execResult.Output)
+		log.Info(ctx, "工具执行成功（尝试 %d 次，缓存: %v），结果长度: %d", execResult.Attempts, execResult.FromCache, len(observation))
+	}
 
-			if toolErr != nil {
-				step.Error = toolErr.Error()
-			}
+	elapsedMs := time.Since(stepStartTime).Milliseconds()
 
-			trace.Steps = append(trace.Steps, step)
+	// 记录步骤
+	step := models.AgentStep{
+		Step: currentStep,
+		Action: &models.AgentAction{
+			Type: "action",
+			Tool: toolName,
+			Args: args,
+		},
+		Observation: observation,
+		ToolOutput:  toolOutput,
+		ElapsedMs:   elapsedMs,
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Attempts:    execResult.Attempts,
+		Cached:      execResult.FromCache,
+	}
 
-			// 更新工具使用统计
-			if _, ok := trace.UsedTools[toolCall.Function.Name]; !ok {
-				trace.UsedTools[toolCall.Function.Name] = map[string]interface{}{
-					"count":    0,
-					"total_ms": int64(0),
-				}
-			}
-			stats := trace.UsedTools[toolCall.Function.Name].(map[string]interface{})
-			stats["count"] = stats["count"].(int) + 1
-			stats["total_ms"] = stats["total_ms"].(int64) + elapsedMs
-			trace.UsedTools[toolCall.Function.Name] = stats
+	if toolErr != nil {
+		step.Error = toolErr.Error()
+	}
 
-			// 发送步骤结束事件
-			if streamCallback != nil {
-				streamCallback(StreamEvent{
-					Type: "step_end",
-					Data: map[string]interface{}{
-						"step":        currentStep,
-						"tool":        toolCall.Function.Name,
-						"observation": observation,
-						"elapsed_ms":  elapsedMs,
-					},
-				})
-			}
+	state.Trace.Steps = append(state.Trace.Steps, step)
+	recordToolUsage(state.Trace, toolName, elapsedMs)
 
-			// 将工具结果添加到消息列表
-			toolMessage := llm.Message{
-				Role:       "tool",
-				Content:    observation,
-				ToolCallID: toolCall.ID,
-				Name:       toolCall.Function.Name,
-			}
-			messages = append(messages, toolMessage)
-		}
+	// 发送步骤结束事件
+	if streamCallback != nil {
+		streamCallback(StreamEvent{
+			Type: "step_end",
+			Data: map[string]interface{}{
+				"step":        currentStep,
+				"tool":        toolName,
+				"observation": observation,
+				"elapsed_ms":  elapsedMs,
+				"attempts":    execResult.Attempts,
+				"cached":      execResult.FromCache,
+			},
+		})
 	}
 
-	// 达到最大步骤数
-	log.Warn(ctx, "达到最大步骤数 %d", e.maxSteps)
+	// 将工具结果添加到消息列表
+	state.Messages = append(state.Messages, llm.Message{
+		Role:       "tool",
+		Content:    observation,
+		ToolCallID: toolCall.ID,
+		Name:       toolName,
+	})
+}
 
-	trace.FinalAnswer = "已达到最大步骤数限制，任务可能未完全完成。"
-	trace.FinishReason = "max_steps"
-	trace.TotalMs = time.Since(startTime).Milliseconds()
+// recordSkippedCall 记录未执行的工具调用（被拒绝或被策略禁止），并把原因作为观察结果告知模型
+func (e *ReActExecutor) recordSkippedCall(
+	state *ReActCheckpoint,
+	toolCall llm.ToolCall,
+	args map[string]interface{},
+	observation string,
+	reason string,
+	streamCallback func(event StreamEvent),
+) {
+	toolName := toolCall.Function.Name
+
+	state.Trace.Steps = append(state.Trace.Steps, models.AgentStep{
+		Step: state.Step,
+		Action: &models.AgentAction{
+			Type: "action",
+			Tool: toolName,
+			Args: args,
+		},
+		Observation: observation,
+		Timestamp:   time.Now().Format(time.RFC3339),
+		Error:       reason,
+	})
 
-	// 发送最终事件（即使未完全完成）
 	if streamCallback != nil {
 		streamCallback(StreamEvent{
-			Type: "final",
+			Type: "step_end",
 			Data: map[string]interface{}{
-				"answer":        trace.FinalAnswer,
-				"finish_reason": "max_steps",
-				"trace":         trace,
-				"token_usage":   trace.TokenUsage,
+				"step":        state.Step,
+				"tool":        toolName,
+				"observation": observation,
+				"skipped":     true,
+			},
+		})
+	}
+
+	state.Messages = append(state.Messages, llm.Message{
+		Role:       "tool",
+		Content:    observation,
+		ToolCallID: toolCall.ID,
+		Name:       toolName,
+	})
+}
+
+// pause 暂停执行：保存检查点并发送 approval_required 事件
+func (e *ReActExecutor) pause(
+	ctx context.Context,
+	state *ReActCheckpoint,
+	pending *models.AgentPendingApproval,
+	startTime time.Time,
+	streamCallback func(event StreamEvent),
+) (*ExecutionResult, error) {
+	trace := state.Trace
+	state.ElapsedMs += time.Since(startTime).Milliseconds()
+	trace.FinishReason = "approval_required"
+	trace.TotalMs = state.ElapsedMs
+	trace.ToolStats = e.toolRunner.Stats()
+
+	checkpoint, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("保存执行检查点失败: %w", err)
+	}
+
+	log.Info(ctx, "工具调用等待用户确认: %s, 参数: %v", pending.Tool, pending.Args)
+
+	if streamCallback != nil {
+		streamCallback(StreamEvent{
+			Type: "approval_required",
+			Data: map[string]interface{}{
+				"step":         pending.Step,
+				"tool":         pending.Tool,
+				"tool_call_id": pending.ToolCallID,
+				"args":         pending.Args,
+				"trace":        trace,
 			},
 		})
 	}
 
 	return &ExecutionResult{
-		Trace:   trace,
-		Success: false,
-		Error:   "达到最大步骤数限制",
+		Trace:      trace,
+		Success:    false,
+		Error:      "等待用户确认工具调用",
+		Pending:    pending,
+		Checkpoint: string(checkpoint),
 	}, nil
 }
 
 // buildMessages 构建消息列表
 func (e *ReActExecutor) buildMessages(
 	userMessage string,
-	conversationHistory string,
+	history []llm.Message,
 	allowedTools []string,
 ) []llm.Message {
 	messages := []llm.Message{
@@ -260,13 +492,8 @@ func (e *ReActExecutor) buildMessages(
 		},
 	}
 
-	// 添加对话历史（如果有）
-	if conversationHistory != "" {
-		messages = append(messages, llm.Message{
-			Role:    "user",
-			Content: "Previous conversation context:\n" + conversationHistory,
-		})
-	}
+	// 添加对话历史（摘要 + 最近的轮次）
+	messages = append(messages, history...)
 
 	// 添加用户消息
 	messages = append(messages, llm.Message{
@@ -277,15 +504,34 @@ func (e *ReActExecutor) buildMessages(
 	return messages
 }
 
+// extractToolOutput 提取工具输出数据，用于记录到步骤的 ToolOutput
+func extractToolOutput(output interface{}) map[string]interface{} {
+	switch v := output.(type) {
+	case *utools.ExecutionResult:
+		if v != nil {
+			return v.Output
+		}
+	case map[string]interface{}:
+		return v
+	}
+	return nil
+}
+
 // StreamEvent 流式事件
 type StreamEvent struct {
-	Type string                 // step_start, step_end, final, error
+	Type string                 // step_start, step_end, tool_progress, approval_required, final, error
 	Data map[string]interface{} // 事件数据
 }
 
+// BudgetGuard 用量预算检查，参数为本次执行已消耗的 Token，超出预算时返回错误
+type BudgetGuard func(usage *models.TokenUsage) error
+
 // ExecutionResult 执行结果
 type ExecutionResult struct {
-	Trace   *models.AgentTrace // 执行轨迹
-	Success bool               // 是否成功
-	Error   string             // 错误信息
+	Trace   *models.AgentTrace           // 执行轨迹
+	Success bool                         // 是否成功
+	Error   string                       // 错误信息
+	Pending *models.AgentPendingApproval // 非空表示执行已暂停，等待用户确认工具调用
+
+	Checkpoint string // 暂停时的执行检查点（JSON），恢复执行时传给 Resume
 }
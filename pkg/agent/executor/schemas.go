@@ -0,0 +1,58 @@
+package executor
+
+import (
+	"auto-forge/pkg/agent/llm"
+	"auto-forge/pkg/agent/registry"
+)
+
+// planOutput 计划的结构化输出
+type planOutput struct {
+	Steps []struct {
+		Step        int    `json:"step"`
+		Description string `json:"description"`
+		Tool        string `json:"tool"`
+		Reasoning   string `json:"reasoning"`
+	} `json:"steps"`
+}
+
+// planResponseSchema 计划输出的 JSON Schema，tool 字段限定为可用工具
+func planResponseSchema(toolDefinitions []llm.ToolDefinition) map[string]interface{} {
+	toolSchema := map[string]interface{}{"type": "string"}
+	if len(toolDefinitions) > 0 {
+		names := make([]interface{}, 0, len(toolDefinitions))
+		for _, td := range toolDefinitions {
+			names = append(names, td.Function.Name)
+		}
+		toolSchema["enum"] = names
+	}
+
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"steps": map[string]interface{}{
+				"type":     "array",
+				"minItems": 1,
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"step":        map[string]interface{}{"type": "integer"},
+						"description": map[string]interface{}{"type": "string"},
+						"tool":        toolSchema,
+						"reasoning":   map[string]interface{}{"type": "string"},
+					},
+					"required": []string{"step", "description", "tool"},
+				},
+			},
+		},
+		"required": []string{"steps"},
+	}
+}
+
+// toolArgsSchema 工具参数的 JSON Schema（与函数调用使用的参数定义一致）
+func toolArgsSchema(toolRegistry *registry.ToolRegistry, toolName string) map[string]interface{} {
+	definitions := toolRegistry.GetToolDefinitions([]string{toolName})
+	if len(definitions) == 0 || definitions[0].Function.Parameters == nil {
+		return map[string]interface{}{"type": "object"}
+	}
+	return definitions[0].Function.Parameters
+}
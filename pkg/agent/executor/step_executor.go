@@ -38,8 +38,9 @@ type ExecuteStepRequest struct {
 
 // ExecuteStepResult 执行步骤结果
 type ExecuteStepResult struct {
-	Step  *models.AgentStep
-	Error error
+	Step    *models.AgentStep
+	Error   error
+	Pending *models.AgentPendingApproval // 非空表示步骤需要用户确认，尚未执行
 }
 
 // ExecuteStep 执行单个步骤（模块化版本）
@@ -252,13 +253,7 @@ func (e *StepExecutor) createErrorStep(
 
 // getToolConfig 获取工具配置
 func (e *StepExecutor) getToolConfig(tool interface{}) *tooling.ExecutionConfig {
-	// 尝试从工具获取配置
-	if configurable, ok := tool.(tooling.ConfigurableTool); ok {
-		return configurable.GetExecutionConfig()
-	}
-
-	// 返回默认配置
-	return tooling.DefaultExecutionConfig()
+	return tooling.ResolveExecutionConfig(tool)
 }
 
 // BuildPreviousStepsContext 构建之前步骤的上下文
@@ -0,0 +1,406 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	anthropicAPIVersion       = "2023-06-01"
+	anthropicDefaultMaxTokens = 4096
+)
+
+// AnthropicClient Anthropic Messages API 客户端
+type AnthropicClient struct {
+	apiKey  string
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+// NewAnthropicClient 创建 Anthropic 客户端
+func NewAnthropicClient(model, apiKey, baseURL string) *AnthropicClient {
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com"
+	}
+
+	return &AnthropicClient{
+		apiKey:  apiKey,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		client: &http.Client{
+			Timeout: 120 * time.Second,
+		},
+	}
+}
+
+// Call 同步调用
+func (c *AnthropicClient) Call(ctx context.Context, messages []Message, options *CallOptions) (*Response, error) {
+	reqBody := c.buildRequest(messages, options, false)
+
+	var apiResp anthropicResponse
+	if err := postJSONAndDecode(ctx, c.client, c.baseURL+"/v1/messages", c.headers(), reqBody, &apiResp); err != nil {
+		return nil, err
+	}
+
+	resp := &Response{
+		FinishReason: convertAnthropicStopReason(apiResp.StopReason),
+		Usage: TokenUsage{
+			PromptTokens:     apiResp.Usage.InputTokens,
+			CompletionTokens: apiResp.Usage.OutputTokens,
+			TotalTokens:      apiResp.Usage.InputTokens + apiResp.Usage.OutputTokens,
+		},
+	}
+
+	var content strings.Builder
+	for _, block := range apiResp.Content {
+		switch block.Type {
+		case "text":
+			content.WriteString(block.Text)
+		case "tool_use":
+			resp.ToolCalls = append(resp.ToolCalls, ToolCall{
+				ID:   block.ID,
+				Type: "function",
+				Function: FunctionCall{
+					Name:      block.Name,
+					Arguments: marshalArguments(block.Input),
+				},
+			})
+		}
+	}
+	resp.Content = content.String()
+
+	return resp, nil
+}
+
+// Stream 流式调用
+func (c *AnthropicClient) Stream(ctx context.Context, messages []Message, options *CallOptions) (<-chan StreamChunk, error) {
+	chunkChan := make(chan StreamChunk, 10)
+
+	reqBody := c.buildRequest(messages, options, true)
+
+	go func() {
+		defer close(chunkChan)
+
+		headers := c.headers()
+		headers["Accept"] = "text/event-stream"
+		resp, err := postJSON(ctx, c.client, c.baseURL+"/v1/messages", headers, reqBody)
+		if err != nil {
+			chunkChan <- StreamChunk{Error: err, Done: true}
+			return
+		}
+		defer resp.Body.Close()
+
+		reader := NewSSEReader(resp.Body)
+		var fullContent strings.Builder
+		var toolCalls []ToolCall
+		// content block 下标 -> toolCalls 下标
+		toolIndex := make(map[int]int)
+		finishReason := ""
+
+		for {
+			event, err := reader.Read()
+			if err != nil {
+				if err != io.EOF {
+					chunkChan <- StreamChunk{Error: err, Done: true}
+					return
+				}
+				break
+			}
+
+			if event.Data == "" {
+				continue
+			}
+
+			var ev anthropicStreamEvent
+			if err := json.Unmarshal([]byte(event.Data), &ev); err != nil {
+				continue
+			}
+
+			switch ev.Type {
+			case "content_block_start":
+				if ev.ContentBlock.Type == "tool_use" {
+					toolIndex[ev.Index] = len(toolCalls)
+					toolCalls = append(toolCalls, ToolCall{
+						ID:       ev.ContentBlock.ID,
+						Type:     "function",
+						Function: FunctionCall{Name: ev.ContentBlock.Name},
+					})
+				}
+			case "content_block_delta":
+				switch ev.Delta.Type {
+				case "text_delta":
+					if ev.Delta.Text != "" {
+						fullContent.WriteString(ev.Delta.Text)
+						chunkChan <- StreamChunk{Content: ev.Delta.Text}
+					}
+				case "input_json_delta":
+					if i, ok := toolIndex[ev.Index]; ok {
+						toolCalls[i].Function.Arguments += ev.Delta.PartialJSON
+					}
+				}
+			case "message_delta":
+				if ev.Delta.StopReason != "" {
+					finishReason = convertAnthropicStopReason(ev.Delta.StopReason)
+				}
+			case "error":
+				chunkChan <- StreamChunk{Error: fmt.Errorf("API 错误: %s", ev.Error.Message), Done: true}
+				return
+			}
+
+			if ev.Type == "message_stop" {
+				break
+			}
+		}
+
+		// 无参数的工具调用不会产生 input_json_delta
+		for i := range toolCalls {
+			if toolCalls[i].Function.Arguments == "" {
+				toolCalls[i].Function.Arguments = "{}"
+			}
+		}
+
+		chunkChan <- StreamChunk{
+			Content:      fullContent.String(),
+			ToolCalls:    toolCalls,
+			FinishReason: finishReason,
+			Done:         true,
+		}
+	}()
+
+	return chunkChan, nil
+}
+
+// GetModelInfo 获取模型信息
+func (c *AnthropicClient) GetModelInfo() ModelInfo {
+	return ModelInfo{
+		Provider:      ProviderAnthropic,
+		Model:         c.model,
+		MaxTokens:     200000,
+		SupportTool:   true,
+		SupportVision: true,
+	}
+}
+
+func (c *AnthropicClient) headers() map[string]string {
+	return map[string]string{
+		"x-api-key":         c.apiKey,
+		"anthropic-version": anthropicAPIVersion,
+	}
+}
+
+// buildRequest 构建请求体
+func (c *AnthropicClient) buildRequest(messages []Message, options *CallOptions, stream bool) map[string]interface{} {
+	system, converted := convertAnthropicMessages(messages)
+
+	req := map[string]interface{}{
+		"model":      c.model,
+		"messages":   converted,
+		"max_tokens": anthropicDefaultMaxTokens,
+	}
+	if stream {
+		req["stream"] = true
+	}
+
+	if options != nil {
+		if options.Temperature > 0 {
+			req["temperature"] = options.Temperature
+		}
+		if options.MaxTokens > 0 {
+			req["max_tokens"] = options.MaxTokens
+		}
+		if options.TopP > 0 {
+			req["top_p"] = options.TopP
+		}
+		if len(options.Stop) > 0 {
+			req["stop_sequences"] = options.Stop
+		}
+		if len(options.Tools) > 0 {
+			tools := make([]map[string]interface{}, 0, len(options.Tools))
+			for _, t := range options.Tools {
+				schema := t.Function.Parameters
+				if schema == nil {
+					schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+				}
+				tools = append(tools, map[string]interface{}{
+					"name":         t.Function.Name,
+					"description":  t.Function.Description,
+					"input_schema": schema,
+				})
+			}
+			req["tools"] = tools
+
+			mode, name := parseToolChoice(options.ToolChoice)
+			switch mode {
+			case "none":
+				req["tool_choice"] = map[string]interface{}{"type": "none"}
+			case "required":
+				req["tool_choice"] = map[string]interface{}{"type": "any"}
+			case "function":
+				req["tool_choice"] = map[string]interface{}{"type": "tool", "name": name}
+			}
+		}
+		// Messages API 没有 JSON 模式，通过系统提示约束输出
+		if format := options.ResponseFormat; format.IsJSON() {
+			if system != "" {
+				system += "\n\n"
+			}
+			system += "Respond with a single valid JSON object only, without any surrounding text."
+			if format.HasSchema() {
+				schema, _ := json.Marshal(format.Schema)
+				system += "\nThe JSON object must conform to this JSON Schema:\n" + string(schema)
+			}
+		}
+	}
+
+	if system != "" {
+		req["system"] = system
+	}
+
+	return req
+}
+
+// convertAnthropicMessages 转换消息格式：system 消息提取为顶层 system，
+// tool 消息转为 user 角色的 tool_result 块，连续同角色消息合并
+func convertAnthropicMessages(messages []Message) (string, []map[string]interface{}) {
+	var systemParts []string
+	var result []map[string]interface{}
+
+	appendBlocks := func(role string, blocks []map[string]interface{}) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(result); n > 0 && result[n-1]["role"] == role {
+			prev := result[n-1]["content"].([]map[string]interface{})
+			result[n-1]["content"] = append(prev, blocks...)
+			return
+		}
+		result = append(result, map[string]interface{}{
+			"role":    role,
+			"content": blocks,
+		})
+	}
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case "system":
+			if msg.Content != "" {
+				systemParts = append(systemParts, msg.Content)
+			}
+		case "tool":
+			appendBlocks("user", []map[string]interface{}{{
+				"type":        "tool_result",
+				"tool_use_id": msg.ToolCallID,
+				"content":     msg.Content,
+			}})
+		case "assistant":
+			var blocks []map[string]interface{}
+			if msg.Content != "" {
+				blocks = append(blocks, map[string]interface{}{"type": "text", "text": msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				blocks = append(blocks, map[string]interface{}{
+					"type":  "tool_use",
+					"id":    tc.ID,
+					"name":  tc.Function.Name,
+					"input": toolArgumentsToMap(tc.Function.Arguments),
+				})
+			}
+			appendBlocks("assistant", blocks)
+		default:
+			var blocks []map[string]interface{}
+			for _, part := range msg.ContentParts() {
+				if part.Type == "image" {
+					blocks = append(blocks, map[string]interface{}{
+						"type": "image",
+						"source": map[string]interface{}{
+							"type":       "base64",
+							"media_type": part.MimeType,
+							"data":       part.Data,
+						},
+					})
+					continue
+				}
+				if part.Text != "" {
+					blocks = append(blocks, map[string]interface{}{"type": "text", "text": part.Text})
+				}
+			}
+			appendBlocks("user", blocks)
+		}
+	}
+
+	return strings.Join(systemParts, "\n\n"), result
+}
+
+// convertAnthropicStopReason 将 stop_reason 映射为 OpenAI 风格的 finish_reason
+func convertAnthropicStopReason(reason string) string {
+	switch reason {
+	case "end_turn", "stop_sequence":
+		return "stop"
+	case "tool_use":
+		return "tool_calls"
+	case "max_tokens":
+		return "length"
+	}
+	return reason
+}
+
+// parseToolChoice 解析 OpenAI 风格的 tool_choice，返回模式（auto/none/required/function）及指定的函数名
+func parseToolChoice(choice interface{}) (mode, name string) {
+	switch v := choice.(type) {
+	case nil:
+		return "auto", ""
+	case string:
+		return v, ""
+	case map[string]interface{}:
+		if fn, ok := v["function"].(map[string]interface{}); ok {
+			name, _ = fn["name"].(string)
+			return "function", name
+		}
+	case map[string]string:
+		if v["name"] != "" {
+			return "function", v["name"]
+		}
+	}
+	return "auto", ""
+}
+
+// Anthropic API 响应结构
+type anthropicResponse struct {
+	Content []struct {
+		Type  string                 `json:"type"`
+		Text  string                 `json:"text"`
+		ID    string                 `json:"id"`
+		Name  string                 `json:"name"`
+		Input map[string]interface{} `json:"input"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+type anthropicStreamEvent struct {
+	Type         string `json:"type"`
+	Index        int    `json:"index"`
+	ContentBlock struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"content_block"`
+	Delta struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
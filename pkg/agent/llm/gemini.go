@@ -0,0 +1,435 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// GeminiClient Google Gemini（generateContent）客户端
+type GeminiClient struct {
+	apiKey  string
+	baseURL string
+	model   string
+	client  *http.Client
+}
+
+// NewGeminiClient 创建 Gemini 客户端
+func NewGeminiClient(model, apiKey, baseURL string) *GeminiClient {
+	if baseURL == "" {
+		baseURL = "https://generativelanguage.googleapis.com/v1beta"
+	}
+
+	return &GeminiClient{
+		apiKey:  apiKey,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   model,
+		client: &http.Client{
+			Timeout: 120 * time.Second,
+		},
+	}
+}
+
+// Call 同步调用
+func (c *GeminiClient) Call(ctx context.Context, messages []Message, options *CallOptions) (*Response, error) {
+	reqBody := c.buildRequest(messages, options)
+
+	url := fmt.Sprintf("%s/models/%s:generateContent", c.baseURL, c.model)
+	var apiResp geminiResponse
+	if err := postJSONAndDecode(ctx, c.client, url, c.headers(), reqBody, &apiResp); err != nil {
+		return nil, err
+	}
+
+	if len(apiResp.Candidates) == 0 {
+		return nil, fmt.Errorf("响应中没有候选结果")
+	}
+
+	candidate := apiResp.Candidates[0]
+	resp := &Response{
+		Usage: TokenUsage{
+			PromptTokens:     apiResp.UsageMetadata.PromptTokenCount,
+			CompletionTokens: apiResp.UsageMetadata.CandidatesTokenCount,
+			TotalTokens:      apiResp.UsageMetadata.TotalTokenCount,
+		},
+	}
+
+	var content strings.Builder
+	for _, part := range candidate.Content.Parts {
+		if part.FunctionCall != nil {
+			resp.ToolCalls = append(resp.ToolCalls, geminiToolCall(len(resp.ToolCalls), part.FunctionCall))
+			continue
+		}
+		content.WriteString(part.Text)
+	}
+	resp.Content = content.String()
+	resp.FinishReason = convertGeminiFinishReason(candidate.FinishReason, len(resp.ToolCalls) > 0)
+
+	return resp, nil
+}
+
+// Stream 流式调用
+func (c *GeminiClient) Stream(ctx context.Context, messages []Message, options *CallOptions) (<-chan StreamChunk, error) {
+	chunkChan := make(chan StreamChunk, 10)
+
+	reqBody := c.buildRequest(messages, options)
+
+	go func() {
+		defer close(chunkChan)
+
+		url := fmt.Sprintf("%s/models/%s:streamGenerateContent?alt=sse", c.baseURL, c.model)
+		headers := c.headers()
+		headers["Accept"] = "text/event-stream"
+		resp, err := postJSON(ctx, c.client, url, headers, reqBody)
+		if err != nil {
+			chunkChan <- StreamChunk{Error: err, Done: true}
+			return
+		}
+		defer resp.Body.Close()
+
+		reader := NewSSEReader(resp.Body)
+		var fullContent strings.Builder
+		var toolCalls []ToolCall
+		finishReason := ""
+
+		for {
+			event, err := reader.Read()
+			if err != nil {
+				if err != io.EOF {
+					chunkChan <- StreamChunk{Error: err, Done: true}
+					return
+				}
+				break
+			}
+
+			if event.Data == "" {
+				continue
+			}
+
+			var chunk geminiResponse
+			if err := json.Unmarshal([]byte(event.Data), &chunk); err != nil {
+				continue
+			}
+			if len(chunk.Candidates) == 0 {
+				continue
+			}
+
+			candidate := chunk.Candidates[0]
+			for _, part := range candidate.Content.Parts {
+				if part.FunctionCall != nil {
+					// Gemini 的函数调用不会被拆分到多个分片中
+					toolCalls = append(toolCalls, geminiToolCall(len(toolCalls), part.FunctionCall))
+					continue
+				}
+				if part.Text != "" {
+					fullContent.WriteString(part.Text)
+					chunkChan <- StreamChunk{Content: part.Text}
+				}
+			}
+			if candidate.FinishReason != "" {
+				finishReason = candidate.FinishReason
+			}
+		}
+
+		chunkChan <- StreamChunk{
+			Content:      fullContent.String(),
+			ToolCalls:    toolCalls,
+			FinishReason: convertGeminiFinishReason(finishReason, len(toolCalls) > 0),
+			Done:         true,
+		}
+	}()
+
+	return chunkChan, nil
+}
+
+// GetModelInfo 获取模型信息
+func (c *GeminiClient) GetModelInfo() ModelInfo {
+	return ModelInfo{
+		Provider:      ProviderGemini,
+		Model:         c.model,
+		MaxTokens:     1000000,
+		SupportTool:   true,
+		SupportVision: true,
+	}
+}
+
+func (c *GeminiClient) headers() map[string]string {
+	return map[string]string{"x-goog-api-key": c.apiKey}
+}
+
+// buildRequest 构建请求体
+func (c *GeminiClient) buildRequest(messages []Message, options *CallOptions) map[string]interface{} {
+	system, contents := convertGeminiMessages(messages)
+
+	req := map[string]interface{}{
+		"contents": contents,
+	}
+	if system != "" {
+		req["systemInstruction"] = map[string]interface{}{
+			"parts": []map[string]interface{}{{"text": system}},
+		}
+	}
+
+	if options != nil {
+		genConfig := map[string]interface{}{}
+		if options.Temperature > 0 {
+			genConfig["temperature"] = options.Temperature
+		}
+		if options.MaxTokens > 0 {
+			genConfig["maxOutputTokens"] = options.MaxTokens
+		}
+		if options.TopP > 0 {
+			genConfig["topP"] = options.TopP
+		}
+		if len(options.Stop) > 0 {
+			genConfig["stopSequences"] = options.Stop
+		}
+		if format := options.ResponseFormat; format.IsJSON() {
+			genConfig["responseMimeType"] = "application/json"
+			if format.HasSchema() {
+				if schema := SanitizeGeminiSchema(format.Schema); len(schema) > 0 {
+					genConfig["responseSchema"] = schema
+				}
+			}
+		}
+		if len(genConfig) > 0 {
+			req["generationConfig"] = genConfig
+		}
+
+		if len(options.Tools) > 0 {
+			decls := make([]map[string]interface{}, 0, len(options.Tools))
+			for _, t := range options.Tools {
+				decl := map[string]interface{}{
+					"name":        t.Function.Name,
+					"description": t.Function.Description,
+				}
+				if params := SanitizeGeminiSchema(t.Function.Parameters); len(params) > 0 {
+					decl["parameters"] = params
+				}
+				decls = append(decls, decl)
+			}
+			req["tools"] = []map[string]interface{}{{"functionDeclarations": decls}}
+
+			callingConfig := map[string]interface{}{}
+			mode, name := parseToolChoice(options.ToolChoice)
+			switch mode {
+			case "none":
+				callingConfig["mode"] = "NONE"
+			case "required":
+				callingConfig["mode"] = "ANY"
+			case "function":
+				callingConfig["mode"] = "ANY"
+				callingConfig["allowedFunctionNames"] = []string{name}
+			}
+			if len(callingConfig) > 0 {
+				req["toolConfig"] = map[string]interface{}{"functionCallingConfig": callingConfig}
+			}
+		}
+	}
+
+	return req
+}
+
+// convertGeminiMessages 转换消息格式：system 消息提取为 systemInstruction，
+// assistant 映射为 model 角色，tool 消息转为 functionResponse，连续同角色消息合并
+func convertGeminiMessages(messages []Message) (string, []map[string]interface{}) {
+	var systemParts []string
+	var result []map[string]interface{}
+	// Gemini 通过函数名而非 ID 关联调用结果
+	callNames := make(map[string]string)
+
+	appendParts := func(role string, parts []map[string]interface{}) {
+		if len(parts) == 0 {
+			return
+		}
+		if n := len(result); n > 0 && result[n-1]["role"] == role {
+			prev := result[n-1]["parts"].([]map[string]interface{})
+			result[n-1]["parts"] = append(prev, parts...)
+			return
+		}
+		result = append(result, map[string]interface{}{
+			"role":  role,
+			"parts": parts,
+		})
+	}
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case "system":
+			if msg.Content != "" {
+				systemParts = append(systemParts, msg.Content)
+			}
+		case "tool":
+			name := callNames[msg.ToolCallID]
+			if name == "" {
+				name = msg.Name
+			}
+			appendParts("user", []map[string]interface{}{{
+				"functionResponse": map[string]interface{}{
+					"name":     name,
+					"response": geminiFunctionResponse(msg.Content),
+				},
+			}})
+		case "assistant":
+			var parts []map[string]interface{}
+			if msg.Content != "" {
+				parts = append(parts, map[string]interface{}{"text": msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				callNames[tc.ID] = tc.Function.Name
+				parts = append(parts, map[string]interface{}{
+					"functionCall": map[string]interface{}{
+						"name": tc.Function.Name,
+						"args": toolArgumentsToMap(tc.Function.Arguments),
+					},
+				})
+			}
+			appendParts("model", parts)
+		default:
+			var parts []map[string]interface{}
+			for _, part := range msg.ContentParts() {
+				if part.Type == "image" {
+					parts = append(parts, map[string]interface{}{
+						"inlineData": map[string]interface{}{
+							"mimeType": part.MimeType,
+							"data":     part.Data,
+						},
+					})
+					continue
+				}
+				if part.Text != "" {
+					parts = append(parts, map[string]interface{}{"text": part.Text})
+				}
+			}
+			appendParts("user", parts)
+		}
+	}
+
+	return strings.Join(systemParts, "\n\n"), result
+}
+
+// geminiFunctionResponse functionResponse.response 必须是对象：JSON 对象原样传递，其他内容包装为 {"result": ...}
+func geminiFunctionResponse(content string) map[string]interface{} {
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &obj); err == nil && obj != nil {
+		return obj
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(content), &value); err == nil {
+		return map[string]interface{}{"result": value}
+	}
+	return map[string]interface{}{"result": content}
+}
+
+// geminiSchemaKeys Gemini 函数声明支持的 OpenAPI Schema 字段
+var geminiSchemaKeys = map[string]bool{
+	"type":        true,
+	"format":      true,
+	"description": true,
+	"nullable":    true,
+	"enum":        true,
+	"properties":  true,
+	"required":    true,
+	"items":       true,
+}
+
+// SanitizeGeminiSchema 递归移除 Gemini 不支持的 JSON Schema 字段（如 additionalProperties、$schema）
+func SanitizeGeminiSchema(schema map[string]interface{}) map[string]interface{} {
+	if schema == nil {
+		return nil
+	}
+
+	result := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		if !geminiSchemaKeys[key] {
+			continue
+		}
+		switch key {
+		case "properties":
+			props, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if len(props) == 0 {
+				continue
+			}
+			cleaned := make(map[string]interface{}, len(props))
+			for name, prop := range props {
+				if p, ok := prop.(map[string]interface{}); ok {
+					cleaned[name] = SanitizeGeminiSchema(p)
+				}
+			}
+			result[key] = cleaned
+		case "items":
+			if item, ok := value.(map[string]interface{}); ok {
+				result[key] = SanitizeGeminiSchema(item)
+			}
+		default:
+			result[key] = value
+		}
+	}
+
+	// 没有属性的 object 会被 Gemini 拒绝
+	if result["type"] == "object" && result["properties"] == nil {
+		delete(result, "required")
+		if len(result) == 1 {
+			return nil
+		}
+	}
+	return result
+}
+
+// geminiToolCall 将 functionCall 转换为工具调用，Gemini 不返回调用 ID，按序号生成
+func geminiToolCall(index int, fc *geminiFunctionCall) ToolCall {
+	return ToolCall{
+		ID:   fmt.Sprintf("call_%d_%s", index, fc.Name),
+		Type: "function",
+		Function: FunctionCall{
+			Name:      fc.Name,
+			Arguments: marshalArguments(fc.Args),
+		},
+	}
+}
+
+// convertGeminiFinishReason 将 finishReason 映射为 OpenAI 风格的 finish_reason
+func convertGeminiFinishReason(reason string, hasToolCalls bool) string {
+	if hasToolCalls {
+		return "tool_calls"
+	}
+	switch reason {
+	case "STOP":
+		return "stop"
+	case "MAX_TOKENS":
+		return "length"
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT":
+		return "content_filter"
+	}
+	return strings.ToLower(reason)
+}
+
+// Gemini API 响应结构
+type geminiResponse struct {
+	Candidates []struct {
+		Content struct {
+			Role  string `json:"role"`
+			Parts []struct {
+				Text         string              `json:"text"`
+				FunctionCall *geminiFunctionCall `json:"functionCall"`
+			} `json:"parts"`
+		} `json:"content"`
+		FinishReason string `json:"finishReason"`
+	} `json:"candidates"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+type geminiFunctionCall struct {
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args"`
+}
@@ -13,10 +13,11 @@ import (
 
 // OpenAIClient OpenAI 客户端
 type OpenAIClient struct {
-	apiKey  string
-	baseURL string
-	model   string
-	client  *http.Client
+	provider string
+	apiKey   string
+	baseURL  string
+	model    string
+	client   *http.Client
 }
 
 // NewOpenAIClient 创建 OpenAI 客户端
@@ -24,11 +25,16 @@ func NewOpenAIClient(model, apiKey, baseURL string) *OpenAIClient {
 	if baseURL == "" {
 		baseURL = "https://api.openai.com/v1"
 	}
+	return NewOpenAICompatibleClient(ProviderOpenAI, model, apiKey, baseURL)
+}
 
+// NewOpenAICompatibleClient 创建兼容 OpenAI Chat Completions 协议的客户端（Ollama、vLLM、LM Studio 等）
+func NewOpenAICompatibleClient(provider, model, apiKey, baseURL string) *OpenAIClient {
 	return &OpenAIClient{
-		apiKey:  apiKey,
-		baseURL: baseURL,
-		model:   model,
+		provider: provider,
+		apiKey:   apiKey,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		model:    model,
 		client: &http.Client{
 			Timeout: 120 * time.Second,
 		},
@@ -179,13 +185,31 @@ func (c *OpenAIClient) GetModelInfo() ModelInfo {
 	}
 
 	return ModelInfo{
-		Provider:    "openai",
-		Model:       c.model,
-		MaxTokens:   maxTokens,
-		SupportTool: true,
+		Provider:      c.provider,
+		Model:         c.model,
+		MaxTokens:     maxTokens,
+		SupportTool:   true,
+		SupportVision: isVisionModel(c.model),
 	}
 }
 
+// visionModelKeywords OpenAI 兼容接口（含 Ollama）中支持图片输入的模型名关键字
+var visionModelKeywords = []string{
+	"gpt-4o", "gpt-4-turbo", "gpt-4.1", "gpt-5", "o1", "o3", "o4", "vision",
+	"llava", "moondream", "minicpm-v", "gemma3", "qwen2.5vl", "qwen2.5-vl", "-vl",
+}
+
+// isVisionModel 按模型名判断是否支持图片输入
+func isVisionModel(model string) bool {
+	model = strings.ToLower(model)
+	for _, keyword := range visionModelKeywords {
+		if strings.Contains(model, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
 // buildRequest 构建请求体
 func (c *OpenAIClient) buildRequest(messages []Message, options *CallOptions, stream bool) map[string]interface{} {
 	req := map[string]interface{}{
@@ -213,7 +237,20 @@ func (c *OpenAIClient) buildRequest(messages []Message, options *CallOptions, st
 		if options.ToolChoice != nil {
 			req["tool_choice"] = options.ToolChoice
 		}
-		if options.ResponseFormat == "json_object" {
+		if format := options.ResponseFormat; format.HasSchema() {
+			name := format.Name
+			if name == "" {
+				name = "response"
+			}
+			req["response_format"] = map[string]interface{}{
+				"type": "json_schema",
+				"json_schema": map[string]interface{}{
+					"name":   name,
+					"schema": format.Schema,
+					"strict": format.Strict,
+				},
+			}
+		} else if format.IsJSON() {
 			req["response_format"] = map[string]string{"type": "json_object"}
 		}
 	}
@@ -234,7 +271,9 @@ func (c *OpenAIClient) doRequest(ctx context.Context, path string, body map[stri
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
@@ -267,7 +306,9 @@ func (c *OpenAIClient) doStreamRequest(ctx context.Context, path string, body ma
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
 	req.Header.Set("Accept", "text/event-stream")
 
 	resp, err := c.client.Do(req)
@@ -292,6 +333,9 @@ func convertMessages(messages []Message) []map[string]interface{} {
 			"role":    msg.Role,
 			"content": msg.Content,
 		}
+		if len(msg.Parts) > 0 {
+			m["content"] = convertOpenAIContentParts(msg.ContentParts())
+		}
 		if msg.Name != "" {
 			m["name"] = msg.Name
 		}
@@ -306,6 +350,25 @@ func convertMessages(messages []Message) []map[string]interface{} {
 	return result
 }
 
+// convertOpenAIContentParts 转换多模态内容，图片以 data URL 传递
+func convertOpenAIContentParts(parts []ContentPart) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(parts))
+	for _, part := range parts {
+		switch part.Type {
+		case "image":
+			result = append(result, map[string]interface{}{
+				"type": "image_url",
+				"image_url": map[string]interface{}{
+					"url": "data:" + part.MimeType + ";base64," + part.Data,
+				},
+			})
+		default:
+			result = append(result, map[string]interface{}{"type": "text", "text": part.Text})
+		}
+	}
+	return result
+}
+
 // convertToolCalls 转换工具调用格式
 func convertToolCalls(apiToolCalls []openAIToolCall) []ToolCall {
 	if len(apiToolCalls) == 0 {
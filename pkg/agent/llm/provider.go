@@ -0,0 +1,72 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 支持的 LLM 提供商
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+	ProviderGemini    = "gemini"
+	ProviderOllama    = "ollama"
+)
+
+// ProviderConfig 单个提供商的连接配置
+type ProviderConfig struct {
+	APIKey  string
+	BaseURL string
+}
+
+// ParseModel 解析 "provider/model" 形式的模型标识
+// 未带前缀或前缀不是已知提供商时（如 OpenRouter 的 "meta-llama/llama-3"），按 OpenAI 处理
+func ParseModel(spec string) (provider, model string) {
+	spec = strings.TrimSpace(spec)
+	if idx := strings.Index(spec, "/"); idx > 0 {
+		prefix := strings.ToLower(spec[:idx])
+		switch prefix {
+		case ProviderOpenAI, ProviderAnthropic, ProviderGemini, ProviderOllama:
+			return prefix, spec[idx+1:]
+		}
+	}
+	return ProviderOpenAI, spec
+}
+
+// NewClientForModel 根据模型标识创建对应提供商的客户端
+func NewClientForModel(spec string, configs map[string]ProviderConfig) (LLMClient, error) {
+	provider, model := ParseModel(spec)
+	if model == "" {
+		return nil, fmt.Errorf("模型名称不能为空")
+	}
+
+	cfg := configs[provider]
+	switch provider {
+	case ProviderAnthropic:
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("Anthropic API Key 未配置，请在 config.yaml 中设置 agent.anthropic.api_key 或设置环境变量 ANTHROPIC_API_KEY")
+		}
+		return NewAnthropicClient(model, cfg.APIKey, cfg.BaseURL), nil
+	case ProviderGemini:
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("Gemini API Key 未配置，请在 config.yaml 中设置 agent.gemini.api_key 或设置环境变量 GEMINI_API_KEY")
+		}
+		return NewGeminiClient(model, cfg.APIKey, cfg.BaseURL), nil
+	case ProviderOllama:
+		return NewOllamaClient(model, cfg.APIKey, cfg.BaseURL), nil
+	default:
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("OpenAI API Key 未配置，请在 config.yaml 中设置 agent.openai.api_key 或设置环境变量 OPENAI_API_KEY")
+		}
+		return NewOpenAIClient(model, cfg.APIKey, cfg.BaseURL), nil
+	}
+}
+
+// NewOllamaClient 创建 Ollama 客户端，走其 OpenAI 兼容接口（/v1/chat/completions），
+// 同样适用于 vLLM、LM Studio 等本地 OpenAI 兼容服务
+func NewOllamaClient(model, apiKey, baseURL string) *OpenAIClient {
+	if baseURL == "" {
+		baseURL = "http://localhost:11434/v1"
+	}
+	return NewOpenAICompatibleClient(ProviderOllama, model, apiKey, baseURL)
+}
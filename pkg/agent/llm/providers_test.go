@@ -0,0 +1,371 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// recordedRequest 桩服务器收到的请求
+type recordedRequest struct {
+	Path    string
+	Query   string
+	Headers http.Header
+	Body    map[string]interface{}
+}
+
+// newFixtureServer 启动本地桩服务器，对任意请求返回 testdata 中录制的响应
+func newFixtureServer(t *testing.T, fixture, contentType string) (*httptest.Server, *recordedRequest) {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatalf("读取 fixture 失败: %v", err)
+	}
+
+	recorded := &recordedRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorded.Path = r.URL.Path
+		recorded.Query = r.URL.RawQuery
+		recorded.Headers = r.Header.Clone()
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &recorded.Body)
+
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, recorded
+}
+
+func weatherTool() []ToolDefinition {
+	return []ToolDefinition{{
+		Type: "function",
+		Function: FunctionDefinition{
+			Name:        "get_weather",
+			Description: "查询城市天气",
+			Parameters: map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": false,
+				"properties": map[string]interface{}{
+					"city": map[string]interface{}{"type": "string", "description": "城市名"},
+				},
+				"required": []interface{}{"city"},
+			},
+		},
+	}}
+}
+
+// toolRoundTrip 一轮完整的工具调用对话
+func toolRoundTrip() []Message {
+	return []Message{
+		{Role: "system", Content: "你是天气助手"},
+		{Role: "user", Content: "北京天气如何？"},
+		{Role: "assistant", ToolCalls: []ToolCall{{
+			ID:       "call_1",
+			Type:     "function",
+			Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"北京"}`},
+		}}},
+		{Role: "tool", ToolCallID: "call_1", Content: `{"temp":25}`},
+	}
+}
+
+func collectStream(t *testing.T, ch <-chan StreamChunk) (string, StreamChunk) {
+	t.Helper()
+
+	var deltas string
+	var final StreamChunk
+	for chunk := range ch {
+		if chunk.Error != nil {
+			t.Fatalf("流式调用失败: %v", chunk.Error)
+		}
+		if chunk.Done {
+			final = chunk
+			continue
+		}
+		deltas += chunk.Content
+	}
+	return deltas, final
+}
+
+func TestParseModel(t *testing.T) {
+	tests := []struct {
+		spec     string
+		provider string
+		model    string
+	}{
+		{"gpt-4o-mini", ProviderOpenAI, "gpt-4o-mini"},
+		{"anthropic/claude-sonnet-4-5", ProviderAnthropic, "claude-sonnet-4-5"},
+		{"Gemini/gemini-2.0-flash", ProviderGemini, "gemini-2.0-flash"},
+		{"ollama/llama3", ProviderOllama, "llama3"},
+		{"meta-llama/llama-3-70b", ProviderOpenAI, "meta-llama/llama-3-70b"},
+	}
+
+	for _, tt := range tests {
+		provider, model := ParseModel(tt.spec)
+		if provider != tt.provider || model != tt.model {
+			t.Errorf("ParseModel(%q) = (%q, %q), want (%q, %q)", tt.spec, provider, model, tt.provider, tt.model)
+		}
+	}
+}
+
+func TestNewClientForModel(t *testing.T) {
+	configs := map[string]ProviderConfig{
+		ProviderAnthropic: {APIKey: "sk-ant"},
+	}
+
+	client, err := NewClientForModel("anthropic/claude-sonnet-4-5", configs)
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+	if info := client.GetModelInfo(); info.Provider != ProviderAnthropic || info.Model != "claude-sonnet-4-5" {
+		t.Errorf("unexpected model info: %+v", info)
+	}
+
+	// Ollama 无需 API Key
+	client, err = NewClientForModel("ollama/llama3", configs)
+	if err != nil {
+		t.Fatalf("创建 Ollama 客户端失败: %v", err)
+	}
+	if info := client.GetModelInfo(); info.Provider != ProviderOllama {
+		t.Errorf("unexpected provider: %s", info.Provider)
+	}
+
+	if _, err := NewClientForModel("gemini/gemini-2.0-flash", configs); err == nil {
+		t.Error("缺少 Gemini API Key 时应返回错误")
+	}
+}
+
+func TestAnthropicClientCall(t *testing.T) {
+	server, req := newFixtureServer(t, "anthropic_tool_use.json", "application/json")
+	client := NewAnthropicClient("claude-sonnet-4-5", "sk-ant", server.URL)
+
+	resp, err := client.Call(context.Background(), toolRoundTrip(), &CallOptions{
+		Tools:      weatherTool(),
+		ToolChoice: "required",
+	})
+	if err != nil {
+		t.Fatalf("调用失败: %v", err)
+	}
+
+	// 请求映射
+	if req.Path != "/v1/messages" {
+		t.Errorf("path = %s", req.Path)
+	}
+	if req.Headers.Get("x-api-key") != "sk-ant" || req.Headers.Get("anthropic-version") == "" {
+		t.Errorf("缺少认证头: %v", req.Headers)
+	}
+	if req.Body["system"] != "你是天气助手" {
+		t.Errorf("system = %v", req.Body["system"])
+	}
+	if choice, _ := req.Body["tool_choice"].(map[string]interface{}); choice["type"] != "any" {
+		t.Errorf("tool_choice = %v", req.Body["tool_choice"])
+	}
+	tools, _ := req.Body["tools"].([]interface{})
+	if len(tools) != 1 || tools[0].(map[string]interface{})["input_schema"] == nil {
+		t.Errorf("tools = %v", req.Body["tools"])
+	}
+	msgs, _ := req.Body["messages"].([]interface{})
+	if len(msgs) != 3 {
+		t.Fatalf("messages = %v", req.Body["messages"])
+	}
+	toolUse := msgs[1].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+	if toolUse["type"] != "tool_use" || toolUse["id"] != "call_1" {
+		t.Errorf("assistant 工具调用映射错误: %v", toolUse)
+	}
+	toolResult := msgs[2].(map[string]interface{})
+	block := toolResult["content"].([]interface{})[0].(map[string]interface{})
+	if toolResult["role"] != "user" || block["type"] != "tool_result" || block["tool_use_id"] != "call_1" {
+		t.Errorf("工具结果映射错误: %v", toolResult)
+	}
+
+	// 响应映射
+	if resp.Content != "我来查询一下天气。" || resp.FinishReason != "tool_calls" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].ID != "toolu_01A09q90qw90lq917835lq9" ||
+		resp.ToolCalls[0].Function.Name != "get_weather" || resp.ToolCalls[0].Function.Arguments != `{"city":"北京"}` {
+		t.Errorf("unexpected tool calls: %+v", resp.ToolCalls)
+	}
+	if resp.Usage.TotalTokens != 165 {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+}
+
+func TestAnthropicClientStream(t *testing.T) {
+	server, req := newFixtureServer(t, "anthropic_stream.sse", "text/event-stream")
+	client := NewAnthropicClient("claude-sonnet-4-5", "sk-ant", server.URL)
+
+	ch, err := client.Stream(context.Background(), []Message{{Role: "user", Content: "北京天气如何？"}}, &CallOptions{Tools: weatherTool()})
+	if err != nil {
+		t.Fatalf("流式调用失败: %v", err)
+	}
+	deltas, final := collectStream(t, ch)
+
+	if req.Body["stream"] != true {
+		t.Errorf("stream 未开启: %v", req.Body)
+	}
+	if deltas != "我来查询一下天气。" || final.Content != deltas {
+		t.Errorf("deltas = %q, final = %q", deltas, final.Content)
+	}
+	if final.FinishReason != "tool_calls" || len(final.ToolCalls) != 1 {
+		t.Fatalf("unexpected final chunk: %+v", final)
+	}
+	if final.ToolCalls[0].ID != "toolu_01" || final.ToolCalls[0].Function.Arguments != `{"city": "北京"}` {
+		t.Errorf("unexpected tool call: %+v", final.ToolCalls[0])
+	}
+}
+
+func TestGeminiClientCall(t *testing.T) {
+	server, req := newFixtureServer(t, "gemini_function_call.json", "application/json")
+	client := NewGeminiClient("gemini-2.0-flash", "g-key", server.URL)
+
+	resp, err := client.Call(context.Background(), toolRoundTrip(), &CallOptions{
+		Tools: weatherTool(),
+		ResponseFormat: JSONSchemaFormat("weather", map[string]interface{}{
+			"type":                 "object",
+			"properties":           map[string]interface{}{"temp": map[string]interface{}{"type": "number"}},
+			"additionalProperties": false,
+		}),
+	})
+	if err != nil {
+		t.Fatalf("调用失败: %v", err)
+	}
+
+	// 请求映射
+	if req.Path != "/models/gemini-2.0-flash:generateContent" {
+		t.Errorf("path = %s", req.Path)
+	}
+	if req.Headers.Get("x-goog-api-key") != "g-key" {
+		t.Errorf("缺少认证头: %v", req.Headers)
+	}
+	if req.Body["systemInstruction"] == nil {
+		t.Error("system 消息未映射为 systemInstruction")
+	}
+	genConfig, _ := req.Body["generationConfig"].(map[string]interface{})
+	if genConfig["responseMimeType"] != "application/json" {
+		t.Errorf("generationConfig = %v", genConfig)
+	}
+	responseSchema, _ := genConfig["responseSchema"].(map[string]interface{})
+	if responseSchema["type"] != "object" || responseSchema["additionalProperties"] != nil {
+		t.Errorf("responseSchema = %v", responseSchema)
+	}
+	tools := req.Body["tools"].([]interface{})
+	decl := tools[0].(map[string]interface{})["functionDeclarations"].([]interface{})[0].(map[string]interface{})
+	if _, ok := decl["parameters"].(map[string]interface{})["additionalProperties"]; ok {
+		t.Error("不支持的 schema 字段未被移除")
+	}
+	contents := req.Body["contents"].([]interface{})
+	if len(contents) != 3 {
+		t.Fatalf("contents = %v", contents)
+	}
+	if contents[1].(map[string]interface{})["role"] != "model" {
+		t.Errorf("assistant 未映射为 model: %v", contents[1])
+	}
+	fnResp := contents[2].(map[string]interface{})["parts"].([]interface{})[0].(map[string]interface{})["functionResponse"].(map[string]interface{})
+	if fnResp["name"] != "get_weather" || fnResp["response"].(map[string]interface{})["temp"] != float64(25) {
+		t.Errorf("functionResponse 映射错误: %v", fnResp)
+	}
+
+	// 响应映射
+	if resp.FinishReason != "tool_calls" || len(resp.ToolCalls) != 1 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if resp.ToolCalls[0].ID == "" || resp.ToolCalls[0].Function.Arguments != `{"city":"北京"}` {
+		t.Errorf("unexpected tool call: %+v", resp.ToolCalls[0])
+	}
+	if resp.Usage.TotalTokens != 92 {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+}
+
+func TestGeminiClientStream(t *testing.T) {
+	server, req := newFixtureServer(t, "gemini_stream.sse", "text/event-stream")
+	client := NewGeminiClient("gemini-2.0-flash", "g-key", server.URL)
+
+	ch, err := client.Stream(context.Background(), []Message{{Role: "user", Content: "北京天气如何？"}}, nil)
+	if err != nil {
+		t.Fatalf("流式调用失败: %v", err)
+	}
+	deltas, final := collectStream(t, ch)
+
+	if req.Path != "/models/gemini-2.0-flash:streamGenerateContent" || req.Query != "alt=sse" {
+		t.Errorf("path = %s?%s", req.Path, req.Query)
+	}
+	if deltas != "北京今天晴，25°C。" || final.Content != deltas || final.FinishReason != "stop" {
+		t.Errorf("deltas = %q, final = %+v", deltas, final)
+	}
+}
+
+func TestOllamaClientCall(t *testing.T) {
+	server, req := newFixtureServer(t, "ollama_tool_call.json", "application/json")
+	client, err := NewClientForModel("ollama/llama3.1", map[string]ProviderConfig{
+		ProviderOllama: {BaseURL: server.URL + "/v1"},
+	})
+	if err != nil {
+		t.Fatalf("创建客户端失败: %v", err)
+	}
+
+	resp, err := client.Call(context.Background(), toolRoundTrip(), &CallOptions{
+		Tools:          weatherTool(),
+		ResponseFormat: JSONSchemaFormat("weather", map[string]interface{}{"type": "object"}),
+	})
+	if err != nil {
+		t.Fatalf("调用失败: %v", err)
+	}
+
+	if req.Path != "/v1/chat/completions" || req.Body["model"] != "llama3.1" {
+		t.Errorf("unexpected request: %s %v", req.Path, req.Body["model"])
+	}
+	format, _ := req.Body["response_format"].(map[string]interface{})
+	jsonSchema, _ := format["json_schema"].(map[string]interface{})
+	if format["type"] != "json_schema" || jsonSchema["name"] != "weather" || jsonSchema["schema"] == nil {
+		t.Errorf("response_format = %v", req.Body["response_format"])
+	}
+	if req.Headers.Get("Authorization") != "" {
+		t.Error("未配置 API Key 时不应发送 Authorization 头")
+	}
+	if resp.FinishReason != "tool_calls" || len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Function.Name != "get_weather" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestImageContentParts(t *testing.T) {
+	msg := Message{
+		Role:    "user",
+		Content: "这张图里是什么？",
+		Parts:   []ContentPart{{Type: "image", MimeType: "image/png", Data: "aGVsbG8="}},
+	}
+
+	openai := convertMessages([]Message{msg})[0]["content"].([]map[string]interface{})
+	if len(openai) != 2 || openai[1]["type"] != "image_url" ||
+		openai[1]["image_url"].(map[string]interface{})["url"] != "data:image/png;base64,aGVsbG8=" {
+		t.Errorf("unexpected openai content: %#v", openai)
+	}
+
+	_, anthropic := convertAnthropicMessages([]Message{msg})
+	blocks := anthropic[0]["content"].([]map[string]interface{})
+	if len(blocks) != 2 || blocks[1]["type"] != "image" ||
+		blocks[1]["source"].(map[string]interface{})["media_type"] != "image/png" {
+		t.Errorf("unexpected anthropic content: %#v", blocks)
+	}
+
+	_, gemini := convertGeminiMessages([]Message{msg})
+	parts := gemini[0]["parts"].([]map[string]interface{})
+	if len(parts) != 2 || parts[1]["inlineData"].(map[string]interface{})["data"] != "aGVsbG8=" {
+		t.Errorf("unexpected gemini parts: %#v", parts)
+	}
+
+	if !NewOllamaClient("llava:13b", "", "").GetModelInfo().SupportVision {
+		t.Error("llava should support vision")
+	}
+	if NewOpenAIClient("gpt-3.5-turbo", "key", "").GetModelInfo().SupportVision {
+		t.Error("gpt-3.5-turbo should not support vision")
+	}
+}
@@ -0,0 +1,222 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ValidateSchema 按 JSON Schema 校验 JSON 值（encoding/json 解码结果），返回带路径的错误列表
+// 支持 type、enum、properties、required、additionalProperties、items、min/maxItems、min/maxLength、minimum/maximum
+func ValidateSchema(schema map[string]interface{}, value interface{}) []string {
+	var errs []string
+	validateSchemaAt(schema, value, "$", &errs)
+	return errs
+}
+
+func validateSchemaAt(schema map[string]interface{}, value interface{}, path string, errs *[]string) {
+	if len(schema) == 0 {
+		return
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if matchesType(t, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			*errs = append(*errs, fmt.Sprintf("%s: 类型应为 %s，实际为 %s", path, strings.Join(types, "|"), jsonTypeOf(value)))
+			return
+		}
+	}
+
+	if enum := toInterfaceSlice(schema["enum"]); len(enum) > 0 {
+		found := false
+		for _, candidate := range enum {
+			if jsonEqual(candidate, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*errs = append(*errs, fmt.Sprintf("%s: 取值 %v 不在允许范围 %v 内", path, value, enum))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		validateObject(schema, v, path, errs)
+	case []interface{}:
+		if min, ok := schemaNumber(schema["minItems"]); ok && float64(len(v)) < min {
+			*errs = append(*errs, fmt.Sprintf("%s: 至少需要 %v 个元素", path, min))
+		}
+		if max, ok := schemaNumber(schema["maxItems"]); ok && float64(len(v)) > max {
+			*errs = append(*errs, fmt.Sprintf("%s: 最多允许 %v 个元素", path, max))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateSchemaAt(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case string:
+		length := float64(len([]rune(v)))
+		if min, ok := schemaNumber(schema["minLength"]); ok && length < min {
+			*errs = append(*errs, fmt.Sprintf("%s: 长度不能小于 %v", path, min))
+		}
+		if max, ok := schemaNumber(schema["maxLength"]); ok && length > max {
+			*errs = append(*errs, fmt.Sprintf("%s: 长度不能大于 %v", path, max))
+		}
+	case float64:
+		if min, ok := schemaNumber(schema["minimum"]); ok && v < min {
+			*errs = append(*errs, fmt.Sprintf("%s: 不能小于 %v", path, min))
+		}
+		if max, ok := schemaNumber(schema["maximum"]); ok && v > max {
+			*errs = append(*errs, fmt.Sprintf("%s: 不能大于 %v", path, max))
+		}
+	}
+}
+
+// validateObject 校验对象的必填字段、属性与额外字段
+func validateObject(schema map[string]interface{}, obj map[string]interface{}, path string, errs *[]string) {
+	for _, name := range toStringSlice(schema["required"]) {
+		if _, ok := obj[name]; !ok {
+			*errs = append(*errs, fmt.Sprintf("%s.%s: 缺少必填字段", path, name))
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	additional, hasAdditional := schema["additionalProperties"]
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if prop, ok := properties[key].(map[string]interface{}); ok {
+			validateSchemaAt(prop, obj[key], path+"."+key, errs)
+			continue
+		}
+		if !hasAdditional {
+			continue
+		}
+		switch a := additional.(type) {
+		case bool:
+			if !a {
+				*errs = append(*errs, fmt.Sprintf("%s.%s: 不允许的字段", path, key))
+			}
+		case map[string]interface{}:
+			validateSchemaAt(a, obj[key], path+"."+key, errs)
+		}
+	}
+}
+
+// schemaTypes 读取 type 关键字（字符串或字符串数组）
+func schemaTypes(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	default:
+		return toStringSlice(v)
+	}
+}
+
+func matchesType(schemaType string, value interface{}) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	// 未知类型不做限制
+	return true
+}
+
+func jsonTypeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// jsonEqual 按 JSON 语义比较（Go 中写的 Schema 可能使用 int、[]string 等类型）
+func jsonEqual(a, b interface{}) bool {
+	aj, errA := json.Marshal(a)
+	bj, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(aj) == string(bj)
+}
+
+func schemaNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func toInterfaceSlice(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		return v
+	case []string:
+		result := make([]interface{}, len(v))
+		for i, s := range v {
+			result[i] = s
+		}
+		return result
+	}
+	return nil
+}
+
+func toStringSlice(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxRepairs 结构化输出不符合 Schema 时默认的最大修复次数
+const DefaultMaxRepairs = 2
+
+// StructuredResult 结构化输出调用结果
+type StructuredResult struct {
+	Value    interface{} // 解析并通过校验的 JSON 值
+	Content  string      // 模型最后一次返回的原始内容
+	Usage    TokenUsage  // 所有尝试累计的 Token 使用
+	Attempts int         // 调用次数（含修复）
+	Errors   []string    // 最后一次的校验错误，成功时为空
+}
+
+// Decode 将结果解码到结构体
+func (r *StructuredResult) Decode(out interface{}) error {
+	data, err := json.Marshal(r.Value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// CallStructured 以 JSON 模式调用模型，并按 options.ResponseFormat 中的 Schema 校验输出。
+// 提供商原生结构化输出可用时由其约束生成；输出无法解析或不符合 Schema 时，把错误反馈给模型重新生成，最多修复 maxRepairs 次。
+// 校验最终失败时同时返回结果（含累计 Token 使用）和错误
+func CallStructured(ctx context.Context, client LLMClient, messages []Message, options *CallOptions, maxRepairs int) (*StructuredResult, error) {
+	callOptions := CallOptions{}
+	if options != nil {
+		callOptions = *options
+	}
+	if !callOptions.ResponseFormat.IsJSON() {
+		callOptions.ResponseFormat = JSONObjectFormat()
+	}
+	format := callOptions.ResponseFormat
+	schema := format.Schema
+	if !format.HasSchema() {
+		schema = map[string]interface{}{"type": "object"}
+	}
+
+	conversation := append([]Message{}, messages...)
+	result := &StructuredResult{}
+
+	for attempt := 0; attempt <= maxRepairs; attempt++ {
+		response, err := client.Call(ctx, conversation, &callOptions)
+		if err != nil {
+			return result, fmt.Errorf("LLM 调用失败: %w", err)
+		}
+		result.Attempts++
+		result.Content = response.Content
+		result.Usage.PromptTokens += response.Usage.PromptTokens
+		result.Usage.CompletionTokens += response.Usage.CompletionTokens
+		result.Usage.TotalTokens += response.Usage.TotalTokens
+
+		var value interface{}
+		value, result.Errors = CheckStructuredContent(schema, response.Content)
+		if len(result.Errors) == 0 {
+			result.Value = value
+			return result, nil
+		}
+
+		conversation = append(conversation,
+			Message{Role: "assistant", Content: response.Content},
+			Message{Role: "user", Content: repairInstruction(format, result.Errors)},
+		)
+	}
+
+	return result, fmt.Errorf("输出不符合 Schema（尝试 %d 次）: %s", result.Attempts, strings.Join(result.Errors, "; "))
+}
+
+// repairInstruction 要求模型按校验错误修正输出
+func repairInstruction(format *ResponseFormat, errs []string) string {
+	var builder strings.Builder
+	builder.WriteString("Your previous reply is invalid:\n")
+	for _, e := range errs {
+		builder.WriteString("- ")
+		builder.WriteString(e)
+		builder.WriteString("\n")
+	}
+	if format.HasSchema() {
+		schema, _ := json.Marshal(format.Schema)
+		builder.WriteString("\nThe reply must conform to this JSON Schema:\n")
+		builder.Write(schema)
+		builder.WriteString("\n")
+	}
+	builder.WriteString("\nReply again with only the corrected JSON, without any surrounding text.")
+	return builder.String()
+}
+
+// ExtractJSON 从模型输出中提取 JSON：去掉 Markdown 代码块，或截取夹在说明文字中的第一个对象/数组
+func ExtractJSON(content string) string {
+	trimmed := strings.TrimSpace(content)
+	if json.Valid([]byte(trimmed)) {
+		return trimmed
+	}
+
+	if start := strings.Index(trimmed, "```"); start >= 0 {
+		body := trimmed[start+3:]
+		if newline := strings.Index(body, "\n"); newline >= 0 {
+			body = body[newline+1:]
+		}
+		if end := strings.Index(body, "```"); end >= 0 {
+			if block := strings.TrimSpace(body[:end]); json.Valid([]byte(block)) {
+				return block
+			}
+		}
+	}
+
+	for _, pair := range [][2]string{{"{", "}"}, {"[", "]"}} {
+		start := strings.Index(trimmed, pair[0])
+		end := strings.LastIndex(trimmed, pair[1])
+		if start >= 0 && end > start {
+			if candidate := trimmed[start : end+1]; json.Valid([]byte(candidate)) {
+				return candidate
+			}
+		}
+	}
+
+	return trimmed
+}
+
+// ParseSchema 解析配置中的 JSON Schema（对象或 JSON 字符串），空值返回 nil
+func ParseSchema(value interface{}) (map[string]interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		return v, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil, nil
+		}
+		var schema map[string]interface{}
+		if err := json.Unmarshal([]byte(v), &schema); err != nil {
+			return nil, fmt.Errorf("JSON Schema 解析失败: %w", err)
+		}
+		return schema, nil
+	}
+	return nil, fmt.Errorf("JSON Schema 必须是对象或 JSON 字符串")
+}
+
+// CheckStructuredContent 解析模型输出并按 Schema 校验，返回解析后的值与校验错误
+func CheckStructuredContent(schema map[string]interface{}, content string) (interface{}, []string) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(ExtractJSON(content)), &value); err != nil {
+		return nil, []string{fmt.Sprintf("输出不是合法的 JSON: %v", err)}
+	}
+	if errs := ValidateSchema(schema, value); len(errs) > 0 {
+		return value, errs
+	}
+	return value, nil
+}
+
+// RepairInstruction 要求模型按校验错误修正输出的提示
+func RepairInstruction(schema map[string]interface{}, errs []string) string {
+	return repairInstruction(JSONSchemaFormat("", schema), errs)
+}
@@ -0,0 +1,126 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// replayClient 依次返回预设内容的客户端
+type replayClient struct {
+	replies []string
+	calls   [][]Message
+	options []*CallOptions
+}
+
+func (c *replayClient) Call(ctx context.Context, messages []Message, options *CallOptions) (*Response, error) {
+	c.calls = append(c.calls, messages)
+	c.options = append(c.options, options)
+	reply := c.replies[len(c.calls)-1]
+	return &Response{Content: reply, Usage: TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}}, nil
+}
+
+func (c *replayClient) Stream(ctx context.Context, messages []Message, options *CallOptions) (<-chan StreamChunk, error) {
+	return nil, nil
+}
+
+func (c *replayClient) GetModelInfo() ModelInfo {
+	return ModelInfo{Provider: "test", Model: "replay"}
+}
+
+var citySchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"city":  map[string]interface{}{"type": "string", "minLength": 1},
+		"days":  map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 7},
+		"units": map[string]interface{}{"type": "string", "enum": []string{"metric", "imperial"}},
+		"tags":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+	},
+	"required":             []string{"city", "days"},
+	"additionalProperties": false,
+}
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		errors int
+	}{
+		{"valid", map[string]interface{}{"city": "北京", "days": float64(3), "units": "metric"}, 0},
+		{"missing required", map[string]interface{}{"city": "北京"}, 1},
+		{"wrong type", map[string]interface{}{"city": "北京", "days": "3"}, 1},
+		{"not an integer", map[string]interface{}{"city": "北京", "days": 2.5}, 1},
+		{"out of range", map[string]interface{}{"city": "北京", "days": float64(10)}, 1},
+		{"enum", map[string]interface{}{"city": "北京", "days": float64(1), "units": "kelvin"}, 1},
+		{"nested items", map[string]interface{}{"city": "北京", "days": float64(1), "tags": []interface{}{"a", float64(1)}}, 1},
+		{"additional property", map[string]interface{}{"city": "北京", "days": float64(1), "extra": true}, 1},
+		{"not an object", []interface{}{}, 1},
+	}
+
+	for _, tt := range tests {
+		if errs := ValidateSchema(citySchema, tt.value); len(errs) != tt.errors {
+			t.Errorf("%s: got %d errors %v, want %d", tt.name, len(errs), errs, tt.errors)
+		}
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	tests := map[string]string{
+		`{"a":1}`:                 `{"a":1}`,
+		"```json\n{\"a\":1}\n```": `{"a":1}`,
+		"Here is the plan:\n{\"a\":1}\nHope it helps.": `{"a":1}`,
+		"Result: [1, 2]": `[1, 2]`,
+	}
+	for input, want := range tests {
+		if got := ExtractJSON(input); got != want {
+			t.Errorf("ExtractJSON(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestCallStructuredRepairs(t *testing.T) {
+	client := &replayClient{replies: []string{
+		"Sure! Here you go: {\"city\": \"北京\"}",
+		"```json\n{\"city\": \"北京\", \"days\": 3}\n```",
+	}}
+	format := JSONSchemaFormat("forecast", citySchema)
+
+	result, err := CallStructured(context.Background(), client, []Message{{Role: "user", Content: "北京未来三天天气"}}, &CallOptions{ResponseFormat: format}, DefaultMaxRepairs)
+	if err != nil {
+		t.Fatalf("CallStructured failed: %v", err)
+	}
+
+	var out struct {
+		City string `json:"city"`
+		Days int    `json:"days"`
+	}
+	if err := result.Decode(&out); err != nil || out.City != "北京" || out.Days != 3 {
+		t.Fatalf("unexpected result: %+v, %v", out, err)
+	}
+	if result.Attempts != 2 || result.Usage.TotalTokens != 30 {
+		t.Errorf("attempts = %d, usage = %+v", result.Attempts, result.Usage)
+	}
+
+	repair := client.calls[1]
+	if len(repair) != 3 || repair[1].Role != "assistant" || !strings.Contains(repair[2].Content, "$.days") {
+		t.Errorf("repair turn not appended: %+v", repair)
+	}
+	if client.options[0].ResponseFormat != format {
+		t.Error("response format not passed to the provider")
+	}
+}
+
+func TestCallStructuredGivesUp(t *testing.T) {
+	client := &replayClient{replies: []string{"no", "still no", "nope"}}
+
+	result, err := CallStructured(context.Background(), client, nil, nil, DefaultMaxRepairs)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	if result.Attempts != DefaultMaxRepairs+1 || len(result.Errors) == 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if !client.options[0].ResponseFormat.IsJSON() {
+		t.Error("JSON mode should be enabled when no format is given")
+	}
+}
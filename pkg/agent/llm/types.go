@@ -16,11 +16,35 @@ type LLMClient interface {
 
 // Message 消息
 type Message struct {
-	Role       string     `json:"role"`                   // system, user, assistant, tool
-	Content    string     `json:"content"`                // 文本内容
-	Name       string     `json:"name,omitempty"`         // 可选：消息发送者名称
-	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`   // 可选：工具调用
-	ToolCallID string     `json:"tool_call_id,omitempty"` // 可选：工具调用ID（用于tool角色）
+	Role       string        `json:"role"`                   // system, user, assistant, tool
+	Content    string        `json:"content"`                // 文本内容
+	Name       string        `json:"name,omitempty"`         // 可选：消息发送者名称
+	ToolCalls  []ToolCall    `json:"tool_calls,omitempty"`   // 可选：工具调用
+	ToolCallID string        `json:"tool_call_id,omitempty"` // 可选：工具调用ID（用于tool角色）
+	Parts      []ContentPart `json:"parts,omitempty"`        // 可选：多模态内容（用于user角色，Content 非空时作为第一个文本片段）
+}
+
+// ContentPart 多模态内容片段
+type ContentPart struct {
+	Type     string `json:"type"`                // text, image
+	Text     string `json:"text,omitempty"`      // 文本内容
+	MimeType string `json:"mime_type,omitempty"` // 图片 MIME 类型
+	Data     string `json:"data,omitempty"`      // 图片 base64 数据
+}
+
+// ContentParts 获取消息的全部内容片段（Content 在前）
+func (m Message) ContentParts() []ContentPart {
+	if len(m.Parts) == 0 {
+		if m.Content == "" {
+			return nil
+		}
+		return []ContentPart{{Type: "text", Text: m.Content}}
+	}
+	parts := make([]ContentPart, 0, len(m.Parts)+1)
+	if m.Content != "" {
+		parts = append(parts, ContentPart{Type: "text", Text: m.Content})
+	}
+	return append(parts, m.Parts...)
 }
 
 // CallOptions 调用选项
@@ -31,7 +55,42 @@ type CallOptions struct {
 	Stop           []string         `json:"stop,omitempty"`            // 停止序列
 	Tools          []ToolDefinition `json:"tools,omitempty"`           // 可用工具列表
 	ToolChoice     interface{}      `json:"tool_choice,omitempty"`     // auto, none, required, {"type": "function", "function": {"name": "xxx"}}
-	ResponseFormat string           `json:"response_format,omitempty"` // text, json_object
+	ResponseFormat *ResponseFormat  `json:"response_format,omitempty"` // 响应格式，nil 表示普通文本
+}
+
+// 响应格式类型
+const (
+	ResponseFormatText       = "text"
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
+// ResponseFormat 响应格式
+type ResponseFormat struct {
+	Type   string                 `json:"type"`             // text, json_object, json_schema
+	Name   string                 `json:"name,omitempty"`   // json_schema：Schema 名称（字母、数字、下划线、连字符）
+	Schema map[string]interface{} `json:"schema,omitempty"` // json_schema：输出需要满足的 JSON Schema
+	Strict bool                   `json:"strict,omitempty"` // json_schema：要求提供商严格按 Schema 解码（仅 OpenAI，Schema 需满足其严格模式限制）
+}
+
+// JSONObjectFormat 要求输出任意 JSON 对象
+func JSONObjectFormat() *ResponseFormat {
+	return &ResponseFormat{Type: ResponseFormatJSONObject}
+}
+
+// JSONSchemaFormat 要求输出满足 Schema 的 JSON
+func JSONSchemaFormat(name string, schema map[string]interface{}) *ResponseFormat {
+	return &ResponseFormat{Type: ResponseFormatJSONSchema, Name: name, Schema: schema}
+}
+
+// IsJSON 是否要求 JSON 输出
+func (f *ResponseFormat) IsJSON() bool {
+	return f != nil && (f.Type == ResponseFormatJSONObject || f.Type == ResponseFormatJSONSchema)
+}
+
+// HasSchema 是否带有 JSON Schema 约束
+func (f *ResponseFormat) HasSchema() bool {
+	return f != nil && f.Type == ResponseFormatJSONSchema && len(f.Schema) > 0
 }
 
 // Response 响应
@@ -87,8 +146,9 @@ type TokenUsage struct {
 
 // ModelInfo 模型信息
 type ModelInfo struct {
-	Provider    string `json:"provider"`     // 提供商：openai, gemini, custom
-	Model       string `json:"model"`        // 模型名称
-	MaxTokens   int    `json:"max_tokens"`   // 最大 token 数
-	SupportTool bool   `json:"support_tool"` // 是否支持工具调用
+	Provider      string `json:"provider"`       // 提供商：openai, gemini, custom
+	Model         string `json:"model"`          // 模型名称
+	MaxTokens     int    `json:"max_tokens"`     // 最大 token 数
+	SupportTool   bool   `json:"support_tool"`   // 是否支持工具调用
+	SupportVision bool   `json:"support_vision"` // 是否支持图片输入
 }
@@ -0,0 +1,229 @@
+package memory
+
+import (
+	"auto-forge/pkg/agent/llm"
+	"auto-forge/pkg/agent/prompt"
+	"auto-forge/pkg/logger"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// defaultContextWindow 模型未声明上下文窗口时的默认值
+const defaultContextWindow = 8192
+
+// Config 对话记忆配置
+type Config struct {
+	HistoryRatio        float64 // 历史消息（含摘要）可占用的上下文窗口比例
+	ReserveTokens       int     // 为系统提示词、工具定义、当前输入与输出预留的 token 数
+	MaxObservationChars int     // 历史工具结果保留的最大字符数
+	SummaryMaxWords     int     // 摘要的最大字数
+}
+
+// DefaultConfig 默认记忆配置
+func DefaultConfig() Config {
+	return Config{
+		HistoryRatio:        0.5,
+		ReserveTokens:       4096,
+		MaxObservationChars: 2000,
+		SummaryMaxWords:     400,
+	}
+}
+
+// Result 记忆构建结果
+type Result struct {
+	Messages       []llm.Message // 发送给模型的历史消息（摘要 + 最近的轮次）
+	Summary        string        // 当前摘要
+	Summarized     bool          // 本次是否更新了摘要
+	FoldedMessages int           // 本次折叠进摘要的存储消息数
+	Tokens         int           // 历史消息估算 token 数
+}
+
+// Manager 对话记忆管理器：按模型上下文窗口控制历史长度，超出预算的旧轮次滚动合并为摘要
+type Manager struct {
+	llmClient llm.LLMClient
+	config    Config
+}
+
+// NewManager 创建记忆管理器，llmClient 同时用于读取上下文窗口与生成摘要
+func NewManager(llmClient llm.LLMClient, config Config) *Manager {
+	defaults := DefaultConfig()
+	if config.HistoryRatio <= 0 || config.HistoryRatio >= 1 {
+		config.HistoryRatio = defaults.HistoryRatio
+	}
+	if config.ReserveTokens <= 0 {
+		config.ReserveTokens = defaults.ReserveTokens
+	}
+	if config.SummaryMaxWords <= 0 {
+		config.SummaryMaxWords = defaults.SummaryMaxWords
+	}
+	return &Manager{
+		llmClient: llmClient,
+		config:    config,
+	}
+}
+
+// Budget 历史消息的 token 预算
+func (m *Manager) Budget() int {
+	window := m.llmClient.GetModelInfo().MaxTokens
+	if window <= 0 {
+		window = defaultContextWindow
+	}
+
+	// 小窗口模型预留不超过窗口的 1/4
+	reserve := m.config.ReserveTokens
+	if reserve > window/4 {
+		reserve = window / 4
+	}
+
+	budget := int(float64(window)*m.config.HistoryRatio) - reserve
+	if budget < 0 {
+		budget = 0
+	}
+	return budget
+}
+
+// summaryAllowance 为摘要预留的 token 数
+func (m *Manager) summaryAllowance() int {
+	// 中英文混合时 1 个词约 2 个 token
+	return m.config.SummaryMaxWords*2 + messageOverheadTokens
+}
+
+// Build 根据已有摘要与未摘要的轮次构建历史消息
+// 从最近的轮次向前保留，放不下的旧轮次合并进摘要；摘要失败时直接丢弃这些轮次，下次再尝试
+func (m *Manager) Build(ctx context.Context, summary string, turns []Turn) *Result {
+	budget := m.Budget()
+	turnBudget := budget - m.summaryAllowance()
+
+	keepFrom := len(turns)
+	used := 0
+	for i := len(turns) - 1; i >= 0; i-- {
+		if used+turns[i].Tokens > turnBudget {
+			break
+		}
+		used += turns[i].Tokens
+		keepFrom = i
+	}
+
+	result := &Result{Summary: summary}
+
+	if keepFrom > 0 {
+		overflow := turns[:keepFrom]
+		newSummary, err := m.Summarize(ctx, summary, overflow)
+		if err != nil {
+			logger.Warn("对话摘要生成失败，丢弃 %d 轮较早的对话: %v", len(overflow), err)
+		} else {
+			result.Summary = newSummary
+			result.Summarized = true
+			for _, turn := range overflow {
+				result.FoldedMessages += turn.MessageCount
+			}
+		}
+	}
+
+	if result.Summary != "" {
+		result.Messages = append(result.Messages, llm.Message{
+			Role:    "system",
+			Content: "Summary of the earlier conversation:\n" + result.Summary,
+		})
+	}
+	for _, turn := range turns[keepFrom:] {
+		result.Messages = append(result.Messages, turn.Messages...)
+	}
+	result.Tokens = MessagesTokens(result.Messages)
+
+	return result
+}
+
+// Summarize 将若干轮对话合并进已有摘要
+// 轮次过多时按预算分批合并，保证单次摘要请求不超过上下文窗口
+func (m *Manager) Summarize(ctx context.Context, summary string, turns []Turn) (string, error) {
+	batchBudget := m.Budget()
+	if batchBudget <= 0 {
+		batchBudget = defaultContextWindow / 2
+	}
+
+	for start := 0; start < len(turns); {
+		end, tokens := start, 0
+		for end < len(turns) && (end == start || tokens+turns[end].Tokens <= batchBudget) {
+			tokens += turns[end].Tokens
+			end++
+		}
+
+		next, err := m.summarizeBatch(ctx, summary, turns[start:end], batchBudget)
+		if err != nil {
+			return "", err
+		}
+		summary = next
+		start = end
+	}
+
+	return summary, nil
+}
+
+// summarizeBatch 调用 LLM 生成一批轮次的摘要
+func (m *Manager) summarizeBatch(ctx context.Context, summary string, turns []Turn, budget int) (string, error) {
+	existing := summary
+	if existing == "" {
+		existing = "(none)"
+	}
+
+	// 单轮超出预算时按字符截断（约 4 个字符 1 个 token）
+	promptText := prompt.MemorySummaryPrompt.Render(map[string]string{
+		"summary":   existing,
+		"turns":     truncate(FormatTurns(turns), budget*4),
+		"max_words": strconv.Itoa(m.config.SummaryMaxWords),
+	})
+
+	response, err := m.llmClient.Call(ctx, []llm.Message{
+		{Role: "system", Content: "You summarize conversations accurately and concisely."},
+		{Role: "user", Content: promptText},
+	}, &llm.CallOptions{
+		Temperature: 0.2,
+		MaxTokens:   m.summaryAllowance(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("调用 LLM 失败: %w", err)
+	}
+
+	newSummary := strings.TrimSpace(response.Content)
+	if newSummary == "" {
+		return "", fmt.Errorf("LLM 返回空摘要")
+	}
+	return newSummary, nil
+}
+
+// FormatTurns 将轮次格式化为纯文本（用于摘要）
+func FormatTurns(turns []Turn) string {
+	var builder strings.Builder
+
+	for _, turn := range turns {
+		for _, msg := range turn.Messages {
+			switch msg.Role {
+			case "user":
+				builder.WriteString("User: ")
+				builder.WriteString(msg.Content)
+			case "assistant":
+				if len(msg.ToolCalls) > 0 {
+					for _, call := range msg.ToolCalls {
+						fmt.Fprintf(&builder, "Assistant called tool %s(%s)", call.Function.Name, call.Function.Arguments)
+					}
+				} else {
+					builder.WriteString("Assistant: ")
+					builder.WriteString(msg.Content)
+				}
+			case "tool":
+				builder.WriteString("Tool result: ")
+				builder.WriteString(msg.Content)
+			default:
+				builder.WriteString("System: ")
+				builder.WriteString(msg.Content)
+			}
+			builder.WriteString("\n")
+		}
+		builder.WriteString("\n")
+	}
+
+	return strings.TrimSpace(builder.String())
+}
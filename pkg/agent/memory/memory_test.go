@@ -0,0 +1,167 @@
+package memory
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/pkg/agent/llm"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// stubClient 固定上下文窗口、记录摘要请求的 LLM 客户端
+type stubClient struct {
+	maxTokens int
+	calls     int
+	prompts   []string
+	err       error
+}
+
+func (c *stubClient) Call(ctx context.Context, messages []llm.Message, options *llm.CallOptions) (*llm.Response, error) {
+	c.calls++
+	c.prompts = append(c.prompts, messages[len(messages)-1].Content)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &llm.Response{Content: "summary v" + string(rune('0'+c.calls))}, nil
+}
+
+func (c *stubClient) Stream(ctx context.Context, messages []llm.Message, options *llm.CallOptions) (<-chan llm.StreamChunk, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *stubClient) GetModelInfo() llm.ModelInfo {
+	return llm.ModelInfo{Provider: "stub", Model: "stub", MaxTokens: c.maxTokens}
+}
+
+// conversation 生成 n 轮对话，每条消息包含 words 个英文单词
+func conversation(n, words int) []models.AgentMessage {
+	content := strings.Repeat("word ", words)
+	var messages []models.AgentMessage
+	for i := 0; i < n; i++ {
+		messages = append(messages,
+			models.AgentMessage{ID: "u", Role: "user", Content: content},
+			models.AgentMessage{ID: "a", Role: "agent", Content: content, Status: "completed"},
+		)
+	}
+	return messages
+}
+
+func TestEstimateTokens(t *testing.T) {
+	if got := EstimateTokens(""); got != 0 {
+		t.Errorf("empty text = %d", got)
+	}
+	if got := EstimateTokens("abcdefgh"); got != 2 {
+		t.Errorf("ascii text = %d, want 2", got)
+	}
+	if got := EstimateTokens("你好世界"); got != 4 {
+		t.Errorf("cjk text = %d, want 4", got)
+	}
+}
+
+func TestBuildTurns_ToolHistory(t *testing.T) {
+	messages := []models.AgentMessage{
+		{ID: "m1", Role: "user", Content: "查一下天气", Files: models.AgentFiles{{Filename: "a.png"}}},
+		{ID: "m-2", Role: "agent", Content: "晴", Status: "completed", Trace: &models.AgentTrace{Steps: []models.AgentStep{
+			{Step: 1, Action: &models.AgentAction{Type: "action", Tool: "weather", Args: map[string]interface{}{"city": "北京"}}, Observation: strings.Repeat("x", 50)},
+			{Step: 2, Action: &models.AgentAction{Type: "final"}},
+		}}},
+		{ID: "m3", Role: "user", Content: "谢谢"},
+	}
+
+	turns := BuildTurns(messages, 10)
+	if len(turns) != 2 {
+		t.Fatalf("expected 2 turns, got %d", len(turns))
+	}
+	first := turns[0]
+	if first.MessageCount != 2 || len(first.Messages) != 4 {
+		t.Fatalf("unexpected first turn: %d stored, %d llm messages", first.MessageCount, len(first.Messages))
+	}
+	if !strings.Contains(first.Messages[0].Content, "a.png") {
+		t.Errorf("attachment not mentioned: %q", first.Messages[0].Content)
+	}
+	call := first.Messages[1]
+	if len(call.ToolCalls) != 1 || call.ToolCalls[0].Function.Name != "weather" || call.ToolCalls[0].Function.Arguments != `{"city":"北京"}` {
+		t.Errorf("unexpected tool call: %+v", call.ToolCalls)
+	}
+	result := first.Messages[2]
+	if result.Role != "tool" || result.ToolCallID != call.ToolCalls[0].ID || !strings.HasSuffix(result.Content, "(truncated)") {
+		t.Errorf("unexpected tool result: %+v", result)
+	}
+	if first.Messages[3].Role != "assistant" || first.Messages[3].Content != "晴" {
+		t.Errorf("unexpected final answer: %+v", first.Messages[3])
+	}
+}
+
+func TestManager_KeepsHistoryWithinBudget(t *testing.T) {
+	client := &stubClient{maxTokens: 128000}
+	turns := BuildTurns(conversation(3, 100), 0)
+
+	result := NewManager(client, DefaultConfig()).Build(context.Background(), "", turns)
+	if client.calls != 0 || result.Summarized {
+		t.Fatalf("short history should not be summarized")
+	}
+	if len(result.Messages) != 6 {
+		t.Errorf("expected all 6 messages kept, got %d", len(result.Messages))
+	}
+}
+
+func TestManager_SummarizesOverflow(t *testing.T) {
+	client := &stubClient{maxTokens: 8192}
+	manager := NewManager(client, Config{HistoryRatio: 0.5, ReserveTokens: 1024, SummaryMaxWords: 100})
+	// 预算 8192*0.5-1024=3072，摘要预留 204，每轮约 758 token，只能保留 3 轮
+	turns := BuildTurns(conversation(6, 300), 0)
+
+	result := manager.Build(context.Background(), "earlier summary", turns)
+	if !result.Summarized || result.Summary != "summary v1" {
+		t.Fatalf("expected overflow summarized, got %+v", result)
+	}
+	if result.FoldedMessages != 6 {
+		t.Errorf("expected 3 turns (6 messages) folded, got %d", result.FoldedMessages)
+	}
+	if !strings.Contains(client.prompts[0], "earlier summary") {
+		t.Error("existing summary should be passed to the summarizer")
+	}
+	if result.Messages[0].Role != "system" || !strings.Contains(result.Messages[0].Content, "summary v1") {
+		t.Errorf("summary should lead the history: %+v", result.Messages[0])
+	}
+	if len(result.Messages) != 7 {
+		t.Errorf("expected summary + 6 messages, got %d", len(result.Messages))
+	}
+	if result.Tokens > manager.Budget() {
+		t.Errorf("history tokens %d exceed budget %d", result.Tokens, manager.Budget())
+	}
+}
+
+func TestManager_SummaryFailureDropsOverflow(t *testing.T) {
+	client := &stubClient{maxTokens: 8192, err: errors.New("boom")}
+	manager := NewManager(client, Config{HistoryRatio: 0.5, ReserveTokens: 1024, SummaryMaxWords: 100})
+
+	result := manager.Build(context.Background(), "", BuildTurns(conversation(6, 300), 0))
+	if result.Summarized || result.FoldedMessages != 0 {
+		t.Errorf("failed summary must not advance progress: %+v", result)
+	}
+	if len(result.Messages) != 6 {
+		t.Errorf("expected only the recent turns, got %d messages", len(result.Messages))
+	}
+}
+
+func TestManager_SummarizeInBatches(t *testing.T) {
+	client := &stubClient{maxTokens: 8192}
+	manager := NewManager(client, Config{HistoryRatio: 0.5, ReserveTokens: 1024, SummaryMaxWords: 100})
+
+	// 10 轮约 7580 token，超过单次摘要预算 3072，需要分批合并
+	summary, err := manager.Summarize(context.Background(), "", BuildTurns(conversation(10, 300), 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.calls < 3 {
+		t.Errorf("expected batched summarization, got %d calls", client.calls)
+	}
+	if !strings.Contains(client.prompts[1], "summary v1") {
+		t.Error("each batch should build on the previous summary")
+	}
+	if summary != "summary v"+string(rune('0'+client.calls)) {
+		t.Errorf("unexpected final summary %q", summary)
+	}
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"auto-forge/pkg/agent/llm"
+	"unicode"
+	"unicode/utf8"
+)
+
+// messageOverheadTokens 每条消息的格式开销（角色、分隔符）
+const messageOverheadTokens = 4
+
+// imageTokens 单张图片的估算 token 数
+const imageTokens = 1000
+
+// EstimateTokens 估算文本的 token 数
+// 不依赖具体分词器：ASCII 约 4 个字符 1 个 token，CJK 等宽字符约 1 个字符 1 个 token
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+
+	ascii, wide := 0, 0
+	for _, r := range text {
+		switch {
+		case r < utf8.RuneSelf:
+			ascii++
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+			unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			wide++
+		default:
+			// 其它非 ASCII 字符按 2 个字符 1 个 token 估算
+			ascii += 2
+		}
+	}
+
+	return wide + (ascii+3)/4
+}
+
+// MessageTokens 估算单条消息的 token 数（包含工具调用参数）
+func MessageTokens(msg llm.Message) int {
+	tokens := messageOverheadTokens + EstimateTokens(msg.Content)
+	for _, part := range msg.Parts {
+		if part.Type == "image" {
+			tokens += imageTokens
+		} else {
+			tokens += EstimateTokens(part.Text)
+		}
+	}
+	for _, call := range msg.ToolCalls {
+		tokens += messageOverheadTokens + EstimateTokens(call.Function.Name) + EstimateTokens(call.Function.Arguments)
+	}
+	return tokens
+}
+
+// MessagesTokens 估算消息列表的 token 数
+func MessagesTokens(messages []llm.Message) int {
+	total := 0
+	for _, msg := range messages {
+		total += MessageTokens(msg)
+	}
+	return total
+}
@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/pkg/agent/llm"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Turn 一轮对话：一条用户消息及其后的 Agent 回复（含工具调用历史）
+type Turn struct {
+	Messages     []llm.Message // 角色化的 LLM 消息
+	MessageCount int           // 对应的已存储消息数（用于记录摘要进度）
+	Tokens       int           // 估算 token 数
+}
+
+// BuildTurns 将已存储的消息按轮次转换为 LLM 消息
+// 每遇到一条用户消息开启新的一轮；Agent 消息的执行轨迹还原为 assistant tool_calls + tool 结果
+func BuildTurns(messages []models.AgentMessage, maxObservationChars int) []Turn {
+	var turns []Turn
+
+	for i := range messages {
+		msg := &messages[i]
+		converted := convertMessage(msg, maxObservationChars)
+
+		if msg.Role == "user" || len(turns) == 0 {
+			turns = append(turns, Turn{})
+		}
+		turn := &turns[len(turns)-1]
+		turn.Messages = append(turn.Messages, converted...)
+		turn.MessageCount++
+		turn.Tokens += MessagesTokens(converted)
+	}
+
+	return turns
+}
+
+// convertMessage 将一条存储消息转换为 LLM 消息（可能为空，如尚无内容的 Agent 消息）
+func convertMessage(msg *models.AgentMessage, maxObservationChars int) []llm.Message {
+	switch msg.Role {
+	case "user":
+		content := msg.Content
+		if len(msg.Files) > 0 {
+			// 保留文件路径，后续轮次仍可将文件传给工具
+			names := make([]string, 0, len(msg.Files))
+			for _, file := range msg.Files {
+				names = append(names, fmt.Sprintf("%s (%s)", file.Filename, file.Path))
+			}
+			content += fmt.Sprintf("\n[附件: %s]", strings.Join(names, ", "))
+		}
+		return []llm.Message{{Role: "user", Content: content}}
+
+	case "agent":
+		return convertAgentMessage(msg, maxObservationChars)
+
+	default:
+		if msg.Content == "" {
+			return nil
+		}
+		return []llm.Message{{Role: "system", Content: msg.Content}}
+	}
+}
+
+// convertAgentMessage 还原 Agent 回复：工具调用与观察结果在前，最终答案在后
+func convertAgentMessage(msg *models.AgentMessage, maxObservationChars int) []llm.Message {
+	var result []llm.Message
+
+	if msg.Trace != nil {
+		for _, step := range msg.Trace.Steps {
+			if step.Action == nil || step.Action.Tool == "" {
+				continue
+			}
+
+			args := "{}"
+			if len(step.Action.Args) > 0 {
+				if data, err := json.Marshal(step.Action.Args); err == nil {
+					args = string(data)
+				}
+			}
+			callID := fmt.Sprintf("hist_%s_%d", strings.ReplaceAll(msg.ID, "-", ""), step.Step)
+
+			observation := step.Observation
+			if step.Error != "" {
+				observation = "Error: " + step.Error
+			}
+
+			result = append(result,
+				llm.Message{
+					Role: "assistant",
+					ToolCalls: []llm.ToolCall{{
+						ID:       callID,
+						Type:     "function",
+						Function: llm.FunctionCall{Name: step.Action.Tool, Arguments: args},
+					}},
+				},
+				llm.Message{
+					Role:       "tool",
+					ToolCallID: callID,
+					Content:    truncate(observation, maxObservationChars),
+				},
+			)
+		}
+	}
+
+	content := msg.Content
+	if content == "" && msg.Status == "failed" && msg.Error != "" {
+		content = "执行失败: " + msg.Error
+	}
+	if content != "" {
+		result = append(result, llm.Message{Role: "assistant", Content: content})
+	}
+
+	return result
+}
+
+// truncate 按字符截断文本
+func truncate(text string, maxChars int) string {
+	if maxChars <= 0 {
+		return text
+	}
+	runes := []rune(text)
+	if len(runes) <= maxChars {
+		return text
+	}
+	return string(runes[:maxChars]) + "...(truncated)"
+}
@@ -134,6 +134,72 @@ Previous context:
 Suggest an alternative approach or explain why the task cannot be completed. Be helpful and constructive.`,
 }
 
+// 对话记忆摘要提示词模板
+var MemorySummaryPrompt = Template{
+	Name: "memory_summary",
+	Template: `You maintain a running summary of a conversation between a user and an AI assistant. Older turns are removed from the context window and only this summary is kept.
+
+Existing summary:
+{summary}
+
+Conversation turns to fold into the summary:
+{turns}
+
+Write the updated summary. It must:
+1. Keep facts, decisions, user preferences and open questions that may matter later
+2. Keep important tool results (names, IDs, numbers, file paths) but drop raw payloads
+3. Be written in the same language as the conversation
+4. Stay under {max_words} words
+
+Updated summary:`,
+}
+
+// 自然语言生成工作流提示词模板
+var WorkflowBuilderPrompt = Template{
+	Name: "workflow_builder",
+	Template: `You design automation workflows for a workflow engine. Turn the user's description into a workflow graph.
+
+Available tools (toolCode, config schema and output fields):
+{tools}
+
+Node types:
+- "tool": runs a tool. Set "toolCode" to one of the tool codes above and "config" to values matching its config schema.
+- "delay": waits. config: {"duration": number, "unit": "seconds|minutes|hours"}.
+- "condition": continues only if the check passes. config: {"conditionType": "simple", "field": "<nodeId>.<output field>", "operator": "equals|not_equals|contains|greater_than|less_than", "value": ...}.
+- "external_trigger": only when the workflow needs input parameters at run time; must be the first node. config: {"params": [{"key": "...", "type": "string|number|boolean", "required": true, "description": "..."}]}.
+
+Data references inside config values:
+- {{nodes.<nodeId>.<output field path>}} reads an output field of an upstream node, e.g. {{nodes.node_1.items}}
+- {{external.<key>}} reads an external_trigger parameter
+- {{env.<NAME>}} reads a workflow environment variable; use it for secrets such as webhook URLs or API keys
+
+Schedule (do NOT add trigger nodes for schedules):
+- manual: value ""
+- daily: value "HH:MM:SS"
+- weekly: value "<days>:HH:MM:SS", days are comma separated, 0 = Sunday, e.g. "1,2,3,4,5:09:00:00"
+- monthly: value "<day>:HH:MM:SS"
+- hourly: value "MM:SS"
+- interval: value is the number of seconds
+
+Rules:
+1. Node IDs are "node_1", "node_2", ... Every edge connects an existing source node to an existing target node and the graph has no cycles.
+2. Only reference output fields listed for the upstream tool, and only reference nodes that run before the current node.
+3. Fill every required config field. Use {{env.NAME}} placeholders for credentials you do not know.
+4. Reply with a single JSON object and nothing else:
+{"name": "...", "description": "...", "nodes": [{"id": "...", "type": "...", "toolCode": "...", "name": "...", "config": {...}}], "edges": [{"id": "...", "source": "...", "target": "..."}], "schedule": {"type": "...", "value": "..."}, "env_vars": ["NAME"]}
+
+Write names and descriptions in the same language as the user's description.`,
+}
+
+// 工作流修复提示词模板
+var WorkflowRepairPrompt = Template{
+	Name: "workflow_repair",
+	Template: `The workflow you returned is invalid:
+{errors}
+
+Fix these problems and reply with the complete corrected workflow as a single JSON object in the same format.`,
+}
+
 // FormatToolDefinitions 格式化工具定义为文本
 func FormatToolDefinitions(tools []map[string]interface{}) string {
 	var builder strings.Builder
@@ -11,8 +11,9 @@ import (
 
 // ToolRegistry 工具注册表
 type ToolRegistry struct {
-	tools map[string]*ToolWrapper
-	mu    sync.RWMutex
+	tools  map[string]*ToolWrapper
+	policy func(toolCode string) error
+	mu     sync.RWMutex
 }
 
 // ToolWrapper 工具包装器
@@ -28,6 +29,25 @@ func NewToolRegistry() *ToolRegistry {
 	}
 }
 
+// SetPolicy 设置工具使用策略：注册时跳过不允许的工具，执行前再次检查（策略可能在执行过程中变更）
+func (r *ToolRegistry) SetPolicy(policy func(toolCode string) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policy = policy
+}
+
+// checkPolicy 检查工具是否允许使用
+func (r *ToolRegistry) checkPolicy(toolCode string) error {
+	r.mu.RLock()
+	policy := r.policy
+	r.mu.RUnlock()
+
+	if policy == nil {
+		return nil
+	}
+	return policy(toolCode)
+}
+
 // RegisterFromUTools 从 UTools 注册所有工具
 func (r *ToolRegistry) RegisterFromUTools() error {
 	r.mu.Lock()
@@ -49,6 +69,11 @@ func (r *ToolRegistry) RegisterFromUTools() error {
 			continue
 		}
 
+		// 跳过管理员策略不允许的工具
+		if r.policy != nil && r.policy(metadata.Code) != nil {
+			continue
+		}
+
 		// 生成工具定义
 		definition, err := generateToolDefinition(tool)
 		if err != nil {
@@ -64,6 +89,22 @@ func (r *ToolRegistry) RegisterFromUTools() error {
 	return nil
 }
 
+// Register 注册单个工具
+func (r *ToolRegistry) Register(tool utools.Tool) error {
+	definition, err := generateToolDefinition(tool)
+	if err != nil {
+		return fmt.Errorf("生成工具定义失败 [%s]: %w", tool.GetMetadata().Code, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tools[tool.GetMetadata().Code] = &ToolWrapper{
+		Tool:       tool,
+		Definition: definition,
+	}
+	return nil
+}
+
 // GetToolDefinitions 获取工具定义列表（用于 LLM）
 func (r *ToolRegistry) GetToolDefinitions(allowedTools []string) []llm.ToolDefinition {
 	r.mu.RLock()
@@ -89,7 +130,7 @@ func (r *ToolRegistry) GetToolDefinitions(allowedTools []string) []llm.ToolDefin
 	return definitions
 }
 
-// Execute 执行工具
+// Execute 直接执行工具（不带超时、重试、缓存；Agent 执行使用 tooling.ToolRunner）
 func (r *ToolRegistry) Execute(ctx context.Context, toolName string, args map[string]interface{}) (interface{}, error) {
 	r.mu.RLock()
 	wrapper, ok := r.tools[toolName]
@@ -98,9 +139,16 @@ func (r *ToolRegistry) Execute(ctx context.Context, toolName string, args map[st
 	if !ok {
 		return nil, fmt.Errorf("工具不存在: %s", toolName)
 	}
+	if err := r.checkPolicy(toolName); err != nil {
+		return nil, err
+	}
+	args, err := utools.CoerceConfig(wrapper.Tool.GetSchema(), args)
+	if err != nil {
+		return nil, fmt.Errorf("参数校验失败: %w", err)
+	}
 
 	// 执行工具
-	result, err := wrapper.Tool.Execute(&utools.ExecutionContext{Context: ctx}, args)
+	result, err := wrapper.Tool.Execute(&utools.ExecutionContext{Context: ctx, UserID: utools.UserIDFromContext(ctx)}, args)
 	if err != nil {
 		return nil, fmt.Errorf("工具执行失败: %w", err)
 	}
@@ -108,15 +156,18 @@ func (r *ToolRegistry) Execute(ctx context.Context, toolName string, args map[st
 	return result, nil
 }
 
-// GetTool 获取工具
+// GetTool 获取工具（不满足策略时返回错误）
 func (r *ToolRegistry) GetTool(toolName string) (utools.Tool, error) {
 	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	wrapper, ok := r.tools[toolName]
+	r.mu.RUnlock()
+
 	if !ok {
 		return nil, fmt.Errorf("工具不存在: %s", toolName)
 	}
+	if err := r.checkPolicy(toolName); err != nil {
+		return nil, err
+	}
 
 	return wrapper.Tool, nil
 }
@@ -172,30 +223,7 @@ func generateToolDefinition(tool utools.Tool) (llm.ToolDefinition, error) {
 	if schema != nil {
 		// 直接遍历 Properties (已经是 map[string]utools.PropertySchema)
 		for key, propSchema := range schema.Properties {
-			// 构建属性定义
-			prop := make(map[string]interface{})
-
-			// 类型
-			if propSchema.Type != "" {
-				prop["type"] = propSchema.Type
-			}
-
-			// 描述
-			if propSchema.Description != "" {
-				prop["description"] = propSchema.Description
-			}
-
-			// 枚举
-			if len(propSchema.Enum) > 0 {
-				prop["enum"] = propSchema.Enum
-			}
-
-			// 默认值
-			if propSchema.Default != nil {
-				prop["default"] = propSchema.Default
-			}
-
-			properties[key] = prop
+			properties[key] = propertyDefinition(propSchema)
 		}
 
 		// Required 字段 (已经是 []string)
@@ -227,6 +255,57 @@ func generateToolDefinition(tool utools.Tool) (llm.ToolDefinition, error) {
 	return definition, nil
 }
 
+// propertyDefinition 将属性 Schema（含嵌套对象与数组）转换为 JSON Schema，与工具执行前的校验保持一致
+func propertyDefinition(propSchema utools.PropertySchema) map[string]interface{} {
+	prop := make(map[string]interface{})
+
+	if propSchema.Type != "" {
+		prop["type"] = propSchema.Type
+	}
+	if propSchema.Description != "" {
+		prop["description"] = propSchema.Description
+	}
+	if len(propSchema.Enum) > 0 {
+		prop["enum"] = propSchema.Enum
+	}
+	if propSchema.Default != nil {
+		prop["default"] = propSchema.Default
+	}
+	if propSchema.Format != "" {
+		prop["format"] = propSchema.Format
+	}
+	if propSchema.Pattern != "" {
+		prop["pattern"] = propSchema.Pattern
+	}
+	if propSchema.MinLength != nil {
+		prop["minLength"] = *propSchema.MinLength
+	}
+	if propSchema.MaxLength != nil {
+		prop["maxLength"] = *propSchema.MaxLength
+	}
+	if propSchema.Minimum != nil {
+		prop["minimum"] = *propSchema.Minimum
+	}
+	if propSchema.Maximum != nil {
+		prop["maximum"] = *propSchema.Maximum
+	}
+	if propSchema.Items != nil {
+		prop["items"] = propertyDefinition(*propSchema.Items)
+	}
+	if len(propSchema.Properties) > 0 {
+		nested := make(map[string]interface{}, len(propSchema.Properties))
+		for key, child := range propSchema.Properties {
+			nested[key] = propertyDefinition(child)
+		}
+		prop["properties"] = nested
+	}
+	if len(propSchema.Required) > 0 {
+		prop["required"] = propSchema.Required
+	}
+
+	return prop
+}
+
 // FormatToolResult 格式化工具结果为字符串
 func FormatToolResult(result interface{}) string {
 	if result == nil {
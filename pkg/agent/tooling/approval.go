@@ -0,0 +1,42 @@
+package tooling
+
+import (
+	"auto-forge/pkg/utools"
+	"strings"
+)
+
+// ApprovalDefaultKey 审批策略配置中表示默认策略的键
+const ApprovalDefaultKey = "*"
+
+// IsValidApproval 是否为合法的审批策略
+func IsValidApproval(policy string) bool {
+	switch policy {
+	case utools.ApprovalAuto, utools.ApprovalConfirm, utools.ApprovalDeny:
+		return true
+	}
+	return false
+}
+
+// ResolveApproval 解析一次工具调用的审批策略
+// 优先级：AgentConfig 中按工具名配置 > 工具按参数决定的策略 > 工具元数据 > AgentConfig 默认（"*"） > auto
+func ResolveApproval(toolName string, tool utools.Tool, args map[string]interface{}, overrides map[string]string) string {
+	if policy := strings.ToLower(overrides[toolName]); IsValidApproval(policy) {
+		return policy
+	}
+
+	if tool != nil {
+		if provider, ok := tool.(utools.ApprovalPolicyProvider); ok {
+			if policy := provider.ApprovalPolicy(args); IsValidApproval(policy) {
+				return policy
+			}
+		}
+		if metadata := tool.GetMetadata(); metadata != nil && IsValidApproval(metadata.Approval) {
+			return metadata.Approval
+		}
+	}
+
+	if policy := strings.ToLower(overrides[ApprovalDefaultKey]); IsValidApproval(policy) {
+		return policy
+	}
+	return utools.ApprovalAuto
+}
@@ -0,0 +1,54 @@
+package tooling
+
+import (
+	"auto-forge/pkg/utools"
+	"testing"
+)
+
+// confirmTool 元数据要求确认的工具
+type confirmTool struct {
+	MockTool
+}
+
+func (t *confirmTool) GetMetadata() *utools.ToolMetadata {
+	return &utools.ToolMetadata{Code: "confirm_tool", Approval: utools.ApprovalConfirm}
+}
+
+// methodTool 按参数决定审批策略的工具
+type methodTool struct {
+	confirmTool
+}
+
+func (t *methodTool) ApprovalPolicy(config map[string]interface{}) string {
+	if config["method"] == "GET" {
+		return utools.ApprovalAuto
+	}
+	return ""
+}
+
+func TestResolveApproval(t *testing.T) {
+	tests := []struct {
+		name      string
+		tool      utools.Tool
+		args      map[string]interface{}
+		overrides map[string]string
+		want      string
+	}{
+		{"默认自动执行", &MockTool{}, nil, nil, utools.ApprovalAuto},
+		{"工具元数据", &confirmTool{}, nil, nil, utools.ApprovalConfirm},
+		{"配置默认策略", &MockTool{}, nil, map[string]string{"*": "deny"}, utools.ApprovalDeny},
+		{"元数据优先于配置默认策略", &confirmTool{}, nil, map[string]string{"*": "auto"}, utools.ApprovalConfirm},
+		{"按工具名配置优先", &confirmTool{}, nil, map[string]string{"confirm_tool": "auto"}, utools.ApprovalAuto},
+		{"非法配置被忽略", &confirmTool{}, nil, map[string]string{"confirm_tool": "maybe"}, utools.ApprovalConfirm},
+		{"按参数决定", &methodTool{}, map[string]interface{}{"method": "GET"}, nil, utools.ApprovalAuto},
+		{"按参数未决定时回退元数据", &methodTool{}, map[string]interface{}{"method": "POST"}, nil, utools.ApprovalConfirm},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveApproval("confirm_tool", tt.tool, tt.args, tt.overrides); got != tt.want {
+				t.Errorf("ResolveApproval() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package tooling
 import (
 	"auto-forge/pkg/cache"
 	"auto-forge/pkg/logger"
+	"auto-forge/pkg/utools"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
@@ -21,17 +22,21 @@ func NewCacheManager() *CacheManager {
 	}
 }
 
-// GenerateCacheKey 生成缓存 key
-func (m *CacheManager) GenerateCacheKey(toolName string, args map[string]interface{}) string {
-	// 将参数序列化为 JSON
-	argsJSON, err := json.Marshal(args)
+// GenerateCacheKey 生成缓存 key，用户与凭证连接参与哈希，避免不同用户或凭证间共享结果
+func (m *CacheManager) GenerateCacheKey(toolName, userID, connectionID string, args map[string]interface{}) string {
+	// 将调用方与参数一起序列化为 JSON
+	keyJSON, err := json.Marshal(map[string]interface{}{
+		"user_id":       userID,
+		"connection_id": connectionID,
+		"args":          args,
+	})
 	if err != nil {
 		logger.Warn("序列化参数失败: %v", err)
 		return ""
 	}
 
 	// 使用 SHA256 生成哈希
-	hash := sha256.Sum256(argsJSON)
+	hash := sha256.Sum256(keyJSON)
 	hashStr := fmt.Sprintf("%x", hash)
 
 	// 格式: tool:cache:{tool_name}:{hash}
@@ -60,6 +65,26 @@ func (m *CacheManager) Get(key string) (interface{}, bool) {
 	return result, true
 }
 
+// GetInto 从缓存获取结果并反序列化到 out
+func (m *CacheManager) GetInto(key string, out interface{}) bool {
+	if key == "" {
+		return false
+	}
+
+	value, err := m.cache.Get(key)
+	if err != nil || value == "" {
+		return false
+	}
+
+	if err := json.Unmarshal([]byte(value), out); err != nil {
+		logger.Warn("反序列化缓存失败: %v", err)
+		return false
+	}
+
+	logger.Info("缓存命中: %s", key)
+	return true
+}
+
 // Set 设置缓存
 func (m *CacheManager) Set(key string, value interface{}, ttl time.Duration) error {
 	if key == "" {
@@ -136,6 +161,11 @@ func ShouldCache(config *CacheConfig, result interface{}, err error) bool {
 		return false
 	}
 
+	// 工具自身报告失败的结果不缓存
+	if execResult, ok := result.(*utools.ExecutionResult); ok && (execResult == nil || !execResult.Success) {
+		return false
+	}
+
 	return true
 }
 
@@ -1,6 +1,7 @@
 package tooling
 
 import (
+	"auto-forge/pkg/logger"
 	"auto-forge/pkg/utools"
 	"context"
 	"fmt"
@@ -24,6 +25,16 @@ func NewToolExecutor(config *ExecutionConfig) *ToolExecutor {
 	}
 }
 
+// Config 获取执行配置
+func (e *ToolExecutor) Config() *ExecutionConfig {
+	return e.config
+}
+
+// CacheEnabled 是否启用结果缓存
+func (e *ToolExecutor) CacheEnabled() bool {
+	return e.config.Cache != nil && e.config.Cache.Enabled
+}
+
 // ExecutionResult 执行结果
 type ExecutionResult struct {
 	Output    interface{}
@@ -38,107 +49,48 @@ func (e *ToolExecutor) Execute(
 	ctx context.Context,
 	tool utools.Tool,
 	args map[string]interface{},
+) *ExecutionResult {
+	return e.ExecuteWithProgress(ctx, tool, args, nil)
+}
+
+// ExecuteWithProgress 执行工具并报告进度（带超时、重试、缓存）
+func (e *ToolExecutor) ExecuteWithProgress(
+	ctx context.Context,
+	tool utools.Tool,
+	args map[string]interface{},
+	progressCallback func(attempt int, message string),
 ) *ExecutionResult {
 	startTime := time.Now()
 	result := &ExecutionResult{}
 
-	// 1. 检查缓存
-	if e.config.Cache != nil && e.config.Cache.Enabled {
-		cacheKey := e.cacheManager.GenerateCacheKey(tool.GetMetadata().Code, args)
-		if cached, found := e.cacheManager.Get(cacheKey); found {
-			result.Output = cached
-			result.FromCache = true
-			result.Attempts = 0
-			result.Duration = time.Since(startTime)
-			return result
-		}
-	}
-
-	// 2. 应用超时
-	timeout := time.Duration(e.config.TimeoutSeconds) * time.Second
-	if timeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, timeout)
-		defer cancel()
-	}
+	// 凭证连接不属于工具参数，单独传给工具
+	connectionID, args := utools.SplitConnection(args)
+	userID := utools.UserIDFromContext(ctx)
 
-	// 执行（带重试）
-	var lastErr error
-	maxAttempts := 1
-	if e.config.Retry != nil {
-		maxAttempts = e.config.Retry.MaxRetries + 1
+	// 模型生成的参数可能把数字、布尔写成字符串，按工具 Schema 转换并校验
+	args, err := utools.CoerceConfig(tool.GetSchema(), args)
+	if err != nil {
+		result.Error = fmt.Errorf("参数校验失败: %w", err)
+		result.Duration = time.Since(startTime)
+		return result
 	}
 
-	for attempt := 0; attempt < maxAttempts; attempt++ {
-		result.Attempts = attempt + 1
-
-		// 如果不是第一次尝试，先等待退避时间
-		if attempt > 0 && e.config.Retry != nil {
-			backoff := e.config.Retry.GetBackoff(attempt - 1)
-			select {
-			case <-time.After(backoff):
-			case <-ctx.Done():
-				result.Error = ctx.Err()
-				result.Duration = time.Since(startTime)
-				return result
-			}
-		}
-
-		// 执行工具
-		execCtx := &utools.ExecutionContext{
-			Context: ctx,
-		}
-
-		output, err := tool.Execute(execCtx, args)
-
-		// 成功
-		if err == nil {
-			result.Output = output
+	// 检查缓存
+	cacheKey := ""
+	if e.CacheEnabled() {
+		cacheKey = e.cacheManager.GenerateCacheKey(tool.GetMetadata().Code, userID, connectionID, args)
+		var cached utools.ExecutionResult
+		if e.cacheManager.GetInto(cacheKey, &cached) {
+			result.Output = &cached
+			result.FromCache = true
 			result.Duration = time.Since(startTime)
-
-			// 3. 存入缓存
-			if ShouldCache(e.config.Cache, output, nil) {
-				cacheKey := e.cacheManager.GenerateCacheKey(tool.GetMetadata().Code, args)
-				cacheTTL := GetCacheTTL(e.config.Cache)
-				if err := e.cacheManager.Set(cacheKey, output, cacheTTL); err != nil {
-					// 缓存失败不影响结果
-					fmt.Printf("缓存设置失败: %v\n", err)
-				}
+			if progressCallback != nil {
+				progressCallback(0, "命中缓存")
 			}
-
 			return result
 		}
-
-		// 失败
-		lastErr = err
-
-		// 检查是否可重试
-		if e.config.Retry == nil || !e.config.Retry.IsRetryable(err) {
-			break
-		}
-
-		// 检查上下文是否已取消
-		if ctx.Err() != nil {
-			break
-		}
 	}
 
-	// 所有尝试都失败
-	result.Error = fmt.Errorf("工具执行失败（尝试 %d 次）: %w", result.Attempts, lastErr)
-	result.Duration = time.Since(startTime)
-	return result
-}
-
-// ExecuteWithProgress 执行工具并报告进度
-func (e *ToolExecutor) ExecuteWithProgress(
-	ctx context.Context,
-	tool utools.Tool,
-	args map[string]interface{},
-	progressCallback func(attempt int, message string),
-) *ExecutionResult {
-	startTime := time.Now()
-	result := &ExecutionResult{}
-
 	// 应用超时
 	timeout := time.Duration(e.config.TimeoutSeconds) * time.Second
 	if timeout > 0 {
@@ -185,7 +137,9 @@ func (e *ToolExecutor) ExecuteWithProgress(
 
 		// 执行工具
 		execCtx := &utools.ExecutionContext{
-			Context: ctx,
+			Context:      ctx,
+			UserID:       userID,
+			ConnectionID: connectionID,
 		}
 
 		output, err := tool.Execute(execCtx, args)
@@ -195,6 +149,14 @@ func (e *ToolExecutor) ExecuteWithProgress(
 			result.Output = output
 			result.Duration = time.Since(startTime)
 
+			// 存入缓存
+			if cacheKey != "" && ShouldCache(e.config.Cache, output, nil) {
+				if err := e.cacheManager.Set(cacheKey, output, GetCacheTTL(e.config.Cache)); err != nil {
+					// 缓存失败不影响结果
+					logger.Warn("缓存设置失败: %v", err)
+				}
+			}
+
 			if progressCallback != nil {
 				progressCallback(attempt+1, "执行成功")
 			}
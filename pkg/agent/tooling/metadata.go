@@ -49,23 +49,10 @@ type CacheConfig struct {
 }
 
 // DefaultExecutionConfig 返回默认执行配置
+// 默认不重试：超时或连接错误可能发生在副作用（发送、上传、写入）之后，重试会重复执行
 func DefaultExecutionConfig() *ExecutionConfig {
 	return &ExecutionConfig{
 		TimeoutSeconds: 300, // 默认 5 分钟
-		Retry: &RetryConfig{
-			MaxRetries:        2,
-			InitialBackoff:    1000,  // 1 秒
-			MaxBackoff:        10000, // 10 秒
-			BackoffMultiplier: 2.0,
-			RetryableErrors: []string{
-				"timeout",
-				"connection",
-				"network",
-				"rate limit",
-				"503",
-				"504",
-			},
-		},
 		Cache: &CacheConfig{
 			Enabled: false,
 			TTL:     5 * time.Minute,
@@ -73,6 +60,24 @@ func DefaultExecutionConfig() *ExecutionConfig {
 	}
 }
 
+// DefaultRetryConfig 返回默认重试配置，仅供只读工具通过 ConfigurableTool 显式开启
+func DefaultRetryConfig() *RetryConfig {
+	return &RetryConfig{
+		MaxRetries:        2,
+		InitialBackoff:    1000,  // 1 秒
+		MaxBackoff:        10000, // 10 秒
+		BackoffMultiplier: 2.0,
+		RetryableErrors: []string{
+			"timeout",
+			"connection",
+			"network",
+			"rate limit",
+			"503",
+			"504",
+		},
+	}
+}
+
 // IsRetryable 判断错误是否可重试
 func (r *RetryConfig) IsRetryable(err error) bool {
 	if err == nil {
@@ -128,3 +133,23 @@ func indexOf(s, substr string) int {
 	}
 	return -1
 }
+
+// ResolveExecutionConfig 获取工具的执行配置：实现 ConfigurableTool 的工具使用自身配置，
+// 未设置超时的按默认超时处理；其他工具使用默认配置（只执行一次，不重试）
+func ResolveExecutionConfig(tool interface{}) *ExecutionConfig {
+	configurable, ok := tool.(ConfigurableTool)
+	if !ok {
+		return DefaultExecutionConfig()
+	}
+
+	config := configurable.GetExecutionConfig()
+	if config == nil {
+		return DefaultExecutionConfig()
+	}
+
+	resolved := *config
+	if resolved.TimeoutSeconds <= 0 {
+		resolved.TimeoutSeconds = DefaultExecutionConfig().TimeoutSeconds
+	}
+	return &resolved
+}
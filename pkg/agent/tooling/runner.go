@@ -0,0 +1,104 @@
+package tooling
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/pkg/agent/registry"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ToolRunner Agent 工具运行器：按工具的 ExecutionConfig 通过 ToolExecutor 执行（超时、重试、缓存），并汇总执行统计
+type ToolRunner struct {
+	toolRegistry *registry.ToolRegistry
+	executors    map[string]*ToolExecutor
+	stats        models.AgentToolStats
+	mu           sync.Mutex
+}
+
+// NewToolRunner 创建工具运行器（一次 Agent 执行使用一个实例）
+func NewToolRunner(toolRegistry *registry.ToolRegistry) *ToolRunner {
+	return &ToolRunner{
+		toolRegistry: toolRegistry,
+		executors:    make(map[string]*ToolExecutor),
+	}
+}
+
+// Run 执行工具，progressCallback 可为空
+func (r *ToolRunner) Run(
+	ctx context.Context,
+	toolName string,
+	args map[string]interface{},
+	progressCallback func(attempt int, message string),
+) *ExecutionResult {
+	startTime := time.Now()
+
+	tool, err := r.toolRegistry.GetTool(toolName)
+	if err != nil {
+		r.record(nil, &ExecutionResult{Error: err})
+		return &ExecutionResult{Error: err, Duration: time.Since(startTime)}
+	}
+
+	executor := r.executorFor(toolName, tool)
+	result := executor.ExecuteWithProgress(ctx, tool, args, progressCallback)
+	r.record(executor, result)
+	return result
+}
+
+// Stats 获取当前执行统计快照
+func (r *ToolRunner) Stats() *models.AgentToolStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.stats
+	return &stats
+}
+
+// Restore 以已有统计为基础继续累计（恢复暂停的执行时使用）
+func (r *ToolRunner) Restore(stats *models.AgentToolStats) {
+	if stats == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stats = *stats
+}
+
+// executorFor 获取（或创建）工具对应的执行器，同一工具复用同一配置
+func (r *ToolRunner) executorFor(toolName string, tool interface{}) *ToolExecutor {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if executor, ok := r.executors[toolName]; ok {
+		return executor
+	}
+	executor := NewToolExecutor(ResolveExecutionConfig(tool))
+	r.executors[toolName] = executor
+	return executor
+}
+
+// record 记录一次执行的统计信息
+func (r *ToolRunner) record(executor *ToolExecutor, result *ExecutionResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stats.Calls++
+	if result.FromCache {
+		r.stats.CacheHits++
+		return
+	}
+	if executor != nil && executor.CacheEnabled() {
+		r.stats.CacheMisses++
+	}
+	if result.Attempts > 1 {
+		r.stats.Retries += result.Attempts - 1
+	}
+	if result.Error != nil {
+		r.stats.Failures++
+		if errors.Is(result.Error, context.DeadlineExceeded) {
+			r.stats.Timeouts++
+		}
+	}
+}
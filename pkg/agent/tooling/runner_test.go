@@ -0,0 +1,181 @@
+package tooling
+
+import (
+	"auto-forge/internal/models"
+	"auto-forge/pkg/agent/registry"
+	"auto-forge/pkg/utools"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// CountingTool 记录执行次数的模拟工具，可配置执行配置
+type CountingTool struct {
+	MockTool
+	code   string
+	calls  int
+	config *ExecutionConfig
+}
+
+func (t *CountingTool) GetMetadata() *utools.ToolMetadata {
+	return &utools.ToolMetadata{Code: t.code, Name: t.code, Description: "counting tool"}
+}
+
+func (t *CountingTool) Execute(ctx *utools.ExecutionContext, config map[string]interface{}) (*utools.ExecutionResult, error) {
+	t.calls++
+	return t.MockTool.Execute(ctx, config)
+}
+
+func (t *CountingTool) GetExecutionConfig() *ExecutionConfig {
+	return t.config
+}
+
+func newRunnerWithTool(t *testing.T, tool utools.Tool) *ToolRunner {
+	t.Helper()
+	reg := registry.NewToolRegistry()
+	if err := reg.Register(tool); err != nil {
+		t.Fatalf("注册工具失败: %v", err)
+	}
+	return NewToolRunner(reg)
+}
+
+// TestToolRunner_Cache 测试启用缓存的工具第二次调用命中缓存
+func TestToolRunner_Cache(t *testing.T) {
+	tool := &CountingTool{
+		code: fmt.Sprintf("cache_tool_%d", time.Now().UnixNano()),
+		config: &ExecutionConfig{
+			Cache: &CacheConfig{Enabled: true, TTL: time.Minute},
+		},
+	}
+	runner := newRunnerWithTool(t, tool)
+	args := map[string]interface{}{"input": "same"}
+
+	first := runner.Run(context.Background(), tool.code, args, nil)
+	second := runner.Run(context.Background(), tool.code, args, nil)
+
+	if first.Error != nil || second.Error != nil {
+		t.Fatalf("unexpected errors: %v / %v", first.Error, second.Error)
+	}
+	if first.FromCache || !second.FromCache {
+		t.Errorf("expected second call from cache, got %v / %v", first.FromCache, second.FromCache)
+	}
+	if tool.calls != 1 {
+		t.Errorf("expected tool executed once, got %d", tool.calls)
+	}
+	if output, ok := second.Output.(*utools.ExecutionResult); !ok || output.Output["result"] != "success" {
+		t.Errorf("cached output not restored: %#v", second.Output)
+	}
+
+	stats := runner.Stats()
+	want := models.AgentToolStats{Calls: 2, CacheHits: 1, CacheMisses: 1}
+	if *stats != want {
+		t.Errorf("stats = %+v, want %+v", *stats, want)
+	}
+}
+
+// TestToolRunner_CacheScopedByCaller 测试不同用户或凭证连接不共享缓存
+func TestToolRunner_CacheScopedByCaller(t *testing.T) {
+	tool := &CountingTool{
+		code: fmt.Sprintf("scoped_cache_tool_%d", time.Now().UnixNano()),
+		config: &ExecutionConfig{
+			Cache: &CacheConfig{Enabled: true, TTL: time.Minute},
+		},
+	}
+	runner := newRunnerWithTool(t, tool)
+
+	userA := utools.ContextWithUserID(context.Background(), "user-a")
+	userB := utools.ContextWithUserID(context.Background(), "user-b")
+
+	runner.Run(userA, tool.code, map[string]interface{}{"input": "same"}, nil)
+	if result := runner.Run(userB, tool.code, map[string]interface{}{"input": "same"}, nil); result.FromCache {
+		t.Error("expected cache miss for a different user")
+	}
+	if result := runner.Run(userA, tool.code, map[string]interface{}{"input": "same", utools.ConnectionConfigKey: "conn-1"}, nil); result.FromCache {
+		t.Error("expected cache miss for a different connection")
+	}
+	if result := runner.Run(userA, tool.code, map[string]interface{}{"input": "same"}, nil); !result.FromCache {
+		t.Error("expected cache hit for the same user and connection")
+	}
+	if tool.calls != 3 {
+		t.Errorf("expected tool executed 3 times, got %d", tool.calls)
+	}
+}
+
+// TestToolRunner_RetryStats 测试重试与失败统计
+func TestToolRunner_RetryStats(t *testing.T) {
+	tool := &CountingTool{
+		code:     "retry_tool",
+		MockTool: MockTool{shouldFail: true, failCount: 1},
+		config: &ExecutionConfig{
+			Retry: &RetryConfig{
+				MaxRetries:        2,
+				InitialBackoff:    10,
+				MaxBackoff:        100,
+				BackoffMultiplier: 2.0,
+				RetryableErrors:   []string{"timeout"},
+			},
+		},
+	}
+	runner := newRunnerWithTool(t, tool)
+
+	result := runner.Run(context.Background(), tool.code, map[string]interface{}{}, nil)
+	if result.Error != nil || result.Attempts != 2 {
+		t.Fatalf("expected success after 2 attempts, got %d: %v", result.Attempts, result.Error)
+	}
+
+	// 不存在的工具计为失败
+	if missing := runner.Run(context.Background(), "missing_tool", nil, nil); missing.Error == nil {
+		t.Error("expected error for missing tool")
+	}
+
+	stats := runner.Stats()
+	want := models.AgentToolStats{Calls: 2, Retries: 1, Failures: 1}
+	if *stats != want {
+		t.Errorf("stats = %+v, want %+v", *stats, want)
+	}
+}
+
+// TestResolveExecutionConfig 测试工具配置解析
+func TestResolveExecutionConfig(t *testing.T) {
+	if config := ResolveExecutionConfig(&MockTool{}); config.TimeoutSeconds != DefaultExecutionConfig().TimeoutSeconds {
+		t.Errorf("expected default config, got %+v", config)
+	} else if config.Retry != nil {
+		t.Error("tools without execution config should run once without retry")
+	}
+
+	tool := &CountingTool{config: &ExecutionConfig{}}
+	config := ResolveExecutionConfig(tool)
+	if config.TimeoutSeconds != DefaultExecutionConfig().TimeoutSeconds {
+		t.Errorf("expected default timeout, got %d", config.TimeoutSeconds)
+	}
+	if config.Retry != nil {
+		t.Error("tool config without retry should not retry")
+	}
+}
+
+// TestPlanValidator_ValidateAndFix 测试不存在的工具步骤被降级为跳过
+func TestPlanValidator_ValidateAndFix(t *testing.T) {
+	reg := registry.NewToolRegistry()
+	if err := reg.Register(&CountingTool{code: "known_tool"}); err != nil {
+		t.Fatalf("注册工具失败: %v", err)
+	}
+	validator := NewPlanValidator(reg)
+
+	plan := &models.AgentPlan{Steps: []models.AgentPlanStep{
+		{Step: 1, Tool: "known_tool"},
+		{Step: 2, Tool: "hallucinated_tool"},
+		{Step: 3, Description: "总结"},
+	}}
+
+	fixed, result := validator.ValidateAndFix(plan)
+	if !result.Valid {
+		t.Errorf("expected fixed plan to be valid, errors: %v", result.Errors)
+	}
+	if fixed.Steps[1].Tool != "" {
+		t.Errorf("expected unknown tool step to be cleared, got %q", fixed.Steps[1].Tool)
+	}
+	if len(result.Warnings) == 0 {
+		t.Error("expected a warning describing the fix")
+	}
+}
@@ -47,6 +47,11 @@ func (v *PlanValidator) Validate(plan *models.AgentPlan) *ValidationResult {
 	for i, step := range plan.Steps {
 		stepNum := i + 1
 
+		// 仅描述的步骤无需执行工具
+		if step.Tool == "" {
+			continue
+		}
+
 		// 检查工具是否存在
 		tool, err := v.toolRegistry.GetTool(step.Tool)
 		if err != nil {
@@ -112,18 +117,34 @@ func (v *PlanValidator) Validate(plan *models.AgentPlan) *ValidationResult {
 }
 
 // ValidateAndFix 验证并尝试修复计划
+// 引用不存在工具的步骤降级为仅描述的步骤（执行时跳过），修复后重新验证
 func (v *PlanValidator) ValidateAndFix(plan *models.AgentPlan) (*models.AgentPlan, *ValidationResult) {
 	result := v.Validate(plan)
 
 	// 如果验证通过或只有警告，直接返回
-	if result.Valid {
+	if result.Valid || plan == nil {
 		return plan, result
 	}
 
-	// TODO: 实现自动修复逻辑
-	// 例如：自动插入缺失的前置步骤
+	var fixes []string
+	for i := range plan.Steps {
+		step := &plan.Steps[i]
+		if step.Tool == "" {
+			continue
+		}
+		if _, err := v.toolRegistry.GetTool(step.Tool); err != nil {
+			fixes = append(fixes, fmt.Sprintf("步骤 %d: 工具 '%s' 不存在，已改为跳过", i+1, step.Tool))
+			step.Tool = ""
+		}
+	}
+
+	if len(fixes) == 0 {
+		return plan, result
+	}
 
-	return plan, result
+	fixed := v.Validate(plan)
+	fixed.Warnings = append(fixes, fixed.Warnings...)
+	return plan, fixed
 }
 
 // getToolConfig 获取工具的执行配置
@@ -18,17 +18,20 @@ const (
 
 // Config 应用配置结构
 type Config struct {
-	App      AppConfig      `yaml:"app" env:"APP"`
-	Admin    AdminConfig    `yaml:"admin" env:"ADMIN"`
-	Database DatabaseConfig `yaml:"database" env:"DB"`
-	Redis    RedisConfig    `yaml:"redis" env:"REDIS"`
-	JWT      JWTConfig      `yaml:"jwt" env:"JWT"`
-	Log      LogConfig      `yaml:"log" env:"LOG"`
-	Mail     MailConfig     `yaml:"mail" env:"MAIL"`
-	CORS     CORSConfig     `yaml:"cors" env:"CORS"`
-	Frontend FrontendConfig `yaml:"frontend" env:"FRONTEND"`
-	OAuth2   OAuth2Config   `yaml:"oauth2" env:"OAUTH2"`
-	Agent    AgentConfig    `yaml:"agent" env:"AGENT"`
+	App       AppConfig       `yaml:"app" env:"APP"`
+	Admin     AdminConfig     `yaml:"admin" env:"ADMIN"`
+	Database  DatabaseConfig  `yaml:"database" env:"DB"`
+	Redis     RedisConfig     `yaml:"redis" env:"REDIS"`
+	JWT       JWTConfig       `yaml:"jwt" env:"JWT"`
+	Log       LogConfig       `yaml:"log" env:"LOG"`
+	Mail      MailConfig      `yaml:"mail" env:"MAIL"`
+	CORS      CORSConfig      `yaml:"cors" env:"CORS"`
+	Frontend  FrontendConfig  `yaml:"frontend" env:"FRONTEND"`
+	OAuth2    OAuth2Config    `yaml:"oauth2" env:"OAUTH2"`
+	Agent     AgentConfig     `yaml:"agent" env:"AGENT"`
+	Plugins   PluginsConfig   `yaml:"plugins" env:"PLUGINS"`
+	DataStore DataStoreConfig `yaml:"datastore" env:"DATASTORE"`
+	Egress    EgressConfig    `yaml:"egress" env:"EGRESS"`
 }
 
 // AppConfig 应用基础配置
@@ -37,6 +40,7 @@ type AppConfig struct {
 	Port            int    `yaml:"port" env:"PORT"`
 	Mode            string `yaml:"mode" env:"MODE"`
 	DefaultRootPass string `yaml:"defaultRootPass" env:"DEFAULT_ROOT_PASS"`
+	BaseURL         string `yaml:"base_url" env:"BASE_URL"` // 对外访问地址，用于告警等消息中的链接
 }
 
 // AdminConfig 管理员配置
@@ -153,8 +157,31 @@ type GitHubOAuth2Config struct {
 
 // AgentConfig Agent AI 配置
 type AgentConfig struct {
-	OpenAI        AgentOpenAIConfig        `yaml:"openai" env:"OPENAI"`
-	DefaultConfig AgentDefaultConfigStruct `yaml:"default_config" env:"DEFAULT_CONFIG"`
+	OpenAI        AgentOpenAIConfig          `yaml:"openai" env:"OPENAI"`
+	Anthropic     AgentProviderConfig        `yaml:"anthropic" env:"ANTHROPIC"`
+	Gemini        AgentProviderConfig        `yaml:"gemini" env:"GEMINI"`
+	Ollama        AgentProviderConfig        `yaml:"ollama" env:"OLLAMA"`
+	DefaultConfig AgentDefaultConfigStruct   `yaml:"default_config" env:"DEFAULT_CONFIG"`
+	Pricing       map[string]AgentModelPrice `yaml:"pricing"` // 模型价格表，键为模型名或模型名前缀
+	Budget        AgentBudgetConfig          `yaml:"budget" env:"BUDGET"`
+}
+
+// AgentModelPrice 模型价格（美元 / 百万 Token）
+type AgentModelPrice struct {
+	Input  float64 `yaml:"input"`
+	Output float64 `yaml:"output"`
+}
+
+// AgentBudgetConfig Agent 用量预算，0 表示不限制
+type AgentBudgetConfig struct {
+	UserDailyTokens     int64   `yaml:"user_daily_tokens" env:"USER_DAILY_TOKENS"`
+	UserMonthlyTokens   int64   `yaml:"user_monthly_tokens" env:"USER_MONTHLY_TOKENS"`
+	UserDailyCost       float64 `yaml:"user_daily_cost" env:"USER_DAILY_COST"`
+	UserMonthlyCost     float64 `yaml:"user_monthly_cost" env:"USER_MONTHLY_COST"`
+	GlobalDailyTokens   int64   `yaml:"global_daily_tokens" env:"GLOBAL_DAILY_TOKENS"`
+	GlobalMonthlyTokens int64   `yaml:"global_monthly_tokens" env:"GLOBAL_MONTHLY_TOKENS"`
+	GlobalDailyCost     float64 `yaml:"global_daily_cost" env:"GLOBAL_DAILY_COST"`
+	GlobalMonthlyCost   float64 `yaml:"global_monthly_cost" env:"GLOBAL_MONTHLY_COST"`
 }
 
 // AgentOpenAIConfig OpenAI 配置
@@ -165,6 +192,12 @@ type AgentOpenAIConfig struct {
 	Timeout      int    `yaml:"timeout" env:"TIMEOUT"`
 }
 
+// AgentProviderConfig 其他 LLM 提供商配置（Anthropic / Gemini / Ollama）
+type AgentProviderConfig struct {
+	APIKey  string `yaml:"api_key" env:"API_KEY"`
+	BaseURL string `yaml:"base_url" env:"BASE_URL"`
+}
+
 // AgentDefaultConfigStruct Agent 默认配置
 type AgentDefaultConfigStruct struct {
 	MaxSteps    int     `yaml:"max_steps" env:"MAX_STEPS"`
@@ -172,6 +205,31 @@ type AgentDefaultConfigStruct struct {
 	Mode        string  `yaml:"mode" env:"MODE"`
 }
 
+// PluginsConfig 外部插件工具配置
+type PluginsConfig struct {
+	Dir            string `yaml:"dir" env:"DIR"`                         // 插件目录，为空时不加载插件
+	Timeout        int    `yaml:"timeout" env:"TIMEOUT"`                 // 单次调用超时（秒）
+	HealthInterval int    `yaml:"health_interval" env:"HEALTH_INTERVAL"` // 健康检查间隔（秒）
+}
+
+// DataStoreConfig 用户数据存储配额，0 表示使用默认值
+type DataStoreConfig struct {
+	MaxCollections  int `yaml:"max_collections" env:"MAX_COLLECTIONS"`     // 每个用户的集合数上限
+	MaxDocuments    int `yaml:"max_documents" env:"MAX_DOCUMENTS"`         // 每个用户的文档总数上限
+	MaxDocumentSize int `yaml:"max_document_size" env:"MAX_DOCUMENT_SIZE"` // 单个文档的大小上限（KB）
+}
+
+// EgressConfig 工具出站请求策略，防止通过工具访问内网与云元数据地址
+type EgressConfig struct {
+	AllowPrivateNetworks bool     `yaml:"allow_private_networks" env:"ALLOW_PRIVATE_NETWORKS"` // 允许访问回环、内网、链路本地等地址
+	AllowCIDRs           []string `yaml:"allow_cidrs" env:"ALLOW_CIDRS"`                       // 例外放行的网段，优先于内网限制
+	DenyCIDRs            []string `yaml:"deny_cidrs" env:"DENY_CIDRS"`                         // 始终禁止的网段
+	AllowDomains         []string `yaml:"allow_domains" env:"ALLOW_DOMAINS"`                   // 非空时只允许访问这些域名
+	DenyDomains          []string `yaml:"deny_domains" env:"DENY_DOMAINS"`                     // 始终禁止的域名
+	MaxRedirects         int      `yaml:"max_redirects" env:"MAX_REDIRECTS"`                   // 最多跟随的重定向次数
+	MaxResponseSize      int      `yaml:"max_response_size" env:"MAX_RESPONSE_SIZE"`           // 响应体大小上限（MB）
+}
+
 var (
 	config Config
 	once   sync.Once
@@ -240,7 +298,20 @@ func loadConfigFromEnv(cfg *Config) {
 
 	// 处理Agent配置的环境变量
 	loadEnvToStruct(envPrefix+"AGENT_OPENAI_", &cfg.Agent.OpenAI)
+	loadEnvToStruct(envPrefix+"AGENT_ANTHROPIC_", &cfg.Agent.Anthropic)
+	loadEnvToStruct(envPrefix+"AGENT_GEMINI_", &cfg.Agent.Gemini)
+	loadEnvToStruct(envPrefix+"AGENT_OLLAMA_", &cfg.Agent.Ollama)
 	loadEnvToStruct(envPrefix+"AGENT_DEFAULT_CONFIG_", &cfg.Agent.DefaultConfig)
+	loadEnvToStruct(envPrefix+"AGENT_BUDGET_", &cfg.Agent.Budget)
+
+	// 处理插件配置的环境变量
+	loadEnvToStruct(envPrefix+"PLUGINS_", &cfg.Plugins)
+
+	// 处理数据存储配置的环境变量
+	loadEnvToStruct(envPrefix+"DATASTORE_", &cfg.DataStore)
+
+	// 处理出站请求策略的环境变量
+	loadEnvToStruct(envPrefix+"EGRESS_", &cfg.Egress)
 }
 
 // loadEnvToStruct 加载环境变量到结构体
@@ -308,6 +379,17 @@ func setFieldValue(field reflect.Value, value string) {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
 			field.SetBool(boolValue)
 		}
+	case reflect.Slice:
+		// 字符串切片以逗号分隔
+		if field.Type().Elem().Kind() == reflect.String {
+			items := make([]string, 0)
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			field.Set(reflect.ValueOf(items))
+		}
 	}
 }
 
@@ -123,14 +123,22 @@ func autoMigrate() error {
 		// 工作流模型
 		&models.Workflow{},
 		&models.WorkflowExecution{},
+		&models.WorkflowPollState{},
+		&models.WorkflowAlertPolicy{},
 		&models.WorkflowTemplate{},
 		&models.TemplateInstall{},
 		&models.TemplateCategory{},
 		// 工具配置模型
 		&models.ToolConfig{},
+		&models.ToolConnection{},
+		&models.OpenAPITool{},
 		// Agent 对话模型
 		&models.AgentConversation{},
 		&models.AgentMessage{},
+		&models.AgentUsage{},
+		// 用户数据存储模型
+		&models.DataCollection{},
+		&models.DataDocument{},
 		// 在这里添加其他模型
 	)
 }
@@ -22,3 +22,12 @@ func GenerateWorkflowAPIKey() (string, error) {
 	}
 	return "wf_" + randomStr, nil
 }
+
+// GenerateWorkflowWebhookToken 生成工作流入站 Webhook 令牌
+func GenerateWorkflowWebhookToken() (string, error) {
+	randomStr, err := GenerateRandomString(40)
+	if err != nil {
+		return "", err
+	}
+	return "wh_" + randomStr, nil
+}
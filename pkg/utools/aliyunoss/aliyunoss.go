@@ -28,6 +28,7 @@ func NewAliyunOSSTool() *AliyunOSSTool {
 		Version:     "1.0.0",
 		Author:      "Cooper Team",
 		AICallable:  true,
+		Approval:    utools.ApprovalConfirm,
 		Tags:        []string{"storage", "upload", "aliyun", "oss"},
 		OutputFieldsSchema: map[string]utools.OutputFieldDef{
 			"response": {
@@ -93,7 +94,7 @@ func (t *AliyunOSSTool) Execute(ctx *utools.ExecutionContext, toolConfig map[str
 	startTime := time.Now()
 
 	// 从数据库加载 OSS 配置
-	dbConfig, err := toolConfigService.GetToolConfigForExecution("aliyun_oss")
+	dbConfig, err := toolConfigService.GetToolConfigForContext(ctx, "aliyun_oss")
 	if err != nil {
 		return &utools.ExecutionResult{
 			Success:    false,
@@ -230,7 +231,7 @@ func (t *AliyunOSSTool) uploadToOSS(endpoint, accessKeyID, accessKeySecret, buck
 	req.Header.Set("Authorization", fmt.Sprintf("OSS %s:%s", accessKeyID, signature))
 
 	// 发送请求
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := utools.NewHTTPClient(utools.HTTPClientOptions{Timeout: 30 * time.Second})
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", err
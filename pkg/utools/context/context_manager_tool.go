@@ -29,7 +29,7 @@ func NewContextManagerTool() *ContextManagerTool {
 		Name:        "对话上下文管理器",
 		Description: "管理多轮对话的上下文历史，支持准备消息（prepare）和保存消息（persist）两种模式",
 		Category:    utools.CategoryData,
-		Version:     "1.0.0",
+		Version:     "1.1.0",
 		Author:      "AutoForge",
 		AICallable:  false,
 		Tags:        []string{"context", "chat", "memory", "conversation"},
@@ -96,14 +96,14 @@ func NewContextManagerTool() *ContextManagerTool {
             "scope": {
                 Type:        "string",
                 Title:       "作用域",
-                Description: "node=每节点独立，workflow=同工作流共享",
-                Default:     "node",
-                Enum:        []interface{}{"node", "workflow"},
+                Description: "user=当前用户内共享，workflow=当前工作流内共享，shared=跨用户共享（需管理员允许）；node 为旧配置，等同 user",
+                Default:     ScopeUser,
+                Enum:        []interface{}{ScopeUser, ScopeWorkflow, ScopeShared, "node"},
             },
             "workflow_id": {
                 Type:        "string",
                 Title:       "工作流ID（可选）",
-                Description: "用于命名空间键；不填则省略",
+                Description: "workflow 作用域使用的工作流；不填则使用当前工作流，只能访问自己的工作流",
             },
             "user_input": {
                 Type:        "string",
@@ -170,7 +170,20 @@ func (t *ContextManagerTool) Execute(ctx *utools.ExecutionContext, config map[st
 	}
 
 
-    cacheKey := fmt.Sprintf("chat:context:%s", sessionKey)
+	scope, _ := config["scope"].(string)
+	if scope == "" || scope == "node" {
+		scope = ScopeUser
+	}
+	workflowID, _ := config["workflow_id"].(string)
+	cacheKey, err := scopedKey(ctx, "context_manager", scope, workflowID, "chat:context:"+sessionKey)
+	if err != nil {
+		return &utools.ExecutionResult{
+			Success:    false,
+			Message:    "无法访问该会话",
+			Error:      err.Error(),
+			DurationMs: time.Since(startTime).Milliseconds(),
+		}, err
+	}
 
 
 	clearHistory, _ := config["clear_history"].(bool)
@@ -503,4 +516,17 @@ func init() {
 	if err := utools.Register(tool); err != nil {
 		panic(fmt.Sprintf("Failed to register Context Manager tool: %v", err))
 	}
+	// 1.1.0 将旧的 node 作用域改为 user
+	if err := utools.RegisterMigration("context_manager", "1.0.0", "1.1.0", func(config map[string]interface{}) (map[string]interface{}, error) {
+		migrated := make(map[string]interface{}, len(config))
+		for key, value := range config {
+			migrated[key] = value
+		}
+		if scope, _ := migrated["scope"].(string); scope == "" || scope == "node" {
+			migrated["scope"] = ScopeUser
+		}
+		return migrated, nil
+	}); err != nil {
+		panic(fmt.Sprintf("Failed to register Context Manager migration: %v", err))
+	}
 }
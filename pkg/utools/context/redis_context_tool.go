@@ -19,9 +19,9 @@ func NewRedisContextTool() *RedisContextTool {
     metadata := &utools.ToolMetadata{
         Code:        "redis_context",
         Name:        "Redis 存储器",
-        Description: "使用 Redis/内存缓存存取上下文数据，支持 get/set/delete 并可选 TTL",
+        Description: "使用 Redis/内存缓存存取上下文数据，支持 get/set/delete 并可选 TTL；键按用户或工作流自动隔离",
         Category:    utools.CategoryData,
-        Version:     "1.0.0",
+        Version:     "1.1.0",
         Author:      "AutoForge",
         AICallable:  false,
         Tags:        []string{"redis", "storage", "context", "state"},
@@ -57,6 +57,13 @@ func NewRedisContextTool() *RedisContextTool {
                 Title:       "值",
                 Description: "当 action=set 时写入的值，支持JSON字符串或普通文本",
             },
+            "scope": {
+                Type:        "string",
+                Title:       "作用域",
+                Description: "user=当前用户内共享，workflow=当前工作流内共享，shared=跨用户共享（需管理员允许）",
+                Default:     ScopeUser,
+                Enum:        []interface{}{ScopeUser, ScopeWorkflow, ScopeShared},
+            },
             "ttl_seconds": {
                 Type:        "number",
                 Title:       "过期时间(秒)",
@@ -127,10 +134,25 @@ func (t *RedisContextTool) Execute(ctx *utools.ExecutionContext, config map[stri
 
     action, _ := config["action"].(string)
     key, _ := config["key"].(string)
+    scope, _ := config["scope"].(string)
+    if scope == "" {
+        scope = ScopeUser
+    }
+
+    // 输出中的 key 保持用户填写的键名，实际读写带作用域命名空间的键
+    storeKey, err := scopedKey(ctx, "redis_context", scope, "", key)
+    if err != nil {
+        return &utools.ExecutionResult{
+            Success:    false,
+            Message:    "无法访问该键",
+            Error:      err.Error(),
+            DurationMs: time.Since(start).Milliseconds(),
+        }, err
+    }
 
     switch action {
     case "get":
-        val, err := cache.Get(key)
+        val, err := cache.Get(storeKey)
         exists := err == nil
 
 
@@ -153,7 +175,7 @@ func (t *RedisContextTool) Execute(ctx *utools.ExecutionContext, config map[stri
                 output["json"] = anyJSON
             }
 
-            if ttl, err := cache.TTL(key); err == nil {
+            if ttl, err := cache.TTL(storeKey); err == nil {
 
                 output["ttl_ms"] = ttl.Milliseconds()
             }
@@ -178,7 +200,7 @@ func (t *RedisContextTool) Execute(ctx *utools.ExecutionContext, config map[stri
             ttlSeconds = v
         }
         exp := time.Duration(ttlSeconds) * time.Second
-        if err := cache.Set(key, val, exp); err != nil {
+        if err := cache.Set(storeKey, val, exp); err != nil {
             return &utools.ExecutionResult{
                 Success:    false,
                 Message:    "写入失败",
@@ -209,7 +231,7 @@ func (t *RedisContextTool) Execute(ctx *utools.ExecutionContext, config map[stri
         }, nil
 
     case "delete":
-        if err := cache.Del(key); err != nil {
+        if err := cache.Del(storeKey); err != nil {
             return &utools.ExecutionResult{
                 Success:    false,
                 Message:    "删除失败",
@@ -247,4 +269,10 @@ func init() {
     if err := utools.Register(tool); err != nil {
         panic(fmt.Sprintf("Failed to register Redis Context tool: %v", err))
     }
+    // 1.1.0 起键按用户隔离，旧配置无需改动即可使用
+    if err := utools.RegisterMigration("redis_context", "1.0.0", "1.1.0", func(config map[string]interface{}) (map[string]interface{}, error) {
+        return config, nil
+    }); err != nil {
+        panic(fmt.Sprintf("Failed to register Redis Context migration: %v", err))
+    }
 }
@@ -0,0 +1,65 @@
+package context
+
+import (
+	"fmt"
+
+	"auto-forge/internal/services/tool_config"
+	"auto-forge/pkg/utools"
+)
+
+// 键的作用域：user=当前用户内共享，workflow=当前工作流内共享，shared=跨用户共享（需管理员在工具配置中开启 allow_shared_scope）
+const (
+	ScopeUser     = "user"
+	ScopeWorkflow = "workflow"
+	ScopeShared   = "shared"
+)
+
+// keyPrefix 工具写入缓存的键统一带此前缀，与验证码、管理员会话等内部键隔离
+const keyPrefix = "utools:ctx:"
+
+// scopedKey 按作用域为用户提供的键加上命名空间，用户只能访问自己命名空间下的键
+func scopedKey(ctx *utools.ExecutionContext, toolCode, scope, workflowID, key string) (string, error) {
+	switch scope {
+	case ScopeShared:
+		if !sharedScopeAllowed(toolCode) {
+			return "", fmt.Errorf("管理员未允许 %s 使用共享作用域", toolCode)
+		}
+		return keyPrefix + "shared:" + key, nil
+	case ScopeUser, ScopeWorkflow:
+	default:
+		return "", fmt.Errorf("不支持的作用域: %s", scope)
+	}
+
+	userID := ""
+	if ctx != nil {
+		userID = ctx.UserID
+		if userID == "" {
+			userID = utools.UserIDFromContext(ctx.Context)
+		}
+	}
+	if userID == "" {
+		return "", fmt.Errorf("无法确定执行用户，不能访问上下文存储")
+	}
+
+	if scope == ScopeUser {
+		return keyPrefix + "user:" + userID + ":" + key, nil
+	}
+
+	if workflowID == "" && ctx != nil {
+		workflowID = ctx.WorkflowID
+	}
+	if workflowID == "" {
+		return "", fmt.Errorf("工作流作用域只能在工作流中使用")
+	}
+	return keyPrefix + "workflow:" + userID + ":" + workflowID + ":" + key, nil
+}
+
+// sharedScopeAllowed 读取管理员的工具配置，判断是否允许共享作用域
+func sharedScopeAllowed(toolCode string) bool {
+	config, err := tool_config.GetToolConfigForExecution(toolCode)
+	if err != nil {
+		return false
+	}
+	allowed, _ := config["allow_shared_scope"].(bool)
+	return allowed
+}
@@ -0,0 +1,36 @@
+package context
+
+import (
+	"testing"
+
+	"auto-forge/pkg/utools"
+)
+
+func TestScopedKey(t *testing.T) {
+	ctx := &utools.ExecutionContext{UserID: "u1", WorkflowID: "wf1"}
+
+	if key, err := scopedKey(ctx, "redis_context", ScopeUser, "", "session"); err != nil || key != "utools:ctx:user:u1:session" {
+		t.Fatalf("user scope: %q %v", key, err)
+	}
+	if key, err := scopedKey(ctx, "redis_context", ScopeWorkflow, "", "session"); err != nil || key != "utools:ctx:workflow:u1:wf1:session" {
+		t.Fatalf("workflow scope: %q %v", key, err)
+	}
+	if key, err := scopedKey(ctx, "context_manager", ScopeWorkflow, "wf2", "chat"); err != nil || key != "utools:ctx:workflow:u1:wf2:chat" {
+		t.Fatalf("explicit workflow: %q %v", key, err)
+	}
+
+	other, _ := scopedKey(&utools.ExecutionContext{UserID: "u2"}, "redis_context", ScopeUser, "", "session")
+	if other == "utools:ctx:user:u1:session" {
+		t.Fatal("different users must not share keys")
+	}
+
+	if _, err := scopedKey(&utools.ExecutionContext{}, "redis_context", ScopeUser, "", "session"); err == nil {
+		t.Fatal("expected error without user")
+	}
+	if _, err := scopedKey(&utools.ExecutionContext{UserID: "u1"}, "redis_context", ScopeWorkflow, "", "session"); err == nil {
+		t.Fatal("expected error for workflow scope outside a workflow")
+	}
+	if _, err := scopedKey(ctx, "redis_context", "global", "", "session"); err == nil {
+		t.Fatal("expected error for unknown scope")
+	}
+}
@@ -0,0 +1,295 @@
+package datastore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	store "auto-forge/internal/services/datastore"
+	"auto-forge/pkg/errors"
+	"auto-forge/pkg/utools"
+)
+
+// DataStoreTool 读写用户自己的数据集合，数据持久化在数据库中，可在多次执行之间保存状态
+type DataStoreTool struct {
+	*utools.BaseTool
+}
+
+// NewDataStoreTool 创建数据存储工具
+func NewDataStoreTool() *DataStoreTool {
+	metadata := &utools.ToolMetadata{
+		Code:        "data_store",
+		Name:        "数据存储",
+		Description: "在当前用户的数据集合中存取 JSON 文档，支持 get/upsert/delete/query，数据可在「数据存储」页面浏览和导出",
+		Category:    utools.CategoryData,
+		Version:     "1.0.0",
+		Author:      "AutoForge",
+		AICallable:  true,
+		Tags:        []string{"storage", "database", "state", "collection"},
+		OutputFieldsSchema: map[string]utools.OutputFieldDef{
+			"action":     {Type: "string", Label: "执行的操作"},
+			"collection": {Type: "string", Label: "集合名称"},
+			"key":        {Type: "string", Label: "文档键"},
+			"found":      {Type: "boolean", Label: "文档是否存在 (仅 get)"},
+			"data":       {Type: "object", Label: "文档内容 (get/upsert)"},
+			"created":    {Type: "boolean", Label: "是否为新建文档 (仅 upsert)"},
+			"deleted":    {Type: "boolean", Label: "文档是否被删除 (仅 delete)"},
+			"documents":  {Type: "array", Label: "查询到的文档 (仅 query)"},
+			"total":      {Type: "number", Label: "满足条件的文档总数 (仅 query)"},
+		},
+	}
+
+	limitMin, limitMax := 1.0, 1000.0
+	schema := &utools.ConfigSchema{
+		Type: "object",
+		Properties: map[string]utools.PropertySchema{
+			"action": {
+				Type:        "string",
+				Title:       "操作类型",
+				Description: "get(读取)、upsert(写入或覆盖)、delete(删除)、query(按条件查询)",
+				Default:     "get",
+				Enum:        []interface{}{"get", "upsert", "delete", "query"},
+			},
+			"collection": {
+				Type:        "string",
+				Title:       "集合名称",
+				Description: "写入时集合不存在会自动创建",
+				MinLength:   func() *int { v := 1; return &v }(),
+			},
+			"key": {
+				Type:        "string",
+				Title:       "文档键",
+				Description: "get/upsert/delete 必填，例如：{{external_trigger.id}}",
+			},
+			"data": {
+				Title:       "文档内容",
+				Description: "upsert 时写入的内容，可以是对象、数组或 JSON 字符串",
+			},
+			"filters": {
+				Type:        "array",
+				Title:       "过滤条件",
+				Description: "query 时使用，多个条件同时满足；op 支持 eq/ne/gt/gte/lt/lte/in/contains/prefix/exists，field 为点分路径，_key 表示文档键",
+				Items: &utools.PropertySchema{
+					Type: "object",
+					Properties: map[string]utools.PropertySchema{
+						"field": {Type: "string", Title: "字段"},
+						"op":    {Type: "string", Title: "操作符", Default: store.OpEq},
+						"value": {Title: "值"},
+					},
+					Required: []string{"op"},
+				},
+			},
+			"sort_by": {
+				Type:        "string",
+				Title:       "排序字段",
+				Description: "query 时使用，默认按文档键排序，_updated_at 表示更新时间",
+			},
+			"sort_desc": {
+				Type:    "boolean",
+				Title:   "降序",
+				Default: false,
+			},
+			"limit": {
+				Type:    "integer",
+				Title:   "返回数量",
+				Default: 100.0,
+				Minimum: &limitMin,
+				Maximum: &limitMax,
+			},
+		},
+		Required: []string{"action", "collection"},
+	}
+
+	return &DataStoreTool{BaseTool: utools.NewBaseTool(metadata, schema)}
+}
+
+// Validate 校验配置
+func (t *DataStoreTool) Validate(config map[string]interface{}) error {
+	if err := t.BaseTool.Validate(config); err != nil {
+		return err
+	}
+
+	action, _ := config["action"].(string)
+	if action != "query" {
+		if key, _ := config["key"].(string); key == "" {
+			return &utools.ValidationError{Field: "key", Message: fmt.Sprintf("key is required for action=%s", action)}
+		}
+	}
+	if action == "upsert" {
+		if _, ok := config["data"]; !ok {
+			return &utools.ValidationError{Field: "data", Message: "data is required for action=upsert"}
+		}
+	}
+	return nil
+}
+
+// Execute 执行数据存储操作
+func (t *DataStoreTool) Execute(ctx *utools.ExecutionContext, config map[string]interface{}) (*utools.ExecutionResult, error) {
+	start := time.Now()
+
+	action, _ := config["action"].(string)
+	name, _ := config["collection"].(string)
+	key, _ := config["key"].(string)
+	output := map[string]interface{}{
+		"action":     action,
+		"collection": name,
+		"key":        key,
+	}
+	fail := func(message string, err error) (*utools.ExecutionResult, error) {
+		return &utools.ExecutionResult{
+			Success:    false,
+			Message:    message,
+			Error:      err.Error(),
+			Output:     output,
+			DurationMs: time.Since(start).Milliseconds(),
+		}, err
+	}
+
+	userID := ""
+	if ctx != nil {
+		userID = ctx.UserID
+		if userID == "" {
+			userID = utools.UserIDFromContext(ctx.Context)
+		}
+	}
+	if userID == "" {
+		return fail("无法访问数据存储", fmt.Errorf("无法确定执行用户"))
+	}
+
+	service := store.NewDataStoreService()
+	collection, err := service.ResolveCollection(userID, name, action == "upsert")
+	if err != nil {
+		// 读取不存在的集合与读取不存在的文档一致，视为未找到而不是失败
+		if action != "upsert" && errors.Is(err, errors.CodeNotFound) {
+			return t.emptyResult(action, output, start), nil
+		}
+		return fail("获取集合失败", err)
+	}
+	collectionID := collection.GetID()
+
+	message := ""
+	switch action {
+	case "get":
+		document, err := service.GetDocument(userID, collectionID, key)
+		if err != nil {
+			if errors.Is(err, errors.CodeNotFound) {
+				return t.emptyResult(action, output, start), nil
+			}
+			return fail("读取失败", err)
+		}
+		output["found"] = true
+		output["data"] = document.Data
+		message = "读取成功"
+
+	case "upsert":
+		document, created, err := service.UpsertDocument(userID, collectionID, key, decodeData(config["data"]))
+		if err != nil {
+			return fail("写入失败", err)
+		}
+		output["data"] = document.Data
+		output["created"] = created
+		message = "写入成功"
+
+	case "delete":
+		deleted, err := service.DeleteDocument(userID, collectionID, key)
+		if err != nil {
+			return fail("删除失败", err)
+		}
+		output["deleted"] = deleted
+		message = "删除成功"
+
+	case "query":
+		query, err := buildQuery(config)
+		if err != nil {
+			return fail("查询条件无效", err)
+		}
+		result, err := service.QueryDocuments(userID, collectionID, query)
+		if err != nil {
+			return fail("查询失败", err)
+		}
+		output["documents"] = result.Documents
+		output["total"] = result.Total
+		message = fmt.Sprintf("查询到 %d 条文档", result.Total)
+
+	default:
+		return fail(fmt.Sprintf("不支持的操作: %s", action), fmt.Errorf("unsupported action"))
+	}
+
+	return &utools.ExecutionResult{
+		Success:    true,
+		Message:    message,
+		Output:     output,
+		DurationMs: time.Since(start).Milliseconds(),
+	}, nil
+}
+
+// emptyResult 集合或文档不存在时的结果
+func (t *DataStoreTool) emptyResult(action string, output map[string]interface{}, start time.Time) *utools.ExecutionResult {
+	message := "文档不存在"
+	switch action {
+	case "get":
+		output["found"] = false
+		output["data"] = nil
+	case "delete":
+		output["deleted"] = false
+	case "query":
+		output["documents"] = []interface{}{}
+		output["total"] = 0
+		message = "集合不存在，查询结果为空"
+	}
+	return &utools.ExecutionResult{
+		Success:    true,
+		Message:    message,
+		Output:     output,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+}
+
+// decodeData 模板变量替换后的对象、数组会变成 JSON 字符串，写入前还原
+func decodeData(data interface{}) interface{} {
+	str, ok := data.(string)
+	if !ok {
+		return data
+	}
+	trimmed := strings.TrimSpace(str)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return data
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(trimmed), &decoded); err != nil {
+		return data
+	}
+	return decoded
+}
+
+// buildQuery 由节点配置构造查询条件
+func buildQuery(config map[string]interface{}) (*store.Query, error) {
+	query := &store.Query{}
+	query.SortBy, _ = config["sort_by"].(string)
+	query.Desc, _ = config["sort_desc"].(bool)
+	if limit, ok := config["limit"].(float64); ok {
+		query.Limit = int(limit)
+	}
+
+	if filters, ok := config["filters"]; ok && filters != nil {
+		encoded, err := json.Marshal(filters)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(encoded, &query.Filters); err != nil {
+			return nil, fmt.Errorf("filters 格式错误: %w", err)
+		}
+	}
+	for i := range query.Filters {
+		query.Filters[i].Value = decodeData(query.Filters[i].Value)
+	}
+	return query, nil
+}
+
+func init() {
+	tool := NewDataStoreTool()
+	if err := utools.Register(tool); err != nil {
+		panic(fmt.Sprintf("Failed to register Data Store tool: %v", err))
+	}
+}
@@ -2,7 +2,6 @@ package downloader
 
 import (
     "auto-forge/pkg/utools"
-    "crypto/tls"
     "fmt"
     "io"
     "net/http"
@@ -83,6 +82,11 @@ func NewFileDownloaderTool() *FileDownloaderTool {
                 Description: "HTTP 请求超时，默认 60 秒",
                 Default:     60.0,
             },
+            "max_size_mb": {
+                Type:        "number",
+                Title:       "文件大小上限(MB)",
+                Description: "超过该大小时中止下载，留空或超过管理员配置的出站响应上限时按该上限",
+            },
             "verify_ssl": {
                 Type:        "boolean",
                 Title:       "验证 SSL",
@@ -123,17 +127,18 @@ func (t *FileDownloaderTool) Execute(ctx *utools.ExecutionContext, config map[st
         followRedirects = v
     }
 
-    client := &http.Client{
-        Timeout: time.Duration(timeout) * time.Second,
-        Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: !verifySSL}},
-        CheckRedirect: func(req *http.Request, via []*http.Request) error {
-            if !followRedirects {
-                return http.ErrUseLastResponse
-            }
-            return nil
-        },
+    maxSizeMB := 0
+    if v, ok := config["max_size_mb"].(float64); ok && v > 0 {
+        maxSizeMB = int(v)
     }
 
+    client := utools.NewHTTPClient(utools.HTTPClientOptions{
+        Timeout:            time.Duration(timeout) * time.Second,
+        NoRedirects:        !followRedirects,
+        MaxResponseSize:    int64(maxSizeMB) << 20,
+        InsecureSkipVerify: !verifySSL,
+    })
+
     req, err := http.NewRequest("GET", urlStr, nil)
     if err != nil {
         return &utools.ExecutionResult{Success: false, Message: "创建请求失败", Error: err.Error(), DurationMs: time.Since(start).Milliseconds()}, err
@@ -0,0 +1,407 @@
+package utools
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"auto-forge/pkg/config"
+	"auto-forge/pkg/logger"
+)
+
+// ErrEgressDenied 出站请求被策略拒绝
+var ErrEgressDenied = errors.New("egress denied")
+
+// ErrResponseTooLarge 响应体超过大小上限
+var ErrResponseTooLarge = errors.New("response too large")
+
+const (
+	defaultMaxRedirects    = 5
+	defaultMaxResponseSize = 10 << 20
+)
+
+// internalCIDRs 除 net.IP 自带判断外，还需禁止的保留网段
+var internalCIDRs = mustParseCIDRs(
+	"0.0.0.0/8",     // 本网络
+	"100.64.0.0/10", // 运营商级 NAT
+	"192.0.0.0/24",  // IETF 协议分配
+	"198.18.0.0/15", // 基准测试
+	"240.0.0.0/4",   // 保留
+)
+
+// EgressPolicy 出站请求策略。域名在每次请求（包括重定向）前检查，
+// IP 在建立连接时按实际连接的地址检查，DNS 重绑定无法绕过
+type EgressPolicy struct {
+	AllowPrivate    bool
+	AllowCIDRs      []*net.IPNet
+	DenyCIDRs       []*net.IPNet
+	AllowDomains    []string
+	DenyDomains     []string
+	MaxRedirects    int
+	MaxResponseSize int64
+}
+
+// NewEgressPolicy 由配置创建出站策略
+func NewEgressPolicy(cfg config.EgressConfig) (*EgressPolicy, error) {
+	allowCIDRs, err := parseCIDRs(cfg.AllowCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	denyCIDRs, err := parseCIDRs(cfg.DenyCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &EgressPolicy{
+		AllowPrivate:    cfg.AllowPrivateNetworks,
+		AllowCIDRs:      allowCIDRs,
+		DenyCIDRs:       denyCIDRs,
+		AllowDomains:    normalizeDomains(cfg.AllowDomains),
+		DenyDomains:     normalizeDomains(cfg.DenyDomains),
+		MaxRedirects:    cfg.MaxRedirects,
+		MaxResponseSize: int64(cfg.MaxResponseSize) << 20,
+	}
+	if policy.MaxRedirects <= 0 {
+		policy.MaxRedirects = defaultMaxRedirects
+	}
+	if policy.MaxResponseSize <= 0 {
+		policy.MaxResponseSize = defaultMaxResponseSize
+	}
+	return policy, nil
+}
+
+// CheckURL 检查请求地址的协议与主机
+func (p *EgressPolicy) CheckURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: unsupported scheme %q", ErrEgressDenied, u.Scheme)
+	}
+	return p.CheckHost(u.Hostname())
+}
+
+// CheckHost 检查主机名；主机为 IP 字面量时同时检查 IP
+func (p *EgressPolicy) CheckHost(host string) error {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" {
+		return fmt.Errorf("%w: empty host", ErrEgressDenied)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return p.CheckIP(ip)
+	}
+
+	for _, pattern := range p.DenyDomains {
+		if MatchDomain(host, pattern) {
+			return fmt.Errorf("%w: domain %s is denied", ErrEgressDenied, host)
+		}
+	}
+	if len(p.AllowDomains) == 0 {
+		return nil
+	}
+	for _, pattern := range p.AllowDomains {
+		if MatchDomain(host, pattern) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: domain %s is not in the allow list", ErrEgressDenied, host)
+}
+
+// CheckIP 检查连接地址：禁止网段优先，其次是放行网段，最后默认禁止内网与保留地址
+func (p *EgressPolicy) CheckIP(ip net.IP) error {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, cidr := range p.DenyCIDRs {
+		if cidr.Contains(ip) {
+			return fmt.Errorf("%w: address %s is denied", ErrEgressDenied, ip)
+		}
+	}
+	for _, cidr := range p.AllowCIDRs {
+		if cidr.Contains(ip) {
+			return nil
+		}
+	}
+	if !p.AllowPrivate && isInternalIP(ip) {
+		return fmt.Errorf("%w: address %s is internal", ErrEgressDenied, ip)
+	}
+	return nil
+}
+
+func isInternalIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.Equal(net.IPv4bcast) {
+		return true
+	}
+	for _, cidr := range internalCIDRs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchDomain 域名匹配：* 匹配全部，*.example.com 匹配子域名，其他为精确匹配
+func MatchDomain(host, pattern string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	switch {
+	case pattern == "*":
+		return true
+	case strings.HasPrefix(pattern, "*."):
+		return strings.HasSuffix(host, pattern[1:])
+	}
+	return host == pattern
+}
+
+var (
+	egressPolicy   *EgressPolicy
+	egressPolicyMu sync.RWMutex
+)
+
+// GetEgressPolicy 获取全局出站策略，首次调用时从配置加载；配置无效时退回默认策略
+func GetEgressPolicy() *EgressPolicy {
+	egressPolicyMu.RLock()
+	policy := egressPolicy
+	egressPolicyMu.RUnlock()
+	if policy != nil {
+		return policy
+	}
+
+	egressPolicyMu.Lock()
+	defer egressPolicyMu.Unlock()
+	if egressPolicy == nil {
+		loaded, err := NewEgressPolicy(config.GetConfig().Egress)
+		if err != nil {
+			logger.Error("出站策略配置无效，使用默认策略: %v", err)
+			loaded, _ = NewEgressPolicy(config.EgressConfig{})
+		}
+		egressPolicy = loaded
+	}
+	return egressPolicy
+}
+
+// SetEgressPolicy 替换全局出站策略（用于测试或运行时调整），传入 nil 时下次使用重新从配置加载
+func SetEgressPolicy(policy *EgressPolicy) {
+	egressPolicyMu.Lock()
+	egressPolicy = policy
+	egressPolicyMu.Unlock()
+
+	// 旧策略的连接池不再使用
+	transportsMu.Lock()
+	for key, transport := range transports {
+		transport.CloseIdleConnections()
+		delete(transports, key)
+	}
+	transportsMu.Unlock()
+}
+
+// HTTPClientOptions 出站 HTTP 客户端选项
+type HTTPClientOptions struct {
+	Timeout            time.Duration
+	NoRedirects        bool  // 不跟随重定向，直接返回 3xx 响应
+	MaxRedirects       int   // 0 表示使用策略的上限
+	MaxResponseSize    int64 // 0 表示使用策略的上限，超过策略上限时按策略上限
+	InsecureSkipVerify bool
+	// TrustedHosts 管理员配置的地址（如导入的 OpenAPI 文档中的服务器）所在主机，访问时不受出站策略限制；
+	// 从这些主机重定向到其他主机时仍按策略检查
+	TrustedHosts []string
+}
+
+// NewHTTPClient 创建遵循出站策略的 HTTP 客户端，访问用户提供的地址时必须使用。
+// 不使用环境变量中的代理，否则连接检查的将是代理地址
+func NewHTTPClient(opts HTTPClientOptions) *http.Client {
+	policy := GetEgressPolicy()
+	transport := egressBaseTransport(policy, opts.InsecureSkipVerify, false)
+
+	var trustedTransport http.RoundTripper
+	trusted := make(map[string]bool, len(opts.TrustedHosts))
+	for _, host := range opts.TrustedHosts {
+		if host = strings.ToLower(strings.TrimSuffix(host, ".")); host != "" {
+			trusted[host] = true
+		}
+	}
+	if len(trusted) > 0 {
+		trustedTransport = egressBaseTransport(policy, opts.InsecureSkipVerify, true)
+	}
+
+	maxSize := opts.MaxResponseSize
+	if maxSize <= 0 || maxSize > policy.MaxResponseSize {
+		maxSize = policy.MaxResponseSize
+	}
+	maxRedirects := opts.MaxRedirects
+	if maxRedirects <= 0 || maxRedirects > policy.MaxRedirects {
+		maxRedirects = policy.MaxRedirects
+	}
+
+	return &http.Client{
+		Timeout: opts.Timeout,
+		Transport: &egressTransport{
+			base:             transport,
+			trustedTransport: trustedTransport,
+			trusted:          trusted,
+			policy:           policy,
+			maxSize:          maxSize,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if opts.NoRedirects {
+				return http.ErrUseLastResponse
+			}
+			if len(via) > maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+}
+
+type transportKey struct {
+	policy   *EgressPolicy
+	insecure bool
+	trusted  bool
+}
+
+var (
+	transports   = make(map[transportKey]*http.Transport)
+	transportsMu sync.Mutex
+)
+
+// egressBaseTransport 按策略复用底层连接池，连接建立时检查实际连接的 IP。
+// trusted 的连接池只用于可信主机，不做 IP 检查，也不会被其他主机的请求复用
+func egressBaseTransport(policy *EgressPolicy, insecure, trusted bool) *http.Transport {
+	key := transportKey{policy: policy, insecure: insecure, trusted: trusted}
+
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+	if transport, ok := transports[key]; ok {
+		return transport
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   15 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return fmt.Errorf("%w: unresolved address %s", ErrEgressDenied, address)
+			}
+			return policy.CheckIP(ip)
+		},
+	}
+	if trusted {
+		dialer.Control = nil
+	}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	transports[key] = transport
+	return transport
+}
+
+// egressTransport 在每次请求前检查目标地址，并限制响应体大小
+type egressTransport struct {
+	base             http.RoundTripper
+	trustedTransport http.RoundTripper
+	trusted          map[string]bool
+	policy           *EgressPolicy
+	maxSize          int64
+}
+
+func (t *egressTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if t.trusted[strings.ToLower(strings.TrimSuffix(req.URL.Hostname(), "."))] {
+		base = t.trustedTransport
+	} else if err := t.policy.CheckURL(req.URL); err != nil {
+		return nil, err
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if resp.ContentLength > t.maxSize {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %d bytes exceeds limit of %d bytes", ErrResponseTooLarge, resp.ContentLength, t.maxSize)
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.maxSize}
+	return resp, nil
+}
+
+// limitedBody 读取超过上限时返回 ErrResponseTooLarge，而不是静默截断
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), ErrResponseTooLarge
+	}
+	return n, err
+}
+
+func parseCIDRs(values []string) ([]*net.IPNet, error) {
+	cidrs := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			if ip := net.ParseIP(value); ip != nil && ip.To4() != nil {
+				value += "/32"
+			} else {
+				value += "/128"
+			}
+		}
+		_, cidr, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %w", value, err)
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	return cidrs, nil
+}
+
+func mustParseCIDRs(values ...string) []*net.IPNet {
+	cidrs, err := parseCIDRs(values)
+	if err != nil {
+		panic(err)
+	}
+	return cidrs
+}
+
+func normalizeDomains(domains []string) []string {
+	result := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		if domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), ".")); domain != "" {
+			result = append(result, domain)
+		}
+	}
+	return result
+}
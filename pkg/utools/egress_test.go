@@ -0,0 +1,164 @@
+package utools
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"auto-forge/pkg/config"
+)
+
+func TestEgressPolicyCheckIP(t *testing.T) {
+	policy, err := NewEgressPolicy(config.EgressConfig{
+		AllowCIDRs: []string{"10.0.8.0/24"},
+		DenyCIDRs:  []string{"203.0.113.0/24"},
+	})
+	if err != nil {
+		t.Fatalf("NewEgressPolicy: %v", err)
+	}
+
+	tests := map[string]bool{
+		"127.0.0.1":        false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::1":              false,
+		"::ffff:127.0.0.1": false,
+		"fd00::1":          false,
+		"fe80::1":          false,
+		"10.0.8.20":        true,
+		"203.0.113.5":      false,
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+	}
+	for address, allowed := range tests {
+		err := policy.CheckIP(net.ParseIP(address))
+		if (err == nil) != allowed {
+			t.Errorf("CheckIP(%s) = %v, want allowed=%v", address, err, allowed)
+		}
+		if err != nil && !errors.Is(err, ErrEgressDenied) {
+			t.Errorf("CheckIP(%s) error %v does not wrap ErrEgressDenied", address, err)
+		}
+	}
+
+	open, _ := NewEgressPolicy(config.EgressConfig{AllowPrivateNetworks: true, DenyCIDRs: []string{"169.254.169.254"}})
+	if err := open.CheckIP(net.ParseIP("10.1.2.3")); err != nil {
+		t.Errorf("private network should be allowed: %v", err)
+	}
+	if err := open.CheckIP(net.ParseIP("169.254.169.254")); err == nil {
+		t.Error("deny list should take precedence over allow_private_networks")
+	}
+}
+
+func TestEgressPolicyCheckURL(t *testing.T) {
+	policy, _ := NewEgressPolicy(config.EgressConfig{
+		AllowDomains: []string{"api.example.com", "*.github.com"},
+		DenyDomains:  []string{"blocked.github.com"},
+	})
+
+	tests := map[string]bool{
+		"https://api.example.com/v1":     true,
+		"https://API.EXAMPLE.COM./v1":    true,
+		"https://raw.github.com/x":       true,
+		"https://blocked.github.com/x":   false,
+		"https://example.com":            false,
+		"ftp://api.example.com":          false,
+		"http://127.0.0.1:8080/":         false,
+		"http://[::1]/":                  false,
+		"http://169.254.169.254/latest/": false,
+	}
+	for raw, allowed := range tests {
+		u, _ := url.Parse(raw)
+		if err := policy.CheckURL(u); (err == nil) != allowed {
+			t.Errorf("CheckURL(%s) = %v, want allowed=%v", raw, err, allowed)
+		}
+	}
+
+	if _, err := NewEgressPolicy(config.EgressConfig{AllowCIDRs: []string{"10.0.0.0/33"}}); err == nil {
+		t.Error("expected error for invalid CIDR")
+	}
+}
+
+func TestHTTPClientBlocksInternalAtConnect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	SetEgressPolicy(nil)
+	defer SetEgressPolicy(nil)
+
+	// localhost 通过域名检查，但解析出的回环地址在建立连接时被拒绝
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	_, err := NewHTTPClient(HTTPClientOptions{}).Get("http://localhost:" + port)
+	if !errors.Is(err, ErrEgressDenied) {
+		t.Fatalf("expected ErrEgressDenied, got %v", err)
+	}
+}
+
+func TestHTTPClientLimits(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/big", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 2048)))
+	})
+	mux.HandleFunc("/2mb", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 2<<20)))
+	})
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 4; i++ {
+			w.Write([]byte(strings.Repeat("x", 512)))
+			w.(http.Flusher).Flush()
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	policy, _ := NewEgressPolicy(config.EgressConfig{AllowCIDRs: []string{"127.0.0.1"}, MaxRedirects: 3, MaxResponseSize: 1})
+	SetEgressPolicy(policy)
+	defer SetEgressPolicy(nil)
+
+	if _, err := NewHTTPClient(HTTPClientOptions{}).Get(server.URL + "/loop"); err == nil || !strings.Contains(err.Error(), "3 redirects") {
+		t.Errorf("expected redirect limit error, got %v", err)
+	}
+
+	resp, err := NewHTTPClient(HTTPClientOptions{NoRedirects: true}).Get(server.URL + "/loop")
+	if err != nil || resp.StatusCode != http.StatusFound {
+		t.Errorf("expected 302 without following, got %v %v", resp, err)
+	}
+
+	// 调用方传入的上限不能超过策略上限
+	resp, err = NewHTTPClient(HTTPClientOptions{MaxResponseSize: 100 << 20}).Get(server.URL + "/2mb")
+	if err == nil {
+		_, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("expected option to be clamped to the policy limit, got %v", err)
+	}
+
+	client := NewHTTPClient(HTTPClientOptions{MaxResponseSize: 1024})
+	if _, err := client.Get(server.URL + "/big"); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("expected ErrResponseTooLarge from Content-Length, got %v", err)
+	}
+
+	resp, err = client.Get(server.URL + "/stream")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if !errors.Is(err, ErrResponseTooLarge) || len(data) != 1024 {
+		t.Errorf("expected ErrResponseTooLarge after 1024 bytes, got %d bytes, %v", len(data), err)
+	}
+}
@@ -25,6 +25,7 @@ func NewEmailTool() *EmailTool {
         Version:     "1.0.0",
         Author:      "AutoForge",
         AICallable:  true,
+        Approval:    utools.ApprovalConfirm,
         Tags:        []string{"email", "notification", "smtp", "alert"},
         OutputFieldsSchema: map[string]utools.OutputFieldDef{
             "recipients_count": {Type: "number", Label: "收件人数"},
@@ -31,6 +31,7 @@ func NewFeishuTool() *FeishuTool {
         Version:     "1.0.0",
         Author:      "AutoForge",
         AICallable:  true,
+        Approval:    utools.ApprovalConfirm,
         Tags:        []string{"feishu", "lark", "notification", "bot", "webhook"},
         OutputFieldsSchema: map[string]utools.OutputFieldDef{
             "success": {Type: "boolean", Label: "是否发送成功"},
@@ -208,7 +209,8 @@ func (t *FeishuTool) Execute(ctx *utools.ExecutionContext, toolConfig map[string
 		}, err
 	}
 
-	resp, err := http.Post(webhookURL, "application/json", bytes.NewBuffer(jsonData))
+	client := utools.NewHTTPClient(utools.HTTPClientOptions{Timeout: 30 * time.Second})
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return &utools.ExecutionResult{
 			Success:    false,
@@ -696,7 +698,7 @@ func (t *FeishuTool) uploadImage(appID, appSecret, imageURL string) (string, err
 
 
 	t.logger.Info("步骤 2: 下载图片", zap.String("image_url", imageURL))
-	resp, err := http.Get(imageURL)
+	resp, err := utools.NewHTTPClient(utools.HTTPClientOptions{Timeout: 30 * time.Second}).Get(imageURL)
 	if err != nil {
 		t.logger.Error("下载图片失败", zap.Error(err))
 		return "", fmt.Errorf("下载图片失败: %v", err)
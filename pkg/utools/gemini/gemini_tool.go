@@ -1,6 +1,8 @@
 package gemini
 
 import (
+	toolConfigService "auto-forge/internal/services/tool_config"
+	"auto-forge/pkg/agent/llm"
 	"auto-forge/pkg/utools"
 	"bytes"
 	"encoding/base64"
@@ -92,6 +94,10 @@ func NewGeminiTool() *GeminiTool {
 				Type:  "string",
 				Label: "AI 回复内容（快捷访问）",
 			},
+			"data": {
+				Type:  "object",
+				Label: "结构化输出（按 response_schema 校验后的 JSON）",
+			},
 		},
 	}
 
@@ -150,6 +156,11 @@ func NewGeminiTool() *GeminiTool {
 				Title:       "图片输入",
 				Description: "传入图片文件对象（可选，仅 vision 模型支持），支持变量",
 			},
+			"response_schema": {
+				Type:        "string",
+				Title:       "输出 JSON Schema",
+				Description: "填写后要求模型按该 JSON Schema 输出（可选），输出经校验后写入 data 字段，不符合时自动要求模型修正",
+			},
 		},
 		Required: []string{"prompt"},
 	}
@@ -214,20 +225,17 @@ func (t *GeminiTool) Execute(ctx *utools.ExecutionContext, config map[string]int
 	}
 
 	// 2. 从数据库加载 Gemini 配置
-	// TODO: 集成工具配置中心后取消注释
-	// dbConfig, err := toolConfigService.GetToolConfigForExecution("gemini_chat")
-	// if err != nil {
-	//     return &utools.ExecutionResult{
-	//         Success:    false,
-	//         Message:    "Gemini 配置错误",
-	//         Error:      err.Error(),
-	//         DurationMs: time.Since(startTime).Milliseconds(),
-	//     }, err
-	// }
-	// apiKey, _ := dbConfig["api_key"].(string)
-
-	// 临时方案：从环境变量读取
-	apiKey := getEnvOrDefault("GEMINI_API_KEY", "")
+	dbConfig, err := toolConfigService.GetToolConfigForContext(ctx, "gemini_chat")
+	if err != nil {
+		return &utools.ExecutionResult{
+			Success:    false,
+			Message:    "Gemini 配置错误",
+			Error:      err.Error(),
+			DurationMs: time.Since(startTime).Milliseconds(),
+		}, err
+	}
+
+	apiKey, _ := dbConfig["api_key"].(string)
 	if apiKey == "" {
 		return &utools.ExecutionResult{
 			Success:    false,
@@ -361,9 +369,96 @@ func (t *GeminiTool) Execute(ctx *utools.ExecutionContext, config map[string]int
 	// 4. 发送请求
 	apiURL := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", model, apiKey)
 
-	jsonData, err := json.Marshal(reqBody)
+	responseSchema, err := llm.ParseSchema(config["response_schema"])
 	if err != nil {
 		return &utools.ExecutionResult{
+			Success:    false,
+			Message:    "response_schema 无效",
+			Error:      err.Error(),
+			DurationMs: time.Since(startTime).Milliseconds(),
+		}, err
+	}
+	if responseSchema != nil {
+		reqBody.GenerationConfig["responseMimeType"] = "application/json"
+		if schema := llm.SanitizeGeminiSchema(responseSchema); len(schema) > 0 {
+			reqBody.GenerationConfig["responseSchema"] = schema
+		}
+	}
+
+	// 指定 response_schema 时校验输出，不符合时把错误反馈给模型重新生成
+	var geminiResp *GeminiResponse
+	var data interface{}
+	text := ""
+	for attempt := 0; ; attempt++ {
+		var failure *utools.ExecutionResult
+		geminiResp, failure, err = t.sendGenerateRequest(apiURL, &reqBody, startTime)
+		if failure != nil {
+			return failure, err
+		}
+
+		// 提取文本
+		text = ""
+		if parts := geminiResp.Candidates[0].Content.Parts; len(parts) > 0 {
+			text = parts[0].Text
+		}
+
+		if responseSchema == nil {
+			break
+		}
+		var schemaErrors []string
+		data, schemaErrors = llm.CheckStructuredContent(responseSchema, text)
+		if len(schemaErrors) == 0 {
+			break
+		}
+		if attempt >= llm.DefaultMaxRepairs {
+			return &utools.ExecutionResult{
+				Success: false,
+				Message: "模型输出不符合 response_schema",
+				Error:   strings.Join(schemaErrors, "; "),
+				Output: map[string]interface{}{
+					"text":          text,
+					"schema_errors": schemaErrors,
+				},
+				DurationMs: time.Since(startTime).Milliseconds(),
+			}, fmt.Errorf("structured output validation failed: %s", strings.Join(schemaErrors, "; "))
+		}
+
+		reqBody.Contents = append(reqBody.Contents,
+			GeminiMessage{Role: "model", Parts: []map[string]interface{}{{"text": text}}},
+			GeminiMessage{Role: "user", Parts: []map[string]interface{}{{"text": llm.RepairInstruction(responseSchema, schemaErrors)}}},
+		)
+	}
+	candidate := geminiResp.Candidates[0]
+
+	// 6. 构建输出
+	responseData := map[string]interface{}{
+		"text":          text,
+		"model":         model,
+		"finish_reason": candidate.FinishReason,
+		"full_response": geminiResp,
+	}
+
+	output := map[string]interface{}{
+		"response": responseData,
+		"text":     text,
+	}
+	if responseSchema != nil {
+		output["data"] = data
+	}
+
+	return &utools.ExecutionResult{
+		Success:    true,
+		Message:    "Gemini AI 执行成功",
+		Output:     output,
+		DurationMs: time.Since(startTime).Milliseconds(),
+	}, nil
+}
+
+// sendGenerateRequest 发送 generateContent 请求，失败时返回可直接作为工具结果的错误结果
+func (t *GeminiTool) sendGenerateRequest(apiURL string, reqBody *GeminiRequest, startTime time.Time) (*GeminiResponse, *utools.ExecutionResult, error) {
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, &utools.ExecutionResult{
 			Success:    false,
 			Message:    "请求序列化失败",
 			Error:      err.Error(),
@@ -373,7 +468,7 @@ func (t *GeminiTool) Execute(ctx *utools.ExecutionContext, config map[string]int
 
 	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return &utools.ExecutionResult{
+		return nil, &utools.ExecutionResult{
 			Success:    false,
 			Message:    "创建请求失败",
 			Error:      err.Error(),
@@ -388,7 +483,7 @@ func (t *GeminiTool) Execute(ctx *utools.ExecutionContext, config map[string]int
 	}
 	resp, err := client.Do(req)
 	if err != nil {
-		return &utools.ExecutionResult{
+		return nil, &utools.ExecutionResult{
 			Success:    false,
 			Message:    "请求失败",
 			Error:      err.Error(),
@@ -399,7 +494,7 @@ func (t *GeminiTool) Execute(ctx *utools.ExecutionContext, config map[string]int
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return &utools.ExecutionResult{
+		return nil, &utools.ExecutionResult{
 			Success:    false,
 			Message:    "读取响应失败",
 			Error:      err.Error(),
@@ -408,7 +503,7 @@ func (t *GeminiTool) Execute(ctx *utools.ExecutionContext, config map[string]int
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return &utools.ExecutionResult{
+		return nil, &utools.ExecutionResult{
 			Success:    false,
 			Message:    fmt.Sprintf("API 返回错误: %d", resp.StatusCode),
 			Error:      string(respBody),
@@ -416,10 +511,10 @@ func (t *GeminiTool) Execute(ctx *utools.ExecutionContext, config map[string]int
 		}, fmt.Errorf("API 返回错误: %d, %s", resp.StatusCode, string(respBody))
 	}
 
-	// 5. 解析响应
+	// 解析响应
 	var geminiResp GeminiResponse
 	if err := json.Unmarshal(respBody, &geminiResp); err != nil {
-		return &utools.ExecutionResult{
+		return nil, &utools.ExecutionResult{
 			Success:    false,
 			Message:    "解析响应失败",
 			Error:      err.Error(),
@@ -428,7 +523,7 @@ func (t *GeminiTool) Execute(ctx *utools.ExecutionContext, config map[string]int
 	}
 
 	if len(geminiResp.Candidates) == 0 {
-		return &utools.ExecutionResult{
+		return nil, &utools.ExecutionResult{
 			Success:    false,
 			Message:    "API 未返回结果",
 			Error:      "no candidates in response",
@@ -436,41 +531,13 @@ func (t *GeminiTool) Execute(ctx *utools.ExecutionContext, config map[string]int
 		}, fmt.Errorf("API 未返回结果")
 	}
 
-	// 提取文本
-	candidate := geminiResp.Candidates[0]
-	text := ""
-	if len(candidate.Content.Parts) > 0 {
-		text = candidate.Content.Parts[0].Text
-	}
-
-	// 6. 构建输出
-	responseData := map[string]interface{}{
-		"text":          text,
-		"model":         model,
-		"finish_reason": candidate.FinishReason,
-		"full_response": geminiResp,
-	}
-
-	return &utools.ExecutionResult{
-		Success: true,
-		Message: "Gemini AI 执行成功",
-		Output: map[string]interface{}{
-			"response": responseData,
-			"text":     text,
-		},
-		DurationMs: time.Since(startTime).Milliseconds(),
-	}, nil
+	return &geminiResp, nil, nil
 }
 
 func float64Ptr(v float64) *float64 {
 	return &v
 }
 
-func getEnvOrDefault(key, defaultValue string) string {
-	// 临时实现，后续集成工具配置中心
-	return defaultValue
-}
-
 func init() {
 	utools.Register(NewGeminiTool())
 }
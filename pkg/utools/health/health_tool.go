@@ -2,7 +2,6 @@ package health
 
 import (
 	"auto-forge/pkg/utools"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -185,20 +184,11 @@ func (t *HealthCheckerTool) Execute(ctx *utools.ExecutionContext, config map[str
 	}
 
 
-	client := &http.Client{
-		Timeout: time.Duration(timeout) * time.Second,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: !verifySSL,
-			},
-		},
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if !followRedirects {
-				return http.ErrUseLastResponse
-			}
-			return nil
-		},
-	}
+	client := utools.NewHTTPClient(utools.HTTPClientOptions{
+		Timeout:            time.Duration(timeout) * time.Second,
+		NoRedirects:        !followRedirects,
+		InsecureSkipVerify: !verifySSL,
+	})
 
 
 	var bodyReader io.Reader
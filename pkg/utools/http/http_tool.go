@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -89,6 +90,16 @@ func NewHTTPTool() *HTTPTool {
 }
 
 
+// ApprovalPolicy 只读请求直接执行，其它方法可能产生副作用，需用户确认
+func (t *HTTPTool) ApprovalPolicy(config map[string]interface{}) string {
+	method, _ := config["method"].(string)
+	switch strings.ToUpper(method) {
+	case "", "GET", "HEAD", "OPTIONS":
+		return utools.ApprovalAuto
+	}
+	return utools.ApprovalConfirm
+}
+
 func (t *HTTPTool) Execute(ctx *utools.ExecutionContext, config map[string]interface{}) (*utools.ExecutionResult, error) {
 	startTime := time.Now()
 
@@ -138,15 +149,11 @@ func (t *HTTPTool) Execute(ctx *utools.ExecutionContext, config map[string]inter
 	}
 
 
-	client := &http.Client{
-		Timeout: time.Duration(timeout) * time.Second,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if followRedirects, ok := config["follow_redirects"].(bool); ok && !followRedirects {
-				return http.ErrUseLastResponse
-			}
-			return nil
-		},
-	}
+	followRedirects, ok := config["follow_redirects"].(bool)
+	client := utools.NewHTTPClient(utools.HTTPClientOptions{
+		Timeout:     time.Duration(timeout) * time.Second,
+		NoRedirects: ok && !followRedirects,
+	})
 
 
 	resp, err := client.Do(req)
@@ -1,6 +1,7 @@
 package kr36
 
 import (
+	"auto-forge/pkg/agent/tooling"
 	"auto-forge/pkg/utools"
 	"encoding/json"
 	"fmt"
@@ -331,6 +332,13 @@ func float64Ptr(v float64) *float64 {
 	return &v
 }
 
+// GetExecutionConfig 只读查询，网络错误可安全重试（实现 ConfigurableTool 接口）
+func (t *KR36Tool) GetExecutionConfig() *tooling.ExecutionConfig {
+	return &tooling.ExecutionConfig{
+		Retry: tooling.DefaultRetryConfig(),
+	}
+}
+
 func init() {
 	utools.Register(NewKR36Tool())
 }
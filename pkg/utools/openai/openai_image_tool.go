@@ -107,7 +107,7 @@ func (t *OpenAIImageTool) Execute(ctx *utools.ExecutionContext, toolConfig map[s
 	startTime := time.Now()
 
 	// 从数据库加载 OpenAI 配置
-	dbConfig, err := toolConfigService.GetToolConfigForExecution("openai_image")
+	dbConfig, err := toolConfigService.GetToolConfigForContext(ctx, "openai_image")
 	if err != nil {
 		return &utools.ExecutionResult{
 			Success:    false,
@@ -220,9 +220,8 @@ func (t *OpenAIImageTool) Execute(ctx *utools.ExecutionContext, toolConfig map[s
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
 
-	client := &http.Client{
-		Timeout: time.Duration(timeout) * time.Second,
-	}
+	// api_base 可由用户连接配置，使用遵循出站策略的客户端
+	client := utools.NewHTTPClient(utools.HTTPClientOptions{Timeout: time.Duration(timeout) * time.Second})
 
 	resp, err := client.Do(req)
 	if err != nil {
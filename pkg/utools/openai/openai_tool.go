@@ -2,6 +2,7 @@ package openai
 
 import (
 	toolConfigService "auto-forge/internal/services/tool_config"
+	"auto-forge/pkg/agent/llm"
 	"auto-forge/pkg/utools"
 	"bytes"
 	"encoding/base64"
@@ -73,6 +74,7 @@ func NewOpenAITool() *OpenAITool {
 			},
 			"content":           {Type: "string", Label: "ChatGPT 回复内容"},
 			"content_json":      {Type: "object", Label: "JSON对象（如果回复是JSON，会自动解析）"},
+			"data":              {Type: "object", Label: "结构化输出（按 response_schema 校验后的 JSON）"},
 			"model":             {Type: "string", Label: "使用的模型"},
 			"finish_reason":     {Type: "string", Label: "结束原因"},
 			"prompt_tokens":     {Type: "number", Label: "提示词 Token 数"},
@@ -122,6 +124,11 @@ func NewOpenAITool() *OpenAITool {
 				Title:       "最大 Token 数",
 				Description: "生成回复的最大 token 数量（可选）",
 			},
+			"response_schema": {
+				Type:        "string",
+				Title:       "输出 JSON Schema",
+				Description: "填写后要求模型按该 JSON Schema 输出（可选），输出经校验后写入 data 字段，不符合时自动要求模型修正",
+			},
 			"timeout": {
 				Type:        "number",
 				Title:       "超时时间",
@@ -174,7 +181,7 @@ func (t *OpenAITool) Execute(ctx *utools.ExecutionContext, toolConfig map[string
 	startTime := time.Now()
 
 	// 从数据库加载 OpenAI 配置
-	dbConfig, err := toolConfigService.GetToolConfigForExecution("openai_chatgpt")
+	dbConfig, err := toolConfigService.GetToolConfigForContext(ctx, "openai_chatgpt")
 	if err != nil {
 		return &utools.ExecutionResult{
 			Success:    false,
@@ -304,9 +311,130 @@ func (t *OpenAITool) Execute(ctx *utools.ExecutionContext, toolConfig map[string
 		requestBody["max_tokens"] = int(maxTokens)
 	}
 
-	jsonData, err := json.Marshal(requestBody)
+	responseSchema, err := llm.ParseSchema(toolConfig["response_schema"])
 	if err != nil {
 		return &utools.ExecutionResult{
+			Success:    false,
+			Message:    "response_schema 无效",
+			Error:      err.Error(),
+			DurationMs: time.Since(startTime).Milliseconds(),
+		}, err
+	}
+	if responseSchema != nil {
+		requestBody["response_format"] = map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   "response",
+				"schema": responseSchema,
+			},
+		}
+	}
+
+	// 指定 response_schema 时校验输出，不符合时把错误反馈给模型重新生成
+	var result map[string]interface{}
+	var data interface{}
+	var content, finishReason string
+	var promptTokens, completionTokens, totalTokens int
+	for attempt := 0; ; attempt++ {
+		var failure *utools.ExecutionResult
+		result, failure, err = t.sendChatRequest(apiBase, apiKey, timeout, requestBody, startTime)
+		if failure != nil {
+			return failure, err
+		}
+
+		content, finishReason = "", ""
+		if choice, ok := result["choices"].([]interface{})[0].(map[string]interface{}); ok {
+			if message, ok := choice["message"].(map[string]interface{}); ok {
+				if c, ok := message["content"].(string); ok {
+					content = c
+				}
+			}
+			if fr, ok := choice["finish_reason"].(string); ok {
+				finishReason = fr
+			}
+		}
+
+		if usage, ok := result["usage"].(map[string]interface{}); ok {
+			if v, ok := usage["prompt_tokens"].(float64); ok {
+				promptTokens += int(v)
+			}
+			if v, ok := usage["completion_tokens"].(float64); ok {
+				completionTokens += int(v)
+			}
+			if v, ok := usage["total_tokens"].(float64); ok {
+				totalTokens += int(v)
+			}
+		}
+
+		if responseSchema == nil {
+			break
+		}
+		var schemaErrors []string
+		data, schemaErrors = llm.CheckStructuredContent(responseSchema, content)
+		if len(schemaErrors) == 0 {
+			break
+		}
+		if attempt >= llm.DefaultMaxRepairs {
+			return &utools.ExecutionResult{
+				Success: false,
+				Message: "模型输出不符合 response_schema",
+				Error:   strings.Join(schemaErrors, "; "),
+				Output: map[string]interface{}{
+					"content":       content,
+					"schema_errors": schemaErrors,
+				},
+				DurationMs: time.Since(startTime).Milliseconds(),
+			}, fmt.Errorf("structured output validation failed: %s", strings.Join(schemaErrors, "; "))
+		}
+
+		messages = append(messages,
+			map[string]interface{}{"role": "assistant", "content": content},
+			map[string]interface{}{"role": "user", "content": llm.RepairInstruction(responseSchema, schemaErrors)},
+		)
+		requestBody["messages"] = messages
+	}
+
+	modelStr, _ := result["model"].(string)
+
+	output := map[string]interface{}{
+		"response":          result,
+		"content":           content,
+		"model":             modelStr,
+		"finish_reason":     finishReason,
+		"prompt_tokens":     promptTokens,
+		"completion_tokens": completionTokens,
+		"total_tokens":      totalTokens,
+		"prompt":            prompt,
+	}
+
+	if responseSchema != nil {
+		output["data"] = data
+	}
+
+	if content != "" {
+		var contentJSON map[string]interface{}
+		if err := json.Unmarshal([]byte(content), &contentJSON); err == nil {
+			output["content_json"] = contentJSON
+
+			for k, v := range contentJSON {
+				output[k] = v
+			}
+		}
+	}
+
+	return &utools.ExecutionResult{
+		Success:    true,
+		Message:    "ChatGPT 调用成功",
+		Output:     output,
+		DurationMs: time.Since(startTime).Milliseconds(),
+	}, nil
+}
+
+// sendChatRequest 发送 Chat Completions 请求，失败时返回可直接作为工具结果的错误结果
+func (t *OpenAITool) sendChatRequest(apiBase, apiKey string, timeout int, requestBody map[string]interface{}, startTime time.Time) (map[string]interface{}, *utools.ExecutionResult, error) {
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, &utools.ExecutionResult{
 			Success:    false,
 			Message:    "构建请求失败",
 			Error:      err.Error(),
@@ -317,7 +445,7 @@ func (t *OpenAITool) Execute(ctx *utools.ExecutionContext, toolConfig map[string
 	url := fmt.Sprintf("%s/chat/completions", apiBase)
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return &utools.ExecutionResult{
+		return nil, &utools.ExecutionResult{
 			Success:    false,
 			Message:    "创建请求失败",
 			Error:      err.Error(),
@@ -328,13 +456,12 @@ func (t *OpenAITool) Execute(ctx *utools.ExecutionContext, toolConfig map[string
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
 
-	client := &http.Client{
-		Timeout: time.Duration(timeout) * time.Second,
-	}
+	// api_base 可由用户连接配置，使用遵循出站策略的客户端
+	client := utools.NewHTTPClient(utools.HTTPClientOptions{Timeout: time.Duration(timeout) * time.Second})
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return &utools.ExecutionResult{
+		return nil, &utools.ExecutionResult{
 			Success:    false,
 			Message:    "API 请求失败",
 			Error:      err.Error(),
@@ -345,7 +472,7 @@ func (t *OpenAITool) Execute(ctx *utools.ExecutionContext, toolConfig map[string
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return &utools.ExecutionResult{
+		return nil, &utools.ExecutionResult{
 			Success:    false,
 			Message:    "读取响应失败",
 			Error:      err.Error(),
@@ -355,7 +482,7 @@ func (t *OpenAITool) Execute(ctx *utools.ExecutionContext, toolConfig map[string
 
 	var result map[string]interface{}
 	if err := json.Unmarshal(body, &result); err != nil {
-		return &utools.ExecutionResult{
+		return nil, &utools.ExecutionResult{
 			Success:    false,
 			Message:    "解析响应失败",
 			Error:      err.Error(),
@@ -371,7 +498,7 @@ func (t *OpenAITool) Execute(ctx *utools.ExecutionContext, toolConfig map[string
 				errorMsg = msg
 			}
 		}
-		return &utools.ExecutionResult{
+		return nil, &utools.ExecutionResult{
 			Success:    false,
 			Message:    fmt.Sprintf("OpenAI API 错误: %s", errorMsg),
 			Error:      errorMsg,
@@ -383,7 +510,7 @@ func (t *OpenAITool) Execute(ctx *utools.ExecutionContext, toolConfig map[string
 	if errorObj, ok := result["error"].(map[string]interface{}); ok {
 		errorMsg, _ := errorObj["message"].(string)
 		errorType, _ := errorObj["type"].(string)
-		return &utools.ExecutionResult{
+		return nil, &utools.ExecutionResult{
 			Success:    false,
 			Message:    fmt.Sprintf("OpenAI API 错误: %s", errorMsg),
 			Error:      errorType,
@@ -394,7 +521,7 @@ func (t *OpenAITool) Execute(ctx *utools.ExecutionContext, toolConfig map[string
 
 	choices, ok := result["choices"].([]interface{})
 	if !ok || len(choices) == 0 {
-		return &utools.ExecutionResult{
+		return nil, &utools.ExecutionResult{
 			Success: false,
 			Message: "OpenAI API 返回格式异常：未找到 choices 字段",
 			Error:   "no choices in response",
@@ -406,63 +533,7 @@ func (t *OpenAITool) Execute(ctx *utools.ExecutionContext, toolConfig map[string
 		}, fmt.Errorf("no choices in response, raw: %v", result)
 	}
 
-	content := ""
-	finishReason := ""
-	if len(choices) > 0 {
-		if choice, ok := choices[0].(map[string]interface{}); ok {
-			if message, ok := choice["message"].(map[string]interface{}); ok {
-				if c, ok := message["content"].(string); ok {
-					content = c
-				}
-			}
-			if fr, ok := choice["finish_reason"].(string); ok {
-				finishReason = fr
-			}
-		}
-	}
-
-	var promptTokens, completionTokens, totalTokens int
-	if usage, ok := result["usage"].(map[string]interface{}); ok {
-		if v, ok := usage["prompt_tokens"].(float64); ok {
-			promptTokens = int(v)
-		}
-		if v, ok := usage["completion_tokens"].(float64); ok {
-			completionTokens = int(v)
-		}
-		if v, ok := usage["total_tokens"].(float64); ok {
-			totalTokens = int(v)
-		}
-	}
-	modelStr, _ := result["model"].(string)
-
-	output := map[string]interface{}{
-		"response":          result,
-		"content":           content,
-		"model":             modelStr,
-		"finish_reason":     finishReason,
-		"prompt_tokens":     promptTokens,
-		"completion_tokens": completionTokens,
-		"total_tokens":      totalTokens,
-		"prompt":            prompt,
-	}
-
-	if content != "" {
-		var contentJSON map[string]interface{}
-		if err := json.Unmarshal([]byte(content), &contentJSON); err == nil {
-			output["content_json"] = contentJSON
-
-			for k, v := range contentJSON {
-				output[k] = v
-			}
-		}
-	}
-
-	return &utools.ExecutionResult{
-		Success:    true,
-		Message:    "ChatGPT 调用成功",
-		Output:     output,
-		DurationMs: time.Since(startTime).Milliseconds(),
-	}, nil
+	return result, nil, nil
 }
 
 // processImage 处理图片输入，支持 file 对象和 base64 字符串
@@ -523,6 +594,7 @@ func (t *OpenAITool) DescribeOutput(config map[string]interface{}) map[string]ut
 		"response":          {Type: "object", Label: "OpenAI 原始响应"},
 		"content":           {Type: "string", Label: "ChatGPT 回复内容（字符串）"},
 		"content_json":      {Type: "object", Label: "JSON对象（如果回复是JSON，会自动解析）"},
+		"data":              {Type: "object", Label: "结构化输出（按 response_schema 校验后的 JSON）"},
 		"model":             {Type: "string", Label: "使用的模型"},
 		"finish_reason":     {Type: "string", Label: "结束原因"},
 		"prompt_tokens":     {Type: "number", Label: "提示词 Token 数"},
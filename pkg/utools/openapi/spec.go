@@ -0,0 +1,524 @@
+package openapi
+
+import (
+	"auto-forge/pkg/utools"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 参数位置
+const (
+	InPath   = "path"
+	InQuery  = "query"
+	InHeader = "header"
+	InBody   = "body"     // 请求体对象的顶层字段
+	InRaw    = "body_raw" // 整个请求体
+)
+
+// 认证方式，凭证字段分别为 api_key / token / username + password
+const (
+	AuthAPIKey = "apiKey"
+	AuthBearer = "bearer"
+	AuthBasic  = "basic"
+)
+
+// maxSchemaDepth 展开 Schema（含 $ref）的最大深度，防止循环引用
+const maxSchemaDepth = 6
+
+var methods = []string{"get", "post", "put", "patch", "delete", "head", "options"}
+
+// Definition 由 OpenAPI 操作生成的工具定义，保存在数据库中并可编辑
+type Definition struct {
+	Code         string                           `json:"code"`
+	Name         string                           `json:"name"`
+	Description  string                           `json:"description"`
+	Category     string                           `json:"category"`
+	AICallable   bool                             `json:"ai_callable"`
+	OperationID  string                           `json:"operation_id"`
+	Method       string                           `json:"method"`
+	BaseURL      string                           `json:"base_url"`
+	Path         string                           `json:"path"`
+	ContentType  string                           `json:"content_type,omitempty"`
+	Parameters   []Parameter                      `json:"parameters"`
+	Auth         *Auth                            `json:"auth,omitempty"`
+	OutputFields map[string]utools.OutputFieldDef `json:"output_fields,omitempty"`
+}
+
+// Parameter 工具配置中的一个参数及其在请求中的位置
+type Parameter struct {
+	Name     string                `json:"name"` // 配置中的字段名
+	Key      string                `json:"key"`  // 请求中的参数名
+	In       string                `json:"in"`   // path / query / header / body / body_raw
+	Required bool                  `json:"required"`
+	Schema   utools.PropertySchema `json:"schema"`
+}
+
+// Auth 操作使用的认证方式
+type Auth struct {
+	Type string `json:"type"`           // apiKey / bearer / basic
+	In   string `json:"in,omitempty"`   // apiKey 的位置：header / query / cookie
+	Name string `json:"name,omitempty"` // apiKey 的参数名
+}
+
+// Document 解析后的 OpenAPI 文档
+type Document struct {
+	Title      string        `json:"title"`
+	Version    string        `json:"version"`
+	Servers    []string      `json:"servers"`
+	Operations []*Definition `json:"operations"`
+}
+
+// Parse 解析 OpenAPI 3 文档（JSON 或 YAML），每个操作生成一个工具定义
+func Parse(data []byte) (*Document, error) {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		if yamlErr := yaml.Unmarshal(data, &raw); yamlErr != nil {
+			return nil, fmt.Errorf("文档不是合法的 JSON 或 YAML: %w", yamlErr)
+		}
+		raw = normalize(raw)
+	}
+	root, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("文档格式错误")
+	}
+	if version, _ := root["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("仅支持 OpenAPI 3.x 文档")
+	}
+
+	p := &parser{root: root}
+	doc := &Document{}
+	if info, ok := root["info"].(map[string]interface{}); ok {
+		doc.Title, _ = info["title"].(string)
+		doc.Version, _ = info["version"].(string)
+	}
+	for _, server := range asSlice(root["servers"]) {
+		if serverURL, _ := asMap(server)["url"].(string); serverURL != "" {
+			doc.Servers = append(doc.Servers, serverURL)
+		}
+	}
+	baseURL := ""
+	if len(doc.Servers) > 0 {
+		baseURL = strings.TrimRight(doc.Servers[0], "/")
+	}
+
+	paths := asMap(root["paths"])
+	pathKeys := make([]string, 0, len(paths))
+	for path := range paths {
+		pathKeys = append(pathKeys, path)
+	}
+	sort.Strings(pathKeys)
+
+	for _, path := range pathKeys {
+		item := p.resolve(asMap(paths[path]))
+		for _, method := range methods {
+			operation, ok := item[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			def := p.operation(method, path, item, operation)
+			def.BaseURL = baseURL
+			doc.Operations = append(doc.Operations, def)
+		}
+	}
+	return doc, nil
+}
+
+type parser struct {
+	root map[string]interface{}
+}
+
+// operation 由单个操作生成工具定义
+func (p *parser) operation(method, path string, item, operation map[string]interface{}) *Definition {
+	operationID, _ := operation["operationId"].(string)
+	if operationID == "" {
+		operationID = method + "_" + path
+	}
+	summary, _ := operation["summary"].(string)
+	description, _ := operation["description"].(string)
+	if description == "" {
+		description = summary
+	}
+	name := summary
+	if name == "" {
+		name = operationID
+	}
+
+	def := &Definition{
+		Code:        CodeFromOperationID(operationID),
+		Name:        name,
+		Description: description,
+		Category:    utools.CategoryNetwork,
+		AICallable:  true,
+		OperationID: operationID,
+		Method:      strings.ToUpper(method),
+		Path:        path,
+		Parameters:  []Parameter{},
+	}
+
+	// 路径级参数可被操作级同名参数覆盖
+	params := map[string]map[string]interface{}{}
+	order := []string{}
+	for _, list := range []interface{}{item["parameters"], operation["parameters"]} {
+		for _, raw := range asSlice(list) {
+			param := p.resolve(asMap(raw))
+			name, _ := param["name"].(string)
+			in, _ := param["in"].(string)
+			if name == "" || (in != InPath && in != InQuery && in != InHeader) {
+				continue
+			}
+			key := in + ":" + name
+			if _, exists := params[key]; !exists {
+				order = append(order, key)
+			}
+			params[key] = param
+		}
+	}
+	used := map[string]bool{}
+	for _, key := range order {
+		param := params[key]
+		name, _ := param["name"].(string)
+		in, _ := param["in"].(string)
+		required, _ := param["required"].(bool)
+		schema := p.property(asMap(param["schema"]), name, 0)
+		if description, _ := param["description"].(string); description != "" {
+			schema.Description = description
+		}
+		def.Parameters = append(def.Parameters, Parameter{
+			Name: uniqueName(used, name), Key: name, In: in, Required: required || in == InPath, Schema: schema,
+		})
+	}
+
+	p.requestBody(def, used, p.resolve(asMap(operation["requestBody"])))
+	def.Auth = p.auth(operation)
+	def.OutputFields = p.outputFields(asMap(operation["responses"]))
+	return def
+}
+
+// requestBody 对象请求体的顶层字段展开为参数，其他请求体作为单个 body 参数
+func (p *parser) requestBody(def *Definition, used map[string]bool, body map[string]interface{}) {
+	content := asMap(body["content"])
+	if len(content) == 0 {
+		return
+	}
+	required, _ := body["required"].(bool)
+
+	contentType := "application/json"
+	media, ok := content[contentType]
+	if !ok {
+		contentType = "application/x-www-form-urlencoded"
+		media, ok = content[contentType]
+	}
+	if !ok {
+		return
+	}
+	def.ContentType = contentType
+
+	schema := p.schema(asMap(asMap(media)["schema"]), 0)
+	properties := asMap(schema["properties"])
+	if len(properties) == 0 {
+		def.Parameters = append(def.Parameters, Parameter{
+			Name: uniqueName(used, "body"), Key: "body", In: InRaw, Required: required, Schema: p.property(schema, "body", 0),
+		})
+		return
+	}
+
+	requiredFields := map[string]bool{}
+	for _, field := range asSlice(schema["required"]) {
+		if name, ok := field.(string); ok {
+			requiredFields[name] = true
+		}
+	}
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		def.Parameters = append(def.Parameters, Parameter{
+			Name:     uniqueName(used, key),
+			Key:      key,
+			In:       InBody,
+			Required: required && requiredFields[key],
+			Schema:   p.property(asMap(properties[key]), key, 0),
+		})
+	}
+}
+
+// auth 操作（或文档级）安全要求中的第一个可用认证方式
+func (p *parser) auth(operation map[string]interface{}) *Auth {
+	security, ok := operation["security"]
+	if !ok {
+		security = p.root["security"]
+	}
+	schemes := asMap(asMap(p.root["components"])["securitySchemes"])
+
+	for _, requirement := range asSlice(security) {
+		for name := range asMap(requirement) {
+			scheme := p.resolve(asMap(schemes[name]))
+			switch scheme["type"] {
+			case "apiKey":
+				in, _ := scheme["in"].(string)
+				key, _ := scheme["name"].(string)
+				return &Auth{Type: AuthAPIKey, In: in, Name: key}
+			case "http":
+				if s, _ := scheme["scheme"].(string); strings.EqualFold(s, "basic") {
+					return &Auth{Type: AuthBasic}
+				}
+				return &Auth{Type: AuthBearer}
+			case "oauth2", "openIdConnect":
+				return &Auth{Type: AuthBearer}
+			}
+		}
+	}
+	return nil
+}
+
+// outputFields 由第一个 2xx JSON 响应的 Schema 生成输出字段
+func (p *parser) outputFields(responses map[string]interface{}) map[string]utools.OutputFieldDef {
+	fields := map[string]utools.OutputFieldDef{
+		"status_code": {Type: "number", Label: "HTTP 状态码"},
+	}
+
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		response := p.resolve(asMap(responses[code]))
+		media, ok := asMap(response["content"])["application/json"]
+		if !ok {
+			continue
+		}
+		data := p.outputField(p.schema(asMap(asMap(media)["schema"]), 0), "响应数据", 0)
+		fields["data"] = data
+		return fields
+	}
+
+	fields["data"] = utools.OutputFieldDef{Type: "string", Label: "响应内容"}
+	return fields
+}
+
+func (p *parser) outputField(schema map[string]interface{}, label string, depth int) utools.OutputFieldDef {
+	if description, _ := schema["description"].(string); description != "" {
+		label = description
+	}
+	field := utools.OutputFieldDef{Type: schemaType(schema), Label: label}
+	properties := asMap(schema["properties"])
+	if depth >= maxSchemaDepth || len(properties) == 0 {
+		return field
+	}
+
+	field.Children = map[string]utools.OutputFieldDef{}
+	for key, value := range properties {
+		field.Children[key] = p.outputField(p.schema(asMap(value), depth+1), key, depth+1)
+	}
+	return field
+}
+
+// property 将 JSON Schema 转换为工具配置字段
+func (p *parser) property(raw map[string]interface{}, title string, depth int) utools.PropertySchema {
+	schema := p.schema(raw, depth)
+	property := utools.PropertySchema{Type: schemaType(schema), Title: title}
+	property.Description, _ = schema["description"].(string)
+	property.Format, _ = schema["format"].(string)
+	property.Pattern, _ = schema["pattern"].(string)
+	property.Default = schema["default"]
+	property.Enum = asSlice(schema["enum"])
+	if v, ok := toFloat(schema["minimum"]); ok {
+		property.Minimum = &v
+	}
+	if v, ok := toFloat(schema["maximum"]); ok {
+		property.Maximum = &v
+	}
+	if v, ok := toFloat(schema["minLength"]); ok {
+		n := int(v)
+		property.MinLength = &n
+	}
+	if v, ok := toFloat(schema["maxLength"]); ok {
+		n := int(v)
+		property.MaxLength = &n
+	}
+	if format, _ := schema["format"].(string); format == "password" {
+		property.Secret = true
+	}
+
+	if depth >= maxSchemaDepth {
+		return property
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		item := p.property(items, title, depth+1)
+		property.Items = &item
+	}
+	if properties := asMap(schema["properties"]); len(properties) > 0 {
+		property.Properties = map[string]utools.PropertySchema{}
+		for key, value := range properties {
+			property.Properties[key] = p.property(asMap(value), key, depth+1)
+		}
+	}
+	return property
+}
+
+// schema 解析 $ref 并合并 allOf；oneOf / anyOf 取第一个分支
+func (p *parser) schema(schema map[string]interface{}, depth int) map[string]interface{} {
+	schema = p.resolve(schema)
+	if depth >= maxSchemaDepth {
+		return schema
+	}
+
+	if allOf := asSlice(schema["allOf"]); len(allOf) > 0 {
+		merged := map[string]interface{}{}
+		properties := map[string]interface{}{}
+		required := []interface{}{}
+		for key, value := range schema {
+			if key != "allOf" {
+				merged[key] = value
+			}
+		}
+		for _, part := range allOf {
+			sub := p.schema(asMap(part), depth+1)
+			for key, value := range asMap(sub["properties"]) {
+				properties[key] = value
+			}
+			required = append(required, asSlice(sub["required"])...)
+			if _, ok := merged["type"]; !ok && sub["type"] != nil {
+				merged["type"] = sub["type"]
+			}
+		}
+		for key, value := range asMap(schema["properties"]) {
+			properties[key] = value
+		}
+		merged["properties"] = properties
+		merged["required"] = append(required, asSlice(schema["required"])...)
+		return merged
+	}
+
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if options := asSlice(schema[key]); len(options) > 0 {
+			return p.schema(asMap(options[0]), depth+1)
+		}
+	}
+	return schema
+}
+
+// resolve 解析文档内的 $ref（#/components/...）
+func (p *parser) resolve(node map[string]interface{}) map[string]interface{} {
+	for i := 0; i < maxSchemaDepth; i++ {
+		ref, ok := node["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return node
+		}
+		var current interface{} = p.root
+		for _, part := range strings.Split(ref[2:], "/") {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			if unescaped, err := url.PathUnescape(part); err == nil {
+				part = unescaped
+			}
+			current = asMap(current)[part]
+		}
+		node = asMap(current)
+	}
+	return node
+}
+
+// schemaType 推断 Schema 的类型
+func schemaType(schema map[string]interface{}) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, candidate := range t {
+			if s, ok := candidate.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	if _, ok := schema["items"]; ok {
+		return "array"
+	}
+	return "string"
+}
+
+var nonCodeChars = regexp.MustCompile(`[^a-z0-9]+`)
+var camelBoundary = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// CodeFromOperationID 由 operationId 生成工具代码（小写下划线，最长 50 个字符）
+func CodeFromOperationID(operationID string) string {
+	code := camelBoundary.ReplaceAllString(operationID, "${1}_${2}")
+	code = strings.Trim(nonCodeChars.ReplaceAllString(strings.ToLower(code), "_"), "_")
+	code = "api_" + code
+	if len(code) > 50 {
+		code = strings.TrimRight(code[:50], "_")
+	}
+	return code
+}
+
+// uniqueName 生成不与已有参数重名的配置字段名
+func uniqueName(used map[string]bool, name string) string {
+	candidate := name
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s_%d", name, i)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// normalize 将 YAML 中非字符串键的映射（如响应码 200）转换为字符串键
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalize(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = normalize(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalize(item)
+		}
+		return v
+	}
+	return value
+}
+
+func asMap(value interface{}) map[string]interface{} {
+	if m, ok := value.(map[string]interface{}); ok {
+		return m
+	}
+	return map[string]interface{}{}
+}
+
+func asSlice(value interface{}) []interface{} {
+	if s, ok := value.([]interface{}); ok {
+		return s
+	}
+	return nil
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
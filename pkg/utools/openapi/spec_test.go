@@ -0,0 +1,211 @@
+package openapi
+
+import (
+	"auto-forge/pkg/utools"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const petSpec = `
+openapi: 3.0.3
+info:
+  title: Pet Store
+  version: "1.0"
+servers:
+  - url: https://pets.example.com/v1/
+security:
+  - apiKeyAuth: []
+paths:
+  /pets/{petId}:
+    parameters:
+      - $ref: '#/components/parameters/PetId'
+    get:
+      operationId: getPetById
+      summary: 获取宠物
+      parameters:
+        - name: fields
+          in: query
+          schema:
+            type: array
+            items:
+              type: string
+      responses:
+        200:
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Pet'
+    put:
+      operationId: updatePet
+      security:
+        - bearerAuth: []
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              allOf:
+                - $ref: '#/components/schemas/Pet'
+                - type: object
+                  properties:
+                    petId:
+                      type: string
+                  required: [name]
+      responses:
+        "204":
+          description: ok
+components:
+  parameters:
+    PetId:
+      name: petId
+      in: path
+      required: true
+      schema:
+        type: integer
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name:
+          type: string
+          description: 名称
+        tags:
+          type: array
+          items:
+            type: string
+        owner:
+          $ref: '#/components/schemas/Pet'
+  securitySchemes:
+    apiKeyAuth:
+      type: apiKey
+      in: header
+      name: X-API-Key
+    bearerAuth:
+      type: http
+      scheme: bearer
+`
+
+func TestParse(t *testing.T) {
+	doc, err := Parse([]byte(petSpec))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if doc.Title != "Pet Store" || len(doc.Operations) != 2 {
+		t.Fatalf("unexpected document: %+v", doc)
+	}
+
+	get := doc.Operations[0]
+	if get.Code != "api_get_pet_by_id" || get.Method != "GET" || get.BaseURL != "https://pets.example.com/v1" {
+		t.Errorf("unexpected operation: %+v", get)
+	}
+	if len(get.Parameters) != 2 || get.Parameters[0].In != InPath || get.Parameters[0].Schema.Type != "integer" || get.Parameters[1].Schema.Items == nil {
+		t.Errorf("unexpected parameters: %+v", get.Parameters)
+	}
+	if get.Auth == nil || get.Auth.Type != AuthAPIKey || get.Auth.Name != "X-API-Key" {
+		t.Errorf("unexpected auth: %+v", get.Auth)
+	}
+	if data := get.OutputFields["data"]; data.Type != "object" || data.Children["name"].Label != "名称" {
+		t.Errorf("unexpected output fields: %+v", get.OutputFields)
+	}
+
+	put := doc.Operations[1]
+	if put.Auth == nil || put.Auth.Type != AuthBearer {
+		t.Errorf("operation security should override the document: %+v", put.Auth)
+	}
+	names := map[string]Parameter{}
+	for _, param := range put.Parameters {
+		names[param.Name] = param
+	}
+	if p := names["petId_2"]; p.In != InBody || p.Key != "petId" {
+		t.Errorf("body field clashing with a path parameter should be renamed: %+v", put.Parameters)
+	}
+	if !names["name"].Required || names["tags"].Required {
+		t.Errorf("unexpected required flags: %+v", put.Parameters)
+	}
+
+	tool := NewTool(put)
+	if tool.GetMetadata().Approval != utools.ApprovalConfirm {
+		t.Error("write operations should require confirmation")
+	}
+	if err := tool.Validate(map[string]interface{}{"petId": float64(1)}); err == nil {
+		t.Error("expected missing required body field")
+	}
+}
+
+func TestBuildRequest(t *testing.T) {
+	var got struct {
+		path, query, key string
+		body             map[string]interface{}
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.path, got.query, got.key = r.URL.Path, r.URL.RawQuery, r.Header.Get("X-API-Key")
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &got.body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name":"kitty"}`))
+	}))
+	defer server.Close()
+
+	def := &Definition{
+		Code: "api_test", Method: "POST", BaseURL: server.URL, Path: "/pets/{petId}", ContentType: "application/json",
+		Parameters: []Parameter{
+			{Name: "petId", Key: "petId", In: InPath},
+			{Name: "fields", Key: "fields", In: InQuery},
+			{Name: "name", Key: "name", In: InBody},
+		},
+		Auth: &Auth{Type: AuthAPIKey, In: "header", Name: "X-API-Key"},
+	}
+	tool := NewTool(def)
+	ctx := &utools.ExecutionContext{Context: context.Background()}
+	config := map[string]interface{}{"petId": "a b", "fields": []interface{}{"x", "y"}, "name": "kitty"}
+
+	req, err := tool.buildRequest(ctx, config, map[string]interface{}{"api_key": "secret"})
+	if err != nil {
+		t.Fatalf("buildRequest failed: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if got.path != "/pets/a b" || got.query != "fields=x&fields=y" || got.key != "secret" || got.body["name"] != "kitty" {
+		t.Errorf("unexpected request: %+v", got)
+	}
+
+	if _, err := tool.buildRequest(ctx, map[string]interface{}{}, nil); err == nil {
+		t.Error("expected missing path parameter error")
+	}
+}
+
+func TestExecuteAllowsAdminBaseURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://localhost:1/internal", http.StatusFound)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	utools.SetEgressPolicy(nil)
+	defer utools.SetEgressPolicy(nil)
+
+	// 管理员导入的文档地址指向内网时仍可调用
+	tool := NewTool(&Definition{Code: "api_internal", Method: "GET", BaseURL: server.URL, Path: "/status"})
+	result, err := tool.Execute(&utools.ExecutionContext{Context: context.Background()}, map[string]interface{}{})
+	if err != nil || !result.Success {
+		t.Fatalf("expected admin base URL to be reachable, got %v", err)
+	}
+
+	// 从可信主机重定向到其他内网主机时仍被拒绝
+	tool = NewTool(&Definition{Code: "api_redirect", Method: "GET", BaseURL: server.URL, Path: "/redirect"})
+	if _, err := tool.Execute(&utools.ExecutionContext{Context: context.Background()}, map[string]interface{}{}); !errors.Is(err, utools.ErrEgressDenied) {
+		t.Fatalf("expected redirect to other internal host to be denied, got %v", err)
+	}
+}
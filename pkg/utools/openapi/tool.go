@@ -0,0 +1,247 @@
+package openapi
+
+import (
+	"auto-forge/internal/services/tool_config"
+	"auto-forge/pkg/utools"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 请求超时与响应体大小上限
+const (
+	requestTimeout  = 30 * time.Second
+	maxResponseSize = 10 * 1024 * 1024
+)
+
+// Tool 由 OpenAPI 操作定义生成的 HTTP 工具
+type Tool struct {
+	*utools.BaseTool
+	def *Definition
+}
+
+// NewTool 根据定义创建工具
+func NewTool(def *Definition) *Tool {
+	metadata := &utools.ToolMetadata{
+		Code:               def.Code,
+		Name:               def.Name,
+		Description:        def.Description,
+		Category:           def.Category,
+		Version:            "1.0.0",
+		Author:             "OpenAPI",
+		AICallable:         def.AICallable,
+		Tags:               []string{"openapi", "http"},
+		OutputFieldsSchema: def.OutputFields,
+	}
+	if def.Method != http.MethodGet && def.Method != http.MethodHead && def.Method != http.MethodOptions {
+		metadata.Approval = utools.ApprovalConfirm
+	}
+
+	schema := &utools.ConfigSchema{
+		Type:       "object",
+		Properties: make(map[string]utools.PropertySchema, len(def.Parameters)),
+		Required:   []string{},
+	}
+	for _, param := range def.Parameters {
+		schema.Properties[param.Name] = param.Schema
+		if param.Required {
+			schema.Required = append(schema.Required, param.Name)
+		}
+	}
+
+	return &Tool{BaseTool: utools.NewBaseTool(metadata, schema), def: def}
+}
+
+// Definition 返回工具定义
+func (t *Tool) Definition() *Definition {
+	return t.def
+}
+
+// Execute 按定义组装并发送请求
+func (t *Tool) Execute(ctx *utools.ExecutionContext, config map[string]interface{}) (*utools.ExecutionResult, error) {
+	startTime := time.Now()
+	fail := func(message string, err error) (*utools.ExecutionResult, error) {
+		return &utools.ExecutionResult{
+			Success:    false,
+			Message:    message,
+			Error:      err.Error(),
+			DurationMs: time.Since(startTime).Milliseconds(),
+		}, fmt.Errorf("%s: %w", message, err)
+	}
+
+	var credentials map[string]interface{}
+	if t.def.Auth != nil {
+		var err error
+		if credentials, err = tool_config.GetToolConfigForContext(ctx, t.def.Code); err != nil {
+			return fail("获取接口凭证失败", err)
+		}
+	}
+
+	req, err := t.buildRequest(ctx, config, credentials)
+	if err != nil {
+		return fail("构建请求失败", err)
+	}
+
+	// 文档中的服务器地址由管理员导入，常用于调用内网接口，不受出站策略限制；
+	// 凭证连接覆盖的 base_url 指向其他主机时仍按策略检查
+	options := utools.HTTPClientOptions{Timeout: requestTimeout}
+	if base, err := url.Parse(t.def.BaseURL); err == nil && base.Hostname() != "" {
+		options.TrustedHosts = []string{base.Hostname()}
+	}
+	client := utools.NewHTTPClient(options)
+	resp, err := client.Do(req)
+	if err != nil {
+		return fail("接口请求失败", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return fail("读取响应失败", err)
+	}
+	responseBody := string(bodyBytes)
+
+	var data interface{} = responseBody
+	var parsed interface{}
+	if json.Unmarshal(bodyBytes, &parsed) == nil {
+		data = parsed
+	}
+
+	success := resp.StatusCode >= 200 && resp.StatusCode < 300
+	message := fmt.Sprintf("%s 调用完成，状态码: %d", t.def.OperationID, resp.StatusCode)
+	if !success {
+		message = fmt.Sprintf("%s 调用失败，状态码: %d", t.def.OperationID, resp.StatusCode)
+	}
+
+	return &utools.ExecutionResult{
+		Success:      success,
+		Message:      message,
+		Output:       map[string]interface{}{"status_code": resp.StatusCode, "data": data},
+		StatusCode:   resp.StatusCode,
+		ResponseBody: responseBody,
+		DurationMs:   time.Since(startTime).Milliseconds(),
+	}, nil
+}
+
+// buildRequest 将配置按参数位置填入路径、查询、请求头与请求体，并附加认证信息
+func (t *Tool) buildRequest(ctx *utools.ExecutionContext, config, credentials map[string]interface{}) (*http.Request, error) {
+	baseURL := t.def.BaseURL
+	if override, _ := credentials["base_url"].(string); override != "" {
+		baseURL = override
+	}
+	if baseURL == "" {
+		return nil, fmt.Errorf("未配置接口地址 base_url")
+	}
+
+	path := t.def.Path
+	query := url.Values{}
+	headers := http.Header{}
+	body := map[string]interface{}{}
+	var rawBody interface{}
+
+	for _, param := range t.def.Parameters {
+		value, ok := config[param.Name]
+		if !ok || value == nil {
+			if param.In == InPath {
+				return nil, fmt.Errorf("缺少路径参数: %s", param.Name)
+			}
+			continue
+		}
+		switch param.In {
+		case InPath:
+			path = strings.ReplaceAll(path, "{"+param.Key+"}", url.PathEscape(stringify(value)))
+		case InQuery:
+			if items, ok := value.([]interface{}); ok {
+				for _, item := range items {
+					query.Add(param.Key, stringify(item))
+				}
+			} else {
+				query.Set(param.Key, stringify(value))
+			}
+		case InHeader:
+			headers.Set(param.Key, stringify(value))
+		case InBody:
+			body[param.Key] = value
+		case InRaw:
+			rawBody = value
+		}
+	}
+
+	switch t.def.Auth.typeOrEmpty() {
+	case AuthAPIKey:
+		key, _ := credentials["api_key"].(string)
+		switch t.def.Auth.In {
+		case "query":
+			query.Set(t.def.Auth.Name, key)
+		case "cookie":
+			headers.Add("Cookie", t.def.Auth.Name+"="+key)
+		default:
+			headers.Set(t.def.Auth.Name, key)
+		}
+	case AuthBearer:
+		token, _ := credentials["token"].(string)
+		headers.Set("Authorization", "Bearer "+token)
+	}
+
+	var reader io.Reader
+	if rawBody != nil || len(body) > 0 {
+		if rawBody == nil {
+			rawBody = body
+		}
+		if t.def.ContentType == "application/x-www-form-urlencoded" {
+			form := url.Values{}
+			for key, value := range asMap(rawBody) {
+				form.Set(key, stringify(value))
+			}
+			reader = strings.NewReader(form.Encode())
+		} else {
+			data, err := json.Marshal(rawBody)
+			if err != nil {
+				return nil, fmt.Errorf("序列化请求体失败: %w", err)
+			}
+			reader = bytes.NewReader(data)
+		}
+		headers.Set("Content-Type", t.def.ContentType)
+	}
+
+	target := strings.TrimRight(baseURL, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx.Context, t.def.Method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = headers
+	req.Header.Set("Accept", "application/json")
+	if t.def.Auth.typeOrEmpty() == AuthBasic {
+		username, _ := credentials["username"].(string)
+		password, _ := credentials["password"].(string)
+		req.SetBasicAuth(username, password)
+	}
+	return req, nil
+}
+
+func (a *Auth) typeOrEmpty() string {
+	if a == nil {
+		return ""
+	}
+	return a.Type
+}
+
+// stringify 将配置值转换为请求参数字符串
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	return fmt.Sprint(value)
+}
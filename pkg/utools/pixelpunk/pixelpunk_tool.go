@@ -6,7 +6,6 @@ import (
 	log "auto-forge/pkg/logger"
 	"auto-forge/pkg/utools"
 	"bytes"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -61,6 +60,7 @@ func NewPixelPunkUploadTool() *PixelPunkUploadTool {
 		Version:     "1.1.0",
 		Author:      "Cooper Team",
 		AICallable:  true,
+		Approval:    utools.ApprovalConfirm,
 		Tags:        []string{"image", "upload", "cdn", "storage", "pixelpunk"},
 		OutputFieldsSchema: map[string]utools.OutputFieldDef{
 			"response": {
@@ -178,7 +178,7 @@ func (t *PixelPunkUploadTool) Execute(ctx *utools.ExecutionContext, config map[s
 	startTime := time.Now()
 
 	// 1. 从数据库加载 PixelPunk 配置
-	dbConfig, err := toolConfigService.GetToolConfigForExecution("pixelpunk_upload")
+	dbConfig, err := toolConfigService.GetToolConfigForContext(ctx, "pixelpunk_upload")
 	if err != nil {
 		return &utools.ExecutionResult{
 			Success:    false,
@@ -373,7 +373,7 @@ func (t *PixelPunkUploadTool) uploadFile(baseURL, apiKey, filePath, accessLevel
 	req.Header.Set("x-pixelpunk-key", apiKey)
 
 	// 8. 发送请求
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := utools.NewHTTPClient(utools.HTTPClientOptions{Timeout: 30 * time.Second})
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("发送请求失败: %w", err)
@@ -437,12 +437,10 @@ func (t *PixelPunkUploadTool) downloadFromURL(urlStr string) (string, error) {
 	}
 
 	// 2. 创建 HTTP 客户端（60 秒超时，跟随重定向，可选禁用 SSL 验证）
-	client := &http.Client{
-		Timeout: 60 * time.Second,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // 允许自签名证书
-		},
-	}
+	client := utools.NewHTTPClient(utools.HTTPClientOptions{
+		Timeout:            60 * time.Second,
+		InsecureSkipVerify: true, // 允许自签名证书
+	})
 
 	// 3. 发送 GET 请求
 	req, err := http.NewRequest("GET", urlStr, nil)
@@ -528,21 +526,7 @@ func (t *PixelPunkUploadTool) GetExecutionConfig() *tooling.ExecutionConfig {
 		// 超时配置：上传可能需要较长时间
 		TimeoutSeconds: 120, // 2 分钟
 
-		// 重试配置：网络问题可以重试
-		Retry: &tooling.RetryConfig{
-			MaxRetries:        2,
-			InitialBackoff:    2000,  // 2 秒
-			MaxBackoff:        10000, // 10 秒
-			BackoffMultiplier: 2.0,
-			RetryableErrors: []string{
-				"timeout",
-				"connection",
-				"network",
-				"503",
-				"504",
-				"EOF",
-			},
-		},
+		// 不自动重试：超时可能发生在上传完成之后，重试会重复上传
 
 		// 依赖配置
 		Dependencies: &tooling.DependencyConfig{
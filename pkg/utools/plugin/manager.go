@@ -0,0 +1,164 @@
+package plugin
+
+import (
+	"auto-forge/pkg/logger"
+	"auto-forge/pkg/utools"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ManifestFile 插件目录下的清单文件名
+const ManifestFile = "plugin.json"
+
+// 默认调用超时与健康检查间隔
+const (
+	DefaultTimeout        = 60 * time.Second
+	DefaultHealthInterval = 30 * time.Second
+)
+
+// Manifest 插件清单，每个插件一个子目录：plugins/<name>/plugin.json
+type Manifest struct {
+	Name    string            `json:"name"`
+	Command string            `json:"command"`           // 可执行文件，相对路径以插件目录为基准
+	Args    []string          `json:"args,omitempty"`    // 启动参数
+	Env     map[string]string `json:"env,omitempty"`     // 额外的环境变量
+	Timeout int               `json:"timeout,omitempty"` // 单次调用超时（秒），0 使用全局配置
+	Dir     string            `json:"-"`                 // 插件目录，也是进程的工作目录
+}
+
+// Options 插件加载选项
+type Options struct {
+	Timeout        time.Duration // 单次调用超时
+	HealthInterval time.Duration // 健康检查间隔，0 使用默认值
+}
+
+// Manager 管理已加载的插件进程与健康检查
+type Manager struct {
+	tools    []*Tool
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// Load 从目录发现插件，启动并注册到工具注册表；单个插件失败只记录日志，不影响其他插件
+func Load(dir string, options Options) (*Manager, error) {
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultTimeout
+	}
+	if options.HealthInterval <= 0 {
+		options.HealthInterval = DefaultHealthInterval
+	}
+
+	manifests, err := Discover(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{stop: make(chan struct{})}
+	for _, manifest := range manifests {
+		tool, err := newTool(manifest, options.Timeout)
+		if err != nil {
+			logger.Error("加载插件 %s 失败: %v", manifest.Name, err)
+			continue
+		}
+		// 插件不能以其他版本的形式接替已有工具
+		if _, err := utools.Get(tool.metadata.Code); err == nil {
+			logger.Error("注册插件 %s 失败: 工具代码已被占用: %s", manifest.Name, tool.metadata.Code)
+			tool.Close()
+			continue
+		}
+		if err := utools.Register(tool); err != nil {
+			logger.Error("注册插件 %s 失败: %v", manifest.Name, err)
+			tool.Close()
+			continue
+		}
+
+		m.tools = append(m.tools, tool)
+		logger.Info("插件已加载: %s -> [%s] %s", manifest.Name, tool.metadata.Code, tool.metadata.Name)
+	}
+
+	go m.healthLoop(options.HealthInterval)
+	return m, nil
+}
+
+// Discover 读取目录下各子目录的插件清单
+func Discover(dir string) ([]*Manifest, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取插件目录失败: %w", err)
+	}
+
+	manifests := make([]*Manifest, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		pluginDir, err := filepath.Abs(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(pluginDir, ManifestFile))
+		if err != nil {
+			continue
+		}
+
+		var manifest Manifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			logger.Error("解析插件清单 %s 失败: %v", pluginDir, err)
+			continue
+		}
+		if manifest.Command == "" {
+			logger.Error("插件清单 %s 未指定 command", pluginDir)
+			continue
+		}
+		if manifest.Name == "" {
+			manifest.Name = entry.Name()
+		}
+		if !filepath.IsAbs(manifest.Command) && filepath.Base(manifest.Command) != manifest.Command {
+			manifest.Command = filepath.Join(pluginDir, manifest.Command)
+		}
+		manifest.Dir = pluginDir
+		manifests = append(manifests, &manifest)
+	}
+	return manifests, nil
+}
+
+// Tools 已加载的插件工具
+func (m *Manager) Tools() []*Tool {
+	return m.tools
+}
+
+// healthLoop 定期检查插件，无响应或已退出的插件会被重启
+func (m *Manager) healthLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			for _, tool := range m.tools {
+				if err := tool.CheckHealth(); err != nil {
+					logger.Warn("插件 %s 健康检查失败: %v", tool.manifest.Name, err)
+				}
+			}
+		}
+	}
+}
+
+// Close 停止健康检查并结束所有插件进程
+func (m *Manager) Close() {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+		for _, tool := range m.tools {
+			tool.Close()
+		}
+	})
+}
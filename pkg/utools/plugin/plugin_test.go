@@ -0,0 +1,114 @@
+package plugin
+
+import (
+	"auto-forge/pkg/utools"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const helperEnv = "AUTO_FORGE_TEST_PLUGIN"
+
+// echoTool 测试插件：原样返回 message，sleep 大于 0 时先等待
+type echoTool struct {
+	*utools.BaseTool
+}
+
+func (t *echoTool) Execute(ctx *utools.ExecutionContext, config map[string]interface{}) (*utools.ExecutionResult, error) {
+	if sleep, _ := config["sleep"].(float64); sleep > 0 {
+		time.Sleep(time.Duration(sleep) * time.Millisecond)
+	}
+	return &utools.ExecutionResult{
+		Success: true,
+		Output:  map[string]interface{}{"message": config["message"], "user_id": ctx.UserID},
+	}, nil
+}
+
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnv) == "1" {
+		tool := &echoTool{utools.NewBaseTool(
+			&utools.ToolMetadata{Code: "test_plugin_echo", Name: "Echo", Version: "1.0.0", AICallable: true},
+			&utools.ConfigSchema{
+				Type:       "object",
+				Properties: map[string]utools.PropertySchema{"message": {Type: "string"}, "sleep": {Type: "number"}},
+				Required:   []string{"message"},
+			},
+		)}
+		Serve(tool)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// writeManifest 把当前测试二进制作为插件写入临时插件目录
+func writeManifest(t *testing.T, timeout int) string {
+	dir := t.TempDir()
+	pluginDir := filepath.Join(dir, "echo")
+	if err := os.Mkdir(pluginDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	manifest, _ := json.Marshal(Manifest{
+		Command: os.Args[0],
+		Env:     map[string]string{helperEnv: "1"},
+		Timeout: timeout,
+	})
+	if err := os.WriteFile(filepath.Join(pluginDir, ManifestFile), manifest, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLoadAndExecute(t *testing.T) {
+	manager, err := Load(writeManifest(t, 0), Options{HealthInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	defer manager.Close()
+	defer utools.GetRegistry().Unregister("test_plugin_echo")
+
+	tool, err := utools.Get("test_plugin_echo")
+	if err != nil {
+		t.Fatalf("plugin not registered: %v", err)
+	}
+	if schema := tool.GetSchema(); schema.Properties["message"].Type != "string" {
+		t.Errorf("unexpected schema: %+v", schema)
+	}
+
+	if err := tool.Validate(map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), "message") {
+		t.Errorf("expected required field error, got %v", err)
+	}
+
+	result, err := tool.Execute(&utools.ExecutionContext{Context: context.Background(), UserID: "u1"}, map[string]interface{}{"message": "hi"})
+	if err != nil || !result.Success || result.Output["message"] != "hi" || result.Output["user_id"] != "u1" {
+		t.Fatalf("unexpected result: %+v, %v", result, err)
+	}
+}
+
+func TestTimeoutAndRestart(t *testing.T) {
+	manager, err := Load(writeManifest(t, 1), Options{HealthInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	defer manager.Close()
+	defer utools.GetRegistry().Unregister("test_plugin_echo")
+
+	tool := manager.Tools()[0]
+	ctx := &utools.ExecutionContext{Context: context.Background()}
+	if _, err := tool.Execute(ctx, map[string]interface{}{"message": "slow", "sleep": float64(3000)}); err == nil {
+		t.Fatal("expected timeout")
+	}
+
+	tool.proc.cmd.Process.Kill()
+	<-tool.proc.exited
+	if err := tool.CheckHealth(); err != nil {
+		t.Fatalf("health check should restart the plugin: %v", err)
+	}
+	result, err := tool.Execute(ctx, map[string]interface{}{"message": "again"})
+	if err != nil || result.Output["message"] != "again" {
+		t.Fatalf("unexpected result after restart: %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,164 @@
+package plugin
+
+import (
+	"auto-forge/pkg/logger"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+)
+
+// process 一个运行中的插件进程，负责请求与响应的配对
+type process struct {
+	name  string
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	nextID  int64
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[int64]chan *rpcResponse
+	exited  chan struct{}
+}
+
+// startProcess 按清单启动插件进程
+func startProcess(manifest *Manifest) (*process, error) {
+	cmd := exec.Command(manifest.Command, manifest.Args...)
+	cmd.Dir = manifest.Dir
+	cmd.Env = os.Environ()
+	for key, value := range manifest.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("启动插件 %s 失败: %w", manifest.Name, err)
+	}
+
+	p := &process{
+		name:    manifest.Name,
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[int64]chan *rpcResponse),
+		exited:  make(chan struct{}),
+	}
+	go p.logStderr(stderr)
+	go p.readLoop(stdout)
+	return p, nil
+}
+
+// readLoop 读取插件响应并分发给等待中的调用；进程退出后结束所有等待
+func (p *process) readLoop(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		var resp rpcResponse
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			logger.Warn("插件 %s 输出了无法解析的消息: %v", p.name, err)
+			continue
+		}
+
+		p.mu.Lock()
+		ch, ok := p.pending[resp.ID]
+		delete(p.pending, resp.ID)
+		p.mu.Unlock()
+		if ok {
+			ch <- &resp
+		}
+	}
+
+	p.cmd.Wait()
+	close(p.exited)
+}
+
+// logStderr 将插件的标准错误输出写入日志
+func (p *process) logStderr(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		logger.Info("[plugin:%s] %s", p.name, scanner.Text())
+	}
+}
+
+// call 发送请求并等待响应，result 为 nil 时忽略返回值
+func (p *process) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	req := rpcRequest{JSONRPC: "2.0", ID: atomic.AddInt64(&p.nextID, 1), Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("序列化参数失败: %w", err)
+		}
+		req.Params = data
+	}
+	line, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	ch := make(chan *rpcResponse, 1)
+	p.mu.Lock()
+	p.pending[req.ID] = ch
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.pending, req.ID)
+		p.mu.Unlock()
+	}()
+
+	p.writeMu.Lock()
+	_, err = p.stdin.Write(append(line, '\n'))
+	p.writeMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("插件 %s 不可用: %w", p.name, err)
+	}
+
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result != nil && len(resp.Result) > 0 {
+			return json.Unmarshal(resp.Result, result)
+		}
+		return nil
+	case <-p.exited:
+		return fmt.Errorf("插件 %s 进程已退出", p.name)
+	case <-ctx.Done():
+		return fmt.Errorf("插件 %s 调用 %s 超时: %w", p.name, method, ctx.Err())
+	}
+}
+
+// alive 进程是否仍在运行
+func (p *process) alive() bool {
+	select {
+	case <-p.exited:
+		return false
+	default:
+		return true
+	}
+}
+
+// stop 关闭输入并结束进程
+func (p *process) stop() {
+	p.stdin.Close()
+	if p.cmd.Process != nil {
+		p.cmd.Process.Kill()
+	}
+	<-p.exited
+}
@@ -0,0 +1,175 @@
+package plugin
+
+import (
+	"auto-forge/pkg/utools"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// ProtocolVersion 插件协议版本。插件在握手时返回的版本必须一致
+//
+// 协议为基于标准输入/输出的 JSON-RPC 2.0，每行一条消息：
+//
+//	handshake     {"protocol_version":"1"}                      -> {"protocol_version":"1"}
+//	get_metadata  null                                          -> utools.ToolMetadata
+//	get_schema    null                                          -> utools.ConfigSchema
+//	validate      {"config":{...}}                              -> null，校验失败时返回 error
+//	execute       {"context":{...},"config":{...}}              -> utools.ExecutionResult
+//	health        null                                          -> {"status":"ok"}
+//
+// 插件的标准错误输出会被记录到服务日志
+const ProtocolVersion = "1"
+
+// 协议方法名
+const (
+	MethodHandshake   = "handshake"
+	MethodGetMetadata = "get_metadata"
+	MethodGetSchema   = "get_schema"
+	MethodValidate    = "validate"
+	MethodExecute     = "execute"
+	MethodHealth      = "health"
+)
+
+// JSON-RPC 错误码
+const (
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeToolError      = -32000
+)
+
+// maxMessageSize 单条消息的最大长度
+const maxMessageSize = 16 * 1024 * 1024
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError 插件返回的错误
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("插件错误 %d: %s", e.Code, e.Message)
+}
+
+// HandshakeParams 握手参数与结果
+type HandshakeParams struct {
+	ProtocolVersion string `json:"protocol_version"`
+}
+
+// ValidateParams validate 方法参数
+type ValidateParams struct {
+	Config map[string]interface{} `json:"config"`
+}
+
+// ExecuteParams execute 方法参数
+type ExecuteParams struct {
+	Context ExecuteContext         `json:"context"`
+	Config  map[string]interface{} `json:"config"`
+}
+
+// ExecuteContext 传给插件的执行上下文（不含 Go 的 context 与凭证）
+type ExecuteContext struct {
+	TaskID    string                 `json:"task_id"`
+	UserID    string                 `json:"user_id"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Serve 以插件身份在标准输入/输出上提供工具，供 Go 编写的插件使用；其他语言按协议自行实现即可
+func Serve(tool utools.Tool) error {
+	return serve(tool, os.Stdin, os.Stdout)
+}
+
+func serve(tool utools.Tool, in io.Reader, out io.Writer) error {
+	var writeMu sync.Mutex
+	var wg sync.WaitGroup
+	encoder := json.NewEncoder(out)
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		var req rpcRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := rpcResponse{JSONRPC: "2.0", ID: req.ID}
+			result, err := handle(tool, req)
+			if err != nil {
+				resp.Error = err
+			} else {
+				resp.Result, _ = json.Marshal(result)
+			}
+
+			writeMu.Lock()
+			defer writeMu.Unlock()
+			encoder.Encode(resp)
+		}()
+	}
+	wg.Wait()
+	return scanner.Err()
+}
+
+// handle 分发插件端收到的请求
+func handle(tool utools.Tool, req rpcRequest) (interface{}, *RPCError) {
+	switch req.Method {
+	case MethodHandshake:
+		return HandshakeParams{ProtocolVersion: ProtocolVersion}, nil
+	case MethodGetMetadata:
+		return tool.GetMetadata(), nil
+	case MethodGetSchema:
+		return tool.GetSchema(), nil
+	case MethodHealth:
+		return map[string]string{"status": "ok"}, nil
+	case MethodValidate:
+		var params ValidateParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &RPCError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		if err := tool.Validate(params.Config); err != nil {
+			return nil, &RPCError{Code: codeToolError, Message: err.Error()}
+		}
+		return nil, nil
+	case MethodExecute:
+		var params ExecuteParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &RPCError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		result, err := tool.Execute(&utools.ExecutionContext{
+			Context:   context.Background(),
+			TaskID:    params.Context.TaskID,
+			UserID:    params.Context.UserID,
+			Variables: params.Context.Variables,
+			Metadata:  params.Context.Metadata,
+		}, params.Config)
+		if err != nil && result == nil {
+			return nil, &RPCError{Code: codeToolError, Message: err.Error()}
+		}
+		if err != nil && result.Error == "" {
+			result.Error = err.Error()
+		}
+		return result, nil
+	}
+	return nil, &RPCError{Code: codeMethodNotFound, Message: "unknown method: " + req.Method}
+}
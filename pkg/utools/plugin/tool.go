@@ -0,0 +1,187 @@
+package plugin
+
+import (
+	"auto-forge/pkg/utools"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// handshakeTimeout 启动握手与读取元数据的超时时间
+const handshakeTimeout = 10 * time.Second
+
+// Tool 由外部插件进程实现的工具，元数据与 Schema 在加载时读取，执行与校验每次通过 RPC 调用
+type Tool struct {
+	manifest *Manifest
+	metadata *utools.ToolMetadata
+	schema   *utools.ConfigSchema
+	timeout  time.Duration
+
+	mu   sync.Mutex
+	proc *process
+}
+
+// newTool 启动插件并读取元数据与 Schema
+func newTool(manifest *Manifest, timeout time.Duration) (*Tool, error) {
+	if manifest.Timeout > 0 {
+		timeout = time.Duration(manifest.Timeout) * time.Second
+	}
+	t := &Tool{manifest: manifest, timeout: timeout}
+
+	proc, err := t.ensureProcess()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), handshakeTimeout)
+	defer cancel()
+	var metadata utools.ToolMetadata
+	if err := proc.call(ctx, MethodGetMetadata, nil, &metadata); err != nil {
+		t.Close()
+		return nil, fmt.Errorf("读取插件 %s 元数据失败: %w", manifest.Name, err)
+	}
+	if metadata.Code == "" {
+		t.Close()
+		return nil, fmt.Errorf("插件 %s 未提供工具代码", manifest.Name)
+	}
+	var schema utools.ConfigSchema
+	if err := proc.call(ctx, MethodGetSchema, nil, &schema); err != nil {
+		t.Close()
+		return nil, fmt.Errorf("读取插件 %s Schema 失败: %w", manifest.Name, err)
+	}
+	if schema.Properties == nil {
+		schema.Properties = map[string]utools.PropertySchema{}
+	}
+
+	t.metadata = &metadata
+	t.schema = &schema
+	return t, nil
+}
+
+// ensureProcess 返回运行中的插件进程，进程不存在或已退出时重新启动并握手
+func (t *Tool) ensureProcess() (*process, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.proc != nil && t.proc.alive() {
+		return t.proc, nil
+	}
+
+	proc, err := startProcess(t.manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), handshakeTimeout)
+	defer cancel()
+	var handshake HandshakeParams
+	if err := proc.call(ctx, MethodHandshake, HandshakeParams{ProtocolVersion: ProtocolVersion}, &handshake); err != nil {
+		proc.stop()
+		return nil, fmt.Errorf("插件 %s 握手失败: %w", t.manifest.Name, err)
+	}
+	if handshake.ProtocolVersion != ProtocolVersion {
+		proc.stop()
+		return nil, fmt.Errorf("插件 %s 协议版本 %s 不受支持，需要 %s", t.manifest.Name, handshake.ProtocolVersion, ProtocolVersion)
+	}
+
+	t.proc = proc
+	return proc, nil
+}
+
+// call 在超时时间内调用插件方法
+func (t *Tool) call(parent context.Context, method string, params, result interface{}) error {
+	proc, err := t.ensureProcess()
+	if err != nil {
+		return err
+	}
+
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, t.timeout)
+	defer cancel()
+	return proc.call(ctx, method, params, result)
+}
+
+// GetMetadata 返回插件工具的元数据
+func (t *Tool) GetMetadata() *utools.ToolMetadata {
+	return t.metadata
+}
+
+// GetSchema 返回插件工具的配置 Schema
+func (t *Tool) GetSchema() *utools.ConfigSchema {
+	return t.schema
+}
+
+// Validate 由插件校验配置
+func (t *Tool) Validate(config map[string]interface{}) error {
+	return t.call(context.Background(), MethodValidate, ValidateParams{Config: config}, nil)
+}
+
+// Execute 由插件执行工具
+func (t *Tool) Execute(ctx *utools.ExecutionContext, config map[string]interface{}) (*utools.ExecutionResult, error) {
+	startTime := time.Now()
+
+	params := ExecuteParams{
+		Context: ExecuteContext{
+			TaskID:    ctx.TaskID,
+			UserID:    ctx.UserID,
+			Variables: ctx.Variables,
+			Metadata:  ctx.Metadata,
+		},
+		Config: config,
+	}
+
+	var result utools.ExecutionResult
+	if err := t.call(ctx.Context, MethodExecute, params, &result); err != nil {
+		return &utools.ExecutionResult{
+			Success:    false,
+			Message:    "插件执行失败",
+			Error:      err.Error(),
+			DurationMs: time.Since(startTime).Milliseconds(),
+		}, err
+	}
+
+	if result.DurationMs == 0 {
+		result.DurationMs = time.Since(startTime).Milliseconds()
+	}
+	if !result.Success {
+		message := result.Error
+		if message == "" {
+			message = result.Message
+		}
+		return &result, fmt.Errorf("插件执行失败: %s", message)
+	}
+	return &result, nil
+}
+
+// CheckHealth 检查插件是否响应，失败时重启进程
+func (t *Tool) CheckHealth() error {
+	ctx, cancel := context.WithTimeout(context.Background(), handshakeTimeout)
+	defer cancel()
+
+	proc, err := t.ensureProcess()
+	if err == nil {
+		if err = proc.call(ctx, MethodHealth, nil, nil); err == nil {
+			return nil
+		}
+	}
+
+	t.Close()
+	if _, restartErr := t.ensureProcess(); restartErr != nil {
+		return fmt.Errorf("%v；重启失败: %v", err, restartErr)
+	}
+	return err
+}
+
+// Close 结束插件进程
+func (t *Tool) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.proc != nil {
+		t.proc.stop()
+		t.proc = nil
+	}
+}
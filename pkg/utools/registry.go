@@ -2,12 +2,16 @@ package utools
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 )
 
+// Registry 工具注册表。同一工具代码可以注册多个版本并存，未指定版本时使用最新版本
 type Registry struct {
-	tools map[string]Tool
-	mu    sync.RWMutex
+	tools      map[string]Tool            // 工具代码 -> 最新版本
+	versions   map[string]map[string]Tool // 工具代码 -> 版本 -> 工具
+	migrations map[string][]migration     // 工具代码 -> 配置迁移
+	mu         sync.RWMutex
 }
 
 var (
@@ -17,13 +21,19 @@ var (
 
 func GetRegistry() *Registry {
 	once.Do(func() {
-		globalRegistry = &Registry{
-			tools: make(map[string]Tool),
-		}
+		globalRegistry = newRegistry()
 	})
 	return globalRegistry
 }
 
+func newRegistry() *Registry {
+	return &Registry{
+		tools:      make(map[string]Tool),
+		versions:   make(map[string]map[string]Tool),
+		migrations: make(map[string][]migration),
+	}
+}
+
 func (r *Registry) Register(tool Tool) error {
 	if tool == nil {
 		return fmt.Errorf("tool cannot be nil")
@@ -41,14 +51,53 @@ func (r *Registry) Register(tool Tool) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.tools[metadata.Code]; exists {
-		return fmt.Errorf("tool '%s' already registered", metadata.Code)
+	versions, exists := r.versions[metadata.Code]
+	if !exists {
+		versions = make(map[string]Tool)
+		r.versions[metadata.Code] = versions
+	}
+	if _, exists := versions[metadata.Version]; exists {
+		return fmt.Errorf("tool '%s' version '%s' already registered", metadata.Code, metadata.Version)
 	}
 
-	r.tools[metadata.Code] = tool
+	versions[metadata.Version] = tool
+	if latest, ok := r.tools[metadata.Code]; !ok || CompareVersions(metadata.Version, latest.GetMetadata().Version) > 0 {
+		r.tools[metadata.Code] = tool
+	}
 	return nil
 }
 
+// GetVersion 获取指定版本的工具，version 为空时返回最新版本
+func (r *Registry) GetVersion(code, version string) (Tool, error) {
+	if version == "" {
+		return r.Get(code)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tool, exists := r.versions[code][version]
+	if !exists {
+		return nil, fmt.Errorf("tool '%s' version '%s' not found", code, version)
+	}
+	return tool, nil
+}
+
+// Versions 返回工具已注册的全部版本（从旧到新）
+func (r *Registry) Versions(code string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]string, 0, len(r.versions[code]))
+	for version := range r.versions[code] {
+		result = append(result, version)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return CompareVersions(result[i], result[j]) < 0
+	})
+	return result
+}
+
 func (r *Registry) Get(code string) (Tool, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -126,6 +175,34 @@ func (r *Registry) Unregister(code string) error {
 	}
 
 	delete(r.tools, code)
+	delete(r.versions, code)
+	return nil
+}
+
+// UnregisterVersion 注销工具的单个版本，注销最新版本后由剩余的最高版本接替
+func (r *Registry) UnregisterVersion(code, version string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions := r.versions[code]
+	if _, exists := versions[version]; !exists {
+		return fmt.Errorf("tool '%s' version '%s' not registered", code, version)
+	}
+
+	delete(versions, version)
+	if len(versions) == 0 {
+		delete(r.versions, code)
+		delete(r.tools, code)
+		return nil
+	}
+
+	var latest Tool
+	for v, tool := range versions {
+		if latest == nil || CompareVersions(v, latest.GetMetadata().Version) > 0 {
+			latest = tool
+		}
+	}
+	r.tools[code] = latest
 	return nil
 }
 
@@ -143,6 +220,11 @@ func Get(code string) (Tool, error) {
 	return GetRegistry().Get(code)
 }
 
+// GetVersion 从全局注册表获取指定版本的工具
+func GetVersion(code, version string) (Tool, error) {
+	return GetRegistry().GetVersion(code, version)
+}
+
 func List() []*ToolMetadata {
 	return GetRegistry().List()
 }
@@ -1,10 +1,9 @@
 package rssfeed
 
 import (
+	"auto-forge/pkg/agent/tooling"
 	"auto-forge/pkg/utools"
 	"fmt"
-	"net"
-	"net/http"
 	"strings"
 	"time"
 
@@ -172,21 +171,9 @@ func (t *RSSFeedTool) Execute(ctx *utools.ExecutionContext, config map[string]in
 	}
 
 	// 2. 循环采集所有订阅源
-	// 配置 HTTP 客户端（增加超时时间，禁用 IPv6）
+	// 订阅地址由用户填写，使用遵循出站策略的客户端
 	fp := gofeed.NewParser()
-	fp.Client = &http.Client{
-		Timeout: 30 * time.Second, // 30秒超时
-		Transport: &http.Transport{
-			// 强制使用 IPv4，避免 IPv6 超时问题
-			DialContext: (&net.Dialer{
-				Timeout:   15 * time.Second,
-				KeepAlive: 30 * time.Second,
-			}).DialContext,
-			MaxIdleConns:        100,
-			IdleConnTimeout:     90 * time.Second,
-			TLSHandshakeTimeout: 10 * time.Second,
-		},
-	}
+	fp.Client = utools.NewHTTPClient(utools.HTTPClientOptions{Timeout: 30 * time.Second})
 
 	cutoffTime := time.Time{}
 	if hoursAgo > 0 {
@@ -404,6 +391,13 @@ func float64Ptr(v float64) *float64 {
 	return &v
 }
 
+// GetExecutionConfig 只读查询，网络错误可安全重试（实现 ConfigurableTool 接口）
+func (t *RSSFeedTool) GetExecutionConfig() *tooling.ExecutionConfig {
+	return &tooling.ExecutionConfig{
+		Retry: tooling.DefaultRetryConfig(),
+	}
+}
+
 func init() {
 	utools.Register(NewRSSFeedTool())
 }
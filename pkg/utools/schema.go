@@ -0,0 +1,283 @@
+package utools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidationErrors 配置校验的全部错误，Field 为出错值的 JSON 路径（如 headers.token、items[0].name）
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Field+": "+err.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// CoerceConfig 按 Schema 校验配置，并将模板替换得到的字符串转换为声明的类型（数字、布尔、数组、对象）。
+// 返回转换后的新配置；存在错误时返回 ValidationErrors，包含所有错误
+func CoerceConfig(schema *ConfigSchema, config map[string]interface{}) (map[string]interface{}, error) {
+	if schema == nil {
+		return config, nil
+	}
+
+	var errs ValidationErrors
+	result := coerceObject("", config, schema.Properties, schema.Required, &errs)
+	if len(errs) > 0 {
+		return result, errs
+	}
+	return result, nil
+}
+
+// coerceObject 校验并转换对象的各个属性，未在 Schema 中声明的属性原样保留
+func coerceObject(path string, object map[string]interface{}, properties map[string]PropertySchema, required []string, errs *ValidationErrors) map[string]interface{} {
+	result := make(map[string]interface{}, len(object))
+	for key, value := range object {
+		propSchema, ok := properties[key]
+		if !ok {
+			result[key] = value
+			continue
+		}
+		// 非字符串字段的空字符串视为未填写（如引用了不存在的变量），交由工具使用默认值
+		if str, isString := value.(string); isString && str == "" && propSchema.Type != "" && propSchema.Type != "string" {
+			continue
+		}
+		if value == nil {
+			continue
+		}
+		result[key] = coerceValue(joinPath(path, key), value, propSchema, errs)
+	}
+
+	for _, field := range required {
+		if _, ok := result[field]; !ok {
+			*errs = append(*errs, &ValidationError{Field: joinPath(path, field), Message: "required field missing"})
+		}
+	}
+	return result
+}
+
+// coerceValue 校验并转换单个值，转换失败时返回原值并记录错误
+func coerceValue(path string, value interface{}, schema PropertySchema, errs *ValidationErrors) interface{} {
+	fail := func(message string) interface{} {
+		*errs = append(*errs, &ValidationError{Field: path, Message: message})
+		return value
+	}
+
+	switch schema.Type {
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			switch v := value.(type) {
+			case float64, float32, int, int64, int32, bool, json.Number:
+				str = fmt.Sprint(v)
+			case map[string]interface{}, []interface{}:
+				data, _ := json.Marshal(v)
+				str = string(data)
+			default:
+				return fail("must be a string")
+			}
+		}
+		if schema.MinLength != nil && utf8.RuneCountInString(str) < *schema.MinLength {
+			return fail("string too short")
+		}
+		if schema.MaxLength != nil && utf8.RuneCountInString(str) > *schema.MaxLength {
+			return fail("string too long")
+		}
+		// 空字符串与未解析的模板表达式在运行时才能确定值，不检查格式
+		if str != "" && !strings.Contains(str, "{{") {
+			if schema.Pattern != "" {
+				re, err := compilePattern(schema.Pattern)
+				if err != nil {
+					return fail("invalid pattern in schema: " + schema.Pattern)
+				}
+				if !re.MatchString(str) {
+					return fail("does not match pattern " + schema.Pattern)
+				}
+			}
+			if message := checkFormat(str, schema.Format); message != "" {
+				return fail(message)
+			}
+		}
+		value = str
+
+	case "number", "integer":
+		num, ok := toNumber(value)
+		if !ok {
+			return fail("must be a number")
+		}
+		if schema.Type == "integer" && num != math.Trunc(num) {
+			return fail("must be an integer")
+		}
+		if schema.Minimum != nil && num < *schema.Minimum {
+			return fail("number too small")
+		}
+		if schema.Maximum != nil && num > *schema.Maximum {
+			return fail("number too large")
+		}
+		value = num
+
+	case "boolean":
+		switch v := value.(type) {
+		case bool:
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return fail("must be a boolean")
+			}
+			value = b
+		default:
+			return fail("must be a boolean")
+		}
+
+	case "array":
+		items, ok := decodeJSONString(value).([]interface{})
+		if !ok {
+			return fail("must be an array")
+		}
+		if schema.Items != nil {
+			coerced := make([]interface{}, len(items))
+			for i, item := range items {
+				coerced[i] = coerceValue(fmt.Sprintf("%s[%d]", path, i), item, *schema.Items, errs)
+			}
+			items = coerced
+		}
+		value = items
+
+	case "object":
+		object, ok := decodeJSONString(value).(map[string]interface{})
+		if !ok {
+			return fail("must be an object")
+		}
+		if len(schema.Properties) > 0 || len(schema.Required) > 0 {
+			object = coerceObject(path, object, schema.Properties, schema.Required, errs)
+		}
+		value = object
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(value, schema.Enum) {
+		return fail("value not in allowed enum")
+	}
+	return value
+}
+
+// toNumber 将 JSON 数字、Go 数值类型或数字字符串统一为 float64
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+	}
+	return 0, false
+}
+
+// decodeJSONString 将 JSON 数组或对象字符串解析为对应的值，其他值原样返回
+func decodeJSONString(value interface{}) interface{} {
+	str, ok := value.(string)
+	if !ok {
+		return value
+	}
+	trimmed := strings.TrimSpace(str)
+	if !strings.HasPrefix(trimmed, "[") && !strings.HasPrefix(trimmed, "{") {
+		return value
+	}
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(trimmed), &decoded); err != nil {
+		return value
+	}
+	return decoded
+}
+
+// inEnum 比较时统一数值类型，使 Schema 中的 int 枚举能匹配 JSON 解码得到的 float64
+func inEnum(value interface{}, enum []interface{}) bool {
+	normalized := normalizeEnumValue(value)
+	for _, enumVal := range enum {
+		if normalizeEnumValue(enumVal) == normalized {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeEnumValue(value interface{}) interface{} {
+	switch value.(type) {
+	case string, bool, nil:
+		return value
+	}
+	if num, ok := toNumber(value); ok {
+		return num
+	}
+	// 数组、对象等不可比较的值按 JSON 比较
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// checkFormat 检查字符串格式，返回错误信息；未知格式不检查
+func checkFormat(value, format string) string {
+	switch format {
+	case "uri", "url":
+		parsed, err := url.Parse(value)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return "must be a valid URI"
+		}
+	case "email":
+		addr, err := mail.ParseAddress(value)
+		if err != nil || addr.Address != value {
+			return "must be a valid email address"
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return "must be a date (YYYY-MM-DD)"
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return "must be an RFC 3339 date-time"
+		}
+	}
+	return ""
+}
+
+var patternCache sync.Map
+
+// compilePattern 编译并缓存 Schema 中的正则
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patternCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(pattern, re)
+	return re, nil
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,128 @@
+package utools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func intPtr(v int) *int { return &v }
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestCoerceConfig(t *testing.T) {
+	schema := &ConfigSchema{
+		Type: "object",
+		Properties: map[string]PropertySchema{
+			"count":   {Type: "integer", Minimum: floatPtr(1)},
+			"ratio":   {Type: "number"},
+			"enabled": {Type: "boolean"},
+			"level":   {Type: "number", Enum: []interface{}{1, 2, 3}},
+			"body":    {Type: "string"},
+			"tags":    {Type: "array", Items: &PropertySchema{Type: "string"}},
+			"headers": {Type: "object"},
+			"timeout": {Type: "number"},
+		},
+		Required: []string{"count"},
+	}
+
+	config := map[string]interface{}{
+		"count":   "3",
+		"ratio":   " 0.5 ",
+		"enabled": "true",
+		"level":   "2",
+		"body":    map[string]interface{}{"a": 1.0},
+		"tags":    `["x", 1]`,
+		"headers": `{"X-Token": "t"}`,
+		"timeout": "",
+		"extra":   "kept",
+	}
+
+	result, err := CoerceConfig(schema, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]interface{}{
+		"count":   3.0,
+		"ratio":   0.5,
+		"enabled": true,
+		"level":   2.0,
+		"body":    `{"a":1}`,
+		"tags":    []interface{}{"x", "1"},
+		"headers": map[string]interface{}{"X-Token": "t"},
+		"extra":   "kept",
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("unexpected result:\n got  %#v\n want %#v", result, expected)
+	}
+	if config["count"] != "3" {
+		t.Fatal("original config should not be modified")
+	}
+}
+
+func TestCoerceConfigCollectsNestedErrors(t *testing.T) {
+	schema := &ConfigSchema{
+		Type: "object",
+		Properties: map[string]PropertySchema{
+			"url":   {Type: "string", Format: "uri"},
+			"email": {Type: "string", Format: "email"},
+			"day":   {Type: "string", Format: "date"},
+			"code":  {Type: "string", Pattern: `^[A-Z]{3}$`, MaxLength: intPtr(3)},
+			"count": {Type: "integer"},
+			"items": {
+				Type: "array",
+				Items: &PropertySchema{
+					Type:     "object",
+					Required: []string{"name"},
+					Properties: map[string]PropertySchema{
+						"name":  {Type: "string"},
+						"score": {Type: "number", Maximum: floatPtr(10)},
+					},
+				},
+			},
+			"template": {Type: "string", Format: "uri"},
+		},
+		Required: []string{"missing"},
+	}
+
+	_, err := CoerceConfig(schema, map[string]interface{}{
+		"url":      "not a url",
+		"email":    "someone",
+		"day":      "2024-13-01",
+		"code":     "abc",
+		"count":    "1.5",
+		"items":    []interface{}{map[string]interface{}{"name": "a", "score": "11"}, map[string]interface{}{}},
+		"template": "{{nodes.fetch.url}}",
+	})
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("expected ValidationErrors, got %T %v", err, err)
+	}
+
+	fields := make(map[string]bool)
+	for _, e := range errs {
+		fields[e.Field] = true
+	}
+	for _, field := range []string{"url", "email", "day", "code", "count", "items[0].score", "items[1].name", "missing"} {
+		if !fields[field] {
+			t.Errorf("expected error for %s, got %v", field, errs)
+		}
+	}
+	if len(errs) != 8 {
+		t.Errorf("expected 8 errors, got %d: %v", len(errs), errs)
+	}
+}
+
+func TestBaseToolValidate(t *testing.T) {
+	tool := NewBaseTool(&ToolMetadata{Code: "test"}, &ConfigSchema{
+		Type:       "object",
+		Properties: map[string]PropertySchema{"mode": {Type: "string", Enum: []interface{}{"a", "b"}}},
+		Required:   []string{"mode"},
+	})
+
+	if err := tool.Validate(map[string]interface{}{"mode": "a"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := tool.Validate(map[string]interface{}{"mode": "c"}); err == nil {
+		t.Fatal("expected enum error")
+	}
+}
@@ -0,0 +1,144 @@
+package script
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"auto-forge/pkg/utools"
+	"github.com/dop251/goja"
+)
+
+const (
+	maxFetchCount     = 10
+	maxFetchRedirects = 5
+	maxFetchBodyBytes = 1 << 20
+)
+
+// fetchOptions fetch 的第二个参数
+type fetchOptions struct {
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+	Body    interface{}       `json:"body"`
+}
+
+// fetch 同步发起 HTTP 请求（不返回 Promise），只能访问管理员配置的 allowed_hosts，
+// 返回 { status, ok, headers, text, json }
+func (sb *sandbox) fetch(call goja.FunctionCall) goja.Value {
+	response, err := sb.doFetch(call.Argument(0).String(), call.Argument(1))
+	if err != nil {
+		panic(sb.vm.NewGoError(err))
+	}
+	return sb.vm.ToValue(response)
+}
+
+func (sb *sandbox) doFetch(rawURL string, optionsValue goja.Value) (map[string]interface{}, error) {
+	if len(sb.limits.allowedHosts) == 0 {
+		return nil, fmt.Errorf("fetch 未启用：管理员未在工具配置中设置 allowed_hosts")
+	}
+	sb.fetches++
+	if sb.fetches > maxFetchCount {
+		return nil, fmt.Errorf("单次执行最多发起 %d 个请求", maxFetchCount)
+	}
+
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+		return nil, fmt.Errorf("无效的请求地址: %s", rawURL)
+	}
+	if !hostAllowed(target.Hostname(), sb.limits.allowedHosts) {
+		return nil, fmt.Errorf("不允许访问 %s", target.Hostname())
+	}
+
+	var options fetchOptions
+	if optionsValue != nil && !goja.IsUndefined(optionsValue) && !goja.IsNull(optionsValue) {
+		data, err := json.Marshal(optionsValue.Export())
+		if err != nil {
+			return nil, fmt.Errorf("无效的请求参数: %w", err)
+		}
+		if err := json.Unmarshal(data, &options); err != nil {
+			return nil, fmt.Errorf("无效的请求参数: %w", err)
+		}
+	}
+	if options.Method == "" {
+		options.Method = http.MethodGet
+	}
+
+	var body io.Reader
+	isJSONBody := false
+	switch b := options.Body.(type) {
+	case nil:
+	case string:
+		body = strings.NewReader(b)
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return nil, fmt.Errorf("无效的请求体: %w", err)
+		}
+		body = strings.NewReader(string(data))
+		isJSONBody = true
+	}
+
+	req, err := http.NewRequestWithContext(sb.ctx, strings.ToUpper(options.Method), target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range options.Headers {
+		req.Header.Set(key, value)
+	}
+	if isJSONBody && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	// 在全局出站策略之上再限制为工具白名单中的域名
+	client := utools.NewHTTPClient(utools.HTTPClientOptions{MaxRedirects: maxFetchRedirects})
+	checkRedirect := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !hostAllowed(req.URL.Hostname(), sb.limits.allowedHosts) {
+			return fmt.Errorf("不允许重定向到 %s", req.URL.Hostname())
+		}
+		return checkRedirect(req, via)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchBodyBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if len(data) > maxFetchBodyBytes {
+		return nil, fmt.Errorf("响应超过 %dKB", maxFetchBodyBytes>>10)
+	}
+
+	headers := make(map[string]interface{}, len(resp.Header))
+	for key := range resp.Header {
+		headers[strings.ToLower(key)] = resp.Header.Get(key)
+	}
+	var parsed interface{}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		parsed = nil
+	}
+
+	return map[string]interface{}{
+		"status":  resp.StatusCode,
+		"ok":      resp.StatusCode >= 200 && resp.StatusCode < 300,
+		"headers": headers,
+		"text":    string(data),
+		"json":    parsed,
+	}, nil
+}
+
+// hostAllowed 白名单条目为域名（精确匹配）、*.example.com（匹配子域名）或 *（允许全部）
+func hostAllowed(host string, allowed []string) bool {
+	for _, pattern := range allowed {
+		if utools.MatchDomain(host, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,240 @@
+package script
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/metrics"
+	"strings"
+	"time"
+
+	"auto-forge/pkg/utools"
+	"github.com/dop251/goja"
+)
+
+const (
+	maxCallStackSize = 512
+	maxLogLines      = 500
+	maxLogBytes      = 64 * 1024
+
+	// maxResultBytes 返回值序列化为 JSON 后的大小上限
+	maxResultBytes = 8 << 20
+
+	memoryCheckInterval = 20 * time.Millisecond
+	heapMetric          = "/memory/classes/heap/objects:bytes"
+	// heapGuardFactor 进程堆增长超过内存上限的该倍数时中断脚本
+	heapGuardFactor = 4
+)
+
+// sandbox 单次脚本执行的运行时，不可复用
+type sandbox struct {
+	vm      *goja.Runtime
+	ctx     context.Context
+	parent  context.Context
+	limits  limits
+	console *console
+	fetches int
+}
+
+func newSandbox(execCtx *utools.ExecutionContext, lim limits) *sandbox {
+	parent := context.Background()
+	if execCtx != nil && execCtx.Context != nil {
+		parent = execCtx.Context
+	}
+
+	sb := &sandbox{
+		vm:      goja.New(),
+		parent:  parent,
+		limits:  lim,
+		console: &console{},
+	}
+	sb.vm.SetMaxCallStackSize(maxCallStackSize)
+	return sb
+}
+
+// setGlobals 注入变量与标准库。变量经 JSON.parse 转为原生 JS 对象，
+// 否则 Go 的切片在脚本中无法 push 等改变长度的操作
+func (sb *sandbox) setGlobals(globals map[string]interface{}) error {
+	parse, ok := goja.AssertFunction(sb.vm.Get("JSON").ToObject(sb.vm).Get("parse"))
+	if !ok {
+		return errors.New("JSON.parse 不可用")
+	}
+	for name, value := range globals {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("序列化 %s 失败: %w", name, err)
+		}
+		parsed, err := parse(goja.Undefined(), sb.vm.ToValue(string(data)))
+		if err != nil {
+			return fmt.Errorf("设置 %s 失败: %w", name, err)
+		}
+		if err := sb.vm.Set(name, parsed); err != nil {
+			return fmt.Errorf("设置 %s 失败: %w", name, err)
+		}
+	}
+	if err := sb.vm.Set("console", sb.console.object(sb.vm)); err != nil {
+		return err
+	}
+	if err := installStdlib(sb.vm); err != nil {
+		return err
+	}
+	return sb.vm.Set("fetch", sb.fetch)
+}
+
+// run 以函数体形式执行脚本，返回可序列化为 JSON 的返回值
+func (sb *sandbox) run(code string) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(sb.parent, sb.limits.timeout)
+	defer cancel()
+	sb.ctx = ctx
+
+	done := make(chan struct{})
+	defer close(done)
+	go sb.watch(ctx, done)
+
+	// 函数头与脚本第一行同行，报错行号与用户代码一致
+	value, err := sb.vm.RunString("(function(){" + code + "\n})()")
+	if err != nil {
+		return nil, sb.translateError(err)
+	}
+
+	exported := value.Export()
+	if exported == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(exported)
+	if err != nil {
+		return nil, fmt.Errorf("返回值无法序列化为 JSON: %w", err)
+	}
+	if len(data) > maxResultBytes {
+		return nil, fmt.Errorf("返回值超过大小上限 (%dMB)", maxResultBytes>>20)
+	}
+	var result interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("返回值无法序列化为 JSON: %w", err)
+	}
+	return result, nil
+}
+
+// watch 超时、取消或堆内存增长超限时中断脚本。
+// 内存检查是进程级的粗粒度保护：goja 不按运行时统计内存，只能观察整个进程的堆增长，
+// 并发执行的其他任务也会计入。为避免误伤，阈值取内存上限的 heapGuardFactor 倍，只拦截失控的脚本，
+// 超限时先触发一次 GC 排除尚未回收的垃圾。返回值、console 与 fetch 响应另有按运行时的大小上限
+func (sb *sandbox) watch(ctx context.Context, done <-chan struct{}) {
+	sample := []metrics.Sample{{Name: heapMetric}}
+	heapBytes := func() uint64 {
+		metrics.Read(sample)
+		if sample[0].Value.Kind() != metrics.KindUint64 {
+			return 0
+		}
+		return sample[0].Value.Uint64()
+	}
+	baseline := heapBytes()
+	threshold := baseline + sb.limits.memoryBytes*heapGuardFactor
+
+	ticker := time.NewTicker(memoryCheckInterval)
+	defer ticker.Stop()
+	var lastGC time.Time
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				sb.vm.Interrupt(fmt.Errorf("脚本执行超时 (%dms)", sb.limits.timeout.Milliseconds()))
+			} else {
+				sb.vm.Interrupt(fmt.Errorf("脚本执行已取消: %w", ctx.Err()))
+			}
+			return
+		case <-ticker.C:
+			if heapBytes() < threshold {
+				continue
+			}
+			if time.Since(lastGC) > 500*time.Millisecond {
+				lastGC = time.Now()
+				runtime.GC()
+				if heapBytes() < threshold {
+					continue
+				}
+			}
+			sb.vm.Interrupt(fmt.Errorf("脚本内存使用超过上限 (%dMB)", sb.limits.memoryBytes>>20))
+			return
+		}
+	}
+}
+
+func (sb *sandbox) translateError(err error) error {
+	var interrupted *goja.InterruptedError
+	if errors.As(err, &interrupted) {
+		if cause, ok := interrupted.Value().(error); ok {
+			return cause
+		}
+		return fmt.Errorf("脚本被中断: %v", interrupted.Value())
+	}
+	var exception *goja.Exception
+	if errors.As(err, &exception) {
+		return errors.New(exception.Error())
+	}
+	return err
+}
+
+// console 收集脚本输出，写入节点执行日志
+type console struct {
+	lines     []string
+	size      int
+	truncated bool
+}
+
+func (c *console) object(vm *goja.Runtime) *goja.Object {
+	obj := vm.NewObject()
+	for _, level := range []string{"log", "info", "warn", "error", "debug"} {
+		level := level
+		_ = obj.Set(level, func(call goja.FunctionCall) goja.Value {
+			c.write(level, call.Arguments)
+			return goja.Undefined()
+		})
+	}
+	return obj
+}
+
+func (c *console) write(level string, args []goja.Value) {
+	if c.truncated {
+		return
+	}
+
+	parts := make([]string, 0, len(args))
+	for _, arg := range args {
+		parts = append(parts, formatLogValue(arg))
+	}
+	line := strings.Join(parts, " ")
+	if level != "log" {
+		line = "[" + level + "] " + line
+	}
+
+	if len(c.lines) >= maxLogLines || c.size+len(line) > maxLogBytes {
+		c.lines = append(c.lines, "... 日志过多，后续输出已忽略")
+		c.truncated = true
+		return
+	}
+	c.lines = append(c.lines, line)
+	c.size += len(line)
+}
+
+func formatLogValue(value goja.Value) string {
+	if value == nil || goja.IsUndefined(value) {
+		return "undefined"
+	}
+	if goja.IsNull(value) {
+		return "null"
+	}
+	switch exported := value.Export().(type) {
+	case string:
+		return exported
+	case map[string]interface{}, []interface{}:
+		if data, err := json.Marshal(exported); err == nil {
+			return string(data)
+		}
+	}
+	return value.String()
+}
@@ -0,0 +1,239 @@
+package script
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"auto-forge/internal/services/tool_config"
+	"auto-forge/pkg/utools"
+)
+
+// 资源限制：节点可在管理员上限内自行调整
+const (
+	defaultTimeoutMs = 3000
+	maxTimeoutMs     = 30000
+	defaultMemoryMB  = 64
+	maxMemoryMB      = 256
+)
+
+// ScriptTool 执行用户编写的 JavaScript 代码
+type ScriptTool struct {
+	*utools.BaseTool
+}
+
+// NewScriptTool 创建脚本工具
+func NewScriptTool() *ScriptTool {
+	metadata := &utools.ToolMetadata{
+		Code:        "script",
+		Name:        "代码执行",
+		Description: "运行自定义 JavaScript 代码，可读取 nodes/env/external/input，return 的值作为节点输出；内置日期、哈希、Base64、URL 工具与受白名单限制的 fetch",
+		Category:    utools.CategoryData,
+		Version:     "1.0.0",
+		Author:      "AutoForge",
+		AICallable:  false,
+		Tags:        []string{"javascript", "script", "code", "transform"},
+	}
+
+	timeoutMin, timeoutMax := 100.0, float64(maxTimeoutMs)
+	memoryMin, memoryMax := 8.0, float64(maxMemoryMB)
+	schema := &utools.ConfigSchema{
+		Type: "object",
+		Properties: map[string]utools.PropertySchema{
+			"script": {
+				Type:        "string",
+				Title:       "脚本",
+				Description: "函数体形式的 JavaScript，例如：const items = nodes.node_1.items; return { count: items.length }",
+				MinLength:   func() *int { v := 1; return &v }(),
+			},
+			"input": {
+				Title:       "输入数据",
+				Description: "在脚本中通过 input 访问，支持变量引用，JSON 字符串会自动解析",
+			},
+			"timeout_ms": {
+				Type:        "integer",
+				Title:       "执行超时 (ms)",
+				Description: "超过后中断脚本，包含 fetch 等待时间",
+				Default:     float64(defaultTimeoutMs),
+				Minimum:     &timeoutMin,
+				Maximum:     &timeoutMax,
+			},
+			"memory_mb": {
+				Type:        "integer",
+				Title:       "内存上限 (MB)",
+				Description: "粗粒度内存保护：按进程堆增长估算，超过该值的 4 倍时中断脚本",
+				Default:     float64(defaultMemoryMB),
+				Minimum:     &memoryMin,
+				Maximum:     &memoryMax,
+			},
+		},
+		Required: []string{"script"},
+	}
+
+	return &ScriptTool{BaseTool: utools.NewBaseTool(metadata, schema)}
+}
+
+// DescribeOutput 脚本返回值的结构由用户决定
+func (t *ScriptTool) DescribeOutput(config map[string]interface{}) map[string]utools.OutputFieldDef {
+	return map[string]utools.OutputFieldDef{
+		"result": {Type: "object", Label: "脚本返回值"},
+	}
+}
+
+// Execute 在沙箱中运行脚本
+func (t *ScriptTool) Execute(ctx *utools.ExecutionContext, config map[string]interface{}) (*utools.ExecutionResult, error) {
+	start := time.Now()
+
+	code, _ := config["script"].(string)
+	if strings.TrimSpace(code) == "" {
+		err := errors.New("script is required")
+		return &utools.ExecutionResult{Success: false, Message: "脚本不能为空", Error: err.Error()}, err
+	}
+
+	limits := resolveLimits(config, adminConfig())
+	sb := newSandbox(ctx, limits)
+	if err := sb.setGlobals(scriptGlobals(ctx, config)); err != nil {
+		return &utools.ExecutionResult{Success: false, Message: "初始化脚本环境失败", Error: err.Error()}, err
+	}
+
+	value, err := sb.run(code)
+	if err != nil {
+		return &utools.ExecutionResult{
+			Success:    false,
+			Message:    "脚本执行失败",
+			Error:      err.Error(),
+			Logs:       sb.console.lines,
+			DurationMs: time.Since(start).Milliseconds(),
+		}, err
+	}
+
+	return &utools.ExecutionResult{
+		Success:    true,
+		Message:    "脚本执行成功",
+		Output:     map[string]interface{}{"result": value},
+		Logs:       sb.console.lines,
+		DurationMs: time.Since(start).Milliseconds(),
+	}, nil
+}
+
+// limits 单次执行的资源限制
+type limits struct {
+	timeout      time.Duration
+	memoryBytes  uint64
+	allowedHosts []string
+}
+
+// resolveLimits 合并节点配置与管理员配置。管理员可在工具配置中设置：
+// allowed_hosts（fetch 允许访问的域名，支持 *.example.com，未配置时禁用 fetch）、max_timeout_ms、max_memory_mb
+func resolveLimits(config, admin map[string]interface{}) limits {
+	timeoutCap := intValue(admin["max_timeout_ms"], maxTimeoutMs)
+	memoryCap := intValue(admin["max_memory_mb"], maxMemoryMB)
+
+	timeoutMs := intValue(config["timeout_ms"], defaultTimeoutMs)
+	if timeoutMs > timeoutCap {
+		timeoutMs = timeoutCap
+	}
+	memoryMB := intValue(config["memory_mb"], defaultMemoryMB)
+	if memoryMB > memoryCap {
+		memoryMB = memoryCap
+	}
+
+	return limits{
+		timeout:      time.Duration(timeoutMs) * time.Millisecond,
+		memoryBytes:  uint64(memoryMB) << 20,
+		allowedHosts: stringList(admin["allowed_hosts"]),
+	}
+}
+
+// adminConfig 读取管理员的工具配置，未配置时返回空
+var adminConfig = func() map[string]interface{} {
+	config, err := tool_config.GetToolConfigForExecution("script")
+	if err != nil || config == nil {
+		return map[string]interface{}{}
+	}
+	return config
+}
+
+// scriptGlobals 准备注入脚本的变量，注入时按 JSON 复制，脚本修改它们不会影响后续节点
+func scriptGlobals(ctx *utools.ExecutionContext, config map[string]interface{}) map[string]interface{} {
+	globals := map[string]interface{}{
+		"nodes":    map[string]interface{}{},
+		"env":      map[string]interface{}{},
+		"external": map[string]interface{}{},
+		"input":    decodeInput(config["input"]),
+	}
+	if ctx != nil {
+		for _, name := range []string{"nodes", "env", "external"} {
+			if value, ok := ctx.Variables[name]; ok && value != nil {
+				globals[name] = value
+			}
+		}
+	}
+	return globals
+}
+
+func decodeInput(input interface{}) interface{} {
+	str, ok := input.(string)
+	if !ok {
+		return input
+	}
+	trimmed := strings.TrimSpace(str)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(trimmed), &decoded); err == nil {
+			return decoded
+		}
+	}
+	return str
+}
+
+func intValue(value interface{}, fallback int) int {
+	switch v := value.(type) {
+	case float64:
+		if v > 0 {
+			return int(v)
+		}
+	case int:
+		if v > 0 {
+			return v
+		}
+	case string:
+		var n int
+		if _, err := fmt.Sscanf(strings.TrimSpace(v), "%d", &n); err == nil && n > 0 {
+			return n
+		}
+	}
+	return fallback
+}
+
+// stringList 支持数组或以逗号、换行分隔的字符串
+func stringList(value interface{}) []string {
+	var items []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			items = append(items, fmt.Sprint(item))
+		}
+	case []string:
+		items = v
+	case string:
+		items = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == '\n' || r == ' ' })
+	}
+
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func init() {
+	tool := NewScriptTool()
+	if err := utools.Register(tool); err != nil {
+		panic(fmt.Sprintf("Failed to register Script tool: %v", err))
+	}
+}
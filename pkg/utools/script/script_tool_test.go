@@ -0,0 +1,188 @@
+package script
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"auto-forge/pkg/config"
+	"auto-forge/pkg/utools"
+)
+
+func runScript(t *testing.T, admin map[string]interface{}, config map[string]interface{}) (*utools.ExecutionResult, error) {
+	t.Helper()
+	original := adminConfig
+	adminConfig = func() map[string]interface{} { return admin }
+	t.Cleanup(func() { adminConfig = original })
+
+	ctx := &utools.ExecutionContext{
+		UserID: "user-1",
+		Variables: map[string]interface{}{
+			"nodes":    map[string]interface{}{"node_1": map[string]interface{}{"items": []interface{}{1.0, 2.0, 3.0}}},
+			"env":      map[string]interface{}{"REGION": "cn"},
+			"external": map[string]interface{}{"name": "forge"},
+		},
+	}
+	return NewScriptTool().Execute(ctx, config)
+}
+
+func TestScriptGlobalsAndResult(t *testing.T) {
+	result, err := runScript(t, nil, map[string]interface{}{
+		"script": `const items = nodes.node_1.items;
+nodes.node_1.items.push(4);
+console.log("count", items.length, { region: env.REGION });
+console.warn("done");
+return { sum: items.reduce((a, b) => a + b, 0), name: external.name, input: input.value };`,
+		"input": `{"value": 42}`,
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	output := result.Output["result"].(map[string]interface{})
+	if output["sum"] != 10.0 || output["name"] != "forge" || output["input"] != 42.0 {
+		t.Errorf("unexpected result %v", output)
+	}
+	want := []string{`count 4 {"region":"cn"}`, "[warn] done"}
+	if strings.Join(result.Logs, "|") != strings.Join(want, "|") {
+		t.Errorf("logs = %q, want %q", result.Logs, want)
+	}
+}
+
+func TestScriptDoesNotMutateVariables(t *testing.T) {
+	original := adminConfig
+	adminConfig = func() map[string]interface{} { return nil }
+	defer func() { adminConfig = original }()
+
+	items := []interface{}{1.0}
+	ctx := &utools.ExecutionContext{Variables: map[string]interface{}{
+		"nodes": map[string]interface{}{"n": map[string]interface{}{"items": items}},
+	}}
+	if _, err := NewScriptTool().Execute(ctx, map[string]interface{}{"script": `nodes.n.items[0] = 99; delete nodes.n`}); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	node, ok := ctx.Variables["nodes"].(map[string]interface{})["n"].(map[string]interface{})
+	if !ok || node["items"].([]interface{})[0] != 1.0 {
+		t.Error("script modified execution context variables")
+	}
+}
+
+func TestScriptStdlib(t *testing.T) {
+	result, err := runScript(t, nil, map[string]interface{}{
+		"script": `const u = url.parse("https://example.com:8443/a/b?x=1&y=2#top");
+return {
+  date: date.format("2024-03-05 08:09:10", "YYYY/MM/DD HH:mm"),
+  added: date.format(date.add("2024-03-05 00:00:00", 1, "d"), "YYYY-MM-DD"),
+  md5: crypto.md5("hello"),
+  hmac: crypto.hmac("sha256", "key", "data"),
+  b64: base64.encode("你好"),
+  decoded: atob(btoa("forge")),
+  host: u.hostname, port: u.port, x: u.query.x, hash: u.hash,
+  qs: url.buildQuery({ q: "a b" }),
+};`,
+	})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	output := result.Output["result"].(map[string]interface{})
+	want := map[string]interface{}{
+		"date":    "2024/03/05 08:09",
+		"added":   "2024-03-06",
+		"md5":     "5d41402abc4b2a76b9719d911017c592",
+		"hmac":    "5031fe3d989c6d1537a013fa6e739da23463fdaec3b70137d828e36ace221bd0",
+		"b64":     "5L2g5aW9",
+		"decoded": "forge",
+		"host":    "example.com",
+		"port":    "8443",
+		"x":       "1",
+		"hash":    "#top",
+		"qs":      "q=a+b",
+	}
+	for key, value := range want {
+		if output[key] != value {
+			t.Errorf("%s = %v, want %v", key, output[key], value)
+		}
+	}
+}
+
+func TestScriptErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		admin  map[string]interface{}
+		config map[string]interface{}
+		want   string
+	}{
+		{"exception", nil, map[string]interface{}{"script": `throw new Error("boom")`}, "boom"},
+		{"timeout", nil, map[string]interface{}{"script": `while (true) {}`, "timeout_ms": 100.0}, "超时"},
+		{"admin timeout cap", map[string]interface{}{"max_timeout_ms": 100.0}, map[string]interface{}{"script": `while (true) {}`, "timeout_ms": 30000.0}, "超时 (100ms)"},
+		{"memory", nil, map[string]interface{}{"script": `const a = []; while (true) { a.push("x".repeat(1024)); }`, "memory_mb": 8.0}, "内存"},
+		{"result too large", nil, map[string]interface{}{"script": `return "x".repeat(9 * 1024 * 1024)`}, "返回值超过大小上限"},
+		{"fetch disabled", nil, map[string]interface{}{"script": `fetch("https://example.com")`}, "fetch 未启用"},
+		{"fetch host denied", map[string]interface{}{"allowed_hosts": "api.example.com"}, map[string]interface{}{"script": `fetch("https://evil.com")`}, "不允许访问 evil.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := runScript(t, tt.admin, tt.config)
+			if err == nil || result.Success {
+				t.Fatal("expected failure")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not contain %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestScriptFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"method":"` + r.Method + `","type":"` + r.Header.Get("Content-Type") + `"}`))
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	admin := map[string]interface{}{"allowed_hosts": []interface{}{serverURL.Hostname()}}
+	script := `const resp = fetch("` + server.URL + `", { method: "post", body: { a: 1 } });
+return { status: resp.status, ok: resp.ok, method: resp.json.method, type: resp.json.type };`
+
+	// 工具白名单不能绕过全局出站策略对回环地址的限制
+	if _, err := runScript(t, admin, map[string]interface{}{"script": script}); err == nil || !strings.Contains(err.Error(), "egress denied") {
+		t.Fatalf("expected egress policy to block loopback, got %v", err)
+	}
+
+	policy, _ := utools.NewEgressPolicy(config.EgressConfig{AllowCIDRs: []string{serverURL.Hostname()}})
+	utools.SetEgressPolicy(policy)
+	defer utools.SetEgressPolicy(nil)
+
+	result, err := runScript(t, admin, map[string]interface{}{"script": script})
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	output := result.Output["result"].(map[string]interface{})
+	if output["status"] != 200.0 || output["ok"] != true || output["method"] != "POST" || output["type"] != "application/json" {
+		t.Errorf("unexpected result %v", output)
+	}
+}
+
+func TestHostAllowed(t *testing.T) {
+	allowed := []string{"api.example.com", "*.github.com"}
+	tests := map[string]bool{
+		"api.example.com":  true,
+		"API.example.com.": true,
+		"example.com":      false,
+		"raw.github.com":   true,
+		"github.com":       false,
+		"evilgithub.com":   false,
+	}
+	for host, want := range tests {
+		if got := hostAllowed(host, allowed); got != want {
+			t.Errorf("hostAllowed(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
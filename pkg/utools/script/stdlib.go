@@ -0,0 +1,236 @@
+package script
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// installStdlib 注入脚本可用的标准库：date、crypto、base64（及 btoa/atob）、url
+func installStdlib(vm *goja.Runtime) error {
+	modules := map[string]map[string]interface{}{
+		"date": {
+			"now":    func() int64 { return time.Now().UnixMilli() },
+			"parse":  func(call goja.FunctionCall) goja.Value { return vm.ToValue(mustTime(vm, call.Argument(0)).UnixMilli()) },
+			"format": func(call goja.FunctionCall) goja.Value { return vm.ToValue(formatDate(vm, call)) },
+			"add":    func(call goja.FunctionCall) goja.Value { return vm.ToValue(addDate(vm, call)) },
+		},
+		"crypto": {
+			"md5":    func(data string) string { return hashHex(md5.New(), data) },
+			"sha1":   func(data string) string { return hashHex(sha1.New(), data) },
+			"sha256": func(data string) string { return hashHex(sha256.New(), data) },
+			"sha512": func(data string) string { return hashHex(sha512.New(), data) },
+			"hmac":   hmacHex,
+		},
+		"base64": {
+			"encode":    func(data string) string { return base64.StdEncoding.EncodeToString([]byte(data)) },
+			"decode":    decodeBase64,
+			"urlEncode": func(data string) string { return base64.RawURLEncoding.EncodeToString([]byte(data)) },
+			"urlDecode": func(data string) (string, error) {
+				decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(data, "="))
+				return string(decoded), err
+			},
+		},
+		"url": {
+			"parse":      parseURL,
+			"buildQuery": buildQuery,
+		},
+	}
+
+	for name, functions := range modules {
+		obj := vm.NewObject()
+		for fn, impl := range functions {
+			if err := obj.Set(fn, impl); err != nil {
+				return err
+			}
+		}
+		if err := vm.Set(name, obj); err != nil {
+			return err
+		}
+	}
+
+	if err := vm.Set("btoa", modules["base64"]["encode"]); err != nil {
+		return err
+	}
+	return vm.Set("atob", decodeBase64)
+}
+
+// dateLayouts 解析日期字符串时依次尝试的格式
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// toTime 接受毫秒时间戳、Date 对象或日期字符串，省略时为当前时间
+func toTime(value goja.Value) (time.Time, error) {
+	if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+		return time.Now(), nil
+	}
+	switch v := value.Export().(type) {
+	case time.Time:
+		return v, nil
+	case int64:
+		return time.UnixMilli(v), nil
+	case float64:
+		return time.UnixMilli(int64(v)), nil
+	case string:
+		for _, layout := range dateLayouts {
+			if t, err := time.ParseInLocation(layout, strings.TrimSpace(v), time.Local); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("无法解析日期: %s", v)
+	}
+	return time.Time{}, fmt.Errorf("不支持的日期类型: %s", value.String())
+}
+
+func mustTime(vm *goja.Runtime, value goja.Value) time.Time {
+	t, err := toTime(value)
+	if err != nil {
+		panic(vm.NewGoError(err))
+	}
+	return t
+}
+
+// layoutReplacer 将 YYYY-MM-DD HH:mm:ss 风格的格式转换为 Go 的时间格式
+var layoutReplacer = strings.NewReplacer(
+	"YYYY", "2006", "YY", "06",
+	"MM", "01", "DD", "02",
+	"HH", "15", "mm", "04", "ss", "05", "SSS", "000",
+	"ZZ", "-0700", "Z", "-07:00",
+)
+
+// formatDate date.format(value, layout = "YYYY-MM-DD HH:mm:ss", timezone)
+func formatDate(vm *goja.Runtime, call goja.FunctionCall) string {
+	t := mustTime(vm, call.Argument(0))
+
+	layout := "YYYY-MM-DD HH:mm:ss"
+	if arg := call.Argument(1); !goja.IsUndefined(arg) && !goja.IsNull(arg) {
+		layout = arg.String()
+	}
+	if arg := call.Argument(2); !goja.IsUndefined(arg) && !goja.IsNull(arg) {
+		location, err := time.LoadLocation(arg.String())
+		if err != nil {
+			panic(vm.NewGoError(fmt.Errorf("无效的时区: %s", arg.String())))
+		}
+		t = t.In(location)
+	}
+	return t.Format(layoutReplacer.Replace(layout))
+}
+
+// addDate date.add(value, amount, unit)，unit 为 ms/s/m/h/d，返回毫秒时间戳
+func addDate(vm *goja.Runtime, call goja.FunctionCall) int64 {
+	t := mustTime(vm, call.Argument(0))
+	amount := call.Argument(1).ToFloat()
+
+	units := map[string]time.Duration{
+		"ms": time.Millisecond,
+		"s":  time.Second,
+		"m":  time.Minute,
+		"h":  time.Hour,
+		"d":  24 * time.Hour,
+	}
+	unit, ok := units[call.Argument(2).String()]
+	if !ok {
+		panic(vm.NewGoError(fmt.Errorf("不支持的时间单位: %s", call.Argument(2).String())))
+	}
+	return t.Add(time.Duration(amount * float64(unit))).UnixMilli()
+}
+
+func hashHex(h hash.Hash, data string) string {
+	h.Write([]byte(data))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hmacHex crypto.hmac(algorithm, key, data)，algorithm 为 md5/sha1/sha256/sha512
+func hmacHex(algorithm, key, data string) (string, error) {
+	constructors := map[string]func() hash.Hash{
+		"md5":    md5.New,
+		"sha1":   sha1.New,
+		"sha256": sha256.New,
+		"sha512": sha512.New,
+	}
+	constructor, ok := constructors[strings.ToLower(algorithm)]
+	if !ok {
+		return "", fmt.Errorf("不支持的哈希算法: %s", algorithm)
+	}
+	return hashHex(hmac.New(constructor, []byte(key)), data), nil
+}
+
+func decodeBase64(data string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("无效的 Base64: %w", err)
+	}
+	return string(decoded), nil
+}
+
+// parseURL 返回与浏览器 URL 对象类似的字段，query 为参数名到首个值的映射
+func parseURL(raw string) (map[string]interface{}, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 URL: %w", err)
+	}
+
+	query := make(map[string]interface{})
+	for key, values := range u.Query() {
+		if len(values) > 0 {
+			query[key] = values[0]
+		}
+	}
+
+	result := map[string]interface{}{
+		"href":     u.String(),
+		"protocol": "",
+		"host":     u.Host,
+		"hostname": u.Hostname(),
+		"port":     u.Port(),
+		"pathname": u.EscapedPath(),
+		"search":   "",
+		"hash":     "",
+		"query":    query,
+	}
+	if u.Scheme != "" {
+		result["protocol"] = u.Scheme + ":"
+	}
+	if u.RawQuery != "" {
+		result["search"] = "?" + u.RawQuery
+	}
+	if u.Fragment != "" {
+		result["hash"] = "#" + u.Fragment
+	}
+	return result, nil
+}
+
+// buildQuery 将对象编码为查询字符串，数组值展开为同名多个参数
+func buildQuery(params map[string]interface{}) string {
+	values := url.Values{}
+	for key, value := range params {
+		switch v := value.(type) {
+		case nil:
+		case []interface{}:
+			for _, item := range v {
+				values.Add(key, fmt.Sprint(item))
+			}
+		default:
+			values.Set(key, fmt.Sprint(v))
+		}
+	}
+	return values.Encode()
+}
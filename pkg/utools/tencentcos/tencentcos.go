@@ -31,6 +31,7 @@ func NewTencentCOSTool() *TencentCOSTool {
 		Version:     "1.0.0",
 		Author:      "Cooper Team",
 		AICallable:  true,
+		Approval:    utools.ApprovalConfirm,
 		Tags:        []string{"storage", "upload", "tencent", "cos"},
 		OutputFieldsSchema: map[string]utools.OutputFieldDef{
 			"response": {
@@ -96,7 +97,7 @@ func (t *TencentCOSTool) Execute(ctx *utools.ExecutionContext, toolConfig map[st
 	startTime := time.Now()
 
 	// 从数据库加载 COS 配置
-	dbConfig, err := toolConfigService.GetToolConfigForExecution("tencent_cos")
+	dbConfig, err := toolConfigService.GetToolConfigForContext(ctx, "tencent_cos")
 	if err != nil {
 		return &utools.ExecutionResult{
 			Success:    false,
@@ -231,7 +232,7 @@ func (t *TencentCOSTool) uploadToCOS(secretID, secretKey, bucket, region, object
 	req.Header.Set("Authorization", authorization)
 
 	// 发送请求
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := utools.NewHTTPClient(utools.HTTPClientOptions{Timeout: 30 * time.Second})
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", err
@@ -25,6 +25,18 @@ type DynamicOutputDescriber interface {
     DescribeOutput(config map[string]interface{}) map[string]OutputFieldDef
 }
 
+// Agent 调用工具前的审批策略
+const (
+	ApprovalAuto    = "auto"    // 直接执行
+	ApprovalConfirm = "confirm" // 暂停并等待用户确认
+	ApprovalDeny    = "deny"    // 禁止 Agent 调用
+)
+
+// ApprovalPolicyProvider 按调用参数决定审批策略的工具（返回空字符串表示沿用元数据）
+type ApprovalPolicyProvider interface {
+	ApprovalPolicy(config map[string]interface{}) string
+}
+
 type ToolMetadata struct {
 	Code               string                    `json:"code"`
 	Name               string                    `json:"name"`
@@ -33,6 +45,7 @@ type ToolMetadata struct {
 	Version            string                    `json:"version"`
 	Author             string                    `json:"author"`
 	AICallable         bool                      `json:"ai_callable"`
+	Approval           string                    `json:"approval,omitempty"` // Agent 调用时的默认审批策略，空表示 auto
 	Tags               []string                  `json:"tags"`
 	OutputFieldsSchema map[string]OutputFieldDef `json:"output_fields_schema,omitempty"`
 }
@@ -65,16 +78,54 @@ type PropertySchema struct {
 	Maximum     *float64      `json:"maximum,omitempty"`
 	Items       *PropertySchema `json:"items,omitempty"`
 	Properties  map[string]PropertySchema `json:"properties,omitempty"`
+	Required    []string      `json:"required,omitempty"` // 嵌套对象的必填属性
 	Secret      bool          `json:"secret,omitempty"`
 }
 
 
 type ExecutionContext struct {
-	Context   context.Context        `json:"-"`
-	TaskID    string                 `json:"task_id"`
-	UserID    string                 `json:"user_id"`
-	Variables map[string]interface{} `json:"variables"`
-	Metadata  map[string]interface{} `json:"metadata"`
+	Context      context.Context        `json:"-"`
+	TaskID       string                 `json:"task_id"`               // 工作流中为执行记录 ID
+	WorkflowID   string                 `json:"workflow_id,omitempty"` // 所属工作流，工作流外调用时为空
+	UserID       string                 `json:"user_id"`
+	ConnectionID string                 `json:"connection_id,omitempty"` // 节点选择的凭证连接，为空时使用用户默认连接或全局配置
+	Variables    map[string]interface{} `json:"variables"`
+	Metadata     map[string]interface{} `json:"metadata"`
+}
+
+// ConnectionConfigKey 节点配置中选择凭证连接的字段，执行前会从配置中移除
+const ConnectionConfigKey = "connection_id"
+
+// SplitConnection 从工具配置中取出凭证连接 ID，返回不含该字段的配置
+func SplitConnection(config map[string]interface{}) (string, map[string]interface{}) {
+	connectionID, _ := config[ConnectionConfigKey].(string)
+	if _, ok := config[ConnectionConfigKey]; !ok {
+		return "", config
+	}
+
+	rest := make(map[string]interface{}, len(config)-1)
+	for key, value := range config {
+		if key != ConnectionConfigKey {
+			rest[key] = value
+		}
+	}
+	return connectionID, rest
+}
+
+type userIDContextKey struct{}
+
+// ContextWithUserID 在 context 中携带发起执行的用户，供无法直接设置 ExecutionContext 的调用方（如 Agent）传递
+func ContextWithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDContextKey{}, userID)
+}
+
+// UserIDFromContext 读取 context 中携带的用户 ID
+func UserIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	userID, _ := ctx.Value(userIDContextKey{}).(string)
+	return userID
 }
 
 
@@ -87,6 +138,7 @@ type ExecutionResult struct {
 	StatusCode   int                    `json:"status_code"`
 	ResponseBody string                 `json:"response_body"`
 	OutputRender *OutputRenderConfig    `json:"output_render,omitempty"`
+	Logs         []string               `json:"logs,omitempty"` // 工具运行期间的输出（如脚本的 console），写入节点执行日志
 }
 
 
@@ -129,92 +181,10 @@ func (bt *BaseTool) GetSchema() *ConfigSchema {
 }
 
 
+// Validate 按 Schema 校验配置（含嵌套对象与数组），返回包含全部错误的 ValidationErrors
 func (bt *BaseTool) Validate(config map[string]interface{}) error {
-
-	for _, field := range bt.schema.Required {
-		if _, ok := config[field]; !ok {
-			return &ValidationError{
-				Field:   field,
-				Message: "required field missing",
-			}
-		}
-	}
-
-
-	for key, value := range config {
-		propSchema, ok := bt.schema.Properties[key]
-		if !ok {
-			continue
-		}
-
-		if err := bt.validateValue(key, value, propSchema); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-
-func (bt *BaseTool) validateValue(key string, value interface{}, schema PropertySchema) error {
-
-	switch schema.Type {
-	case "string":
-		str, ok := value.(string)
-		if !ok {
-			return &ValidationError{Field: key, Message: "must be a string"}
-		}
-
-		if schema.MinLength != nil && len(str) < *schema.MinLength {
-			return &ValidationError{Field: key, Message: "string too short"}
-		}
-		if schema.MaxLength != nil && len(str) > *schema.MaxLength {
-			return &ValidationError{Field: key, Message: "string too long"}
-		}
-
-	case "number":
-		num, ok := value.(float64)
-		if !ok {
-			return &ValidationError{Field: key, Message: "must be a number"}
-		}
-		if schema.Minimum != nil && num < *schema.Minimum {
-			return &ValidationError{Field: key, Message: "number too small"}
-		}
-		if schema.Maximum != nil && num > *schema.Maximum {
-			return &ValidationError{Field: key, Message: "number too large"}
-		}
-
-	case "boolean":
-		if _, ok := value.(bool); !ok {
-			return &ValidationError{Field: key, Message: "must be a boolean"}
-		}
-
-	case "array":
-		if _, ok := value.([]interface{}); !ok {
-			return &ValidationError{Field: key, Message: "must be an array"}
-		}
-
-	case "object":
-		if _, ok := value.(map[string]interface{}); !ok {
-			return &ValidationError{Field: key, Message: "must be an object"}
-		}
-	}
-
-
-	if len(schema.Enum) > 0 {
-		found := false
-		for _, enumVal := range schema.Enum {
-			if value == enumVal {
-				found = true
-				break
-			}
-		}
-		if !found {
-			return &ValidationError{Field: key, Message: "value not in allowed enum"}
-		}
-	}
-
-	return nil
+	_, err := CoerceConfig(bt.schema, config)
+	return err
 }
 
 
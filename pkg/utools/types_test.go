@@ -0,0 +1,34 @@
+package utools
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSplitConnection(t *testing.T) {
+	config := map[string]interface{}{"bucket": "images", ConnectionConfigKey: "conn-1"}
+
+	connectionID, rest := SplitConnection(config)
+	if connectionID != "conn-1" {
+		t.Errorf("connectionID = %q, want conn-1", connectionID)
+	}
+	if _, ok := rest[ConnectionConfigKey]; ok || rest["bucket"] != "images" {
+		t.Errorf("unexpected config: %v", rest)
+	}
+	if _, ok := config[ConnectionConfigKey]; !ok {
+		t.Error("original config should not be modified")
+	}
+
+	if connectionID, _ := SplitConnection(map[string]interface{}{"bucket": "images"}); connectionID != "" {
+		t.Errorf("connectionID = %q, want empty", connectionID)
+	}
+}
+
+func TestUserIDFromContext(t *testing.T) {
+	if userID := UserIDFromContext(context.Background()); userID != "" {
+		t.Errorf("userID = %q, want empty", userID)
+	}
+	if userID := UserIDFromContext(ContextWithUserID(context.Background(), "u1")); userID != "u1" {
+		t.Errorf("userID = %q, want u1", userID)
+	}
+}
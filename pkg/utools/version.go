@@ -0,0 +1,155 @@
+package utools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"auto-forge/pkg/logger"
+)
+
+// ConfigMigration 将节点配置从一个工具版本升级到另一个版本
+type ConfigMigration func(config map[string]interface{}) (map[string]interface{}, error)
+
+type migration struct {
+	from    string
+	to      string
+	migrate ConfigMigration
+}
+
+// CompareVersions 比较两个版本号（如 1.2.0、v2.0），按点分隔的各段依次比较，数字段按数值比较；
+// 返回 -1、0 或 1。空版本视为最低版本
+func CompareVersions(a, b string) int {
+	partsA := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	partsB := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	if a == "" {
+		partsA = nil
+	}
+	if b == "" {
+		partsB = nil
+	}
+
+	for i := 0; i < len(partsA) || i < len(partsB); i++ {
+		partA, partB := "0", "0"
+		if i < len(partsA) {
+			partA = partsA[i]
+		}
+		if i < len(partsB) {
+			partB = partsB[i]
+		}
+
+		numA, errA := strconv.Atoi(partA)
+		numB, errB := strconv.Atoi(partB)
+		switch {
+		case errA == nil && errB == nil:
+			if numA != numB {
+				if numA < numB {
+					return -1
+				}
+				return 1
+			}
+		case partA != partB:
+			if partA < partB {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// RegisterMigration 注册工具配置从 from 版本到 to 版本的迁移，多个迁移可串联（1.0 -> 1.1 -> 2.0）
+func (r *Registry) RegisterMigration(code, from, to string, migrate ConfigMigration) error {
+	if migrate == nil || CompareVersions(from, to) >= 0 {
+		return fmt.Errorf("invalid migration for tool '%s': %s -> %s", code, from, to)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, m := range r.migrations[code] {
+		if m.from == from && m.to == to {
+			return fmt.Errorf("migration for tool '%s' %s -> %s already registered", code, from, to)
+		}
+	}
+	r.migrations[code] = append(r.migrations[code], migration{from: from, to: to, migrate: migrate})
+	return nil
+}
+
+// migrationPath 查找从 from 到 to 的迁移链，每一步选择不超过目标版本的最远迁移
+func (r *Registry) migrationPath(code, from, to string) ([]migration, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	path := make([]migration, 0)
+	current := from
+	for current != to {
+		var next *migration
+		for i, m := range r.migrations[code] {
+			if m.from != current || CompareVersions(m.to, to) > 0 {
+				continue
+			}
+			if next == nil || CompareVersions(m.to, next.to) > 0 {
+				next = &r.migrations[code][i]
+			}
+		}
+		if next == nil {
+			return nil, fmt.Errorf("no migration for tool '%s' from version '%s' to '%s'", code, current, to)
+		}
+		path = append(path, *next)
+		current = next.to
+	}
+	return path, nil
+}
+
+// CanMigrate 判断是否存在从 from 到 to 的迁移链
+func (r *Registry) CanMigrate(code, from, to string) bool {
+	_, err := r.migrationPath(code, from, to)
+	return err == nil
+}
+
+// MigrateConfig 按迁移链将节点配置从 from 版本升级到 to 版本
+func (r *Registry) MigrateConfig(code, from, to string, config map[string]interface{}) (map[string]interface{}, error) {
+	path, err := r.migrationPath(code, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range path {
+		if config, err = m.migrate(config); err != nil {
+			return nil, fmt.Errorf("migrate tool '%s' config %s -> %s: %w", code, m.from, m.to, err)
+		}
+	}
+	return config, nil
+}
+
+// ResolveTool 获取节点固定的工具版本；该版本已不可用时，将配置迁移到最新版本后使用最新版本。
+// version 为空表示未固定版本，直接使用最新版本
+func (r *Registry) ResolveTool(code, version string, config map[string]interface{}) (Tool, map[string]interface{}, error) {
+	if tool, err := r.GetVersion(code, version); err == nil {
+		return tool, config, nil
+	}
+
+	latest, err := r.Get(code)
+	if err != nil {
+		return nil, nil, err
+	}
+	latestVersion := latest.GetMetadata().Version
+	migrated, err := r.MigrateConfig(code, version, latestVersion, config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("tool '%s' version '%s' is no longer available: %w", code, version, err)
+	}
+
+	logger.Warn("工具 [%s] 版本 %s 已不可用，配置已迁移到 %s", code, version, latestVersion)
+	return latest, migrated, nil
+}
+
+// RegisterMigration 在全局注册表注册配置迁移
+func RegisterMigration(code, from, to string, migrate ConfigMigration) error {
+	return GetRegistry().RegisterMigration(code, from, to, migrate)
+}
+
+// ResolveTool 从全局注册表获取节点固定版本的工具
+func ResolveTool(code, version string, config map[string]interface{}) (Tool, map[string]interface{}, error) {
+	return GetRegistry().ResolveTool(code, version, config)
+}
@@ -0,0 +1,107 @@
+package utools
+
+import "testing"
+
+type versionTestTool struct {
+	*BaseTool
+}
+
+func (t *versionTestTool) Execute(ctx *ExecutionContext, config map[string]interface{}) (*ExecutionResult, error) {
+	return &ExecutionResult{Success: true}, nil
+}
+
+func newVersionTestTool(code, version string) Tool {
+	return &versionTestTool{NewBaseTool(&ToolMetadata{Code: code, Version: version}, &ConfigSchema{Type: "object"})}
+}
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0.0", "1.0.0", 0},
+		{"1.2.0", "1.10.0", -1},
+		{"v2.0", "1.9.9", 1},
+		{"1.0", "1.0.0", 0},
+		{"", "0.1.0", -1},
+	}
+	for _, c := range cases {
+		if got := CompareVersions(c.a, c.b); got != c.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestRegistryVersions(t *testing.T) {
+	r := newRegistry()
+	for _, version := range []string{"1.0.0", "2.0.0", "1.5.0"} {
+		if err := r.Register(newVersionTestTool("demo", version)); err != nil {
+			t.Fatalf("register %s: %v", version, err)
+		}
+	}
+	if err := r.Register(newVersionTestTool("demo", "1.5.0")); err == nil {
+		t.Fatal("expected duplicate version to be rejected")
+	}
+
+	latest, _ := r.Get("demo")
+	if latest.GetMetadata().Version != "2.0.0" {
+		t.Fatalf("latest = %s, want 2.0.0", latest.GetMetadata().Version)
+	}
+	if tool, err := r.GetVersion("demo", "1.0.0"); err != nil || tool.GetMetadata().Version != "1.0.0" {
+		t.Fatalf("GetVersion 1.0.0: %v", err)
+	}
+	if versions := r.Versions("demo"); len(versions) != 3 || versions[0] != "1.0.0" || versions[2] != "2.0.0" {
+		t.Fatalf("unexpected versions: %v", versions)
+	}
+
+	if err := r.UnregisterVersion("demo", "2.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if latest, _ := r.Get("demo"); latest.GetMetadata().Version != "1.5.0" {
+		t.Fatalf("latest after unregister = %s, want 1.5.0", latest.GetMetadata().Version)
+	}
+}
+
+func TestResolveToolMigratesRemovedVersion(t *testing.T) {
+	r := newRegistry()
+	if err := r.Register(newVersionTestTool("demo", "3.0.0")); err != nil {
+		t.Fatal(err)
+	}
+
+	rename := func(from, to string) ConfigMigration {
+		return func(config map[string]interface{}) (map[string]interface{}, error) {
+			result := make(map[string]interface{}, len(config))
+			for key, value := range config {
+				if key == from {
+					key = to
+				}
+				result[key] = value
+			}
+			return result, nil
+		}
+	}
+	if err := r.RegisterMigration("demo", "1.0.0", "2.0.0", rename("url", "endpoint")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RegisterMigration("demo", "2.0.0", "3.0.0", rename("endpoint", "target")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RegisterMigration("demo", "2.0.0", "1.0.0", rename("a", "b")); err == nil {
+		t.Fatal("expected downgrade migration to be rejected")
+	}
+
+	tool, config, err := r.ResolveTool("demo", "1.0.0", map[string]interface{}{"url": "https://example.com"})
+	if err != nil {
+		t.Fatalf("ResolveTool: %v", err)
+	}
+	if tool.GetMetadata().Version != "3.0.0" || config["target"] != "https://example.com" {
+		t.Fatalf("unexpected resolve result: %s %v", tool.GetMetadata().Version, config)
+	}
+
+	if _, _, err := r.ResolveTool("demo", "0.9.0", map[string]interface{}{}); err == nil {
+		t.Fatal("expected error for version without migration path")
+	}
+	if tool, _, err := r.ResolveTool("demo", "", nil); err != nil || tool.GetMetadata().Version != "3.0.0" {
+		t.Fatalf("unpinned node should use latest version: %v", err)
+	}
+}